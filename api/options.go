@@ -1,6 +1,8 @@
 package git
 
 import (
+	"github.com/izhujiang/gogit/core"
+	"github.com/izhujiang/gogit/core/config"
 	"github.com/izhujiang/gogit/plumbing"
 	"github.com/izhujiang/gogit/porcelain"
 )
@@ -24,25 +26,61 @@ type HashObjectOption struct {
 // Typa alias
 // Type A = B
 
+// Workspace is an opened work tree with its repository, staging area and references
+type Workspace = core.Workspace
+
 type CatFileOption = plumbing.CatFileOption
 type DumpOption = plumbing.DumpOption
 type LsTreeOption = plumbing.LsTreeOption
 
-type AddOption struct {
-}
-
 type LsFilesOption = plumbing.LsFilesOption
 type WriteTreeOption = plumbing.WriteTreeOption
 type ReadTreeOption = plumbing.ReadTreeOption
 type UpdateIndexOption = plumbing.UpdateIndexOption
+type RepackOption = plumbing.RepackOption
+type PackRefsOption = plumbing.PackRefsOption
+type UpdateRefOption = plumbing.UpdateRefOption
+type RevParseOption = plumbing.RevParseOption
+type RevListOption = plumbing.RevListOption
+type MergeBaseOption = plumbing.MergeBaseOption
+type CheckIgnoreOption = plumbing.CheckIgnoreOption
 
 type CommitTreeOption struct {
 	// the id of a parent commit object
 	Parents []string
 	// A paragraph in the commit log message.
 	Message string
+	// Override the author in the form of "Name <email>", and the author date
+	Author string
+	Date   string
 }
 
+type InitOption = porcelain.InitOption
+type AddOption = porcelain.AddOption
 type RemoveOption = porcelain.RemoveOption
 type LogOption = porcelain.LogOption
 type CommitOption = porcelain.CommitOption
+type GcOption = porcelain.GcOption
+type ReflogShowOption = porcelain.ReflogShowOption
+type ReflogExpireOption = porcelain.ReflogExpireOption
+type ReflogDeleteOption = porcelain.ReflogDeleteOption
+type ConfigOption = porcelain.ConfigOption
+type TagOption = porcelain.TagOption
+type BranchOption = porcelain.BranchOption
+type StatusOption = porcelain.StatusOption
+type CheckoutOption = porcelain.CheckoutOption
+type ResetOption = porcelain.ResetOption
+type RestoreOption = porcelain.RestoreOption
+type CleanOption = porcelain.CleanOption
+
+// ErrConfigKeyNotFound is returned by Config if the key to get or unset does not exist
+var ErrConfigKeyNotFound = config.ErrKeyNotFound
+
+// ErrNoMergeBase is returned by MergeBase if no commits are found
+var ErrNoMergeBase = plumbing.ErrNoMergeBase
+
+// ErrNotAncestor is returned by MergeBase with IsAncestor if the first commit is not an ancestor of the second
+var ErrNotAncestor = plumbing.ErrNotAncestor
+
+// ErrNotIgnored is returned by CheckIgnore if none of the paths is ignored
+var ErrNotIgnored = plumbing.ErrNotIgnored
@@ -3,77 +3,76 @@ package git
 import (
 	"fmt"
 	"io"
-	"log"
 
 	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core"
 	"github.com/izhujiang/gogit/core/object"
 	"github.com/izhujiang/gogit/plumbing"
 )
 
-func HashObject(w io.Writer, r io.Reader, option *HashObjectOption) error {
+// HashObject computes the object id, and writes the object into the repository of ws with option.Write.
+// ws may be nil if the object is not written.
+func HashObject(ws *Workspace, w io.Writer, r io.Reader, option *HashObjectOption) error {
 	ho := &plumbing.HashObjectOption{
 		ObjectType: object.ParseObjectKind(option.ObjectType),
 		Write:      option.Write,
 	}
-	h, err := plumbing.HashObject(r, ho)
+	var s core.ObjectStorer
+	if ws != nil {
+		s = ws.Repository()
+	}
+	h, err := plumbing.HashObject(s, r, ho)
 	hStr := fmt.Sprintf("%s\n", h)
 	w.Write([]byte(hStr))
 
 	return err
 }
 
-// CatFile Provide content or type and size information for repository objects which is identified by 40 characters.
-func CatFile(w io.Writer, objectId string, option *CatFileOption) error {
-	// fmt.Println("CatFileOption:", *option)
-	oid, err := common.NewHash(objectId)
+// CatFile Provide content or type and size information for repository objects which is identified by a revision, such as HEAD:README.
+func CatFile(ws *Workspace, w io.Writer, objectId string, option *CatFileOption) error {
+	oid, err := ws.ResolveRevision(objectId)
 	if err != nil {
-		log.Fatal(err)
-	} else {
-		err := plumbing.CatFile(w, oid, (*plumbing.CatFileOption)(option))
 		return err
 	}
-
-	return nil
+	return plumbing.CatFile(ws, w, oid, (*plumbing.CatFileOption)(option))
 }
 
-func Dump(w io.Writer, objectId string, option *DumpOption) error {
+func Dump(ws *Workspace, w io.Writer, objectId string, option *DumpOption) error {
 	var err error
 	if objectId == "index" {
-		err = plumbing.DumpIndex(w, (*plumbing.DumpOption)(option))
+		err = plumbing.DumpIndex(ws, w, (*plumbing.DumpOption)(option))
 	} else {
-		oid, err := common.NewHash(objectId)
+		var oid common.Hash
+		oid, err = ws.ResolveRevision(objectId)
 		if err != nil {
-			log.Fatal(err)
-		} else {
-			err = plumbing.DumpObject(w, oid, (*plumbing.DumpOption)(option))
+			return err
 		}
+		err = plumbing.DumpObject(ws, w, oid, (*plumbing.DumpOption)(option))
 	}
 
 	return err
 }
 
 // Shows one or more objects (blobs, trees, tags and commits).
-func Show(w io.Writer, name string) error {
-	oid, err := common.NewHash(name)
+func Show(ws *Workspace, w io.Writer, name string) error {
+	oid, err := ws.ResolveRevision(name)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	plumbing.Show(w, oid)
-
-	return nil
+	return plumbing.Show(ws, w, oid)
 }
 
 func Ls() error {
 	return nil
 }
 
-// List the contents of a tree object
-func LsTree(w io.Writer, treeId string, option *LsTreeOption) error {
-	oid, err := common.NewHash(treeId)
+// List the contents of a tree object, treeId is any tree-ish revision such as HEAD or v1.0:src
+func LsTree(ws *Workspace, w io.Writer, treeId string, option *LsTreeOption) error {
+	oid, err := ws.ResolveRevisionAs(treeId, object.Kind_Tree)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	err = plumbing.LsTree(w, oid, (*plumbing.LsTreeOption)(option))
+	err = plumbing.LsTree(ws, w, oid, (*plumbing.LsTreeOption)(option))
 	return err
 }
 
@@ -83,50 +82,98 @@ func MakeTree() {
 }
 
 // LsFiles show information about files in the index and the working tree.
-func LsFiles(w io.Writer, option *LsFilesOption) error {
-	return plumbing.LsFiles(w, (*plumbing.LsFilesOption)(option))
+func LsFiles(ws *Workspace, w io.Writer, option *LsFilesOption) error {
+	return plumbing.LsFiles(ws, w, (*plumbing.LsFilesOption)(option))
 }
 
-func UpdateIndex(option *UpdateIndexOption) error {
-	return plumbing.UpdateIndex((*plumbing.UpdateIndexOption)(option))
+// CheckIgnore outputs paths which are ignored by .gitignore files and exclude files
+func CheckIgnore(ws *Workspace, w io.Writer, paths []string, option *CheckIgnoreOption) error {
+	return plumbing.CheckIgnore(ws, w, paths, (*plumbing.CheckIgnoreOption)(option))
 }
 
-func WriteTree(w io.Writer, option *WriteTreeOption) error {
-	tid, err := plumbing.WriteTree((*plumbing.WriteTreeOption)(option))
+func UpdateIndex(ws *Workspace, option *UpdateIndexOption) error {
+	return plumbing.UpdateIndex(ws, (*plumbing.UpdateIndexOption)(option))
+}
+
+func WriteTree(ws *Workspace, w io.Writer, option *WriteTreeOption) error {
+	tid, err := plumbing.WriteTree(ws, (*plumbing.WriteTreeOption)(option))
 	fmt.Fprintf(w, "%s\n", tid)
 	return err
 }
 
-func ReadTree(w io.Writer, treeId string, option *ReadTreeOption) error {
-	oid, err := common.NewHash(treeId)
+func ReadTree(ws *Workspace, w io.Writer, treeId string, option *ReadTreeOption) error {
+	oid, err := ws.ResolveRevisionAs(treeId, object.Kind_Tree)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	return plumbing.ReadTree(w, oid, (*plumbing.ReadTreeOption)(option))
+	return plumbing.ReadTree(ws, w, oid, (*plumbing.ReadTreeOption)(option))
 }
 
-func CommitTree(w io.Writer, treeId string, option *CommitTreeOption) error {
-	oid, err := common.NewHash(treeId)
+func CommitTree(ws *Workspace, w io.Writer, treeId string, option *CommitTreeOption) error {
+	oid, err := ws.ResolveRevisionAs(treeId, object.Kind_Tree)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	parents := make([]common.Hash, 0, len(option.Parents))
 	for _, p := range option.Parents {
-		pid, err := common.NewHash(p)
-		if err == nil {
-			parents = append(parents, pid)
+		pid, err := ws.ResolveRevisionAs(p, object.Kind_Commit)
+		if err != nil {
+			return err
 		}
+		parents = append(parents, pid)
 	}
 
 	cto := &plumbing.CommitTreeOption{
 		Parents: parents,
 		Message: option.Message,
+		Author:  option.Author,
+		Date:    option.Date,
 	}
 
-	commitId, err := plumbing.CommitTree(oid, cto)
+	commitId, err := plumbing.CommitTree(ws, oid, cto)
 	w.Write([]byte(commitId.String()))
 
 	return err
 }
+
+// MakeTag creates a tag object from r with extra validation, and writes its id
+func MakeTag(ws *Workspace, w io.Writer, r io.Reader) error {
+	oid, err := plumbing.MakeTag(ws, r)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%s\n", oid)
+	return nil
+}
+
+// PackRefs packs heads and tags for efficient repository access
+func PackRefs(ws *Workspace, option *PackRefsOption) error {
+	return plumbing.PackRefs(ws, (*plumbing.PackRefsOption)(option))
+}
+
+// UpdateRef updates the object name stored in a ref safely, or reads the updates from r with option.Stdin
+func UpdateRef(ws *Workspace, w io.Writer, r io.Reader, args []string, option *UpdateRefOption) error {
+	return plumbing.UpdateRef(ws, w, r, args, option)
+}
+
+// Repack packs unpacked objects in a repository into a pack
+func Repack(ws *Workspace, w io.Writer, option *RepackOption) error {
+	return plumbing.Repack(ws, w, (*plumbing.RepackOption)(option))
+}
+
+// RevParse resolves revisions in args, such as HEAD~2, main@{u} and v1.0^{tree}, and writes their object ids
+func RevParse(ws *Workspace, w io.Writer, args []string, option *RevParseOption) error {
+	return plumbing.RevParse(ws, w, args, option)
+}
+
+// RevList lists commits reachable from revisions in args in reverse chronological order
+func RevList(ws *Workspace, w io.Writer, args []string, option *RevListOption) error {
+	return plumbing.RevList(ws, w, args, option)
+}
+
+// MergeBase writes the best common ancestors of commits in args for a merge, or answers other queries of ancestry
+func MergeBase(ws *Workspace, w io.Writer, args []string, option *MergeBaseOption) error {
+	return plumbing.MergeBase(ws, w, args, option)
+}
@@ -2,17 +2,27 @@ package git
 
 import (
 	"io"
-	"log"
 
-	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core"
 	"github.com/izhujiang/gogit/porcelain"
 )
 
 // Local  Operations
 
-// Init
-func Init(w io.Writer, root string) error {
-	return porcelain.Init(w, root)
+// Open the workspace whose work tree is root, and whose repository is root/.git
+func Open(root string) (*Workspace, error) {
+	return core.Open(root)
+}
+
+// Discover the workspace containing path by searching path and its parent directories for a git repository,
+// GIT_DIR, GIT_WORK_TREE and GIT_CEILING_DIRECTORIES are honoured.
+func Discover(path string) (*Workspace, error) {
+	return core.Discover(path)
+}
+
+// Init creates an empty git repository in root, or reinitializes an existing one
+func Init(w io.Writer, root string, option *InitOption) (*Workspace, error) {
+	return porcelain.Init(w, root, (*porcelain.InitOption)(option))
 }
 
 // Add file contents to the index
@@ -24,36 +34,105 @@ func Init(w io.Writer, root string) error {
 // Thus after making any changes to the working tree, and before running the commit command, you must use the add command to add any new or
 // modified files to the index.
 
-func Add(paths []string, option *AddOption) error {
-	return porcelain.Add(paths)
+func Add(ws *Workspace, paths []string, option *AddOption) error {
+	return porcelain.Add(ws, paths, (*porcelain.AddOption)(option))
 }
 
-func Remove(paths []string, option *RemoveOption) error {
-	return porcelain.Remove(paths, option)
+func Remove(ws *Workspace, paths []string, option *RemoveOption) error {
+	return porcelain.Remove(ws, paths, option)
 }
 
-func Reset() error {
-	return nil
+// Reset points the current branch to the commit args[0], HEAD if args are empty, and resets the index, and the work
+// tree with option.Hard, to it. Only index entries of paths are reset if there are any.
+func Reset(ws *Workspace, w io.Writer, args []string, paths []string, option *ResetOption) error {
+	return porcelain.Reset(ws, w, args, paths, (*porcelain.ResetOption)(option))
 }
 
-func Commit(w io.Writer, option *CommitOption) error {
-	return porcelain.Commit(w, (*porcelain.CommitOption)(option))
+// Restore restores paths in the work tree from the index, or in the index with option.Staged, from option.Source or
+// HEAD by default
+func Restore(ws *Workspace, paths []string, option *RestoreOption) error {
+	return porcelain.Restore(ws, paths, (*porcelain.RestoreOption)(option))
 }
 
-func Status() error {
-	return nil
+// Clean removes untracked files matching paths from the work tree, nothing is removed without option.Force unless
+// clean.requireForce is false
+func Clean(ws *Workspace, w io.Writer, paths []string, option *CleanOption) error {
+	return porcelain.Clean(ws, w, paths, (*porcelain.CleanOption)(option))
 }
 
-func Config() error {
-	return nil
+func Commit(ws *Workspace, w io.Writer, option *CommitOption) error {
+	return porcelain.Commit(ws, w, (*porcelain.CommitOption)(option))
 }
 
-func Branch() error {
-	return nil
+// Cleanup unnecessary files and optimize the local repository
+func Gc(ws *Workspace, w io.Writer, option *GcOption) error {
+	return porcelain.Gc(ws, w, (*porcelain.GcOption)(option))
 }
 
-func Checkout() error {
-	return nil
+// Status shows the working tree status, paths which differ between HEAD, the index and the work tree
+func Status(ws *Workspace, w io.Writer, option *StatusOption) error {
+	return porcelain.Status(ws, w, option)
+}
+
+// Config gets and sets repository or global options, ws is nil outside of a repository
+func Config(ws *Workspace, w io.Writer, args []string, option *ConfigOption) error {
+	return porcelain.Config(ws, w, args, (*porcelain.ConfigOption)(option))
+}
+
+// Tag creates the tag name pointing to target, an annotated tag object is created with option.Annotate or option.Message
+func Tag(ws *Workspace, w io.Writer, name string, target string, option *TagOption) error {
+	return porcelain.Tag(ws, w, name, target, (*porcelain.TagOption)(option))
+}
+
+// ListTags lists tags matching any of the patterns, or all tags without patterns
+func ListTags(ws *Workspace, w io.Writer, patterns []string) error {
+	return porcelain.ListTags(ws, w, patterns)
+}
+
+// DeleteTags deletes the tags of names
+func DeleteTags(ws *Workspace, w io.Writer, names []string) error {
+	return porcelain.DeleteTags(ws, w, names)
+}
+
+// ListBranches lists branches matching any of the patterns, or all branches without patterns
+func ListBranches(ws *Workspace, w io.Writer, patterns []string, option *BranchOption) error {
+	return porcelain.ListBranches(ws, w, patterns, (*porcelain.BranchOption)(option))
+}
+
+// CreateBranch creates branch name at startPoint, HEAD if it is empty
+func CreateBranch(ws *Workspace, w io.Writer, name string, startPoint string, option *BranchOption) error {
+	return porcelain.CreateBranch(ws, w, name, startPoint, (*porcelain.BranchOption)(option))
+}
+
+// DeleteBranches deletes branches of names, unmerged ones are deleted only with option.Force
+func DeleteBranches(ws *Workspace, w io.Writer, names []string, option *BranchOption) error {
+	return porcelain.DeleteBranches(ws, w, names, (*porcelain.BranchOption)(option))
+}
+
+// RenameBranch renames branch oldName, the current branch if it is empty, to newName
+func RenameBranch(ws *Workspace, w io.Writer, oldName string, newName string, option *BranchOption) error {
+	return porcelain.RenameBranch(ws, w, oldName, newName, (*porcelain.BranchOption)(option))
+}
+
+// SetUpstream sets upstream of branch name, the current branch if it is empty
+func SetUpstream(ws *Workspace, w io.Writer, name string, upstream string) error {
+	return porcelain.SetUpstream(ws, w, name, upstream)
+}
+
+// UnsetUpstream removes upstream of branch name, the current branch if it is empty
+func UnsetUpstream(ws *Workspace, name string) error {
+	return porcelain.UnsetUpstream(ws, name)
+}
+
+// Checkout switches to the branch or commit args[0], or restores paths from the tree-ish args[0] or the index.
+// paths are those given after "--", nil if there is no "--".
+func Checkout(ws *Workspace, w io.Writer, args []string, paths []string, option *CheckoutOption) error {
+	return porcelain.Checkout(ws, w, args, paths, (*porcelain.CheckoutOption)(option))
+}
+
+// Switch switches to the branch args[0], or detaches HEAD at the commit args[0] with option.Detach
+func Switch(ws *Workspace, w io.Writer, args []string, option *CheckoutOption) error {
+	return porcelain.Switch(ws, w, args, (*porcelain.CheckoutOption)(option))
 }
 
 func Merge() error {
@@ -64,10 +143,22 @@ func Stash() error {
 	return nil
 }
 
-func Log(w io.Writer, commitId string, option *LogOption) error {
-	oid, err := common.NewHash(commitId)
-	if err != nil {
-		log.Fatal(err)
-	}
-	return porcelain.Log(w, oid, (*porcelain.LogOption)(option))
+// Log shows the commit logs reachable from revisions, such as main, v1.0..HEAD and ^origin/main, HEAD if there are none
+func Log(ws *Workspace, w io.Writer, revisions []string, option *LogOption) error {
+	return porcelain.Log(ws, w, revisions, option)
+}
+
+// ReflogShow shows the reflog of ref from the newest entry, HEAD if ref is empty
+func ReflogShow(ws *Workspace, w io.Writer, ref string, option *ReflogShowOption) error {
+	return porcelain.ReflogShow(ws, w, ref, option)
+}
+
+// ReflogExpire prunes old entries from the reflogs of refs, or all reflogs with option.All
+func ReflogExpire(ws *Workspace, w io.Writer, refs []string, option *ReflogExpireOption) error {
+	return porcelain.ReflogExpire(ws, w, refs, option)
+}
+
+// ReflogDelete deletes the reflog entries selected by <ref>@{n}
+func ReflogDelete(ws *Workspace, w io.Writer, selectors []string, option *ReflogDeleteOption) error {
+	return porcelain.ReflogDelete(ws, w, selectors, option)
 }
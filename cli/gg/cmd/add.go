@@ -28,6 +28,10 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	addForce bool
+)
+
 // addCmd represents the add command
 var addCmd = &cobra.Command{
 	Use:   "add",
@@ -46,8 +50,8 @@ Please see git-commit(1) for alternative ways to add content to a commit.
 		// args have support glob, which means a* -> args [a1, g2, ...], a*/** -> args [a1/ba1, a2/b2, ...]
 		if len(args) > 0 {
 			paths := args
-			option := git.AddOption{}
-			err := git.Add(paths, &option)
+			option := git.AddOption{Force: addForce}
+			err := git.Add(openWorkspace(), paths, &option)
 			if err != nil {
 				log.Fatal(err)
 			}
@@ -57,4 +61,6 @@ Please see git-commit(1) for alternative ways to add content to a commit.
 
 func init() {
 	rootCmd.AddCommand(addCmd)
+
+	addCmd.Flags().BoolVarP(&addForce, "force", "f", false, "Allow adding otherwise ignored files.")
 }
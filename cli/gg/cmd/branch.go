@@ -0,0 +1,126 @@
+/*
+Copyright © 2022 Jiang Zhu <m.zhujiang@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"log"
+	"os"
+
+	git "github.com/izhujiang/gogit/api"
+	"github.com/spf13/cobra"
+)
+
+var (
+	branchVerbose       int
+	branchAll           bool
+	branchRemotes       bool
+	branchList          bool
+	branchDelete        bool
+	branchForceDelete   bool
+	branchMove          bool
+	branchForceMove     bool
+	branchForce         bool
+	branchTrack         bool
+	branchSetUpstreamTo string
+	branchUnsetUpstream bool
+)
+
+var branchCmd = &cobra.Command{
+	Use:   "branch [<options>] [<branchname>] [<start-point>]",
+	Short: "List, create, or delete branches",
+	Long: `If --list is given, or if there are no non-option arguments, existing branches are listed; the current branch will be highlighted
+with an asterisk. Option -r causes the remote-tracking branches to be listed, and option -a shows both local and remote branches.
+
+The command's second form creates a new branch head named <branchname> which points to the current HEAD, or <start-point> if given.
+When a local branch is started off a remote-tracking branch, the remote-tracking branch is set up as the upstream of the new branch.
+
+With a -m or -M option, <oldbranch> will be renamed to <newbranch>, along with its config.
+
+With a -d or -D option, <branchname> will be deleted. The branch must be fully merged in its upstream branch, or in HEAD if no
+upstream was set with --track or --set-upstream-to, unless -D is given.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ws := openWorkspace()
+		option := &git.BranchOption{
+			Verbose: branchVerbose,
+			Remotes: branchRemotes,
+			All:     branchAll,
+			Force:   branchForce || branchForceDelete || branchForceMove,
+			Track:   branchTrack,
+		}
+
+		var err error
+		switch {
+		case branchDelete || branchForceDelete:
+			err = git.DeleteBranches(ws, os.Stdout, args, option)
+		case branchMove || branchForceMove:
+			switch len(args) {
+			case 1:
+				err = git.RenameBranch(ws, os.Stdout, "", args[0], option)
+			case 2:
+				err = git.RenameBranch(ws, os.Stdout, args[0], args[1], option)
+			default:
+				log.Fatal("branch name required")
+			}
+		case cmd.Flags().Changed("set-upstream-to"):
+			name := ""
+			if len(args) > 0 {
+				name = args[0]
+			}
+			err = git.SetUpstream(ws, os.Stdout, name, branchSetUpstreamTo)
+		case branchUnsetUpstream:
+			name := ""
+			if len(args) > 0 {
+				name = args[0]
+			}
+			err = git.UnsetUpstream(ws, name)
+		case branchList || len(args) == 0:
+			err = git.ListBranches(ws, os.Stdout, args, option)
+		default:
+			startPoint := ""
+			if len(args) > 1 {
+				startPoint = args[1]
+			}
+			err = git.CreateBranch(ws, os.Stdout, args[0], startPoint, option)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(branchCmd)
+
+	branchCmd.Flags().CountVarP(&branchVerbose, "verbose", "v", "When in list mode, show sha1 and commit subject line for each head, along with relationship to upstream branch (if any). If given twice, print the name of the upstream branch, as well.")
+	branchCmd.Flags().BoolVarP(&branchAll, "all", "a", false, "List both remote-tracking branches and local branches.")
+	branchCmd.Flags().BoolVarP(&branchRemotes, "remotes", "r", false, "List the remote-tracking branches.")
+	branchCmd.Flags().BoolVarP(&branchList, "list", "l", false, "List branches. With optional <pattern>..., e.g. git branch --list 'maint-*', list only the branches that match the pattern(s).")
+	branchCmd.Flags().BoolVarP(&branchDelete, "delete", "d", false, "Delete a branch. The branch must be fully merged in its upstream branch, or in HEAD if no upstream was set.")
+	branchCmd.Flags().BoolVarP(&branchForceDelete, "D", "D", false, "Shortcut for --delete --force.")
+	branchCmd.Flags().BoolVarP(&branchMove, "move", "m", false, "Move/rename a branch, together with its config.")
+	branchCmd.Flags().BoolVarP(&branchForceMove, "M", "M", false, "Shortcut for --move --force.")
+	branchCmd.Flags().BoolVarP(&branchForce, "force", "f", false, "Reset <branchname> to <start-point>, even if <branchname> exists already. Allow deleting or renaming over unmerged or existing branches.")
+	branchCmd.Flags().BoolVarP(&branchTrack, "track", "t", false, "When creating a new branch, set up branch.<name>.remote and branch.<name>.merge configuration entries to mark the start-point branch as \"upstream\" from the new branch.")
+	branchCmd.Flags().StringVarP(&branchSetUpstreamTo, "set-upstream-to", "u", "", "Set up <branchname>'s tracking information so <upstream> is considered <branchname>'s upstream branch. If no <branchname> is specified, then it defaults to the current branch.")
+	branchCmd.Flags().BoolVar(&branchUnsetUpstream, "unset-upstream", false, "Remove the upstream information for <branchname>. If no branch is specified it defaults to the current branch.")
+	branchCmd.MarkFlagsMutuallyExclusive("delete", "D", "move", "M", "list", "set-upstream-to", "unset-upstream")
+}
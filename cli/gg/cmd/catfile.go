@@ -22,6 +22,7 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"log"
 	"os"
 
 	git "github.com/izhujiang/gogit/api"
@@ -52,7 +53,9 @@ to quickly create a Cobra application.`,
 				PrintContent: printContent,
 			}
 
-			git.CatFile(os.Stdout, args[0], option)
+			if err := git.CatFile(openWorkspace(), os.Stdout, args[0], option); err != nil {
+				log.Fatal(err)
+			}
 		}
 	},
 }
@@ -0,0 +1,76 @@
+/*
+Copyright © 2022 Jiang Zhu <m.zhujiang@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"log"
+	"os"
+
+	git "github.com/izhujiang/gogit/api"
+	"github.com/spf13/cobra"
+)
+
+var (
+	checkIgnoreVerbose     bool
+	checkIgnoreNonMatching bool
+	checkIgnoreQuiet       bool
+	checkIgnoreNoIndex     bool
+)
+
+var checkIgnoreCmd = &cobra.Command{
+	Use:   "check-ignore [-q] [-v] [-n] [--no-index] <pathname>...",
+	Short: "Debug gitignore / exclude files",
+	Long: `For each pathname given via the command-line, check whether the file is excluded by .gitignore (or other input
+files to the exclude mechanism) and output the path if it is excluded.
+
+By default, tracked files are not shown at all since they are not subject to exclude rules.
+
+With --verbose, the matching pattern is output as <source>:<linenum>:<pattern><TAB><pathname>, including negated
+patterns which re-include the path.
+
+It exits with status 1 without any message if none of the provided paths are ignored.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		option := &git.CheckIgnoreOption{
+			Verbose:     checkIgnoreVerbose,
+			NonMatching: checkIgnoreNonMatching,
+			Quiet:       checkIgnoreQuiet,
+			NoIndex:     checkIgnoreNoIndex,
+		}
+
+		if err := git.CheckIgnore(openWorkspace(), os.Stdout, args, option); err != nil {
+			if errors.Is(err, git.ErrNotIgnored) {
+				os.Exit(1)
+			}
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkIgnoreCmd)
+
+	checkIgnoreCmd.Flags().BoolVarP(&checkIgnoreQuiet, "quiet", "q", false, "Don't output anything, just set exit status. This is only valid with a single pathname.")
+	checkIgnoreCmd.Flags().BoolVarP(&checkIgnoreVerbose, "verbose", "v", false, "Instead of printing the paths that are excluded, for each path that matches an exclude pattern, print the exclude pattern together with the path.")
+	checkIgnoreCmd.Flags().BoolVarP(&checkIgnoreNonMatching, "non-matching", "n", false, "Show given paths which don't match any pattern. This only makes sense when --verbose is enabled.")
+	checkIgnoreCmd.Flags().BoolVar(&checkIgnoreNoIndex, "no-index", false, "Don't look in the index when undertaking the checks.")
+}
@@ -22,27 +22,62 @@ THE SOFTWARE.
 package cmd
 
 import (
-	"fmt"
+	"log"
+	"os"
 
+	git "github.com/izhujiang/gogit/api"
 	"github.com/spf13/cobra"
 )
 
-// checkoutCmd represents the checkout command
+var (
+	checkoutNewBranch      string
+	checkoutForceNewBranch string
+	checkoutDetach         bool
+	checkoutForce          bool
+)
+
 var checkoutCmd = &cobra.Command{
-	Use:   "checkout",
-	Short: "A brief description of your command",
-	Long: `A longer description that spans multiple lines and likely contains examples
-and usage of using your command. For example:
-
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
+	Use:   "checkout [<options>] [<branch>] [--] [<pathspec>...]",
+	Short: "Switch branches or restore working tree files",
+	Long: `Updates files in the working tree to match the version in the index or the specified tree. If no pathspec was given,
+git checkout will also update HEAD to set the specified branch as the current branch.
+
+git checkout <branch> switches to <branch> by updating the index and the files in the working tree, and by pointing HEAD at
+the branch. Local modifications to the files in the working tree are kept, so that they can be committed to the <branch>,
+unless they would be overwritten. With -b or -B, a new branch is created at <start-point> and checked out.
+
+git checkout --detach [<commit>] prepares to work on top of <commit> by detaching HEAD at it, which is also done for a
+<commit> which is not a branch name.
+
+git checkout [<tree-ish>] [--] <pathspec>... overwrites the contents of the files matching the pathspec. When the <tree-ish>
+is given, the paths are updated both in the index and in the working tree, otherwise they are restored from the index.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("checkout called")
+		var paths []string
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			args, paths = args[:dash], append([]string{}, args[dash:]...)
+		}
+		ws := openWorkspace()
+		option := &git.CheckoutOption{
+			NewBranch: checkoutNewBranch,
+			Detach:    checkoutDetach,
+			Force:     checkoutForce,
+		}
+		if checkoutForceNewBranch != "" {
+			option.NewBranch, option.ForceCreate = checkoutForceNewBranch, true
+		}
+
+		if err := git.Checkout(ws, os.Stdout, args, paths, option); err != nil {
+			log.Fatal(err)
+		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(checkoutCmd)
 
+	checkoutCmd.Flags().StringVarP(&checkoutNewBranch, "create", "b", "", "Create a new branch named <new-branch> and start it at <start-point>.")
+	checkoutCmd.Flags().StringVarP(&checkoutForceNewBranch, "force-create", "B", "", "Creates the branch <new-branch> and start it at <start-point>; if it already exists, then reset it to <start-point>.")
+	checkoutCmd.Flags().BoolVarP(&checkoutDetach, "detach", "d", false, "Rather than checking out a branch to work on it, check out <commit> for inspection and discardable experiments.")
+	checkoutCmd.Flags().BoolVarP(&checkoutForce, "force", "f", false, "When switching branches, proceed even if the index or the working tree differs from HEAD, and even if there are untracked files in the way. This is used to throw away local changes.")
+	checkoutCmd.MarkFlagsMutuallyExclusive("b", "B", "detach")
 }
@@ -0,0 +1,77 @@
+/*
+Copyright © 2022 Jiang Zhu <m.zhujiang@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"log"
+	"os"
+
+	git "github.com/izhujiang/gogit/api"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cleanDryRun      bool
+	cleanForce       int
+	cleanDirs        bool
+	cleanNoIgnore    bool
+	cleanOnlyIgnored bool
+	cleanQuiet       bool
+)
+
+var cleanCmd = &cobra.Command{
+	Use:   "clean [-d] [-f] [-n] [-q] [-x | -X] [--] [<pathspec>...]",
+	Short: "Remove untracked files from the working tree",
+	Long: `Cleans the working tree by recursively removing files that are not under version control, starting from the current
+directory.
+
+Normally, only files unknown to Git are removed, but if the -x option is specified, ignored files are also removed. This
+can, for example, be useful to remove all build products.
+
+If any optional <pathspec>... arguments are given, only those paths that match the pathspec are affected.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		option := &git.CleanOption{
+			DryRun:      cleanDryRun,
+			Force:       cleanForce,
+			Dirs:        cleanDirs,
+			NoIgnore:    cleanNoIgnore,
+			OnlyIgnored: cleanOnlyIgnored,
+			Quiet:       cleanQuiet,
+		}
+
+		if err := git.Clean(openWorkspace(), os.Stdout, args, option); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cleanCmd)
+
+	cleanCmd.Flags().BoolVarP(&cleanDryRun, "dry-run", "n", false, "Don't actually remove anything, just show what would be done.")
+	cleanCmd.Flags().CountVarP(&cleanForce, "force", "f", "Required to delete files unless clean.requireForce is false. Given twice, untracked nested git repositories are removed as well.")
+	cleanCmd.Flags().BoolVarP(&cleanDirs, "d", "d", false, "Normally, when no <pathspec> is specified, git clean will not recurse into untracked directories. This option recurses into them and removes them as well.")
+	cleanCmd.Flags().BoolVarP(&cleanNoIgnore, "x", "x", false, "Don't use the standard ignore rules, but still use -e options given on the command line.")
+	cleanCmd.Flags().BoolVarP(&cleanOnlyIgnored, "X", "X", false, "Remove only files ignored by Git.")
+	cleanCmd.Flags().BoolVarP(&cleanQuiet, "quiet", "q", false, "Be quiet, only report errors, but not the files that are successfully removed.")
+	cleanCmd.MarkFlagsMutuallyExclusive("x", "X")
+}
@@ -22,6 +22,7 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"log"
 	"os"
 
 	git "github.com/izhujiang/gogit/api"
@@ -38,13 +39,19 @@ var commitCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		option := &git.CommitOption{
 			Message: message,
+			Author:  author,
+			Date:    date,
+		}
+		if err := git.Commit(openWorkspace(), os.Stdout, option); err != nil {
+			log.Fatal(err)
 		}
-		git.Commit(os.Stdout, option)
 	},
 }
 
 func init() {
 	commitCmd.Flags().StringVarP(&message, "message", "m", "", "Use the given <msg> as the commit message. If multiple -m options are given, their values are concatenated as separate paragraphs.")
+	commitCmd.Flags().StringVar(&author, "author", "", "Override the commit author. Specify an explicit author using the standard A U Thor <author@example.com> format.")
+	commitCmd.Flags().StringVar(&date, "date", "", "Override the author date used in the commit, in RFC 2822, ISO 8601 or git's internal format @<unix timestamp> <time zone offset>.")
 	rootCmd.AddCommand(commitCmd)
 
 }
@@ -23,6 +23,7 @@ package cmd
 
 import (
 	"bytes"
+	"log"
 	"os"
 
 	git "github.com/izhujiang/gogit/api"
@@ -32,6 +33,8 @@ import (
 var (
 	parents []string
 	message string
+	author  string
+	date    string
 )
 
 // commitTreeCmd represents the commitTree command
@@ -57,9 +60,13 @@ to quickly create a Cobra application.`,
 			option := &git.CommitTreeOption{
 				Parents: parents,
 				Message: message,
+				Author:  author,
+				Date:    date,
 			}
 
-			git.CommitTree(os.Stdout, args[0], option)
+			if err := git.CommitTree(openWorkspace(), os.Stdout, args[0], option); err != nil {
+				log.Fatal(err)
+			}
 
 		}
 	},
@@ -68,6 +75,8 @@ to quickly create a Cobra application.`,
 func init() {
 	commitTreeCmd.Flags().StringArrayVarP(&parents, "parents", "p", []string{}, "Each -p indicates the id of a parent commit object.")
 	commitTreeCmd.Flags().StringVarP(&message, "message", "m", "", "A paragraph in the commit log message.")
+	commitTreeCmd.Flags().StringVar(&author, "author", "", "Override the commit author. Specify an explicit author using the standard A U Thor <author@example.com> format.")
+	commitTreeCmd.Flags().StringVar(&date, "date", "", "Override the author date used in the commit, in RFC 2822, ISO 8601 or git's internal format @<unix timestamp> <time zone offset>.")
 
 	rootCmd.AddCommand(commitTreeCmd)
 
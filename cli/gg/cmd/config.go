@@ -0,0 +1,128 @@
+/*
+Copyright © 2022 Jiang Zhu <m.zhujiang@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"log"
+	"os"
+
+	git "github.com/izhujiang/gogit/api"
+	"github.com/izhujiang/gogit/porcelain"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configGlobal     bool
+	configLocal      bool
+	configSystem     bool
+	configFile       string
+	configGet        bool
+	configGetAll     bool
+	configGetRegexp  bool
+	configUnset      bool
+	configUnsetAll   bool
+	configAdd        bool
+	configList       bool
+	configShowOrigin bool
+	configType       string
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Get and set repository or global options",
+	Long: `You can query/set/replace/unset options with this command. The name is actually the section and the key separated by a dot, and the value
+will be escaped.
+
+Multiple lines can be added to an option by using the --add option. If you want to update or unset an option which can occur on multiple
+lines, a value-pattern (which is an extended regular expression, unless the --fixed-value option is given) needs to be given. Only the
+existing values that match the pattern are updated or unset. If you want to handle the lines that do not match the pattern, just prepend a
+single exclamation mark in front.
+
+When reading, the values are read from the system, global and repository local configuration files by default, and options --system,
+--global, --local and --file <filename> can be used to tell the command to read from only that location.
+
+When writing, the new value is written to the repository local configuration file by default, and options --system, --global, --file
+<filename> can be used to tell the command to write to that location.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		option := &git.ConfigOption{
+			System:     configSystem,
+			Global:     configGlobal,
+			Local:      configLocal,
+			File:       configFile,
+			Type:       configType,
+			ShowOrigin: configShowOrigin,
+		}
+		switch {
+		case configGet:
+			option.Op = porcelain.Config_Get
+		case configGetAll:
+			option.Op = porcelain.Config_GetAll
+		case configGetRegexp:
+			option.Op = porcelain.Config_GetRegexp
+		case configUnset:
+			option.Op = porcelain.Config_Unset
+		case configUnsetAll:
+			option.Op = porcelain.Config_UnsetAll
+		case configAdd:
+			option.Op = porcelain.Config_Add
+		case configList:
+			option.Op = porcelain.Config_List
+		}
+
+		// config works outside of a repository with --global, --system or --file
+		ws, _ := git.Discover(".")
+
+		err := git.Config(ws, os.Stdout, args, option)
+		if errors.Is(err, git.ErrConfigKeyNotFound) {
+			if configUnset || configUnsetAll {
+				os.Exit(5)
+			}
+			os.Exit(1)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+
+	configCmd.Flags().BoolVar(&configGlobal, "global", false, "For writing options: write to global ~/.gitconfig file rather than the repository .git/config. For reading options: read only from global ~/.gitconfig and from $XDG_CONFIG_HOME/git/config.")
+	configCmd.Flags().BoolVar(&configSystem, "system", false, "For writing options: write to system-wide $(prefix)/etc/gitconfig rather than the repository .git/config. For reading options: read only from system-wide $(prefix)/etc/gitconfig.")
+	configCmd.Flags().BoolVar(&configLocal, "local", false, "For writing options: write to the repository .git/config file. This is the default behavior. For reading options: read only from the repository .git/config.")
+	configCmd.Flags().StringVarP(&configFile, "file", "f", "", "For writing options: write to the specified file rather than the repository .git/config. For reading options: read only from the specified file.")
+	configCmd.MarkFlagsMutuallyExclusive("global", "system", "local", "file")
+
+	configCmd.Flags().BoolVar(&configGet, "get", false, "Get the value for a given key (optionally filtered by a regex matching the value). Returns error code 1 if the key was not found.")
+	configCmd.Flags().BoolVar(&configGetAll, "get-all", false, "Like get, but returns all values for a multi-valued key.")
+	configCmd.Flags().BoolVar(&configGetRegexp, "get-regexp", false, "Like --get-all, but interprets the name as a regular expression and writes out the key names.")
+	configCmd.Flags().BoolVar(&configUnset, "unset", false, "Remove the line matching the key from config file.")
+	configCmd.Flags().BoolVar(&configUnsetAll, "unset-all", false, "Remove all lines matching the key from config file.")
+	configCmd.Flags().BoolVar(&configAdd, "add", false, "Adds a new line to the option without altering any existing values.")
+	configCmd.Flags().BoolVarP(&configList, "list", "l", false, "List all variables set in config file, along with their values.")
+	configCmd.MarkFlagsMutuallyExclusive("get", "get-all", "get-regexp", "unset", "unset-all", "add", "list")
+
+	configCmd.Flags().BoolVar(&configShowOrigin, "show-origin", false, "Augment the output of all queried config options with the origin type (file, standard input, blob, command line) and the actual origin (config file path, ref, or blob id if applicable).")
+	configCmd.Flags().StringVar(&configType, "type", "", "git config will ensure that any input or output is valid under the given type constraint(s), and will canonicalize outgoing values in <type>'s canonical form: bool, int or path.")
+}
@@ -22,6 +22,7 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"log"
 	"os"
 
 	git "github.com/izhujiang/gogit/api"
@@ -43,7 +44,9 @@ to quickly create a Cobra application.`,
 			oid := args[0]
 			w := os.Stdout
 			option := git.DumpOption{}
-			git.Dump(w, oid, &option)
+			if err := git.Dump(openWorkspace(), w, oid, &option); err != nil {
+				log.Fatal(err)
+			}
 		}
 	},
 }
@@ -0,0 +1,58 @@
+/*
+Copyright © 2022 Jiang Zhu <m.zhujiang@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"log"
+	"os"
+
+	git "github.com/izhujiang/gogit/api"
+	"github.com/spf13/cobra"
+)
+
+var (
+	aggressive bool
+)
+
+// gcCmd represents the gc command
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Cleanup unnecessary files and optimize the local repository",
+	Long: `Runs a number of housekeeping tasks within the current repository, such as compressing file revisions (to reduce disk space and increase
+performance) and removing loose objects which have been packed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		option := &git.GcOption{
+			Aggressive: aggressive,
+		}
+
+		err := git.Gc(openWorkspace(), os.Stdout, option)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	gcCmd.Flags().BoolVar(&aggressive, "aggressive", false, "More aggressively optimize the repository at the expense of taking much more time.")
+
+	rootCmd.AddCommand(gcCmd)
+}
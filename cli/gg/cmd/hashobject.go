@@ -49,6 +49,12 @@ defaults to "blob".`,
 			Write:      writeToDatabase,
 		}
 
+		// the object is hashed without a repository unless it is written
+		var ws *git.Workspace
+		if writeToDatabase {
+			ws = openWorkspace()
+		}
+
 		// TODO: read text from stdin or file specified by args[0]
 		if len(args) > 0 {
 			path := args[0]
@@ -58,10 +64,10 @@ defaults to "blob".`,
 			}
 			defer f.Close()
 
-			git.HashObject(os.Stdout, f, option)
+			git.HashObject(ws, os.Stdout, f, option)
 		} else {
 			if usingStdin {
-				git.HashObject(os.Stdout, os.Stdin, option)
+				git.HashObject(ws, os.Stdout, os.Stdin, option)
 			}
 		}
 	},
@@ -11,6 +11,10 @@ import (
 )
 
 // initCmd represents the init command
+var (
+	bare bool
+)
+
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Create an empty Git repository or reinitialize an existing one",
@@ -22,17 +26,21 @@ var initCmd = &cobra.Command{
        Running git init in an existing repository is safe. It will not overwrite things that are already there. The primary reason for rerunning git init is to pick up newly added templates (or to move the repository to another place if --separate-git-dir is given).
 `,
 	Run: func(cmd *cobra.Command, args []string) {
+		option := &git.InitOption{
+			Bare: bare,
+		}
 		if len(args) > 0 {
-			git.Init(os.Stdout, args[0])
+			git.Init(os.Stdout, args[0], option)
 
 		} else {
-			git.Init(os.Stdout, "")
+			git.Init(os.Stdout, "", option)
 		}
 
 	},
 }
 
 func init() {
+	initCmd.Flags().BoolVar(&bare, "bare", false, "Create a bare repository. If GIT_DIR environment is not set, it is set to the current working directory.")
 	rootCmd.AddCommand(initCmd)
 
 }
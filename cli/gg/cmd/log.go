@@ -22,6 +22,7 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"log"
 	"os"
 
 	git "github.com/izhujiang/gogit/api"
@@ -29,30 +30,89 @@ import (
 )
 
 var (
-	stat bool
+	stat           bool
+	logMaxCount    int
+	logReverse     bool
+	logFirstParent bool
+	logTopoOrder   bool
+	logDateOrder   bool
+	logAll         bool
+	logNumStat     bool
+	logOneline     bool
+	logPretty      string
+	logFormat      string
+	logGraph       bool
+	logDecorate    bool
+	logAuthor      string
+	logGrep        string
+	logSince       string
+	logUntil       string
 )
 
 // logCmd represents the log command
 var logCmd = &cobra.Command{
-	Use:   "log",
+	Use:   "log [<options>] [<revision-range>] [-- <path>...]",
 	Short: "Show commit logs",
 	Long: `Shows the commit logs.
 
        List commits that are reachable by following the parent links from the given commit(s), but exclude
        commits that are reachable from the one(s) given with a ^ in front of them. The output is given in
-       reverse chronological order by default.`,
+       reverse chronological order by default.
+
+       Paths given after -- limit commits to those changing files in them, and the history is simplified
+       by leaving out merges which bring no changes to them from one of their parents.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if len(args) > 0 {
-			commitId := args[0]
-			option := &git.LogOption{}
-			git.Log(os.Stdout, commitId, option)
+		var paths []string
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			args, paths = args[:dash], args[dash:]
+		}
+		option := &git.LogOption{
+			Stat:        stat,
+			MaxCount:    logMaxCount,
+			Reverse:     logReverse,
+			FirstParent: logFirstParent,
+			TopoOrder:   logTopoOrder,
+			DateOrder:   logDateOrder,
+			All:         logAll,
+			NumStat:     logNumStat,
+			Oneline:     logOneline,
+			Pretty:      logPretty,
+			Format:      logFormat,
+			Graph:       logGraph,
+			Decorate:    logDecorate,
+			Author:      logAuthor,
+			Grep:        logGrep,
+			Since:       logSince,
+			Until:       logUntil,
+			Paths:       paths,
+		}
+		if err := git.Log(openWorkspace(), os.Stdout, args, option); err != nil {
+			log.Fatal(err)
 		}
 	},
 }
 
 func init() {
 
+	logCmd.Flags().IntVarP(&logMaxCount, "max-count", "n", 0, "Limit the number of commits to output.")
+	logCmd.Flags().BoolVar(&logReverse, "reverse", false, "Output the commits chosen to be shown in reverse order.")
+	logCmd.Flags().BoolVar(&logFirstParent, "first-parent", false, "Follow only the first parent commit upon seeing a merge commit.")
+	logCmd.Flags().BoolVar(&logTopoOrder, "topo-order", false, "Show no parents before all of its children are shown, and avoid showing commits on multiple lines of history intermixed.")
+	logCmd.Flags().BoolVar(&logDateOrder, "date-order", false, "Show no parents before all of its children are shown, but otherwise show commits in the commit timestamp order.")
+	logCmd.Flags().BoolVar(&logAll, "all", false, "Pretend as if all the refs in refs/, along with HEAD, are listed on the command line as <commit>.")
 	logCmd.Flags().BoolVar(&stat, "stat", false, `Generate a diffstat. By default, as much space as necessary will be used for the filename part, and the rest for the graph part.`)
+	logCmd.Flags().BoolVar(&logNumStat, "numstat", false, "Similar to --stat, but shows number of added and deleted lines in decimal notation and pathname without abbreviation.")
+	logCmd.Flags().BoolVar(&logOneline, "oneline", false, "Shorthand for --pretty=oneline --abbrev-commit.")
+	logCmd.Flags().StringVar(&logPretty, "pretty", "", "Pretty-print the contents of the commit logs in a given format: oneline, short, medium, full, fuller, format:<string> or tformat:<string>.")
+	logCmd.Flags().StringVar(&logFormat, "format", "", "Pretty-print the contents of the commit logs in a given format, like --pretty=tformat:<format>.")
+	logCmd.Flags().BoolVar(&logGraph, "graph", false, "Draw a text-based graphical representation of the commit history on the left hand side of the output.")
+	logCmd.Flags().BoolVar(&logDecorate, "decorate", false, "Print out the ref names of any commits that are shown.")
+	logCmd.Flags().StringVar(&logAuthor, "author", "", "Limit the commits output to ones with author header lines that match the regular expression.")
+	logCmd.Flags().StringVar(&logGrep, "grep", "", "Limit the commits output to ones with log message that matches the regular expression.")
+	logCmd.Flags().StringVar(&logSince, "since", "", "Show commits more recent than a specific date.")
+	logCmd.Flags().StringVar(&logSince, "after", "", "Show commits more recent than a specific date.")
+	logCmd.Flags().StringVar(&logUntil, "until", "", "Show commits older than a specific date.")
+	logCmd.Flags().StringVar(&logUntil, "before", "", "Show commits older than a specific date.")
 	rootCmd.AddCommand(logCmd)
 
 	// Here you will define your flags and configuration settings.
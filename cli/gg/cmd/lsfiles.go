@@ -22,6 +22,7 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"log"
 	"os"
 
 	git "github.com/izhujiang/gogit/api"
@@ -29,7 +30,12 @@ import (
 )
 
 var (
-	showStage bool
+	showStage         bool
+	lsFilesCached     bool
+	lsFilesOthers     bool
+	lsFilesIgnored    bool
+	lsFilesDirectory  bool
+	lsFilesExcludeStd bool
 )
 
 // lsFilesCmd represents the lsFiles command
@@ -96,14 +102,31 @@ var lsFilesCmd = &cobra.Command{
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		w := os.Stdout
-		option := &git.LsFilesOption{Stage: true}
-
-		git.LsFiles(w, option)
+		option := &git.LsFilesOption{
+			Cached:          lsFilesCached,
+			Stage:           showStage,
+			Others:          lsFilesOthers,
+			Ignored:         lsFilesIgnored,
+			Directory:       lsFilesDirectory,
+			ExcludeStandard: lsFilesExcludeStd,
+		}
+		if !option.Stage && !option.Others && !option.Ignored {
+			option.Cached = true
+		}
+
+		if err := git.LsFiles(openWorkspace(), w, option); err != nil {
+			log.Fatal(err)
+		}
 
 	},
 }
 
 func init() {
+	lsFilesCmd.Flags().BoolVarP(&lsFilesCached, "cached", "c", false, "Show all files cached in Git's index, i.e. all tracked files. (This is the default if no -c/-s/-d/-o/-u/-k/-m/--resolve-undo options are specified.)")
+	lsFilesCmd.Flags().BoolVarP(&lsFilesOthers, "others", "o", false, "Show other (i.e. untracked) files in the output.")
+	lsFilesCmd.Flags().BoolVarP(&lsFilesIgnored, "ignored", "i", false, "Show only ignored files in the output. Must be used with either an explicit -c or -o.")
+	lsFilesCmd.Flags().BoolVar(&lsFilesDirectory, "directory", false, "If a whole directory is classified as \"other\", show just its name (with a trailing slash) and not its whole contents.")
+	lsFilesCmd.Flags().BoolVar(&lsFilesExcludeStd, "exclude-standard", false, "Add the standard Git exclusions: .git/info/exclude, .gitignore in each directory, and the user's global exclusion file.")
 	lsFilesCmd.Flags().BoolVarP(&showStage, "stage", "s", false, "Show staged contents' mode bits, object name and stage number in the output.")
 	rootCmd.AddCommand(lsFilesCmd)
 
@@ -22,6 +22,7 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"log"
 	"os"
 
 	git "github.com/izhujiang/gogit/api"
@@ -44,7 +45,9 @@ to quickly create a Cobra application.`,
 			Recurse: false,
 		}
 		w := os.Stdout
-		git.LsTree(w, args[0], option)
+		if err := git.LsTree(openWorkspace(), w, args[0], option); err != nil {
+			log.Fatal(err)
+		}
 	},
 }
 
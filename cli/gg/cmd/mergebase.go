@@ -0,0 +1,79 @@
+/*
+Copyright © 2022 Jiang Zhu <m.zhujiang@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"log"
+	"os"
+
+	git "github.com/izhujiang/gogit/api"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mergeBaseAll         bool
+	mergeBaseOctopus     bool
+	mergeBaseIndependent bool
+	mergeBaseIsAncestor  bool
+	mergeBaseForkPoint   bool
+)
+
+var mergeBaseCmd = &cobra.Command{
+	Use:   "merge-base [-a | --all] [--octopus | --independent | --is-ancestor | --fork-point] <commit>...",
+	Short: "Find as good common ancestors as possible for a merge",
+	Long: `Finds the best common ancestors between two commits to use in a three-way merge. One common ancestor is
+better than another if the latter is an ancestor of the former. With more than two commits, the merge base is
+computed between the first commit and a hypothetical merge of the others.
+
+With criss-cross merges there may be more than one best common ancestor, and --all prints all of them.
+
+It exits with status 1 without any message if no merge base is found, or --is-ancestor finds the first commit is
+not an ancestor of the second.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		option := &git.MergeBaseOption{
+			All:         mergeBaseAll,
+			Octopus:     mergeBaseOctopus,
+			Independent: mergeBaseIndependent,
+			IsAncestor:  mergeBaseIsAncestor,
+			ForkPoint:   mergeBaseForkPoint,
+		}
+
+		if err := git.MergeBase(openWorkspace(), os.Stdout, args, option); err != nil {
+			if errors.Is(err, git.ErrNoMergeBase) || errors.Is(err, git.ErrNotAncestor) {
+				os.Exit(1)
+			}
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mergeBaseCmd)
+
+	mergeBaseCmd.Flags().BoolVarP(&mergeBaseAll, "all", "a", false, "Output all merge bases for the commits, instead of just one.")
+	mergeBaseCmd.Flags().BoolVar(&mergeBaseOctopus, "octopus", false, "Compute the best common ancestors of all supplied commits, in preparation for an n-way merge.")
+	mergeBaseCmd.Flags().BoolVar(&mergeBaseIndependent, "independent", false, "Print a minimal subset of the supplied commits with the same ancestors.")
+	mergeBaseCmd.Flags().BoolVar(&mergeBaseIsAncestor, "is-ancestor", false, "Check if the first <commit> is an ancestor of the second <commit>, and exit with status 0 if true, or with status 1 if not.")
+	mergeBaseCmd.Flags().BoolVar(&mergeBaseForkPoint, "fork-point", false, "Find the point at which a branch forked from another branch <ref>, using the reflog of <ref>.")
+}
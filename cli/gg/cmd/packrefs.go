@@ -0,0 +1,64 @@
+/*
+Copyright © 2022 Jiang Zhu <m.zhujiang@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"log"
+
+	git "github.com/izhujiang/gogit/api"
+	"github.com/spf13/cobra"
+)
+
+var (
+	packRefsAll     bool
+	packRefsPrune   bool
+	packRefsNoPrune bool
+)
+
+var packRefsCmd = &cobra.Command{
+	Use:   "pack-refs",
+	Short: "Pack heads and tags for efficient repository access",
+	Long: `Traditionally, tips of branches and tags (collectively known as refs) were stored one file per ref in a (sub)directory under
+$GIT_DIR/refs directory. This command is used to solve the storage and performance problem by storing the refs in a single file,
+$GIT_DIR/packed-refs. When a ref is missing from the traditional $GIT_DIR/refs directory hierarchy, it is looked up in this file and
+used if found.
+
+Subsequent updates to branches always create new files under $GIT_DIR/refs directory hierarchy.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		option := &git.PackRefsOption{
+			All:   packRefsAll,
+			Prune: packRefsPrune && !packRefsNoPrune,
+		}
+
+		if err := git.PackRefs(openWorkspace(), option); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(packRefsCmd)
+
+	packRefsCmd.Flags().BoolVar(&packRefsAll, "all", false, "The command by default packs all tags and refs that are already packed, and leaves other refs alone. This option causes all refs to be packed as well.")
+	packRefsCmd.Flags().BoolVar(&packRefsNoPrune, "no-prune", false, "The command usually removes loose refs under $GIT_DIR/refs hierarchy after packing them. This option tells it not to.")
+	packRefsCmd.Flags().BoolVar(&packRefsPrune, "prune", true, "Remove loose refs after packing them, which is the default.")
+}
@@ -22,6 +22,7 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"log"
 	"os"
 
 	git "github.com/izhujiang/gogit/api"
@@ -60,7 +61,9 @@ to quickly create a Cobra application.`,
 				Prefix:    prefix,
 			}
 
-			git.ReadTree(w, id, option)
+			if err := git.ReadTree(openWorkspace(), w, id, option); err != nil {
+				log.Fatal(err)
+			}
 
 		}
 
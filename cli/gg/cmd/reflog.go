@@ -0,0 +1,141 @@
+/*
+Copyright © 2022 Jiang Zhu <m.zhujiang@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"log"
+	"os"
+
+	git "github.com/izhujiang/gogit/api"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reflogMaxCount int
+
+	reflogExpire            string
+	reflogExpireUnreachable string
+	reflogAll               bool
+	reflogDryRun            bool
+	reflogVerbose           bool
+
+	reflogRewrite   bool
+	reflogUpdateRef bool
+)
+
+var reflogCmd = &cobra.Command{
+	Use:   "reflog [show] [<ref>]",
+	Short: "Manage reflog information",
+	Long: `Reference logs, or "reflogs", record when the tips of branches and other references were updated in the local repository.
+Reflogs are useful in various Git commands, to specify the old value of a reference. For example, HEAD@{2} means "where HEAD
+used to be two moves ago", master@{one.week.ago} means "where master used to point to one week ago in this local repository".
+
+The "show" subcommand (which is also the default, in the absence of any subcommands) shows the log of the reference provided
+in the command-line (or HEAD, by default).`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		reflogShow(args)
+	},
+}
+
+var reflogShowCmd = &cobra.Command{
+	Use:   "show [<ref>]",
+	Short: "Show the log of the reference, HEAD by default",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		reflogShow(args)
+	},
+}
+
+var reflogExpireCmd = &cobra.Command{
+	Use:   "expire [--expire=<time>] [--expire-unreachable=<time>] [--dry-run] [--verbose] [--all | <refs>...]",
+	Short: "Prune older reflog entries",
+	Long: `Prunes older reflog entries. Entries older than expire time, or entries older than expire-unreachable time and not
+reachable from the current tip, are removed from the reflog. This is typically not used directly by end users, instead,
+see git gc.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		option := &git.ReflogExpireOption{
+			Expire:            reflogExpire,
+			ExpireUnreachable: reflogExpireUnreachable,
+			All:               reflogAll,
+			DryRun:            reflogDryRun,
+			Verbose:           reflogVerbose,
+		}
+
+		if err := git.ReflogExpire(openWorkspace(), os.Stdout, args, option); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+var reflogDeleteCmd = &cobra.Command{
+	Use:   "delete [--rewrite] [--updateref] [--dry-run] <ref>@{<specifier>}...",
+	Short: "Delete single entries from the reflog",
+	Long: `Removes single entries from the reflog. Its argument must be an exact entry (e.g. "git reflog delete master@{2}").
+This subcommand is also typically not used directly by end users.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		option := &git.ReflogDeleteOption{
+			Rewrite:   reflogRewrite,
+			UpdateRef: reflogUpdateRef,
+			DryRun:    reflogDryRun,
+		}
+
+		if err := git.ReflogDelete(openWorkspace(), os.Stdout, args, option); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func reflogShow(args []string) {
+	ref := ""
+	if len(args) > 0 {
+		ref = args[0]
+	}
+	option := &git.ReflogShowOption{
+		MaxCount: reflogMaxCount,
+	}
+
+	if err := git.ReflogShow(openWorkspace(), os.Stdout, ref, option); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(reflogCmd)
+	reflogCmd.AddCommand(reflogShowCmd)
+	reflogCmd.AddCommand(reflogExpireCmd)
+	reflogCmd.AddCommand(reflogDeleteCmd)
+
+	reflogCmd.Flags().IntVarP(&reflogMaxCount, "max-count", "n", 0, "Limit the number of entries to output.")
+	reflogShowCmd.Flags().IntVarP(&reflogMaxCount, "max-count", "n", 0, "Limit the number of entries to output.")
+
+	reflogExpireCmd.Flags().StringVar(&reflogExpire, "expire", "", "Prune entries older than the specified time, gc.reflogExpire or 90 days by default.")
+	reflogExpireCmd.Flags().StringVar(&reflogExpireUnreachable, "expire-unreachable", "", "Prune entries older than the specified time that are not reachable from the current tip of the branch, gc.reflogExpireUnreachable or 30 days by default.")
+	reflogExpireCmd.Flags().BoolVar(&reflogAll, "all", false, "Process the reflogs of all references.")
+	reflogExpireCmd.Flags().BoolVarP(&reflogDryRun, "dry-run", "n", false, "Do not actually prune any entries; just show what would have been pruned.")
+	reflogExpireCmd.Flags().BoolVar(&reflogVerbose, "verbose", false, "Print extra information on screen.")
+
+	reflogDeleteCmd.Flags().BoolVar(&reflogRewrite, "rewrite", false, "Adjust the old SHA-1 of the entry after a deleted one to the new SHA-1 of the entry that now precedes it.")
+	reflogDeleteCmd.Flags().BoolVar(&reflogUpdateRef, "updateref", false, "Update the reference to the value of the top reflog entry (i.e. <ref>@{0}) if the previous top entry was deleted.")
+	reflogDeleteCmd.Flags().BoolVarP(&reflogDryRun, "dry-run", "n", false, "Do not actually delete any entries.")
+}
@@ -0,0 +1,74 @@
+/*
+Copyright © 2022 Jiang Zhu <m.zhujiang@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"log"
+	"os"
+
+	git "github.com/izhujiang/gogit/api"
+	"github.com/izhujiang/gogit/core"
+	"github.com/spf13/cobra"
+)
+
+var (
+	repackAll             bool
+	repackKeepUnreachable bool
+	repackDelete          bool
+	repackWindow          int
+	repackDepth           int
+)
+
+// repackCmd represents the repack command
+var repackCmd = &cobra.Command{
+	Use:   "repack",
+	Short: "Pack unpacked objects in a repository",
+	Long: `This command is used to combine all objects that do not currently reside in a "pack", into a pack. It can also be used to re-organize
+existing packs into a single, more efficient pack.
+
+A pack is a collection of objects, individually compressed, with delta compression applied, stored in a single file, with an associated
+index file.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		option := &git.RepackOption{
+			All:             repackAll || repackKeepUnreachable,
+			Delete:          repackDelete,
+			KeepUnreachable: repackKeepUnreachable,
+			Window:          repackWindow,
+			Depth:           repackDepth,
+		}
+
+		err := git.Repack(openWorkspace(), os.Stdout, option)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	repackCmd.Flags().BoolVarP(&repackAll, "all", "a", false, "Instead of incrementally packing the unpacked objects, pack everything referenced into a single pack.")
+	repackCmd.Flags().BoolVarP(&repackKeepUnreachable, "unpack-unreachable", "A", false, "Same as -a, unless -d is used. Then any unreachable objects in a previous pack become loose, unpacked objects, instead of being left in the old pack.")
+	repackCmd.Flags().BoolVarP(&repackDelete, "delete", "d", false, "After packing, if the newly created packs make some existing packs redundant, remove the redundant packs.")
+	repackCmd.Flags().IntVar(&repackWindow, "window", core.Default_Pack_Window, "The number of objects to consider when doing delta compression.")
+	repackCmd.Flags().IntVar(&repackDepth, "depth", core.Default_Pack_Depth, "The maximum delta depth.")
+
+	rootCmd.AddCommand(repackCmd)
+}
@@ -0,0 +1,77 @@
+/*
+Copyright © 2022 Jiang Zhu <m.zhujiang@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"log"
+	"os"
+
+	git "github.com/izhujiang/gogit/api"
+	"github.com/spf13/cobra"
+)
+
+var (
+	resetSoft  bool
+	resetMixed bool
+	resetHard  bool
+	resetQuiet bool
+)
+
+var resetCmd = &cobra.Command{
+	Use:   "reset [--soft | --mixed | --hard] [-q] [<commit>] | [<tree-ish>] [--] <pathspec>...",
+	Short: "Reset current HEAD to the specified state",
+	Long: `In the first form, reset the current branch head to <commit> and possibly update the index (resetting it to the tree
+of <commit>) and the working tree depending on the mode, which defaults to --mixed. The previous head is saved in ORIG_HEAD.
+
+  --soft   Does not touch the index file or the working tree at all, but resets the head to <commit>.
+  --mixed  Resets the index but not the working tree, and reports what has not been updated.
+  --hard   Resets the index and working tree. Any changes to tracked files in the working tree since <commit> are discarded.
+
+In the second form, reset the index entries for all paths that match the <pathspec> to their state at <tree-ish>, HEAD by
+default. It does not affect the working tree or the current branch, and is the opposite of git add <pathspec>.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var paths []string
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			args, paths = args[:dash], append([]string{}, args[dash:]...)
+		}
+		option := &git.ResetOption{
+			Soft:  resetSoft,
+			Mixed: resetMixed,
+			Hard:  resetHard,
+			Quiet: resetQuiet,
+		}
+
+		if err := git.Reset(openWorkspace(), os.Stdout, args, paths, option); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(resetCmd)
+
+	resetCmd.Flags().BoolVar(&resetSoft, "soft", false, "Does not touch the index file or the working tree at all, but resets the head to <commit>.")
+	resetCmd.Flags().BoolVar(&resetMixed, "mixed", false, "Resets the index but not the working tree and reports what has not been updated. This is the default action.")
+	resetCmd.Flags().BoolVar(&resetHard, "hard", false, "Resets the index and working tree. Any changes to tracked files in the working tree since <commit> are discarded.")
+	resetCmd.Flags().BoolVarP(&resetQuiet, "quiet", "q", false, "Be quiet, only report errors.")
+	resetCmd.MarkFlagsMutuallyExclusive("soft", "mixed", "hard")
+}
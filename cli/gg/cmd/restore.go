@@ -0,0 +1,67 @@
+/*
+Copyright © 2022 Jiang Zhu <m.zhujiang@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"log"
+
+	git "github.com/izhujiang/gogit/api"
+	"github.com/spf13/cobra"
+)
+
+var (
+	restoreSource   string
+	restoreStaged   bool
+	restoreWorktree bool
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore [--source=<tree>] [--staged] [--worktree] [--] <pathspec>...",
+	Short: "Restore working tree files",
+	Long: `Restore specified paths in the working tree with some contents from a restore source. If a path is tracked but does
+not exist in the restore source, it will be removed to match the source.
+
+The command can also be used to restore the content in the index with --staged, or restore both the working tree and the
+index with --staged --worktree.
+
+By default, if --staged is given, the contents are restored from HEAD, otherwise from the index. Use --source to restore
+from a different commit.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		option := &git.RestoreOption{
+			Source:   restoreSource,
+			Staged:   restoreStaged,
+			Worktree: restoreWorktree,
+		}
+
+		if err := git.Restore(openWorkspace(), args, option); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+
+	restoreCmd.Flags().StringVarP(&restoreSource, "source", "s", "", "Restore the working tree files with the content from the given tree.")
+	restoreCmd.Flags().BoolVarP(&restoreStaged, "staged", "S", false, "Restore the index.")
+	restoreCmd.Flags().BoolVarP(&restoreWorktree, "worktree", "W", false, "Restore the working tree, which is the default without --staged.")
+}
@@ -0,0 +1,100 @@
+/*
+Copyright © 2022 Jiang Zhu <m.zhujiang@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"log"
+	"os"
+
+	git "github.com/izhujiang/gogit/api"
+	"github.com/spf13/cobra"
+)
+
+var (
+	revListMaxCount    int
+	revListReverse     bool
+	revListFirstParent bool
+	revListTopoOrder   bool
+	revListDateOrder   bool
+	revListAll         bool
+	revListCount       bool
+	revListAuthor      string
+	revListGrep        string
+	revListSince       string
+	revListUntil       string
+)
+
+var revListCmd = &cobra.Command{
+	Use:   "rev-list [<options>] <commit>... [-- <path>...]",
+	Short: "Lists commit objects in reverse chronological order",
+	Long: `List commits that are reachable by following the parent links from the given commit(s), but exclude commits
+that are reachable from the one(s) given with a ^ in front of them. The output is given in reverse chronological
+order by default.
+
+A range of commits can be given as <commit1>..<commit2>, which is a shorthand for ^<commit1> <commit2>, or
+<commit1>...<commit2> for commits reachable from either one but not from both.
+
+Paths given after -- limit commits to those changing files in them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var paths []string
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			args, paths = args[:dash], args[dash:]
+		}
+
+		option := &git.RevListOption{
+			MaxCount:    revListMaxCount,
+			Reverse:     revListReverse,
+			FirstParent: revListFirstParent,
+			TopoOrder:   revListTopoOrder,
+			DateOrder:   revListDateOrder,
+			All:         revListAll,
+			Count:       revListCount,
+			Paths:       paths,
+			Author:      revListAuthor,
+			Grep:        revListGrep,
+			Since:       revListSince,
+			Until:       revListUntil,
+		}
+
+		if err := git.RevList(openWorkspace(), os.Stdout, args, option); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(revListCmd)
+
+	revListCmd.Flags().IntVarP(&revListMaxCount, "max-count", "n", 0, "Limit the number of commits to output.")
+	revListCmd.Flags().BoolVar(&revListReverse, "reverse", false, "Output the commits chosen to be shown in reverse order.")
+	revListCmd.Flags().BoolVar(&revListFirstParent, "first-parent", false, "Follow only the first parent commit upon seeing a merge commit.")
+	revListCmd.Flags().BoolVar(&revListTopoOrder, "topo-order", false, "Show no parents before all of its children are shown, and avoid showing commits on multiple lines of history intermixed.")
+	revListCmd.Flags().BoolVar(&revListDateOrder, "date-order", false, "Show no parents before all of its children are shown, but otherwise show commits in the commit timestamp order.")
+	revListCmd.Flags().BoolVar(&revListAll, "all", false, "Pretend as if all the refs in refs/, along with HEAD, are listed on the command line as <commit>.")
+	revListCmd.Flags().BoolVar(&revListCount, "count", false, "Print a number stating how many commits would have been listed, and suppress all other output.")
+	revListCmd.Flags().StringVar(&revListAuthor, "author", "", "Limit the commits output to ones with author header lines that match the regular expression.")
+	revListCmd.Flags().StringVar(&revListGrep, "grep", "", "Limit the commits output to ones with log message that matches the regular expression.")
+	revListCmd.Flags().StringVar(&revListSince, "since", "", "Show commits more recent than a specific date.")
+	revListCmd.Flags().StringVar(&revListSince, "after", "", "Show commits more recent than a specific date.")
+	revListCmd.Flags().StringVar(&revListUntil, "until", "", "Show commits older than a specific date.")
+	revListCmd.Flags().StringVar(&revListUntil, "before", "", "Show commits older than a specific date.")
+}
@@ -0,0 +1,76 @@
+/*
+Copyright © 2022 Jiang Zhu <m.zhujiang@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"log"
+	"os"
+
+	git "github.com/izhujiang/gogit/api"
+	"github.com/spf13/cobra"
+)
+
+var (
+	revParseVerify           bool
+	revParseQuiet            bool
+	revParseShort            int
+	revParseAbbrevRef        bool
+	revParseSymbolicFullName bool
+)
+
+var revParseCmd = &cobra.Command{
+	Use:   "rev-parse [--verify [-q]] [--short[=<length>]] [--abbrev-ref | --symbolic-full-name] <args>...",
+	Short: "Pick out and massage parameters",
+	Long: `Resolves revisions to object names, one per line. Revisions are specified as in gitrevisions, such as
+HEAD~2, main^2, v1.0^{tree}, HEAD^{/fix}, :/fix, main@{upstream}, @{-1}, HEAD@{yesterday}, HEAD:README and :0:README.
+
+^<rev> is printed as ^<object name>, and <rev1>..<rev2> is printed as the object name of <rev2> followed by
+^<object name> of <rev1>.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		option := &git.RevParseOption{
+			Verify:           revParseVerify,
+			Quiet:            revParseQuiet,
+			Short:            revParseShort,
+			AbbrevRef:        revParseAbbrevRef,
+			SymbolicFullName: revParseSymbolicFullName,
+		}
+
+		if err := git.RevParse(openWorkspace(), os.Stdout, args, option); err != nil {
+			if option.Quiet && (option.Verify || option.Short > 0) {
+				os.Exit(1)
+			}
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(revParseCmd)
+
+	revParseCmd.Flags().BoolVar(&revParseVerify, "verify", false, "Verify that exactly one parameter is provided, and that it can be turned into an object.")
+	revParseCmd.Flags().BoolVarP(&revParseQuiet, "quiet", "q", false, "Only meaningful in --verify mode. Do not output an error message if the first argument is not a valid object name.")
+	revParseCmd.Flags().IntVar(&revParseShort, "short", 0, "Same as --verify but shortens the object name to a unique prefix with at least <length> characters, 7 by default.")
+	revParseCmd.Flags().Lookup("short").NoOptDefVal = "7"
+	revParseCmd.Flags().BoolVar(&revParseAbbrevRef, "abbrev-ref", false, "A non-ambiguous short name of the objects name.")
+	revParseCmd.Flags().BoolVar(&revParseSymbolicFullName, "symbolic-full-name", false, "Print the full names of references, such as refs/heads/main for HEAD.")
+}
@@ -48,7 +48,7 @@ checkout(1)), git rm will only remove paths within the sparse-checkout patterns.
 			option := git.RemoveOption{
 				Recursive: recursive,
 			}
-			err := git.Remove(paths, &option)
+			err := git.Remove(openWorkspace(), paths, &option)
 			if err != nil {
 				log.Fatal(err)
 			}
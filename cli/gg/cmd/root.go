@@ -22,8 +22,10 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"log"
 	"os"
 
+	git "github.com/izhujiang/gogit/api"
 	"github.com/spf13/cobra"
 )
 
@@ -62,3 +64,12 @@ func init() {
 	// when this action is called directly.
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
+
+// openWorkspace discovers the workspace containing the current directory, exits if it is not in a git repository
+func openWorkspace() *git.Workspace {
+	ws, err := git.Discover(".")
+	if err != nil {
+		log.Fatal(err)
+	}
+	return ws
+}
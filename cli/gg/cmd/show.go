@@ -22,6 +22,7 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"log"
 	"os"
 
 	git "github.com/izhujiang/gogit/api"
@@ -41,7 +42,9 @@ to quickly create a Cobra application.`,
 	Args: cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) > 0 {
-			git.Show(os.Stdout, args[0])
+			if err := git.Show(openWorkspace(), os.Stdout, args[0]); err != nil {
+				log.Fatal(err)
+			}
 		}
 	},
 }
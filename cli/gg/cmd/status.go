@@ -22,34 +22,101 @@ THE SOFTWARE.
 package cmd
 
 import (
-	"fmt"
+	"log"
+	"os"
+	"strings"
 
+	git "github.com/izhujiang/gogit/api"
 	"github.com/spf13/cobra"
 )
 
+var (
+	statusShort          bool
+	statusBranch         bool
+	statusPorcelain      string
+	statusNullTerminated bool
+	statusUntrackedFiles string
+	statusIgnored        bool
+)
+
 // statusCmd represents the status command
 var statusCmd = &cobra.Command{
-	Use:   "status",
+	Use:   "status [-s | --porcelain[=<version>]] [-b] [-z] [-u[<mode>]] [--ignored] [--] [<pathspec>...]",
 	Short: "Show the working tree status",
 	Long: `Displays paths that have differences between the index file and the current HEAD commit, paths that have differences between the working tree
-       and the index file, and paths in the working tree that are not tracked by Git (and are not ignored by gitignore(5)). The first are what you
-       would commit by running git commit; the second and third are what you could commit by running git add before running git commit.
-	   `,
+and the index file, and paths in the working tree that are not tracked by Git (and are not ignored by gitignore(5)). The first are what you
+would commit by running git commit; the second and third are what you could commit by running git add before running git commit.
+
+The short format shows the status of each path as XY, where X is the status of the index and Y is that of the work tree,
+such as "M " for modified and staged, " M" for modified but not staged, "A " for added, "??" for untracked and "!!" for ignored.
+The porcelain formats v1 and v2 are stable for scripts, paths in them are relative to the root of the work tree.`,
+	// -u takes its mode only in the same argument like git, which pflag can't parse, see untrackedFilesArgs
+	DisableFlagParsing: true,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("status called")
+		if err := cmd.Flags().Parse(untrackedFilesArgs(args)); err != nil {
+			log.Fatal(err)
+		}
+		if help, _ := cmd.Flags().GetBool("help"); help {
+			cmd.Help()
+			return
+		}
+		args = cmd.Flags().Args()
+
+		option := &git.StatusOption{
+			Short:          statusShort,
+			Porcelain:      statusPorcelain,
+			Branch:         statusBranch,
+			NullTerminated: statusNullTerminated,
+			UntrackedFiles: statusUntrackedFiles,
+			Ignored:        statusIgnored,
+			Paths:          args,
+		}
+
+		if err := git.Status(openWorkspace(), os.Stdout, option); err != nil {
+			log.Fatal(err)
+		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(statusCmd)
 
-	// Here you will define your flags and configuration settings.
+	statusCmd.Flags().BoolVarP(&statusShort, "short", "s", false, "Give the output in the short-format.")
+	statusCmd.Flags().BoolVarP(&statusBranch, "branch", "b", false, "Show the branch and tracking info even in short-format.")
+	statusCmd.Flags().StringVar(&statusPorcelain, "porcelain", "", "Give the output in an easy-to-parse format for scripts, v1 or v2.")
+	statusCmd.Flags().Lookup("porcelain").NoOptDefVal = "v1"
+	statusCmd.Flags().BoolVarP(&statusNullTerminated, "null", "z", false, "Terminate entries with NUL, instead of LF. This implies the --porcelain=v1 output format if no other format is given.")
+	statusCmd.Flags().StringVar(&statusUntrackedFiles, "untracked-files", "normal", "Show untracked files, no, normal or all, -u[<mode>] for short.")
+	statusCmd.Flags().Lookup("untracked-files").NoOptDefVal = "all"
+	statusCmd.Flags().BoolVar(&statusIgnored, "ignored", false, "Show ignored files as well.")
+}
 
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// statusCmd.PersistentFlags().String("foo", "", "A help for foo")
+// untrackedFilesArgs rewrites -u[<mode>] into --untracked-files[=<mode>], also when it follows other short options
+// such as -suno. The mode must be in the same argument, so "-u no" shows all untracked files in the pathspec "no".
+func untrackedFilesArgs(args []string) []string {
+	result := make([]string, 0, len(args))
+	for i, arg := range args {
+		if arg == "--" {
+			return append(result, args[i:]...)
+		}
+		if len(arg) < 2 || arg[0] != '-' || arg[1] == '-' {
+			result = append(result, arg)
+			continue
+		}
 
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// statusCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+		shorts, mode, found := strings.Cut(arg[1:], "u")
+		if !found || strings.Contains(shorts, "=") {
+			result = append(result, arg)
+			continue
+		}
+		if shorts != "" {
+			result = append(result, "-"+shorts)
+		}
+		if mode == "" {
+			result = append(result, "--untracked-files")
+		} else {
+			result = append(result, "--untracked-files="+mode)
+		}
+	}
+	return result
 }
@@ -0,0 +1,77 @@
+/*
+Copyright © 2022 Jiang Zhu <m.zhujiang@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"log"
+	"os"
+
+	git "github.com/izhujiang/gogit/api"
+	"github.com/spf13/cobra"
+)
+
+var (
+	switchCreate         string
+	switchForceCreate    string
+	switchDetach         bool
+	switchDiscardChanges bool
+)
+
+var switchCmd = &cobra.Command{
+	Use:   "switch [<options>] [<branch>]",
+	Short: "Switch branches",
+	Long: `Switch to a specified branch. The working tree and the index are updated to match the branch. All new commits will be
+added to the tip of this branch.
+
+Optionally a new branch could be created with either -c or -C, or HEAD could be detached at a commit with --detach.
+Switching branches does not require a clean index and working tree, unless the operation would result in the loss of
+local changes, or --discard-changes is given to throw them away.
+
+If <branch> is not found but there does exist a tracking branch in exactly one remote with a matching name, a new branch
+tracking it is created.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ws := openWorkspace()
+		option := &git.CheckoutOption{
+			NewBranch: switchCreate,
+			Detach:    switchDetach,
+			Force:     switchDiscardChanges,
+		}
+		if switchForceCreate != "" {
+			option.NewBranch, option.ForceCreate = switchForceCreate, true
+		}
+
+		if err := git.Switch(ws, os.Stdout, args, option); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(switchCmd)
+
+	switchCmd.Flags().StringVarP(&switchCreate, "create", "c", "", "Create a new branch named <new-branch> starting at <start-point> before switching to the branch.")
+	switchCmd.Flags().StringVarP(&switchForceCreate, "force-create", "C", "", "Similar to --create except that if <new-branch> already exists, it will be reset to <start-point>.")
+	switchCmd.Flags().BoolVarP(&switchDetach, "detach", "d", false, "Switch to a commit for inspection and discardable experiments.")
+	switchCmd.Flags().BoolVarP(&switchDiscardChanges, "discard-changes", "f", false, "Proceed even if the index or the working tree differs from HEAD. Both the index and working tree are restored to match the switching target.")
+	switchCmd.Flags().BoolVar(&switchDiscardChanges, "force", false, "An alias for --discard-changes.")
+	switchCmd.MarkFlagsMutuallyExclusive("create", "force-create", "detach")
+}
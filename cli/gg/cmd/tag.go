@@ -0,0 +1,86 @@
+/*
+Copyright © 2022 Jiang Zhu <m.zhujiang@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"log"
+	"os"
+
+	git "github.com/izhujiang/gogit/api"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tagAnnotate bool
+	tagMessage  string
+	tagForce    bool
+	tagDelete   bool
+	tagList     bool
+)
+
+var tagCmd = &cobra.Command{
+	Use:   "tag [-a] [-f] [-m <msg>] <tagname> [<commit> | <object>]",
+	Short: "Create, list or delete a tag object",
+	Long: `Add a tag reference in refs/tags/, unless -d/-l is given to delete or list tags.
+
+If one of -a or -m is passed, the command creates a tag object, and requires a tag message. Otherwise, a tag reference that points directly
+at the given object (i.e., a lightweight tag) is created.
+
+With -l, list tags with names that match the given patterns (or all if no pattern is given). Running "git tag" without arguments also lists
+all tags.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ws := openWorkspace()
+
+		var err error
+		switch {
+		case tagDelete:
+			err = git.DeleteTags(ws, os.Stdout, args)
+		case tagList || len(args) == 0:
+			err = git.ListTags(ws, os.Stdout, args)
+		default:
+			target := ""
+			if len(args) > 1 {
+				target = args[1]
+			}
+			option := &git.TagOption{
+				Annotate: tagAnnotate,
+				Message:  tagMessage,
+				Force:    tagForce,
+			}
+			err = git.Tag(ws, os.Stdout, args[0], target, option)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tagCmd)
+
+	tagCmd.Flags().BoolVarP(&tagAnnotate, "annotate", "a", false, "Make an unsigned, annotated tag object")
+	tagCmd.Flags().StringVarP(&tagMessage, "message", "m", "", "Use the given tag message, which implies -a.")
+	tagCmd.Flags().BoolVarP(&tagForce, "force", "f", false, "Replace an existing tag with the given name (instead of failing)")
+	tagCmd.Flags().BoolVarP(&tagDelete, "delete", "d", false, "Delete existing tags with the given names.")
+	tagCmd.Flags().BoolVarP(&tagList, "list", "l", false, "List tags. With optional <pattern>..., e.g. git tag --list 'v-*', list only the tags that match the pattern(s).")
+	tagCmd.MarkFlagsMutuallyExclusive("delete", "list")
+}
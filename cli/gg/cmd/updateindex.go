@@ -77,7 +77,7 @@ var updateIndexCmd = &cobra.Command{
 			option.Path = args[0]
 		}
 
-		git.UpdateIndex(option)
+		git.UpdateIndex(openWorkspace(), option)
 	},
 }
 
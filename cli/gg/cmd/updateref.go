@@ -0,0 +1,87 @@
+/*
+Copyright © 2022 Jiang Zhu <m.zhujiang@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"log"
+	"os"
+
+	git "github.com/izhujiang/gogit/api"
+	"github.com/spf13/cobra"
+)
+
+var (
+	updateRefDelete  bool
+	updateRefNoDeref bool
+	updateRefStdin   bool
+	updateRefZero    bool
+	updateRefMessage string
+)
+
+var updateRefCmd = &cobra.Command{
+	Use:   "update-ref [-d] [--no-deref] <ref> [<new-oid>] [<old-oid>] | --stdin [-z]",
+	Short: "Update the object name stored in a ref safely",
+	Long: `Given two arguments, stores the <new-oid> in the <ref>, possibly dereferencing the symbolic refs. E.g. git update-ref HEAD <new-oid>
+updates the current branch head to the new object.
+
+Given three arguments, stores the <new-oid> in the <ref>, possibly dereferencing the symbolic refs, after verifying that the current
+value of the <ref> matches <old-oid>. E.g. git update-ref refs/heads/master <new-oid> <old-oid> updates the master branch head to
+<new-oid> only if its current value is <old-oid>. You can specify 40 "0" or an empty string as <old-oid> to make sure that the ref
+you are creating does not exist.
+
+With --stdin, update-ref reads instructions from standard input and performs all modifications together:
+
+	update SP <ref> SP <new-oid> [SP <old-oid>] LF
+	create SP <ref> SP <new-oid> LF
+	delete SP <ref> [SP <old-oid>] LF
+	verify SP <ref> [SP <old-oid>] LF
+	option SP <opt> LF
+	start LF
+	prepare LF
+	commit LF
+	abort LF
+
+The refs are locked and verified before any of them is modified, and all of them are modified or none.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		option := &git.UpdateRefOption{
+			Delete:  updateRefDelete,
+			NoDeref: updateRefNoDeref,
+			Stdin:   updateRefStdin,
+			Zero:    updateRefZero,
+			Message: updateRefMessage,
+		}
+
+		if err := git.UpdateRef(openWorkspace(), os.Stdout, os.Stdin, args, option); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(updateRefCmd)
+
+	updateRefCmd.Flags().BoolVarP(&updateRefDelete, "delete", "d", false, "Delete the named ref after verifying that it still contains <old-oid>.")
+	updateRefCmd.Flags().BoolVar(&updateRefNoDeref, "no-deref", false, "Update <ref> itself rather than the result of following the symbolic pointers.")
+	updateRefCmd.Flags().BoolVar(&updateRefStdin, "stdin", false, "Read instructions from standard input, one per line.")
+	updateRefCmd.Flags().StringVarP(&updateRefMessage, "message", "m", "", "The reason of the update, which is recorded in the reflog.")
+	updateRefCmd.Flags().BoolVarP(&updateRefZero, "null", "z", false, "With --stdin, read instructions NUL-terminated.")
+}
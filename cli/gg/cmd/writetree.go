@@ -42,7 +42,7 @@ Conceptually, git write-tree sync()s the current index contents into a set of tr
 	Run: func(cmd *cobra.Command, args []string) {
 		w := os.Stdout
 		option := &git.WriteTreeOption{}
-		git.WriteTree(w, option)
+		git.WriteTree(openWorkspace(), w, option)
 	},
 }
 
@@ -0,0 +1,179 @@
+package common
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDateLayout is the default format of dates in git log and git show
+const DefaultDateLayout = "Mon Jan 2 15:04:05 2006 -0700"
+
+var (
+	ErrInvalidDate = errors.New("Invalid date format.")
+
+	rawDateRegexp = regexp.MustCompile(`^(@?)(\d+)(?:\s+([+-]\d{4}))?$`)
+	// relative dates like 2.weeks.ago or "3 days ago"
+	relativeDateRegexp = regexp.MustCompile(`^(\d+)[. ](second|minute|hour|day|week|month|year)s?[. ]ago$`)
+
+	// layouts with time zone
+	dateLayouts = []string{
+		// RFC 2822
+		"Mon, 2 Jan 2006 15:04:05 -0700",
+		"Mon, 2 Jan 2006 15:04:05 -0700 (MST)",
+		"2 Jan 2006 15:04:05 -0700",
+		// ISO 8601
+		time.RFC3339,
+		"2006-01-02T15:04:05-0700",
+		"2006-01-02 15:04:05 -0700",
+		"2006-01-02 15:04:05-07:00",
+		"2006-01-02 15:04:05 -07:00",
+		// default format of git log
+		DefaultDateLayout,
+	}
+	// layouts in local time zone
+	localDateLayouts = []string{
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05",
+		"2006-01-02",
+		"Mon Jan 2 15:04:05 2006",
+	}
+)
+
+// ParseDate parses date in git's formats: the internal format "<unix timestamp> <time zone offset>" (optionally led by @),
+// RFC 2822 like "Thu, 07 Apr 2005 22:13:13 +0200" and ISO 8601 like "2005-04-07T22:13:13+02:00".
+// Time zone of the date is kept, and local time zone is used if it is omitted.
+func ParseDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+
+	if m := rawDateRegexp.FindStringSubmatch(s); m != nil && (m[1] == "@" || len(m[2]) >= 9) {
+		sec, err := strconv.ParseInt(m[2], 10, 64)
+		if err != nil {
+			return time.Time{}, ErrInvalidDate
+		}
+		loc := time.UTC
+		if m[3] != "" {
+			loc = ParseTimeZone(m[3])
+		}
+		return time.Unix(sec, 0).In(loc), nil
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range localDateLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, ErrInvalidDate
+}
+
+// ParseApproxidate parses dates relative to now, such as "now", "yesterday" and "2.weeks.ago" (or "2 weeks ago"),
+// and absolute dates in the formats of ParseDate
+func ParseApproxidate(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	switch strings.ToLower(s) {
+	case "now":
+		return now, nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1), nil
+	}
+
+	m := relativeDateRegexp.FindStringSubmatch(strings.ToLower(s))
+	if m == nil {
+		return ParseDate(s)
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		return time.Time{}, ErrInvalidDate
+	}
+	switch m[2] {
+	case "second":
+		return now.Add(-time.Duration(n) * time.Second), nil
+	case "minute":
+		return now.Add(-time.Duration(n) * time.Minute), nil
+	case "hour":
+		return now.Add(-time.Duration(n) * time.Hour), nil
+	case "day":
+		return now.AddDate(0, 0, -n), nil
+	case "week":
+		return now.AddDate(0, 0, -7*n), nil
+	case "month":
+		return now.AddDate(0, -n, 0), nil
+	default:
+		return now.AddDate(-n, 0, 0), nil
+	}
+}
+
+// ParseTimeZone parses offset like +0800, a fixed zone with the offset is returned
+func ParseTimeZone(s string) *time.Location {
+	if len(s) != 5 || (s[0] != '+' && s[0] != '-') {
+		return time.UTC
+	}
+	hh, err1 := strconv.Atoi(s[1:3])
+	mm, err2 := strconv.Atoi(s[3:5])
+	if err1 != nil || err2 != nil {
+		return time.UTC
+	}
+
+	offset := hh*3600 + mm*60
+	if s[0] == '-' {
+		offset = -offset
+	}
+	return time.FixedZone("", offset)
+}
+
+// FormatDate formats date in git's internal format "<unix timestamp> <time zone offset>"
+func FormatDate(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 10) + " " + t.Format("-0700")
+}
+
+// FormatRelativeDate formats t relative to now as git does, such as "5 minutes ago" and "2 years, 3 months ago"
+func FormatRelativeDate(t time.Time, now time.Time) string {
+	if now.Before(t) {
+		return "in the future"
+	}
+	plural := func(n int64, unit string) string {
+		if n == 1 {
+			return "1 " + unit
+		}
+		return strconv.FormatInt(n, 10) + " " + unit + "s"
+	}
+
+	diff := int64(now.Sub(t) / time.Second)
+	if diff < 90 {
+		return plural(diff, "second") + " ago"
+	}
+	// minutes
+	if diff = (diff + 30) / 60; diff < 90 {
+		return plural(diff, "minute") + " ago"
+	}
+	// hours
+	if diff = (diff + 30) / 60; diff < 36 {
+		return plural(diff, "hour") + " ago"
+	}
+	// days from here on
+	if diff = (diff + 12) / 24; diff < 14 {
+		return plural(diff, "day") + " ago"
+	}
+	if diff < 70 {
+		return plural((diff+3)/7, "week") + " ago"
+	}
+	if diff < 365 {
+		return plural((diff+15)/30, "month") + " ago"
+	}
+	if diff < 1825 {
+		months := (diff*12*2 + 365) / (365 * 2)
+		if months%12 == 0 {
+			return plural(months/12, "year") + " ago"
+		}
+		return plural(months/12, "year") + ", " + plural(months%12, "month") + " ago"
+	}
+	return plural((diff+183)/365, "year") + " ago"
+}
@@ -0,0 +1,79 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDate(t *testing.T) {
+	cases := map[string]string{
+		"1112911993 +0200":                "1112911993 +0200",
+		"@1112911993 -0530":               "1112911993 -0530",
+		"@0":                              "0 +0000",
+		"Thu, 07 Apr 2005 22:13:13 +0200": "1112904793 +0200",
+		"Thu, 7 Apr 2005 22:13:13 -0700":  "1112937193 -0700",
+		"2005-04-07T22:13:13+02:00":       "1112904793 +0200",
+		"2005-04-07T22:13:13Z":            "1112911993 +0000",
+		"2005-04-07 22:13:13 +0200":       "1112904793 +0200",
+		"Thu Apr 7 22:13:13 2005 +0200":   "1112904793 +0200",
+	}
+	for s, want := range cases {
+		d, err := ParseDate(s)
+		assert.Nil(t, err, s)
+		assert.Equal(t, want, FormatDate(d), s)
+	}
+
+	d, err := ParseDate("2005-04-07 22:13:13")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2005, 4, 7, 22, 13, 13, 0, time.Local).Unix(), d.Unix())
+
+	for _, s := range []string{"", "yesterday", "2005-13-45", "12345"} {
+		_, err := ParseDate(s)
+		assert.Equal(t, ErrInvalidDate, err, s)
+	}
+}
+
+func TestParseApproxidate(t *testing.T) {
+	now := time.Unix(1112911993, 0).UTC()
+	cases := map[string]int64{
+		"now":                  1112911993,
+		"yesterday":            1112911993 - 86400,
+		"10.seconds.ago":       1112911983,
+		"2 weeks ago":          1112911993 - 14*86400,
+		"1.hour.ago":           1112911993 - 3600,
+		"@1112900000 +0200":    1112900000,
+		"2005-04-07T22:13:13Z": 1112911993,
+	}
+	for s, want := range cases {
+		d, err := ParseApproxidate(s, now)
+		assert.Nil(t, err, s)
+		assert.Equal(t, want, d.Unix(), s)
+	}
+
+	_, err := ParseApproxidate("3.fortnights.ago", now)
+	assert.Equal(t, ErrInvalidDate, err)
+}
+
+func TestFormatRelativeDate(t *testing.T) {
+	now := time.Unix(1112911993, 0)
+	cases := map[int64]string{
+		1:                "1 second ago",
+		89:               "89 seconds ago",
+		90:               "2 minutes ago",
+		3600:             "60 minutes ago",
+		5400:             "2 hours ago",
+		86400:            "24 hours ago",
+		3 * 86400:        "3 days ago",
+		20 * 86400:       "3 weeks ago",
+		100 * 86400:      "3 months ago",
+		400 * 86400:      "1 year, 1 month ago",
+		730 * 86400:      "2 years ago",
+		10 * 365 * 86400: "10 years ago",
+		-60:              "in the future",
+	}
+	for ago, want := range cases {
+		assert.Equal(t, want, FormatRelativeDate(now.Add(-time.Duration(ago)*time.Second), now), ago)
+	}
+}
@@ -52,13 +52,11 @@ func CompareOrderedNameHashPairs(pairA NameHashPairs, pairB NameHashPairs) *Chan
 		changes.Remove = append(
 			changes.Remove,
 			&Change{pairA[i], nil})
-		i++
 	}
 	for ; j < len(pairB); j++ {
 		changes.Create = append(
 			changes.Create,
 			&Change{nil, pairB[j]})
-		j++
 	}
 	return changes
 }
@@ -0,0 +1,261 @@
+package core
+
+import (
+	"github.com/izhujiang/gogit/common"
+)
+
+// IsAncestor reports whether ancestor is reachable from commit by following parents, a commit is an ancestor of itself
+func (r *Repository) IsAncestor(ancestor common.Hash, commit common.Hash) (bool, error) {
+	found := false
+	err := r.walkAncestors(commit, func(oid common.Hash) bool {
+		found = oid == ancestor
+		return !found
+	})
+	return found, err
+}
+
+// AheadBehind counts commits reachable from a but not from b (ahead), and those reachable from b but not from a (behind)
+func (r *Repository) AheadBehind(a common.Hash, b common.Hash) (int, int, error) {
+	fromA, err := r.ancestors(a)
+	if err != nil {
+		return 0, 0, err
+	}
+	fromB, err := r.ancestors(b)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	ahead, behind := 0, 0
+	for oid := range fromA {
+		if !fromB[oid] {
+			ahead++
+		}
+	}
+	for oid := range fromB {
+		if !fromA[oid] {
+			behind++
+		}
+	}
+	return ahead, behind, nil
+}
+
+// ancestors returns all commits reachable from commit, including itself
+func (r *Repository) ancestors(commit common.Hash) (map[common.Hash]bool, error) {
+	seen := make(map[common.Hash]bool)
+	err := r.walkAncestors(commit, func(oid common.Hash) bool {
+		seen[oid] = true
+		return true
+	})
+	return seen, err
+}
+
+// walkAncestors visits commits reachable from commit in breadth-first order until fn returns false
+func (r *Repository) walkAncestors(commit common.Hash, fn func(oid common.Hash) bool) error {
+	seen := map[common.Hash]bool{commit: true}
+	queue := []common.Hash{commit}
+	for len(queue) > 0 {
+		oid := queue[0]
+		queue = queue[1:]
+		if !fn(oid) {
+			return nil
+		}
+
+		c, err := r.GetAsCommit(oid)
+		if err != nil {
+			return err
+		}
+		for _, p := range c.Parents() {
+			if !seen[p] {
+				seen[p] = true
+				queue = append(queue, p)
+			}
+		}
+	}
+	return nil
+}
+
+// flags of commits painted while looking for merge bases
+const (
+	paint_parent1 = 1 << iota
+	paint_parent2
+	paint_stale
+	paint_result
+)
+
+// MergeBases returns the best common ancestors of a and b from the newest, none of which is an ancestor of another.
+// There are more than one with criss-cross merges.
+func (r *Repository) MergeBases(a common.Hash, b common.Hash) ([]common.Hash, error) {
+	return r.MergeBasesMany(a, []common.Hash{b})
+}
+
+// MergeBasesMany returns the best common ancestors of one and a hypothetical merge of others, like MergeBases
+func (r *Repository) MergeBasesMany(one common.Hash, others []common.Hash) ([]common.Hash, error) {
+	for _, other := range others {
+		if other == one {
+			return []common.Hash{one}, nil
+		}
+	}
+
+	// commits are painted with the sides they are reachable from, and those reachable from both are candidates
+	walk := r.NewRevWalk()
+	flags := make(map[common.Hash]int)
+	push := func(oid common.Hash, f int) error {
+		wc, err := walk.lookup(oid)
+		if err != nil {
+			return err
+		}
+		flags[oid] |= f
+		walk.queue.push(wc)
+		return nil
+	}
+	if err := push(one, paint_parent1); err != nil {
+		return nil, err
+	}
+	for _, other := range others {
+		if err := push(other, paint_parent2); err != nil {
+			return nil, err
+		}
+	}
+
+	candidates := make([]common.Hash, 0)
+	for walk.queue.Len() > 0 && !walkAllStale(walk.queue, flags) {
+		wc := walk.queue.pop()
+		f := flags[wc.Id()] & (paint_parent1 | paint_parent2 | paint_stale)
+		if f == paint_parent1|paint_parent2 {
+			if flags[wc.Id()]&paint_result == 0 {
+				flags[wc.Id()] |= paint_result
+				candidates = append(candidates, wc.Id())
+			}
+			// ancestors of a common ancestor are not the best ones
+			f |= paint_stale
+		}
+		for _, p := range wc.Parents() {
+			if flags[p]&f == f {
+				continue
+			}
+			if err := push(p, f); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// a candidate may be reachable from another one through a longer path
+	return r.removeRedundant(candidates)
+}
+
+// OctopusMergeBases returns the best common ancestors of all commits, for a merge of them at once
+func (r *Repository) OctopusMergeBases(commits []common.Hash) ([]common.Hash, error) {
+	if len(commits) == 0 {
+		return nil, nil
+	}
+	bases := []common.Hash{commits[0]}
+	for _, c := range commits[1:] {
+		next := make([]common.Hash, 0)
+		for _, b := range bases {
+			found, err := r.MergeBases(c, b)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, found...)
+		}
+		bases = next
+	}
+	// bases found from different ones may be the same, or reachable from each other
+	return r.IndependentCommits(bases)
+}
+
+// IndependentCommits returns commits which are not reachable from any others, in the order they are given
+func (r *Repository) IndependentCommits(commits []common.Hash) ([]common.Hash, error) {
+	unique := make([]common.Hash, 0, len(commits))
+	seen := make(map[common.Hash]bool)
+	for _, c := range commits {
+		if !seen[c] {
+			seen[c] = true
+			unique = append(unique, c)
+		}
+	}
+	return r.removeRedundant(unique)
+}
+
+// removeRedundant leaves out commits which are ancestors of others
+func (r *Repository) removeRedundant(commits []common.Hash) ([]common.Hash, error) {
+	result := make([]common.Hash, 0, len(commits))
+	for i, c := range commits {
+		redundant := false
+		for j, other := range commits {
+			if i == j {
+				continue
+			}
+			if ok, err := r.IsAncestor(c, other); err != nil {
+				return nil, err
+			} else if ok {
+				redundant = true
+				break
+			}
+		}
+		if !redundant {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+
+// ForkPoint finds the point at which commit forked from any value the reference ref has had, which is the only best
+// common ancestor of commit and those values recorded in the reflog of ref, or its value if there is no reflog.
+// ok is false if there is no such point, such as when the merge base is not one of the values, and it fails if ref
+// does not exist.
+func (ws *Workspace) ForkPoint(ref string, commit common.Hash) (oid common.Hash, ok bool, err error) {
+	refs, repo := ws.References(), ws.Repository()
+	name, err := refs.FullRefName(ref)
+	if err != nil {
+		return common.ZeroHash, false, err
+	}
+
+	values := make([]common.Hash, 0)
+	seen := make(map[common.Hash]bool)
+	add := func(oid common.Hash) {
+		if oid == common.ZeroHash || seen[oid] {
+			return
+		}
+		// values which are not commits are skipped
+		if _, err := repo.GetAsCommit(oid); err == nil {
+			seen[oid] = true
+			values = append(values, oid)
+		}
+	}
+	entries, err := refs.Reflog(name)
+	if err != nil && err != ErrReflogNotFound {
+		return common.ZeroHash, false, err
+	}
+	for i, e := range entries {
+		if i == 0 {
+			add(e.Old)
+		}
+		add(e.New)
+	}
+	if len(values) == 0 {
+		tip, err := refs.ReadRef(name)
+		if err != nil {
+			return common.ZeroHash, false, err
+		}
+		add(tip)
+	}
+
+	bases, err := repo.MergeBasesMany(commit, values)
+	if err != nil {
+		return common.ZeroHash, false, err
+	}
+	if len(bases) != 1 || !seen[bases[0]] {
+		return common.ZeroHash, false, nil
+	}
+	return bases[0], true, nil
+}
+
+func walkAllStale(q *walkQueue, flags map[common.Hash]int) bool {
+	for _, it := range q.items {
+		if flags[it.commit.Id()]&paint_stale == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"io"
+	"testing"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeBases(t *testing.T) {
+	ws, err := Init(io.Discard, t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs := ws.References()
+	repo := ws.Repository()
+
+	commit := newTestHistory(t, repo).commit
+
+	// m1 and b1 are merged into each other as x1 and x2, which is a criss-cross merge
+	a := commit("a")
+	m1 := commit("m1", a)
+	b1 := commit("b1", a)
+	x1 := commit("x1", m1, b1)
+	x2 := commit("x2", b1, m1)
+	side := commit("side", a)
+
+	bases, err := repo.MergeBases(x1, x2)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []common.Hash{m1, b1}, bases)
+	bases, err = repo.MergeBases(m1, x1)
+	assert.Nil(t, err)
+	assert.Equal(t, []common.Hash{m1}, bases)
+	bases, err = repo.MergeBasesMany(m1, []common.Hash{b1, side})
+	assert.Nil(t, err)
+	assert.Equal(t, []common.Hash{a}, bases)
+	bases, err = repo.MergeBasesMany(x1, []common.Hash{x2, side})
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []common.Hash{m1, b1}, bases)
+
+	bases, err = repo.OctopusMergeBases([]common.Hash{x1, x2, side})
+	assert.Nil(t, err)
+	assert.Equal(t, []common.Hash{a}, bases)
+	independent, err := repo.IndependentCommits([]common.Hash{m1, x1, b1, side, x1})
+	assert.Nil(t, err)
+	assert.Equal(t, []common.Hash{x1, side}, independent)
+
+	// up is rewound from u2 and topic forked from it there, which is found only in the reflog of up
+	u1 := commit("u1", a)
+	u2 := commit("u2", u1)
+	topic := commit("t", u2)
+	u3 := commit("u3", u1)
+	assert.Nil(t, refs.UpdateRef("refs/heads/up", u1, common.ZeroHash, "branch: Created from a"))
+	assert.Nil(t, refs.UpdateRef("refs/heads/up", u2, u1, "commit: u2"))
+	assert.Nil(t, refs.UpdateRef("refs/heads/up", u1, u2, "reset: moving to HEAD~1"))
+	assert.Nil(t, refs.UpdateRef("refs/heads/up", u3, u1, "commit: u3"))
+
+	bases, err = repo.MergeBases(u3, topic)
+	assert.Nil(t, err)
+	assert.Equal(t, []common.Hash{u1}, bases)
+	oid, ok, err := ws.ForkPoint("up", topic)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, u2, oid)
+	_, ok, err = ws.ForkPoint("up", side)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	_, _, err = ws.ForkPoint("nosuch", topic)
+	assert.ErrorIs(t, err, ErrRefNotFound)
+}
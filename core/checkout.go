@@ -0,0 +1,417 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core/internal/index"
+)
+
+var (
+	// ErrCheckoutConflict is returned if checking out a tree would overwrite local changes or untracked files
+	ErrCheckoutConflict = errors.New("Checkout would overwrite local changes.")
+	// ErrUnmergedIndex is returned if the index has conflicts which must be resolved before switching trees
+	ErrUnmergedIndex = errors.New("You need to resolve your current index first.")
+	// ErrPathspecNotMatched is returned if a path to check out matches no files
+	ErrPathspecNotMatched = errors.New("Pathspec did not match any file(s) known to git.")
+)
+
+// CheckoutTree switches the index and the work tree from oldTree, the tree of HEAD which is ZeroHash if HEAD is unborn,
+// to newTree by a two-tree merge. Changes in the index and the work tree are carried over for files which are the same
+// in both trees, and the checkout is refused if those of other files, or untracked files, would be overwritten.
+// Local changes are discarded and untracked files are overwritten with force.
+func (ws *Workspace) CheckoutTree(oldTree common.Hash, newTree common.Hash, force bool) error {
+	if err := ws.CheckWorkTree(); err != nil {
+		return err
+	}
+	oldFiles, err := ws.repository.treeFileMap(oldTree)
+	if err != nil {
+		return err
+	}
+	newFiles, err := ws.repository.treeFileMap(newTree)
+	if err != nil {
+		return err
+	}
+
+	sa := ws.StagingArea()
+	sa.Load()
+	entries := make(map[string]*index.IndexEntry)
+	paths := make(map[string]bool)
+	unmerged := false
+	sa.Foreach(func(e *index.IndexEntry) {
+		if e.Stage() > 0 {
+			unmerged = true
+			paths[e.Path()] = true
+			return
+		}
+		entries[e.Path()] = e
+	})
+	if unmerged && !force {
+		return ErrUnmergedIndex
+	}
+	for p := range oldFiles {
+		paths[p] = true
+	}
+	for p := range newFiles {
+		paths[p] = true
+	}
+	if force {
+		for p := range entries {
+			paths[p] = true
+		}
+	}
+
+	var matcher *IgnoreMatcher
+	if !force {
+		if matcher, err = ws.IgnoreMatcher(); err != nil {
+			return err
+		}
+	}
+	indexTime := ws.indexModTime()
+	updates, removes := make([]*common.NameHashPair, 0), make([]string, 0)
+	changed, untracked, dirs := make([]string, 0), make([]string, 0), make([]string, 0)
+	for _, p := range sortedKeys(paths) {
+		h, m, e := oldFiles[p], newFiles[p], entries[p]
+		if force {
+			if m == nil {
+				removes = append(removes, p)
+				continue
+			}
+			if entryMatches(e, m) {
+				if code, _, err := ws.worktreeChange(e, m.Mode, indexTime); err == nil && code == Status_Unmodified {
+					continue
+				}
+			}
+			updates = append(updates, m)
+			continue
+		}
+
+		if sameFile(h, m) {
+			continue
+		}
+		if !entryMatches(e, h) {
+			// the change is staged already, or it would be lost
+			if !entryMatches(e, m) {
+				changed = append(changed, p)
+			}
+			continue
+		}
+		if e != nil {
+			// files missing in the work tree are written again
+			code, _, err := ws.worktreeChange(e, normalizeFileMode(e.Mode()), indexTime)
+			if err != nil {
+				return err
+			}
+			if code != Status_Unmodified && code != Status_Deleted {
+				changed = append(changed, p)
+				continue
+			}
+		}
+		if m == nil {
+			removes = append(removes, p)
+			continue
+		}
+		if e == nil {
+			files, lost := ws.untrackedInTheWay(p, entries, matcher)
+			if lost {
+				dirs = append(dirs, p)
+			}
+			untracked = append(untracked, files...)
+		}
+		updates = append(updates, m)
+	}
+
+	if len(changed) > 0 || len(untracked) > 0 || len(dirs) > 0 {
+		return checkoutConflict(changed, dirs, untracked)
+	}
+
+	for _, p := range removes {
+		sa.Delete(p)
+		if err := ws.removeWorktreeFile(p); err != nil {
+			return err
+		}
+	}
+	for _, f := range updates {
+		e, err := ws.checkoutFile(f.Name, f.Oid, f.Mode)
+		if err != nil {
+			return err
+		}
+		sa.Put(e)
+	}
+	sa.Sort()
+	return sa.Save()
+}
+
+// CheckoutPaths writes files matching paths, which are relative to the root of the work tree, from the tree treeId into
+// both the index and the work tree, or from the index into the work tree if treeId is ZeroHash. Local changes of the
+// files are overwritten, and files not in the tree are kept. The number of files written is returned, those up to
+// date are skipped.
+func (ws *Workspace) CheckoutPaths(treeId common.Hash, paths []string) (int, error) {
+	if err := ws.CheckWorkTree(); err != nil {
+		return 0, err
+	}
+	sa := ws.StagingArea()
+	sa.Load()
+	entries := make(map[string]*index.IndexEntry)
+	unmerged := make(map[string]bool)
+	sa.Foreach(func(e *index.IndexEntry) {
+		if e.Stage() > 0 {
+			unmerged[e.Path()] = true
+			return
+		}
+		entries[e.Path()] = e
+	})
+
+	files := make(map[string]*common.NameHashPair)
+	if treeId == common.ZeroHash {
+		for p, e := range entries {
+			files[p] = &common.NameHashPair{Oid: e.Oid(), Name: p, Mode: normalizeFileMode(e.Mode())}
+		}
+	} else {
+		var err error
+		if files, err = ws.repository.treeFileMap(treeId); err != nil {
+			return 0, err
+		}
+	}
+
+	// all paths must match before any file is written
+	for _, spec := range paths {
+		found := false
+		for p := range files {
+			if MatchPathspec(p, []string{spec}) {
+				found = true
+				break
+			}
+		}
+		if treeId == common.ZeroHash {
+			for p := range unmerged {
+				if MatchPathspec(p, []string{spec}) {
+					return 0, fmt.Errorf("path '%s' is unmerged", p)
+				}
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("%w '%s'", ErrPathspecNotMatched, spec)
+		}
+	}
+
+	indexTime, count := ws.indexModTime(), 0
+	for _, p := range sortedKeys(files) {
+		f, e := files[p], entries[p]
+		if !MatchPathspec(p, paths) {
+			continue
+		}
+		if !unmerged[p] && entryMatches(e, f) {
+			if code, _, err := ws.worktreeChange(e, f.Mode, indexTime); err == nil && code == Status_Unmodified {
+				continue
+			}
+		}
+		e, err := ws.checkoutFile(p, f.Oid, f.Mode)
+		if err != nil {
+			return 0, err
+		}
+		sa.Put(e)
+		count++
+	}
+	sa.Sort()
+	return count, sa.Save()
+}
+
+// checkoutConflict lists files whose local changes would be overwritten, directories with untracked files to be
+// replaced by files, and untracked files in the way like git
+func checkoutConflict(changed []string, dirs []string, untracked []string) error {
+	sb := &strings.Builder{}
+	if len(changed) > 0 {
+		sb.WriteString("\nYour local changes to the following files would be overwritten by checkout:\n")
+		for _, p := range changed {
+			sb.WriteString("\t" + p + "\n")
+		}
+		sb.WriteString("Please commit your changes or stash them before you switch branches.")
+	}
+	if len(dirs) > 0 {
+		sb.WriteString("\nUpdating the following directories would lose untracked files in them:\n")
+		for _, p := range dirs {
+			sb.WriteString("\t" + p + "\n")
+		}
+	}
+	if len(untracked) > 0 {
+		sb.WriteString("\nThe following untracked working tree files would be overwritten by checkout:\n")
+		for _, p := range untracked {
+			sb.WriteString("\t" + p + "\n")
+		}
+		sb.WriteString("Please move or remove them before you switch branches.")
+	}
+	return fmt.Errorf("%w%s\nAborting", ErrCheckoutConflict, sb.String())
+}
+
+// treeFileMap returns files in the tree oid by their paths, nothing if oid is ZeroHash
+func (r *Repository) treeFileMap(oid common.Hash) (map[string]*common.NameHashPair, error) {
+	files := make(map[string]*common.NameHashPair)
+	if oid == common.ZeroHash {
+		return files, nil
+	}
+	pairs := make(common.NameHashPairs, 0)
+	if err := r.treeFiles(oid, "", &pairs); err != nil {
+		return nil, err
+	}
+	for _, p := range pairs {
+		files[p.Name] = p
+	}
+	return files, nil
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sameFile reports whether files in two trees are the same, or both are missing
+func sameFile(a *common.NameHashPair, b *common.NameHashPair) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Oid == b.Oid && a.Mode == b.Mode
+}
+
+// entryMatches reports whether the index entry e has the file f in a tree, or both are missing
+func entryMatches(e *index.IndexEntry, f *common.NameHashPair) bool {
+	if e == nil || f == nil {
+		return e == nil && f == nil
+	}
+	return e.Oid() == f.Oid && normalizeFileMode(e.Mode()) == f.Mode
+}
+
+// untrackedInTheWay returns untracked files which would be overwritten by writing the file p at leading directories
+// of p or at p, and reports whether p is a directory which has untracked files. Ignored files are expendable.
+func (ws *Workspace) untrackedInTheWay(p string, entries map[string]*index.IndexEntry, matcher *IgnoreMatcher) ([]string, bool) {
+	found := make([]string, 0)
+	for dir := path.Dir(p); dir != "."; dir = path.Dir(dir) {
+		fi, err := os.Lstat(filepath.Join(ws.root, filepath.FromSlash(dir)))
+		if err == nil && !fi.IsDir() && entries[dir] == nil && !matcher.Ignored(dir, false) {
+			found = append(found, dir)
+		}
+	}
+
+	fp := filepath.Join(ws.root, filepath.FromSlash(p))
+	fi, err := os.Lstat(fp)
+	if err != nil {
+		return found, false
+	}
+	if !fi.IsDir() {
+		if !matcher.Ignored(p, false) {
+			found = append(found, p)
+		}
+		return found, false
+	}
+	lost := false
+	filepath.WalkDir(fp, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(ws.root, name)
+		rel = filepath.ToSlash(rel)
+		if entries[rel] == nil && !matcher.Ignored(rel, false) {
+			lost = true
+		}
+		return nil
+	})
+	return found, lost
+}
+
+// removeWorktreeFile removes the file p from the work tree, along with its parent directories which become empty
+func (ws *Workspace) removeWorktreeFile(p string) error {
+	fp := filepath.Join(ws.root, filepath.FromSlash(p))
+	if fi, err := os.Lstat(fp); err == nil && fi.IsDir() {
+		// a submodule, or a directory replacing the file
+		os.Remove(fp)
+	} else if err := os.Remove(fp); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for dir := filepath.Dir(fp); len(dir) > len(ws.root); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// checkoutFile writes the blob oid into the file p with mode, whatever is in the way is replaced, and returns the
+// index entry with the stat data of the new file
+func (ws *Workspace) checkoutFile(p string, oid common.Hash, mode common.FileMode) (*index.IndexEntry, error) {
+	fp := filepath.Join(ws.root, filepath.FromSlash(p))
+	if err := ws.makeParentDirs(p); err != nil {
+		return nil, err
+	}
+	if fi, err := os.Lstat(fp); err == nil && fi.IsDir() {
+		if err := os.RemoveAll(fp); err != nil {
+			return nil, err
+		}
+	}
+
+	if mode == common.Submodule {
+		if err := os.MkdirAll(fp, 0755); err != nil {
+			return nil, err
+		}
+		return index.NewIndexEntry(oid, mode, p), nil
+	}
+
+	blob, err := ws.repository.GetAsBlob(oid)
+	if err != nil {
+		return nil, err
+	}
+	// the file is created again so that its permission follows umask like git
+	if err := os.Remove(fp); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if mode == common.Symlink {
+		err = os.Symlink(blob.Content(), fp)
+	} else {
+		perm := os.FileMode(0666)
+		if mode == common.Executable {
+			perm = 0777
+		}
+		err = os.WriteFile(fp, []byte(blob.Content()), perm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Lstat(fp)
+	if err != nil {
+		return nil, err
+	}
+	return index.NewIndexEntryWithFileInfo(oid, mode, p, fi), nil
+}
+
+// makeParentDirs creates leading directories of p in the work tree, files in the way are removed
+func (ws *Workspace) makeParentDirs(p string) error {
+	dir := ws.root
+	parts := strings.Split(p, "/")
+	for _, name := range parts[:len(parts)-1] {
+		dir = filepath.Join(dir, name)
+		fi, err := os.Lstat(dir)
+		if err == nil && fi.IsDir() {
+			continue
+		}
+		if err == nil {
+			if err := os.Remove(dir); err != nil {
+				return err
+			}
+		}
+		if err := os.Mkdir(dir, 0777); err != nil {
+			return err
+		}
+	}
+	return nil
+}
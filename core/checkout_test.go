@@ -0,0 +1,95 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckoutTree(t *testing.T) {
+	ws := newTestWorkspace(t)
+	root := ws.Root()
+	blob, put, read := ws.blob, ws.putTree, ws.read
+	indexed := func() []string {
+		return sortedKeys(ws.indexed())
+	}
+
+	// a, d/b and the executable x, then a changed, d replaced by a file, x not executable and the symlink l
+	d := object.EmptyTree()
+	d.Append(object.NewTreeEntry(blob("b1\n"), "b", common.Regular))
+	t1 := object.EmptyTree()
+	t1.Append(object.NewTreeEntry(blob("a1\n"), "a", common.Regular))
+	t1.Append(object.NewTreeEntry(put(d), "d", common.Dir))
+	t1.Append(object.NewTreeEntry(blob("x\n"), "x", common.Executable))
+	tree1 := put(t1)
+	t2 := object.EmptyTree()
+	t2.Append(object.NewTreeEntry(blob("a2\n"), "a", common.Regular))
+	t2.Append(object.NewTreeEntry(blob("d\n"), "d", common.Regular))
+	t2.Append(object.NewTreeEntry(blob("a"), "l", common.Symlink))
+	t2.Append(object.NewTreeEntry(blob("x\n"), "x", common.Regular))
+	tree2 := put(t2)
+
+	err := ws.CheckoutTree(common.ZeroHash, tree1, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "d/b", "x"}, indexed())
+	assert.Equal(t, "b1\n", read("d/b"))
+	fi, err := os.Stat(filepath.Join(root, "x"))
+	assert.Nil(t, err)
+	assert.NotZero(t, fi.Mode().Perm()&0100)
+
+	// local changes and untracked files in the way are kept
+	ws.write("a", "local\n")
+	err = ws.CheckoutTree(tree1, tree2, false)
+	assert.True(t, errors.Is(err, ErrCheckoutConflict))
+	assert.Equal(t, "local\n", read("a"))
+
+	n, err := ws.CheckoutPaths(common.ZeroHash, []string{"a", "d"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, "a1\n", read("a"))
+	_, err = ws.CheckoutPaths(tree1, []string{"nope"})
+	assert.True(t, errors.Is(err, ErrPathspecNotMatched))
+
+	ws.write("l", "untracked\n")
+	err = ws.CheckoutTree(tree1, tree2, false)
+	assert.True(t, errors.Is(err, ErrCheckoutConflict))
+	assert.Nil(t, os.Remove(filepath.Join(root, "l")))
+
+	assert.Nil(t, ws.CheckoutTree(tree1, tree2, false))
+	assert.Equal(t, []string{"a", "d", "l", "x"}, indexed())
+	assert.Equal(t, "a2\n", read("a"))
+	assert.Equal(t, "d\n", read("d"))
+	target, err := os.Readlink(filepath.Join(root, "l"))
+	assert.Nil(t, err)
+	assert.Equal(t, "a", target)
+	fi, err = os.Stat(filepath.Join(root, "x"))
+	assert.Nil(t, err)
+	assert.Zero(t, fi.Mode().Perm()&0100)
+
+	// force discards local changes, and files not in the tree are removed
+	ws.write("a", "local\n")
+	assert.Nil(t, ws.CheckoutTree(tree2, tree1, true))
+	assert.Equal(t, []string{"a", "d/b", "x"}, indexed())
+	assert.Equal(t, "a1\n", read("a"))
+	_, err = os.Lstat(filepath.Join(root, "l"))
+	assert.True(t, os.IsNotExist(err))
+
+	// HEAD is detached, or points to a branch, with the move logged
+	one := newTestHistory(t, ws.Repository()).commitTree("one", tree1, 0)
+	refs := ws.References()
+	assert.Nil(t, refs.CreateBranch("side", one, false, "branch: Created from one"))
+	assert.Nil(t, refs.SwitchHead("", one, "checkout: moving from main to one"))
+	assert.Equal(t, "", refs.CurrentBranch())
+	assert.Nil(t, refs.SwitchHead(RefPrefix_Heads+"side", one, "checkout: moving from one to side"))
+	assert.Equal(t, "side", refs.CurrentBranch())
+	entries, err := refs.Reflog("HEAD")
+	assert.Nil(t, err)
+	if assert.Equal(t, 2, len(entries)) {
+		assert.Equal(t, "checkout: moving from one to side", entries[1].Message)
+	}
+}
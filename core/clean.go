@@ -0,0 +1,163 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/izhujiang/gogit/core/internal/index"
+)
+
+// CleanMode is which untracked files are removed by Clean
+type CleanMode int
+
+const (
+	// untracked files which are not ignored
+	Clean_Untracked CleanMode = iota
+	// ignored files as well
+	Clean_All
+	// ignored files only
+	Clean_Ignored
+)
+
+// CleanFiles returns files in the work tree to be removed by clean, selected by mode among those matching paths,
+// which are relative to the root of the work tree. Untracked directories are returned as a whole, ending with "/", with
+// dirs if they have nothing to keep, otherwise their files are returned one by one. Other repositories are only
+// returned with nested. Nothing is removed, the list is sorted.
+func (ws *Workspace) CleanFiles(mode CleanMode, dirs bool, nested bool, paths []string) ([]string, error) {
+	if err := ws.CheckWorkTree(); err != nil {
+		return nil, err
+	}
+	var matcher *IgnoreMatcher
+	if mode != Clean_All {
+		var err error
+		if matcher, err = ws.IgnoreMatcher(); err != nil {
+			return nil, err
+		}
+	}
+	sa := ws.StagingArea()
+	sa.Load()
+	tracked := make(map[string]bool)
+	sa.Foreach(func(e *index.IndexEntry) {
+		tracked[e.Path()] = true
+	})
+
+	w := &cleanWalker{
+		root:     ws.root,
+		mode:     mode,
+		dirs:     dirs,
+		nested:   nested,
+		matcher:  matcher,
+		tracked:  tracked,
+		tracks:   trackedDirs(tracked),
+		pathspec: paths,
+	}
+	files, _, err := w.walk("", false)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// cleanWalker collects files to be removed from the work tree
+type cleanWalker struct {
+	root    string
+	mode    CleanMode
+	dirs    bool
+	nested  bool
+	matcher *IgnoreMatcher
+	// tracked files and directories which have them
+	tracked map[string]bool
+	tracks  map[string]bool
+
+	pathspec []string
+}
+
+// walk returns files to be removed in dir, all of which are ignored if dirIgnored, and whether nothing in dir is kept
+func (w *cleanWalker) walk(dir string, dirIgnored bool) ([]string, bool, error) {
+	entries, err := os.ReadDir(filepath.Join(w.root, filepath.FromSlash(dir)))
+	if err != nil {
+		return nil, false, err
+	}
+	files, whole := make([]string, 0), true
+	for _, d := range entries {
+		p := d.Name()
+		if dir != "" {
+			p = dir + "/" + d.Name()
+		}
+		if w.tracked[p] || (dir == "" && d.Name() == repositoryName) {
+			whole = false
+			continue
+		}
+		ignored := dirIgnored || (w.matcher != nil && w.matcher.ignored(p, d.IsDir()))
+
+		if !d.IsDir() {
+			if !w.selected(ignored) || !MatchPathspec(p, w.pathspec) {
+				whole = false
+				continue
+			}
+			files = append(files, p)
+			continue
+		}
+
+		if w.tracks[p] {
+			sub, _, err := w.walk(p, ignored)
+			if err != nil {
+				return nil, false, err
+			}
+			files = append(files, sub...)
+			whole = false
+			continue
+		}
+		if !matchPathspecDir(p, w.pathspec) {
+			whole = false
+			continue
+		}
+		// another repository is removed as a whole or kept
+		if _, err := os.Lstat(filepath.Join(w.root, filepath.FromSlash(p), repositoryName)); err == nil {
+			if w.nested && w.dirs && w.selected(ignored) && MatchPathspec(p, w.pathspec) {
+				files = append(files, p+"/")
+			} else {
+				whole = false
+			}
+			continue
+		}
+		// ignored directories are kept unless ignored files are removed, ignored files in untracked directories are
+		// removed without dirs like git
+		if (w.mode == Clean_Untracked && ignored) || (!w.dirs && (w.mode != Clean_Ignored || ignored)) {
+			whole = false
+			continue
+		}
+		sub, subWhole, err := w.walk(p, ignored)
+		if err != nil {
+			return nil, false, err
+		}
+		// a directory which has only ignored files is ignored as a whole like git, it is removed with dirs and
+		// kept otherwise
+		onlyIgnored := w.mode == Clean_Ignored && subWhole && len(sub) > 0
+		if w.dirs && subWhole && (w.selected(ignored) || onlyIgnored) && MatchPathspec(p, w.pathspec) {
+			files = append(files, p+"/")
+			continue
+		}
+		if !w.dirs && onlyIgnored {
+			whole = false
+			continue
+		}
+		files = append(files, sub...)
+		whole = false
+	}
+	return files, whole, nil
+}
+
+// selected reports whether an untracked file is removed in the mode
+func (w *cleanWalker) selected(ignored bool) bool {
+	switch w.mode {
+	case Clean_All:
+		return true
+	case Clean_Ignored:
+		return ignored
+	default:
+		return !ignored
+	}
+}
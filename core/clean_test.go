@@ -0,0 +1,47 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanFiles(t *testing.T) {
+	ws := newTestWorkspace(t)
+	ws.write(".gitignore", "*.o\nbuild/\n")
+	ws.write("d/b", "b\n")
+	ws.stage(".gitignore", "d/b")
+
+	for _, p := range []string{"t", "z.o", "d/t", "d/t.o", "u/x", "u/x.o", "u/v/y", "build/out", "sub/.git/HEAD",
+		"s/n", "s/deep/y.o", "s/mix/m", "s/mix/m.o", "w/x/y.o"} {
+		ws.write(p, p+"\n")
+	}
+	assert.Nil(t, os.Mkdir(filepath.Join(ws.Root(), "e"), 0755))
+
+	cases := []struct {
+		mode   CleanMode
+		dirs   bool
+		nested bool
+		paths  []string
+		files  []string
+	}{
+		{Clean_Untracked, false, false, nil, []string{"d/t", "t"}},
+		// u has the ignored file u/x.o, so it is not removed as a whole
+		{Clean_Untracked, true, false, nil, []string{"d/t", "e/", "s/mix/m", "s/n", "t", "u/v/", "u/x"}},
+		{Clean_Untracked, true, true, nil, []string{"d/t", "e/", "s/mix/m", "s/n", "sub/", "t", "u/v/", "u/x"}},
+		{Clean_All, false, false, nil, []string{"d/t", "d/t.o", "t", "z.o"}},
+		{Clean_All, true, false, nil, []string{"build/", "d/t", "d/t.o", "e/", "s/", "t", "u/", "w/", "z.o"}},
+		// s/deep and w have only ignored files, they are kept without dirs
+		{Clean_Ignored, false, false, nil, []string{"d/t.o", "s/mix/m.o", "u/x.o", "z.o"}},
+		{Clean_Ignored, true, false, nil, []string{"build/", "d/t.o", "s/deep/", "s/mix/m.o", "u/x.o", "w/", "z.o"}},
+		{Clean_All, true, false, []string{"u/v"}, []string{"u/v/"}},
+		{Clean_Untracked, true, false, []string{"d"}, []string{"d/t"}},
+	}
+	for _, c := range cases {
+		files, err := ws.CleanFiles(c.mode, c.dirs, c.nested, c.paths)
+		assert.Nil(t, err)
+		assert.Equal(t, c.files, files)
+	}
+}
@@ -0,0 +1,379 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/izhujiang/gogit/core/internal/utils"
+)
+
+const (
+	env_git_config_system   = "GIT_CONFIG_SYSTEM"
+	env_git_config_nosystem = "GIT_CONFIG_NOSYSTEM"
+	env_git_config_global   = "GIT_CONFIG_GLOBAL"
+	env_xdg_config_home     = "XDG_CONFIG_HOME"
+
+	system_config_path = "/etc/gitconfig"
+
+	max_include_depth = 10
+)
+
+var (
+	ErrIncludeDepth = errors.New("Exceeded maximum include depth while including config files.")
+)
+
+type Scope int
+
+const (
+	ScopeSystem Scope = iota
+	ScopeGlobal
+	ScopeLocal
+	// a config file given explicitly, such as gg config --file
+	ScopeFile
+)
+
+func (s Scope) String() string {
+	switch s {
+	case ScopeSystem:
+		return "system"
+	case ScopeGlobal:
+		return "global"
+	case ScopeLocal:
+		return "local"
+	case ScopeFile:
+		return "command"
+	default:
+		return "unknown"
+	}
+}
+
+// Entry is a key with its value, along with the file where it is defined
+type Entry struct {
+	// canonical name, such as core.bare and remote.origin.url
+	Name  string
+	Value string
+	// the key has no "=", which is true as boolean
+	NoValue bool
+
+	Scope  Scope
+	Origin string
+}
+
+func (e *Entry) Bool() (bool, error) {
+	if e.NoValue {
+		return true, nil
+	}
+	return ParseBool(e.Value)
+}
+
+func (e *Entry) Int() (int64, error) {
+	return ParseInt(e.Value)
+}
+
+func (e *Entry) Path() (string, error) {
+	return ExpandPath(e.Value)
+}
+
+// Config is the merged view of config files in system, global and local scopes, the later overrides the former.
+type Config struct {
+	gitDir  string
+	entries []*Entry
+}
+
+// Load config files of all scopes for the repository in gitDir, with included files followed.
+// Missing files are skipped. An empty gitDir loads system and global scopes only.
+func Load(gitDir string) (*Config, error) {
+	c := &Config{
+		gitDir:  gitDir,
+		entries: make([]*Entry, 0, 32),
+	}
+
+	for _, scope := range []Scope{ScopeSystem, ScopeGlobal, ScopeLocal} {
+		for _, path := range scopePaths(scope, gitDir) {
+			if err := c.loadFile(path, scope, 0); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// LoadScope loads config files of the single scope, system, global or local
+func LoadScope(scope Scope, gitDir string) (*Config, error) {
+	c := &Config{
+		gitDir: gitDir,
+	}
+
+	for _, path := range scopePaths(scope, gitDir) {
+		if err := c.loadFile(path, scope, 0); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// ScopePath returns the config file of the scope to be written
+func ScopePath(scope Scope, gitDir string) string {
+	switch scope {
+	case ScopeSystem:
+		return SystemConfigPath()
+	case ScopeGlobal:
+		return GlobalConfigPath()
+	case ScopeLocal:
+		if gitDir != "" {
+			return LocalConfigPath(gitDir)
+		}
+	}
+	return ""
+}
+
+// config files of the scope in the order of loading
+func scopePaths(scope Scope, gitDir string) []string {
+	switch scope {
+	case ScopeSystem:
+		if path := SystemConfigPath(); path != "" {
+			return []string{path}
+		}
+	case ScopeGlobal:
+		return GlobalConfigPaths()
+	case ScopeLocal:
+		if gitDir != "" {
+			return []string{LocalConfigPath(gitDir)}
+		}
+	}
+	return nil
+}
+
+// LoadFile loads the single config file in path, with included files followed
+func LoadFile(path string, gitDir string) (*Config, error) {
+	c := &Config{
+		gitDir: gitDir,
+	}
+	if err := c.loadFile(path, ScopeFile, 0); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Config) loadFile(path string, scope Scope, depth int) error {
+	if depth > max_include_depth {
+		return fmt.Errorf("%w: %s", ErrIncludeDepth, path)
+	}
+
+	f, err := ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, e := range f.Entries() {
+		e.Scope = scope
+		c.entries = append(c.entries, e)
+
+		included := ""
+		if e.Name == "include.path" {
+			included = e.Value
+		} else if strings.HasPrefix(e.Name, "includeif.") && strings.HasSuffix(e.Name, ".path") {
+			cond := e.Name[len("includeif.") : len(e.Name)-len(".path")]
+			if c.matchCondition(cond, path) {
+				included = e.Value
+			}
+		}
+		if included == "" {
+			continue
+		}
+
+		included, err = ExpandPath(included)
+		if err != nil {
+			return err
+		}
+		if !filepath.IsAbs(included) {
+			included = filepath.Join(filepath.Dir(path), included)
+		}
+		if err := c.loadFile(included, scope, depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// matchCondition evaluates condition of includeIf, only gitdir: and gitdir/i: are supported
+func (c *Config) matchCondition(cond string, path string) bool {
+	foldCase := false
+	switch {
+	case strings.HasPrefix(cond, "gitdir:"):
+		cond = cond[len("gitdir:"):]
+	case strings.HasPrefix(cond, "gitdir/i:"):
+		cond = cond[len("gitdir/i:"):]
+		foldCase = true
+	default:
+		return false
+	}
+	if c.gitDir == "" || cond == "" {
+		return false
+	}
+
+	pattern, err := ExpandPath(cond)
+	if err != nil {
+		return false
+	}
+	if strings.HasPrefix(pattern, "./") {
+		pattern = filepath.Join(filepath.Dir(path), pattern[2:])
+		if strings.HasSuffix(cond, "/") {
+			pattern += "/"
+		}
+	} else if !filepath.IsAbs(pattern) {
+		pattern = "**/" + pattern
+	}
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+
+	gitDirs := []string{filepath.Clean(c.gitDir)}
+	if real, err := filepath.EvalSymlinks(c.gitDir); err == nil && real != gitDirs[0] {
+		gitDirs = append(gitDirs, real)
+	}
+	for _, d := range gitDirs {
+		if utils.Wildmatch(filepath.ToSlash(pattern), filepath.ToSlash(d), foldCase) {
+			return true
+		}
+	}
+	return false
+}
+
+// Entries returns all entries in the order of loading
+func (c *Config) Entries() []*Entry {
+	return c.entries
+}
+
+// Lookup returns the last entry of the key, nil if it does not exist
+func (c *Config) Lookup(name string) *Entry {
+	entries := c.LookupAll(name)
+	if len(entries) == 0 {
+		return nil
+	}
+	return entries[len(entries)-1]
+}
+
+// LookupAll returns all entries of the key
+func (c *Config) LookupAll(name string) []*Entry {
+	sec, key, err := splitName(name)
+	if err != nil {
+		return nil
+	}
+
+	canonical := sec + "." + key
+	entries := make([]*Entry, 0)
+	for _, e := range c.entries {
+		if e.Name == canonical {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Get returns the value of the key, the last one wins if the key has multiple values
+func (c *Config) Get(name string) (string, bool) {
+	e := c.Lookup(name)
+	if e == nil {
+		return "", false
+	}
+	return e.Value, true
+}
+
+// GetAll returns all values of the key
+func (c *Config) GetAll(name string) []string {
+	entries := c.LookupAll(name)
+	values := make([]string, 0, len(entries))
+	for _, e := range entries {
+		values = append(values, e.Value)
+	}
+	return values
+}
+
+// GetBool returns the key as boolean, def if it does not exist
+func (c *Config) GetBool(name string, def bool) (bool, error) {
+	e := c.Lookup(name)
+	if e == nil {
+		return def, nil
+	}
+	return e.Bool()
+}
+
+// GetInt returns the key as integer, def if it does not exist
+func (c *Config) GetInt(name string, def int64) (int64, error) {
+	e := c.Lookup(name)
+	if e == nil {
+		return def, nil
+	}
+	return e.Int()
+}
+
+// GetPath returns the key as a path with ~/ expanded
+func (c *Config) GetPath(name string) (string, bool) {
+	e := c.Lookup(name)
+	if e == nil {
+		return "", false
+	}
+	path, err := e.Path()
+	if err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// SystemConfigPath returns $GIT_CONFIG_SYSTEM or /etc/gitconfig, empty if $GIT_CONFIG_NOSYSTEM is set
+func SystemConfigPath() string {
+	if v, err := ParseBool(os.Getenv(env_git_config_nosystem)); err == nil && v {
+		return ""
+	}
+	if path := os.Getenv(env_git_config_system); path != "" {
+		return path
+	}
+	return system_config_path
+}
+
+// GlobalConfigPaths returns $XDG_CONFIG_HOME/git/config and ~/.gitconfig in the order of loading, or $GIT_CONFIG_GLOBAL if it is set
+func GlobalConfigPaths() []string {
+	if path := os.Getenv(env_git_config_global); path != "" {
+		return []string{path}
+	}
+
+	paths := make([]string, 0, 2)
+	if xdg := os.Getenv(env_xdg_config_home); xdg != "" {
+		paths = append(paths, filepath.Join(xdg, "git", "config"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if os.Getenv(env_xdg_config_home) == "" {
+			paths = append(paths, filepath.Join(home, ".config", "git", "config"))
+		}
+		paths = append(paths, filepath.Join(home, ".gitconfig"))
+	}
+	return paths
+}
+
+// GlobalConfigPath returns the global config file to be written, ~/.gitconfig is preferred unless only the XDG one exists
+func GlobalConfigPath() string {
+	paths := GlobalConfigPaths()
+	if len(paths) == 0 {
+		return ""
+	}
+
+	last := paths[len(paths)-1]
+	if len(paths) > 1 && !utils.FileExists(last) && utils.FileExists(paths[0]) {
+		return paths[0]
+	}
+	return last
+}
+
+// LocalConfigPath returns the config file of the repository
+func LocalConfigPath(gitDir string) string {
+	return filepath.Join(gitDir, "config")
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sample = `# user settings
+[user]
+	name = Jiang Zhu ; trailing comment
+	email = "m.zhujiang@gmail.com"
+[core] bare = false
+	filemode
+	autocrlf = "  spaced  "
+	editor = vim \
+-u NONE
+[remote "origin"]
+	url = https://example.com/a.git
+	fetch = +refs/heads/*:refs/remotes/origin/*
+	fetch = +refs/tags/*:refs/tags/*
+[Branch.Main]
+	remote = origin
+[pack]
+	windowMemory = 10m
+	msg = "tab\tnewline\nquote\"backslash\\"
+`
+
+func TestParse(t *testing.T) {
+	f, err := Parse("config", []byte(sample))
+	assert.Nil(t, err)
+	assert.Equal(t, sample, string(f.Bytes()))
+
+	c := &Config{entries: f.Entries()}
+	get := func(name string) string {
+		v, _ := c.Get(name)
+		return v
+	}
+	assert.Equal(t, "Jiang Zhu", get("user.name"))
+	assert.Equal(t, "m.zhujiang@gmail.com", get("USER.Email"))
+	assert.Equal(t, "  spaced  ", get("core.autocrlf"))
+	assert.Equal(t, "vim -u NONE", get("core.editor"))
+	assert.Equal(t, "tab\tnewline\nquote\"backslash\\", get("pack.msg"))
+	assert.Equal(t, "origin", get("branch.main.remote"))
+	assert.Equal(t, []string{"+refs/heads/*:refs/remotes/origin/*", "+refs/tags/*:refs/tags/*"}, c.GetAll("remote.origin.fetch"))
+
+	_, found := c.Get("remote.ORIGIN.url")
+	assert.False(t, found)
+
+	bare, err := c.GetBool("core.bare", true)
+	assert.Nil(t, err)
+	assert.False(t, bare)
+	filemode, err := c.GetBool("core.filemode", false)
+	assert.Nil(t, err)
+	assert.True(t, filemode)
+	size, err := c.GetInt("pack.windowmemory", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(10<<20), size)
+
+	for _, bad := range []string{"key = value\n", "[core\n", "[core]\n\tname = \"unterminated\n", "[core]\n\t1key = v\n", "[core]\n\tk = \\q\n"} {
+		_, err := Parse("config", []byte(bad))
+		assert.ErrorIs(t, err, ErrInvalidConfig, bad)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	f, err := Parse("config", []byte(sample))
+	assert.Nil(t, err)
+
+	assert.Nil(t, f.Set("user.name", "Someone Else"))
+	assert.Nil(t, f.Set("core.bare", "true"))
+	assert.Equal(t, ErrMultipleValues, f.Set("remote.origin.fetch", "x"))
+	assert.Nil(t, f.Add("remote.origin.pushurl", "ssh://example.com/a.git"))
+	assert.Nil(t, f.Set("remote.upstream.url", "#hash"))
+	n, err := f.UnsetAll("remote.origin.fetch")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+	assert.Nil(t, f.Unset("core.filemode"))
+	assert.Equal(t, ErrKeyNotFound, f.Unset("core.filemode"))
+	assert.True(t, f.RenameSection("branch.main", "branch.trunk"))
+	assert.Equal(t, ErrInvalidKey, f.Set("nokey", "x"))
+
+	want := `# user settings
+[user]
+	name = Someone Else
+	email = "m.zhujiang@gmail.com"
+[core] bare = true
+	autocrlf = "  spaced  "
+	editor = vim \
+-u NONE
+[remote "origin"]
+	url = https://example.com/a.git
+	pushurl = ssh://example.com/a.git
+[branch "trunk"]
+	remote = origin
+[pack]
+	windowMemory = 10m
+	msg = "tab\tnewline\nquote\"backslash\\"
+[remote "upstream"]
+	url = "#hash"
+`
+	assert.Equal(t, want, string(f.Bytes()))
+
+	reparsed, err := Parse("config", f.Bytes())
+	assert.Nil(t, err)
+	v, _ := reparsed.Get("remote.upstream.url")
+	assert.Equal(t, "#hash", v)
+
+	assert.True(t, f.RemoveSection("remote.upstream"))
+	assert.False(t, f.RemoveSection("remote.upstream"))
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	gitDir := filepath.Join(dir, "work", "project", ".git")
+	os.MkdirAll(gitDir, 0755)
+
+	system := filepath.Join(dir, "gitconfig")
+	global := filepath.Join(dir, "global")
+	os.WriteFile(system, []byte("[user]\n\tname = system\n\temail = system@example.com\n"), 0644)
+	os.WriteFile(global, []byte("[user]\n\tname = global\n[include]\n\tpath = included\n[includeIf \"gitdir:work/\"]\n\tpath = work.inc\n[includeIf \"gitdir:other/\"]\n\tpath = other.inc\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "included"), []byte("[core]\n\teditor = vi\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "work.inc"), []byte("[user]\n\temail = work@example.com\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "other.inc"), []byte("[user]\n\temail = other@example.com\n"), 0644)
+	os.WriteFile(LocalConfigPath(gitDir), []byte("[user]\n\tname = local\n"), 0644)
+
+	t.Setenv(env_git_config_system, system)
+	t.Setenv(env_git_config_global, global)
+	c, err := Load(gitDir)
+	assert.Nil(t, err)
+
+	e := c.Lookup("user.name")
+	assert.Equal(t, "local", e.Value)
+	assert.Equal(t, ScopeLocal, e.Scope)
+	e = c.Lookup("user.email")
+	assert.Equal(t, "work@example.com", e.Value)
+	assert.Equal(t, ScopeGlobal, e.Scope)
+	assert.Equal(t, filepath.Join(dir, "work.inc"), e.Origin)
+	editor, _ := c.Get("core.editor")
+	assert.Equal(t, "vi", editor)
+	assert.Equal(t, []string{"system", "global", "local"}, c.GetAll("user.name"))
+
+	// include itself
+	os.WriteFile(filepath.Join(dir, "included"), []byte("[include]\n\tpath = included\n"), 0644)
+	_, err = Load(gitDir)
+	assert.ErrorIs(t, err, ErrIncludeDepth)
+
+	t.Setenv(env_git_config_nosystem, "1")
+	assert.Equal(t, "", SystemConfigPath())
+}
@@ -0,0 +1,619 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrInvalidConfig  = errors.New("Bad config line.")
+	ErrInvalidKey     = errors.New("Invalid key.")
+	ErrMultipleValues = errors.New("Cannot overwrite multiple values with a single value.")
+	ErrKeyNotFound    = errors.New("Key does not exist.")
+	ErrLocked         = errors.New("Config file is locked by another process.")
+)
+
+// File is a config file in git's INI dialect. The raw text of every line is kept,
+// so that rewriting the file preserves the comments, blank lines and ordering of the user.
+type File struct {
+	Path     string
+	sections []*section
+}
+
+// section is led by its header, the first section of a file has no header and holds comments before any header
+type section struct {
+	// canonical name, section name in lower case and the subsection as is, such as remote.origin
+	name   string
+	header string
+	lines  []*line
+}
+
+type line struct {
+	raw string
+	// key in lower case, empty for comments and blank lines
+	key   string
+	value string
+	// key without "=", which is true as boolean
+	noValue bool
+}
+
+func (l *line) isEntry() bool {
+	return l.key != ""
+}
+
+// NewFile returns an empty config file which will be saved in path
+func NewFile(path string) *File {
+	return &File{
+		Path:     path,
+		sections: []*section{{}},
+	}
+}
+
+// ReadFile parses the config file in path
+func ReadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(path, data)
+}
+
+// Parse parses data as the content of the config file in path
+func Parse(path string, data []byte) (*File, error) {
+	p := &parser{
+		data: data,
+		path: path,
+		line: 1,
+	}
+	// skip UTF-8 BOM, which is kept in the header of the first section
+	if bytes.HasPrefix(data, []byte("\xef\xbb\xbf")) {
+		p.pos = 3
+	}
+
+	f := &File{
+		Path:     path,
+		sections: []*section{{header: string(data[:p.pos])}},
+	}
+	if err := p.parse(f); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Bytes returns the content of the file
+func (f *File) Bytes() []byte {
+	var buf bytes.Buffer
+	for _, s := range f.sections {
+		buf.WriteString(s.header)
+		for _, l := range s.lines {
+			buf.WriteString(l.raw)
+		}
+	}
+	return buf.Bytes()
+}
+
+// Save writes the file through a lock file, which is renamed to the file at last
+func (f *File) Save() error {
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0755); err != nil {
+		return err
+	}
+
+	lockPath := f.Path + ".lock"
+	lf, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("%w: %s", ErrLocked, lockPath)
+		}
+		return err
+	}
+
+	_, err = lf.Write(f.Bytes())
+	if cerr := lf.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(lockPath)
+		return err
+	}
+
+	return os.Rename(lockPath, f.Path)
+}
+
+// Entries returns all entries of the file in order, included files are not followed
+func (f *File) Entries() []*Entry {
+	entries := make([]*Entry, 0)
+	for _, s := range f.sections {
+		for _, l := range s.lines {
+			if l.isEntry() {
+				entries = append(entries, &Entry{
+					Name:    s.name + "." + l.key,
+					Value:   l.value,
+					NoValue: l.noValue,
+					Origin:  f.Path,
+				})
+			}
+		}
+	}
+	return entries
+}
+
+// Get returns the last value of the key
+func (f *File) Get(name string) (string, bool) {
+	values := f.GetAll(name)
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[len(values)-1], true
+}
+
+// GetAll returns all values of the key
+func (f *File) GetAll(name string) []string {
+	sec, key, err := splitName(name)
+	if err != nil {
+		return nil
+	}
+
+	values := make([]string, 0)
+	for _, s := range f.sections {
+		if s.name != sec {
+			continue
+		}
+		for _, l := range s.lines {
+			if l.key == key {
+				values = append(values, l.value)
+			}
+		}
+	}
+	return values
+}
+
+// Set replaces the value of the key, or adds the key if it does not exist.
+// ErrMultipleValues is returned if the key has multiple values.
+func (f *File) Set(name string, value string) error {
+	sec, key, err := splitName(name)
+	if err != nil {
+		return err
+	}
+
+	var found *line
+	for _, s := range f.sections {
+		if s.name != sec {
+			continue
+		}
+		for _, l := range s.lines {
+			if l.key == key {
+				if found != nil {
+					return ErrMultipleValues
+				}
+				found = l
+			}
+		}
+	}
+
+	if found == nil {
+		return f.Add(name, value)
+	}
+
+	found.raw = leadingSpace(found.raw) + formatEntry(name, value)
+	found.value = value
+	found.noValue = false
+	return nil
+}
+
+// ReplaceAll replaces all values of the key with a single value
+func (f *File) ReplaceAll(name string, value string) error {
+	if _, err := f.UnsetAll(name); err != nil {
+		return err
+	}
+	return f.Add(name, value)
+}
+
+// Add appends a value to the key after the last entry of its section, the section is created at the end of file if necessary
+func (f *File) Add(name string, value string) error {
+	sec, key, err := splitName(name)
+	if err != nil {
+		return err
+	}
+
+	l := &line{
+		raw:   "\t" + formatEntry(name, value),
+		key:   key,
+		value: value,
+	}
+
+	var last *section
+	for _, s := range f.sections {
+		if s.name == sec {
+			last = s
+		}
+	}
+	if last == nil {
+		last = &section{
+			name:   sec,
+			header: formatHeader(sec) + "\n",
+		}
+		if !f.endsWithNewline() {
+			last.header = "\n" + last.header
+		}
+		f.sections = append(f.sections, last)
+		last.lines = append(last.lines, l)
+		return nil
+	}
+
+	// insert after the last entry of the section, or after the line of header
+	at := 0
+	for i, ol := range last.lines {
+		if ol.isEntry() {
+			at = i + 1
+		}
+	}
+	if at == 0 && len(last.lines) > 0 && !strings.HasSuffix(last.header, "\n") && strings.HasSuffix(last.lines[0].raw, "\n") {
+		at = 1
+	}
+	prev := last.header
+	if at > 0 {
+		prev = last.lines[at-1].raw
+	}
+	if !strings.HasSuffix(prev, "\n") {
+		l.raw = "\n" + l.raw
+	}
+	last.lines = append(last.lines[:at], append([]*line{l}, last.lines[at:]...)...)
+
+	return nil
+}
+
+// Unset removes the key, ErrMultipleValues is returned if the key has multiple values
+func (f *File) Unset(name string) error {
+	values := f.GetAll(name)
+	if len(values) == 0 {
+		return ErrKeyNotFound
+	}
+	if len(values) > 1 {
+		return ErrMultipleValues
+	}
+
+	_, err := f.UnsetAll(name)
+	return err
+}
+
+// UnsetAll removes all values of the key, returns the number of removed values
+func (f *File) UnsetAll(name string) (int, error) {
+	return f.UnsetMatch(name, func(value string) bool { return true })
+}
+
+// UnsetMatch removes the values of the key for which match returns true
+func (f *File) UnsetMatch(name string, match func(value string) bool) (int, error) {
+	sec, key, err := splitName(name)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, s := range f.sections {
+		if s.name != sec {
+			continue
+		}
+
+		lines := s.lines[:0]
+		prev := s.header
+		for _, l := range s.lines {
+			if l.key == key && match(l.value) {
+				removed++
+				// an entry following the header in the same line leaves the line break
+				if !strings.HasSuffix(prev, "\n") && strings.HasSuffix(l.raw, "\n") {
+					l = &line{raw: "\n"}
+				} else {
+					continue
+				}
+			}
+			lines = append(lines, l)
+			prev = l.raw
+		}
+		s.lines = lines
+	}
+
+	return removed, nil
+}
+
+// RemoveSection removes all sections with the name (such as branch.main), returns false if there is no such section
+func (f *File) RemoveSection(name string) bool {
+	sec := canonicalSection(name)
+	sections := f.sections[:0]
+	removed := false
+	for i, s := range f.sections {
+		if i > 0 && s.name == sec {
+			removed = true
+			continue
+		}
+		sections = append(sections, s)
+	}
+	f.sections = sections
+
+	return removed
+}
+
+// RenameSection renames all sections with the name, returns false if there is no such section
+func (f *File) RenameSection(oldName string, newName string) bool {
+	sec := canonicalSection(oldName)
+	renamed := false
+	for i, s := range f.sections {
+		if i > 0 && s.name == sec {
+			s.name = canonicalSection(newName)
+			header := leadingSpace(s.header) + formatHeader(s.name)
+			if strings.HasSuffix(s.header, "\n") {
+				header += "\n"
+			}
+			s.header = header
+			renamed = true
+		}
+	}
+
+	return renamed
+}
+
+func (f *File) endsWithNewline() bool {
+	for i := len(f.sections) - 1; i >= 0; i-- {
+		s := f.sections[i]
+		for j := len(s.lines) - 1; j >= 0; j-- {
+			if s.lines[j].raw != "" {
+				return strings.HasSuffix(s.lines[j].raw, "\n")
+			}
+		}
+		if s.header != "" {
+			return strings.HasSuffix(s.header, "\n")
+		}
+	}
+	return true
+}
+
+// whitespaces before the entry or header, line breaks included
+func leadingSpace(raw string) string {
+	return raw[:len(raw)-len(strings.TrimLeft(raw, " \t\r\n"))]
+}
+
+// --------------------------------------------------------------------------
+
+type parser struct {
+	data []byte
+	pos  int
+	path string
+	line int
+}
+
+func (p *parser) errorf() error {
+	return fmt.Errorf("%w: line %d in %s", ErrInvalidConfig, p.line, p.path)
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.data)
+}
+
+func (p *parser) parse(f *File) error {
+	cur := f.sections[0]
+	for !p.eof() {
+		start := p.pos
+		for !p.eof() && (p.data[p.pos] == ' ' || p.data[p.pos] == '\t' || p.data[p.pos] == '\r') {
+			p.pos++
+		}
+		if p.eof() {
+			cur.lines = append(cur.lines, &line{raw: string(p.data[start:p.pos])})
+			break
+		}
+
+		c := p.data[p.pos]
+		switch {
+		case c == '\n':
+			p.pos++
+			p.line++
+			cur.lines = append(cur.lines, &line{raw: string(p.data[start:p.pos])})
+
+		case c == '#' || c == ';':
+			p.skipLine()
+			cur.lines = append(cur.lines, &line{raw: string(p.data[start:p.pos])})
+
+		case c == '[':
+			name, err := p.parseHeader()
+			if err != nil {
+				return err
+			}
+			cur = &section{
+				name:   name,
+				header: string(p.data[start:p.pos]),
+			}
+			f.sections = append(f.sections, cur)
+
+		case isAlpha(c):
+			if len(f.sections) == 1 {
+				// key without section
+				return p.errorf()
+			}
+			l, err := p.parseEntry()
+			if err != nil {
+				return err
+			}
+			l.raw = string(p.data[start:p.pos])
+			cur.lines = append(cur.lines, l)
+
+		default:
+			return p.errorf()
+		}
+	}
+
+	return nil
+}
+
+func (p *parser) skipLine() {
+	for !p.eof() {
+		c := p.data[p.pos]
+		p.pos++
+		if c == '\n' {
+			p.line++
+			return
+		}
+	}
+}
+
+// parseHeader parses [section], [section "subsection"] and the legacy [section.subsection]
+func (p *parser) parseHeader() (string, error) {
+	p.pos++ // [
+	start := p.pos
+	for !p.eof() && (isAlnum(p.data[p.pos]) || p.data[p.pos] == '-' || p.data[p.pos] == '.') {
+		p.pos++
+	}
+	if p.eof() || p.pos == start {
+		return "", p.errorf()
+	}
+	name := strings.ToLower(string(p.data[start:p.pos]))
+
+	if p.data[p.pos] == ']' {
+		p.pos++
+		return name, nil
+	}
+
+	// subsection, only " and \ are escaped
+	for !p.eof() && (p.data[p.pos] == ' ' || p.data[p.pos] == '\t') {
+		p.pos++
+	}
+	if p.eof() || p.data[p.pos] != '"' || strings.Contains(name, ".") {
+		return "", p.errorf()
+	}
+	p.pos++
+
+	var sub strings.Builder
+	for {
+		if p.eof() || p.data[p.pos] == '\n' {
+			return "", p.errorf()
+		}
+		c := p.data[p.pos]
+		p.pos++
+		if c == '"' {
+			break
+		}
+		if c == '\\' {
+			if p.eof() || p.data[p.pos] == '\n' {
+				return "", p.errorf()
+			}
+			c = p.data[p.pos]
+			p.pos++
+		}
+		sub.WriteByte(c)
+	}
+
+	if p.eof() || p.data[p.pos] != ']' {
+		return "", p.errorf()
+	}
+	p.pos++
+
+	return name + "." + sub.String(), nil
+}
+
+// parseEntry parses key = value until the end of line, line continuations included
+func (p *parser) parseEntry() (*line, error) {
+	start := p.pos
+	for !p.eof() && (isAlnum(p.data[p.pos]) || p.data[p.pos] == '-') {
+		p.pos++
+	}
+	l := &line{
+		key: strings.ToLower(string(p.data[start:p.pos])),
+	}
+
+	for !p.eof() && (p.data[p.pos] == ' ' || p.data[p.pos] == '\t' || p.data[p.pos] == '\r') {
+		p.pos++
+	}
+	if p.eof() || p.data[p.pos] == '\n' || p.data[p.pos] == '#' || p.data[p.pos] == ';' {
+		l.noValue = true
+		p.skipLine()
+		return l, nil
+	}
+	if p.data[p.pos] != '=' {
+		return nil, p.errorf()
+	}
+	p.pos++
+
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	l.value = value
+
+	return l, nil
+}
+
+func (p *parser) parseValue() (string, error) {
+	var value strings.Builder
+	quoted := false
+	spaces := 0
+	for !p.eof() {
+		c := p.data[p.pos]
+		p.pos++
+
+		if c == '\n' {
+			p.line++
+			if quoted {
+				return "", p.errorf()
+			}
+			return value.String(), nil
+		}
+		if !quoted && (c == ';' || c == '#') {
+			p.skipLine()
+			return value.String(), nil
+		}
+		if !quoted && (c == ' ' || c == '\t' || c == '\r') {
+			// spaces are kept only between non-space characters
+			if value.Len() > 0 {
+				spaces++
+			}
+			continue
+		}
+
+		for ; spaces > 0; spaces-- {
+			value.WriteByte(' ')
+		}
+
+		switch c {
+		case '\\':
+			if p.eof() {
+				return "", p.errorf()
+			}
+			e := p.data[p.pos]
+			p.pos++
+			switch e {
+			case '\n':
+				p.line++
+			case 't':
+				value.WriteByte('\t')
+			case 'b':
+				value.WriteByte('\b')
+			case 'n':
+				value.WriteByte('\n')
+			case '\\', '"':
+				value.WriteByte(e)
+			default:
+				return "", p.errorf()
+			}
+		case '"':
+			quoted = !quoted
+		default:
+			value.WriteByte(c)
+		}
+	}
+
+	if quoted {
+		return "", p.errorf()
+	}
+	return value.String(), nil
+}
+
+func isAlpha(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isAlnum(c byte) bool {
+	return isAlpha(c) || (c >= '0' && c <= '9')
+}
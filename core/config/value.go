@@ -0,0 +1,151 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidBool = errors.New("Invalid boolean value.")
+	ErrInvalidInt  = errors.New("Invalid integer value.")
+)
+
+// ParseBool parses true, yes, on, 1 and false, no, off, 0 and the empty string, case insensitively
+func ParseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "true", "yes", "on", "1":
+		return true, nil
+	case "false", "no", "off", "0", "":
+		return false, nil
+	}
+
+	if n, err := ParseInt(value); err == nil {
+		return n != 0, nil
+	}
+	return false, ErrInvalidBool
+}
+
+// ParseInt parses integer with an optional unit suffix k, m or g
+func ParseInt(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, ErrInvalidInt
+	}
+
+	unit := int64(1)
+	switch value[len(value)-1] {
+	case 'k', 'K':
+		unit = 1 << 10
+	case 'm', 'M':
+		unit = 1 << 20
+	case 'g', 'G':
+		unit = 1 << 30
+	}
+	if unit > 1 {
+		value = value[:len(value)-1]
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidInt
+	}
+	return n * unit, nil
+}
+
+// ExpandPath expands the leading ~/ to the home directory
+func ExpandPath(value string) (string, error) {
+	if value == "~" || strings.HasPrefix(value, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, value[1:]), nil
+	}
+
+	return value, nil
+}
+
+// splitName splits name like section.subsection.key into the canonical section and the key in lower case
+func splitName(name string) (string, string, error) {
+	first := strings.IndexByte(name, '.')
+	last := strings.LastIndexByte(name, '.')
+	if first <= 0 || last == len(name)-1 {
+		return "", "", ErrInvalidKey
+	}
+
+	sec, key := name[:first], name[last+1:]
+	if !validName(sec, true) || !validName(key, false) {
+		return "", "", ErrInvalidKey
+	}
+
+	return canonicalSection(name[:last]), strings.ToLower(key), nil
+}
+
+// canonicalSection turns section name in lower case, while subsection is case sensitive
+func canonicalSection(name string) string {
+	sec, sub, found := strings.Cut(name, ".")
+	if !found {
+		return strings.ToLower(sec)
+	}
+	return strings.ToLower(sec) + "." + sub
+}
+
+func validName(name string, isSection bool) bool {
+	if name == "" || (!isSection && !isAlpha(name[0])) {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isAlnum(name[i]) && name[i] != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// formatHeader formats canonical section name as [section "subsection"]
+func formatHeader(sec string) string {
+	name, sub, found := strings.Cut(sec, ".")
+	if !found {
+		return "[" + name + "]"
+	}
+
+	sub = strings.ReplaceAll(sub, `\`, `\\`)
+	sub = strings.ReplaceAll(sub, `"`, `\"`)
+	return "[" + name + ` "` + sub + `"]`
+}
+
+// formatEntry formats key = value with line break, the key keeps its case in name
+func formatEntry(name string, value string) string {
+	key := name[strings.LastIndexByte(name, '.')+1:]
+	return key + " = " + quoteValue(value) + "\n"
+}
+
+// quoteValue escapes the value, and quotes it if it has leading or trailing spaces or comment characters
+func quoteValue(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '\\':
+			b.WriteString(`\\`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\b':
+			b.WriteString(`\b`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	s := b.String()
+	if strings.ContainsAny(value, "#;") || strings.TrimSpace(value) != value {
+		s = `"` + s + `"`
+	}
+	return s
+}
@@ -0,0 +1,187 @@
+package core
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/izhujiang/gogit/core/config"
+	"github.com/izhujiang/gogit/core/internal/utils"
+)
+
+const (
+	env_git_dir                          = "GIT_DIR"
+	env_git_work_tree                    = "GIT_WORK_TREE"
+	env_git_ceiling_directories          = "GIT_CEILING_DIRECTORIES"
+	env_git_discovery_across_filesystems = "GIT_DISCOVERY_ACROSS_FILESYSTEM"
+
+	gitfile_prefix = "gitdir:"
+)
+
+var (
+	errInvalidGitFile     = errors.New("Invalid gitfile format.")
+	errOutsideWorkTree    = errors.New("Path is outside of the work tree.")
+	errFilesystemBoundary = errors.New("Not a git repository, stopping at filesystem boundary (GIT_DISCOVERY_ACROSS_FILESYSTEM not set).")
+	errNotAGitDirectory   = errors.New("Not a git repository, HEAD, objects or refs is missing.")
+)
+
+// Discover finds the workspace containing path as git does: $GIT_DIR (and $GIT_WORK_TREE) is used if it is set,
+// otherwise the parent directories of path are searched for a .git directory or a .git file (gitdir: <path>)
+// until a filesystem boundary or one of $GIT_CEILING_DIRECTORIES is reached.
+// A directory which is a git directory itself is opened as a bare repository.
+func Discover(path string) (*Workspace, error) {
+	if path == "" {
+		path = "."
+	}
+	cwd, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var root, gitDir string
+	if dir := os.Getenv(env_git_dir); dir != "" {
+		gitDir, err = filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		if !isGitDir(gitDir) {
+			return nil, errNotAGitDirectory
+		}
+		// without GIT_WORK_TREE, the current directory is regarded as the top of the work tree unless core.bare is set
+		if !isBareRepository(gitDir) {
+			root = cwd
+		}
+	} else {
+		root, gitDir, err = discoverGitDir(cwd)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if wt := os.Getenv(env_git_work_tree); wt != "" {
+		root, err = filepath.Abs(wt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ws := newWorkspace(root, gitDir)
+	ws.cwd = cwd
+	return ws, nil
+}
+
+// walk up from dir, returns the top of work tree (empty for bare repositories) and the git directory
+func discoverGitDir(dir string) (string, string, error) {
+	ceilings := ceilingDirectories()
+	acrossFs := os.Getenv(env_git_discovery_across_filesystems) == "true" || os.Getenv(env_git_discovery_across_filesystems) == "1"
+
+	for {
+		gitDir, err := resolveDotGit(dir)
+		if err != nil {
+			return "", "", err
+		}
+		if gitDir != "" {
+			return dir, gitDir, nil
+		}
+		if isGitDir(dir) {
+			return "", dir, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", errRepositoryNotExists
+		}
+		for _, c := range ceilings {
+			if parent == c {
+				return "", "", errRepositoryNotExists
+			}
+		}
+		if !acrossFs && !sameFilesystem(dir, parent) {
+			return "", "", errFilesystemBoundary
+		}
+
+		dir = parent
+	}
+}
+
+// resolveDotGit returns the git directory which dir/.git is or points to, empty if dir/.git does not exist
+func resolveDotGit(dir string) (string, error) {
+	dotGit := filepath.Join(dir, repositoryName)
+	fi, err := os.Stat(dotGit)
+	if err != nil {
+		return "", nil
+	}
+
+	if fi.IsDir() {
+		if isGitDir(dotGit) {
+			return dotGit, nil
+		}
+		return "", nil
+	}
+
+	gitDir, err := readGitFile(dotGit)
+	if err != nil {
+		return "", err
+	}
+	if !isGitDir(gitDir) {
+		return "", errNotAGitDirectory
+	}
+	return gitDir, nil
+}
+
+// readGitFile reads the git directory from a .git file of worktrees and submodules, a relative path is relative to the file
+func readGitFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Scan()
+	line := scanner.Text()
+	if !strings.HasPrefix(line, gitfile_prefix) {
+		return "", errInvalidGitFile
+	}
+
+	gitDir := strings.TrimSpace(line[len(gitfile_prefix):])
+	if gitDir == "" {
+		return "", errInvalidGitFile
+	}
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(path), gitDir)
+	}
+	return filepath.Clean(gitDir), nil
+}
+
+// isGitDir reports whether path looks like a git directory, which contains HEAD, objects and refs
+func isGitDir(path string) bool {
+	return utils.FileExists(filepath.Join(path, "HEAD")) &&
+		utils.DirectoryExists(filepath.Join(path, "objects")) &&
+		utils.DirectoryExists(filepath.Join(path, "refs"))
+}
+
+// isBareRepository reads core.bare from the config of the git directory
+func isBareRepository(gitDir string) bool {
+	c, err := config.LoadFile(config.LocalConfigPath(gitDir), gitDir)
+	if err != nil {
+		return false
+	}
+
+	bare, _ := c.GetBool("core.bare", false)
+	return bare
+}
+
+// directories listed in GIT_CEILING_DIRECTORIES, relative paths are ignored
+func ceilingDirectories() []string {
+	dirs := make([]string, 0)
+	for _, d := range filepath.SplitList(os.Getenv(env_git_ceiling_directories)) {
+		if d == "" || !filepath.IsAbs(d) {
+			continue
+		}
+		dirs = append(dirs, filepath.Clean(d))
+	}
+	return dirs
+}
@@ -0,0 +1,27 @@
+//go:build unix
+
+package core
+
+import (
+	"os"
+	"syscall"
+)
+
+// sameFilesystem reports whether both paths are on the same device
+func sameFilesystem(a, b string) bool {
+	fa, err := os.Stat(a)
+	if err != nil {
+		return true
+	}
+	fb, err := os.Stat(b)
+	if err != nil {
+		return true
+	}
+
+	sa, ok := fa.Sys().(*syscall.Stat_t)
+	sb, ok2 := fb.Sys().(*syscall.Stat_t)
+	if !ok || !ok2 {
+		return true
+	}
+	return uint64(sa.Dev) == uint64(sb.Dev)
+}
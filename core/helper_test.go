@@ -0,0 +1,104 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core/object"
+	"github.com/stretchr/testify/assert"
+)
+
+// testHistory creates commits in repo for tests, each one a hundred seconds after the previous one
+type testHistory struct {
+	t    *testing.T
+	repo *Repository
+	// the tree of commits made by commit, and the time of the last commit
+	tree common.Hash
+	at   int64
+}
+
+func newTestHistory(t *testing.T, repo *Repository) *testHistory {
+	return &testHistory{t: t, repo: repo, at: 1112911993}
+}
+
+// sig returns the signature of the next commit
+func (h *testHistory) sig() *object.Signature {
+	return object.NewSignature("A U Thor", "author@example.com", time.Unix(h.at+100, 0))
+}
+
+func (h *testHistory) commit(msg string, parents ...common.Hash) common.Hash {
+	return h.commitTree(msg, h.tree, 0, parents...)
+}
+
+// commitTree creates a commit of tree, whose clock is skewed by skew seconds
+func (h *testHistory) commitTree(msg string, tree common.Hash, skew int64, parents ...common.Hash) common.Hash {
+	h.at += 100
+	sig := object.NewSignature("A U Thor", "author@example.com", time.Unix(h.at+skew, 0))
+	g := object.NewCommit(common.ZeroHash, tree, parents, sig, sig, msg).ToGitObject()
+	assert.Nil(h.t, h.repo.Put(g))
+	return g.Id()
+}
+
+// testWorkspace is a workspace in a temporary directory, with helpers to build objects, files and the index
+type testWorkspace struct {
+	*Workspace
+	t *testing.T
+}
+
+func newTestWorkspace(t *testing.T) *testWorkspace {
+	ws, err := Init(io.Discard, t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testWorkspace{Workspace: ws, t: t}
+}
+
+func (w *testWorkspace) blob(content string) common.Hash {
+	oid, err := HashObjectFromReader(bytes.NewBufferString(content), object.Kind_Blob, w.repository)
+	assert.Nil(w.t, err)
+	return oid
+}
+
+// putTree hashes tree and stores it
+func (w *testWorkspace) putTree(tree *object.Tree) common.Hash {
+	tree.Hash()
+	assert.Nil(w.t, w.repository.Put(tree.ToGitObject()))
+	return tree.Id()
+}
+
+// write creates the file path in the work tree along with its leading directories
+func (w *testWorkspace) write(path string, content string) {
+	fp := filepath.Join(w.root, filepath.FromSlash(path))
+	assert.Nil(w.t, os.MkdirAll(filepath.Dir(fp), 0755))
+	assert.Nil(w.t, os.WriteFile(fp, []byte(content), 0644))
+}
+
+// read returns the content of the file path in the work tree, empty if it does not exist
+func (w *testWorkspace) read(path string) string {
+	b, _ := os.ReadFile(filepath.Join(w.root, filepath.FromSlash(path)))
+	return string(b)
+}
+
+// stage adds files in the work tree to the index
+func (w *testWorkspace) stage(paths ...string) {
+	sa := w.StagingArea()
+	sa.Load()
+	assert.Nil(w.t, sa.Stage(w.repository, paths))
+	assert.Nil(w.t, sa.Save())
+}
+
+// indexed returns object ids of index entries by their paths
+func (w *testWorkspace) indexed() map[string]common.Hash {
+	sa := w.StagingArea()
+	sa.Load()
+	ids := make(map[string]common.Hash)
+	sa.ForEachEntry(func(oid common.Hash, p string) {
+		ids[p] = oid
+	})
+	return ids
+}
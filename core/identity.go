@@ -0,0 +1,87 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core/object"
+)
+
+// roles of identities in commits and tags
+const (
+	Role_Author    = "author"
+	Role_Committer = "committer"
+)
+
+var (
+	errIdentityUnknown = errors.New("Identity unknown, please tell me who you are with git config user.name and user.email.")
+)
+
+// Identity returns the signature of author or committer. Name and email are taken from GIT_AUTHOR_NAME/GIT_AUTHOR_EMAIL
+// (GIT_COMMITTER_* for committer), author.name/author.email (committer.*), user.name/user.email in config, and the login user at last.
+// The date is taken from GIT_AUTHOR_DATE (GIT_COMMITTER_DATE), or the current time.
+func (ws *Workspace) Identity(role string) (*object.Signature, error) {
+	c, err := ws.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	env := "GIT_" + strings.ToUpper(role) + "_"
+	name := os.Getenv(env + "NAME")
+	if name == "" {
+		name, _ = c.Get(role + ".name")
+	}
+	if name == "" {
+		name, _ = c.Get("user.name")
+	}
+
+	email := os.Getenv(env + "EMAIL")
+	if email == "" {
+		email, _ = c.Get(role + ".email")
+	}
+	if email == "" {
+		email, _ = c.Get("user.email")
+	}
+	if email == "" {
+		email = os.Getenv("EMAIL")
+	}
+
+	if name == "" || email == "" {
+		if u, err := user.Current(); err == nil {
+			if name == "" {
+				name = u.Name
+				if name == "" {
+					name = u.Username
+				}
+			}
+			if email == "" {
+				host, _ := os.Hostname()
+				if u.Username != "" && host != "" {
+					email = u.Username + "@" + host
+				}
+			}
+		}
+	}
+
+	if name == "" || email == "" {
+		return nil, errIdentityUnknown
+	}
+
+	when, err := IdentityDate(role)
+	if err != nil {
+		return nil, err
+	}
+	return object.NewSignature(name, email, when), nil
+}
+
+// IdentityDate returns the date of author or committer from GIT_AUTHOR_DATE (GIT_COMMITTER_DATE for committer), or the current time
+func IdentityDate(role string) (time.Time, error) {
+	if d := os.Getenv("GIT_" + strings.ToUpper(role) + "_DATE"); d != "" {
+		return common.ParseDate(d)
+	}
+	return time.Now(), nil
+}
@@ -0,0 +1,41 @@
+package core
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentity(t *testing.T) {
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("GIT_AUTHOR_NAME", "")
+	t.Setenv("GIT_AUTHOR_EMAIL", "")
+	t.Setenv("GIT_COMMITTER_NAME", "")
+	t.Setenv("GIT_COMMITTER_EMAIL", "")
+
+	ws, err := Init(io.Discard, t.TempDir(), false)
+	assert.Nil(t, err)
+	f, _ := ws.LocalConfig()
+	f.Set("user.name", "Conf User")
+	f.Set("user.email", "conf@example.com")
+	f.Set("committer.email", "committer@example.com")
+	assert.Nil(t, f.Save())
+
+	sig, err := ws.Identity(Role_Author)
+	assert.Nil(t, err)
+	assert.Equal(t, "Conf User <conf@example.com>", sig.Name+" <"+sig.Email+">")
+	sig, _ = ws.Identity(Role_Committer)
+	assert.Equal(t, "committer@example.com", sig.Email)
+
+	t.Setenv("GIT_AUTHOR_NAME", "Env User")
+	t.Setenv("GIT_AUTHOR_DATE", "2005-04-07T22:13:13+02:00")
+	sig, err = ws.Identity(Role_Author)
+	assert.Nil(t, err)
+	assert.Equal(t, "Env User <conf@example.com> 1112904793 +0200", sig.String())
+
+	t.Setenv("GIT_AUTHOR_DATE", "not a date")
+	_, err = ws.Identity(Role_Author)
+	assert.NotNil(t, err)
+}
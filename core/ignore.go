@@ -0,0 +1,227 @@
+package core
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/izhujiang/gogit/core/internal/utils"
+)
+
+const (
+	ignoreFileName = ".gitignore"
+)
+
+// IgnoreRule is a pattern in an ignore file
+type IgnoreRule struct {
+	// Source is the ignore file of the rule, relative to the root of the work tree if it is inside
+	Source string
+	// Line is the line number of the rule in Source, starting from 1
+	Line int
+	// Pattern is the line as written, without trailing spaces
+	Pattern string
+
+	re *regexp.Regexp
+	// the directory of the ignore file relative to the root of the work tree, patterns with "/" are relative to it
+	base string
+	// negated by "!", the path is not ignored if the rule matches
+	negative bool
+	// the pattern ends with "/" and matches directories only
+	dirOnly bool
+	// the pattern has no "/" and matches base names at any level
+	basename bool
+}
+
+// Negative reports whether the rule is negated by "!", which re-includes paths excluded by previous rules
+func (rule *IgnoreRule) Negative() bool {
+	return rule.negative
+}
+
+// parseIgnoreRules parses patterns in the ignore file source of the directory base, invalid patterns are skipped
+func parseIgnoreRules(content string, source string, base string, foldCase bool) []*IgnoreRule {
+	rules := make([]*IgnoreRule, 0)
+	for n, line := range strings.Split(content, "\n") {
+		line = trimIgnorePattern(strings.TrimSuffix(line, "\r"))
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		rule := &IgnoreRule{Source: source, Line: n + 1, Pattern: line, base: base}
+		if line[0] == '!' {
+			rule.negative = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		rule.basename = !strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+
+		re, err := utils.CompileWildmatch(line, foldCase)
+		if err != nil {
+			continue
+		}
+		rule.re = re
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// trimIgnorePattern removes trailing spaces of the pattern unless they are escaped with backslashes
+func trimIgnorePattern(line string) string {
+	end := len(line)
+	for end > 0 && line[end-1] == ' ' {
+		if end > 1 && line[end-2] == '\\' {
+			break
+		}
+		end--
+	}
+	return line[:end]
+}
+
+// match reports whether the rule matches path, which is relative to the root of the work tree
+func (rule *IgnoreRule) match(p string, isDir bool) bool {
+	if rule.dirOnly && !isDir {
+		return false
+	}
+	if rule.basename {
+		return rule.re.MatchString(path.Base(p))
+	}
+	if rule.base != "" {
+		if !strings.HasPrefix(p, rule.base+"/") {
+			return false
+		}
+		p = p[len(rule.base)+1:]
+	}
+	return rule.re.MatchString(p)
+}
+
+// IgnoreMatcher tells whether paths in the work tree are ignored by .gitignore files in their directories and
+// parents, $GIT_DIR/info/exclude and the file of core.excludesFile, in the order of precedence
+type IgnoreMatcher struct {
+	root     string
+	foldCase bool
+	// rules of info/exclude following those of core.excludesFile, the last matching one wins
+	excludes []*IgnoreRule
+	// rules of each directory which are loaded when they are needed
+	rules map[string][]*IgnoreRule
+}
+
+// IgnoreMatcher loads the exclude files of the repository and the user, .gitignore files are loaded when they are needed
+func (ws *Workspace) IgnoreMatcher() (*IgnoreMatcher, error) {
+	cfg, err := ws.Config()
+	if err != nil {
+		return nil, err
+	}
+	foldCase, err := cfg.GetBool("core.ignorecase", false)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &IgnoreMatcher{
+		root:     ws.root,
+		foldCase: foldCase,
+		excludes: make([]*IgnoreRule, 0),
+		rules:    make(map[string][]*IgnoreRule),
+	}
+	excludesFile, ok := cfg.GetPath("core.excludesfile")
+	if !ok {
+		excludesFile = defaultExcludesFile()
+	}
+	for _, fp := range []string{excludesFile, filepath.Join(ws.repository.Path, "info", "exclude")} {
+		if fp == "" {
+			continue
+		}
+		b, err := os.ReadFile(fp)
+		if err != nil {
+			continue
+		}
+		source := fp
+		if rel, err := filepath.Rel(ws.root, fp); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			source = filepath.ToSlash(rel)
+		}
+		m.excludes = append(m.excludes, parseIgnoreRules(string(b), source, "", foldCase)...)
+	}
+	return m, nil
+}
+
+// defaultExcludesFile returns $XDG_CONFIG_HOME/git/ignore, or ~/.config/git/ignore if $XDG_CONFIG_HOME is not set
+func defaultExcludesFile() string {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "git", "ignore")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".config", "git", "ignore")
+	}
+	return ""
+}
+
+// Match returns the rule which decides whether p, relative to the root of the work tree and separated by "/", is
+// ignored, nil if none matches. The rule excluding a leading directory of p is returned since files in it can't be
+// re-included, otherwise it is the last one matching p, which is negative if p is re-included.
+func (m *IgnoreMatcher) Match(p string, isDir bool) *IgnoreRule {
+	for i := 0; i < len(p); i++ {
+		if p[i] != '/' {
+			continue
+		}
+		if rule := m.match(p[:i], true); rule != nil && !rule.negative {
+			return rule
+		}
+	}
+	return m.match(p, isDir)
+}
+
+// Ignored reports whether p, which is relative to the root of the work tree and separated by "/", is ignored
+func (m *IgnoreMatcher) Ignored(p string, isDir bool) bool {
+	rule := m.Match(p, isDir)
+	return rule != nil && !rule.negative
+}
+
+// match returns the last rule matching p regardless of its leading directories. Rules in deeper directories take
+// precedence, followed by those of the exclude files.
+func (m *IgnoreMatcher) match(p string, isDir bool) *IgnoreRule {
+	dir := p
+	for dir != "" {
+		if dir = path.Dir(dir); dir == "." {
+			dir = ""
+		}
+		if rule := lastMatch(m.load(dir), p, isDir); rule != nil {
+			return rule
+		}
+	}
+	return lastMatch(m.excludes, p, isDir)
+}
+
+// ignored reports whether p is ignored by its own rules, the walker of the work tree checks leading directories
+func (m *IgnoreMatcher) ignored(p string, isDir bool) bool {
+	rule := m.match(p, isDir)
+	return rule != nil && !rule.negative
+}
+
+func lastMatch(rules []*IgnoreRule, p string, isDir bool) *IgnoreRule {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].match(p, isDir) {
+			return rules[i]
+		}
+	}
+	return nil
+}
+
+func (m *IgnoreMatcher) load(dir string) []*IgnoreRule {
+	if rules, ok := m.rules[dir]; ok {
+		return rules
+	}
+	var rules []*IgnoreRule
+	source := path.Join(dir, ignoreFileName)
+	if b, err := os.ReadFile(filepath.Join(m.root, filepath.FromSlash(source))); err == nil {
+		rules = parseIgnoreRules(string(b), source, dir, m.foldCase)
+	}
+	m.rules[dir] = rules
+	return rules
+}
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, "gitconfig"))
+	t.Setenv("XDG_CONFIG_HOME", home)
+
+	ws := newTestWorkspace(t)
+	write := ws.write
+	write(".gitignore", "*.o\nbuild/\n!keep.o\n/anchored\na/**/z\n\\#hash\ntrail\\ \nspaces  \n")
+	write("d/.gitignore", "!*.o\nlocal\n")
+	write(".git/info/exclude", "# comment\nsecret*\n")
+	assert.Nil(t, os.MkdirAll(filepath.Join(home, "git"), 0755))
+	assert.Nil(t, os.WriteFile(filepath.Join(home, "git", "ignore"), []byte("*.swp\n"), 0644))
+
+	m, err := ws.IgnoreMatcher()
+	assert.Nil(t, err)
+
+	ignored := []string{"x.o", "e/x.o", "build", "build/out", "anchored", "a/z", "a/b/c/z", "#hash", "trail ", "spaces",
+		"d/local", "secret1", "d/secret2", "x.swp"}
+	for _, p := range ignored {
+		assert.True(t, m.Ignored(p, p == "build"), p)
+	}
+	notIgnored := []string{"keep.o", "x", "d/anchored", "b/z", "hash", "trail", "d/x.o", "file/build"}
+	for _, p := range notIgnored {
+		assert.False(t, m.Ignored(p, false), p)
+	}
+
+	rule := m.Match("d/e/x.o", false)
+	assert.Equal(t, "d/.gitignore", rule.Source)
+	assert.Equal(t, 1, rule.Line)
+	assert.True(t, rule.Negative())
+
+	// files in excluded directories can't be re-included
+	rule = m.Match("build/keep.o", false)
+	assert.Equal(t, ".gitignore", rule.Source)
+	assert.Equal(t, "build/", rule.Pattern)
+
+	rule = m.Match("secret", false)
+	assert.Equal(t, ".git/info/exclude", rule.Source)
+	assert.Equal(t, 2, rule.Line)
+
+	rule = m.Match("x.swp", false)
+	assert.Equal(t, filepath.Join(home, "git", "ignore"), rule.Source)
+
+	assert.Nil(t, m.Match("x", false))
+}
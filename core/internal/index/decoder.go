@@ -194,7 +194,6 @@ func decodeExtensions(r *bufio.Reader, idx *Index) error {
 			// If the first byte is 'A'..'Z' the extension is optional and can be ignored.
 			// if extensionSig[0] >= 0x41 && extensionSig[0] <= 0x5A {
 			// unknown extension, just save temporally
-			fmt.Println("Unknow extention:", string(sign))
 			sign, _ = ReadSlice(r, 4)
 			size, _ := ReadUint32(r)
 			data, _ := ReadSlice(r, int(size))
@@ -83,11 +83,12 @@ type Index struct {
 	unknownExtensions []*Extension
 }
 
+// Load reads the index file in path, entries loaded before are replaced
 func (idx *Index) Load(path string) {
+	idx.Reset()
 	f, err := os.Open(path)
 	if err != nil {
 		idx.version = idx_version_2
-		idx.IndexEntries.reset()
 		return
 	}
 	defer f.Close()
@@ -184,12 +185,40 @@ func (idx *Index) Remove(path string, recursive bool) {
 	// idx.numberOfIndexEntries = uint32(idx.size())
 }
 
+// Put adds e to the index, replacing entries of the same path in all stages
+func (idx *Index) Put(e *IndexEntry) {
+	idx.Delete(e.filepath)
+	idx.Append(e)
+}
+
+// Delete removes entries of path in all stages, and reports whether any is found
+func (idx *Index) Delete(path string) bool {
+	entries := idx.entries[:0]
+	for _, e := range idx.entries {
+		if e.filepath != path {
+			entries = append(entries, e)
+		}
+	}
+	if len(entries) == len(idx.entries) {
+		return false
+	}
+	for i := len(entries); i < len(idx.entries); i++ {
+		idx.entries[i] = nil
+	}
+	idx.entries = entries
+	idx.CacheTree.invalidatePath(filepath.Dir(path))
+	return true
+}
+
 // using files in the index entries to build trees
 func (idx *Index) UpdateCacheTree() {
 	// cacheTree is already valid, do nothing
 	root := idx.CacheTree.Root()
 
 	if root == nil || root.Id() == common.ZeroHash {
+		// entries in trees are stale after index entries are updated or removed, so trees are built again
+		idx.CacheTree.reset()
+
 		// path --> *Tree map, cache Tree has been created
 		treeMap := make(map[string]*object.Tree)
 		idx.Foreach(func(e *IndexEntry) {
@@ -47,7 +47,7 @@ func NewIndexEntryWithFileInfo(oid common.Hash, mode common.FileMode, fpath stri
 	}
 
 	stat := fi.Sys().(*syscall.Stat_t)
-	e.mode = common.FileMode(stat.Mode)
+	e.mode = indexFileMode(common.FileMode(stat.Mode))
 	e.cTime = time.Unix(int64(stat.Ctimespec.Sec), int64(stat.Ctimespec.Nsec))
 	e.mTime = fi.ModTime()
 	e.dev = uint32(stat.Dev)
@@ -61,7 +61,7 @@ func (e *IndexEntry) Update(oid common.Hash, fi os.FileInfo) {
 	e.oid = oid
 	if fi != nil {
 		stat := fi.Sys().(*syscall.Stat_t)
-		e.mode = common.FileMode(stat.Mode)
+		e.mode = indexFileMode(common.FileMode(stat.Mode))
 		e.cTime = time.Unix(int64(stat.Ctimespec.Sec), int64(stat.Ctimespec.Nsec))
 		e.mTime = fi.ModTime()
 		e.dev = uint32(stat.Dev)
@@ -81,6 +81,34 @@ func (e *IndexEntry) Update(oid common.Hash, fi os.FileInfo) {
 	}
 }
 
+// indexFileMode converts the mode of stat(2) into one recorded in the index, whose permission is either 644 or 755
+func indexFileMode(m common.FileMode) common.FileMode {
+	switch m & 0170000 {
+	case common.Symlink:
+		return common.Symlink
+	case common.Dir:
+		return common.Dir
+	}
+	if m&0111 != 0 {
+		return common.Executable
+	}
+	return common.Regular
+}
+
+func (e *IndexEntry) Oid() common.Hash {
+	return e.oid
+}
+
+// path relative to the root of working area
+func (e *IndexEntry) Path() string {
+	return e.filepath
+}
+
+// Stage returns the merge stage of the entry, 0 for normal entries
+func (e *IndexEntry) Stage() Stage {
+	return e.stage
+}
+
 type IndexEntries struct {
 	entries []*IndexEntry
 }
@@ -0,0 +1,78 @@
+package pack
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/izhujiang/gogit/core/object"
+)
+
+const (
+	// same as the default of core.deltaBaseCacheLimit
+	default_delta_base_cache_limit = 96 << 20
+)
+
+type cachedObject struct {
+	offset  int64
+	kind    object.ObjectKind
+	content []byte
+}
+
+// baseCache keeps recently reconstructed objects indexed by their offset in the packfile, so objects deltified against
+// the same base, or along the same chain, don't rebuild the base again and again. Least recently used objects are
+// evicted when the total size exceeds the limit.
+type baseCache struct {
+	lock  sync.Mutex
+	limit int
+	size  int
+	lru   *list.List
+	items map[int64]*list.Element
+}
+
+func newBaseCache(limit int) *baseCache {
+	return &baseCache{
+		limit: limit,
+		lru:   list.New(),
+		items: make(map[int64]*list.Element),
+	}
+}
+
+// content of cached objects are shared and must not be modified
+func (c *baseCache) get(offset int64) (object.ObjectKind, []byte, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	e, ok := c.items[offset]
+	if !ok {
+		return object.Kind_Unknow, nil, false
+	}
+	c.lru.MoveToFront(e)
+	o := e.Value.(*cachedObject)
+	return o.kind, o.content, true
+}
+
+func (c *baseCache) put(offset int64, kind object.ObjectKind, content []byte) {
+	if len(content) > c.limit {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if e, ok := c.items[offset]; ok {
+		c.lru.MoveToFront(e)
+		return
+	}
+
+	e := c.lru.PushFront(&cachedObject{offset, kind, content})
+	c.items[offset] = e
+	c.size += len(content)
+
+	for c.size > c.limit {
+		last := c.lru.Back()
+		o := last.Value.(*cachedObject)
+		c.lru.Remove(last)
+		delete(c.items, o.offset)
+		c.size -= len(o.content)
+	}
+}
@@ -0,0 +1,105 @@
+package pack
+
+import (
+	"errors"
+)
+
+// Delta data
+// varint		size of the base object
+// varint		size of the object to be reconstructed
+// instructions:
+//	copy		1xxxxxxx, followed by up to 4 offset bytes and 3 size bytes selected by the low 7 bits, size 0 means 0x10000
+//	insert		0xxxxxxx, the low 7 bits is the number of bytes to be inserted from the delta data
+//	reserved	00000000
+
+const (
+	deltaCopyMaxSize = 0x10000
+)
+
+var (
+	ErrInvalidDelta = errors.New("Invalid delta data.")
+)
+
+// PatchDelta reconstructs the object from the base object and the delta data
+func PatchDelta(base, delta []byte) ([]byte, error) {
+	srcSize, delta, ok := deltaHeaderSize(delta)
+	if !ok || srcSize != len(base) {
+		return nil, ErrInvalidDelta
+	}
+	dstSize, delta, ok := deltaHeaderSize(delta)
+	if !ok {
+		return nil, ErrInvalidDelta
+	}
+
+	dst := make([]byte, 0, dstSize)
+	for len(delta) > 0 {
+		cmd := delta[0]
+		delta = delta[1:]
+
+		switch {
+		case cmd&0x80 != 0:
+			var offset, size int
+			for i := uint(0); i < 4; i++ {
+				if cmd&(1<<i) != 0 {
+					if len(delta) == 0 {
+						return nil, ErrInvalidDelta
+					}
+					offset |= int(delta[0]) << (8 * i)
+					delta = delta[1:]
+				}
+			}
+			for i := uint(0); i < 3; i++ {
+				if cmd&(1<<(4+i)) != 0 {
+					if len(delta) == 0 {
+						return nil, ErrInvalidDelta
+					}
+					size |= int(delta[0]) << (8 * i)
+					delta = delta[1:]
+				}
+			}
+			if size == 0 {
+				size = deltaCopyMaxSize
+			}
+			if offset+size > len(base) || len(dst)+size > dstSize {
+				return nil, ErrInvalidDelta
+			}
+			dst = append(dst, base[offset:offset+size]...)
+
+		case cmd != 0:
+			size := int(cmd)
+			if size > len(delta) || len(dst)+size > dstSize {
+				return nil, ErrInvalidDelta
+			}
+			dst = append(dst, delta[:size]...)
+			delta = delta[size:]
+
+		default:
+			// reserved for future expansion
+			return nil, ErrInvalidDelta
+		}
+	}
+
+	if len(dst) != dstSize {
+		return nil, ErrInvalidDelta
+	}
+
+	return dst, nil
+}
+
+// little-endian varint, 7 bits per byte
+func deltaHeaderSize(delta []byte) (int, []byte, bool) {
+	size := 0
+	shift := uint(0)
+	for i, c := range delta {
+		size |= int(c&0x7f) << shift
+		shift += 7
+		if c&0x80 == 0 {
+			return size, delta[i+1:], true
+		}
+		if shift > 56 {
+			break
+		}
+	}
+
+	return 0, nil, false
+}
@@ -0,0 +1,147 @@
+package pack
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+const (
+	// base objects are indexed by blocks with this size
+	deltaBlockSize = 16
+	// candidates of a block to be compared with
+	deltaMaxCandidates = 8
+	deltaMaxInsertSize = 0x7f
+)
+
+// DiffDelta computes delta data which turns base into target, see PatchDelta for the format.
+// It returns nil if the delta would be larger than maxSize, maxSize <= 0 means no limitation.
+func DiffDelta(base, target []byte, maxSize int) []byte {
+	blocks := indexBlocks(base)
+
+	delta := &bytes.Buffer{}
+	writeDeltaHeaderSize(delta, len(base))
+	writeDeltaHeaderSize(delta, len(target))
+
+	insertFrom := 0
+	i := 0
+	for i+deltaBlockSize <= len(target) {
+		offset, size := longestMatch(base, target, i, blocks)
+		if size < deltaBlockSize {
+			i++
+			continue
+		}
+
+		// extend the match backward over the bytes pending to be inserted
+		for offset > 0 && i > insertFrom && base[offset-1] == target[i-1] {
+			offset--
+			i--
+			size++
+		}
+
+		writeInsert(delta, target[insertFrom:i])
+		writeCopy(delta, offset, size)
+		i += size
+		insertFrom = i
+
+		if maxSize > 0 && delta.Len() > maxSize {
+			return nil
+		}
+	}
+	writeInsert(delta, target[insertFrom:])
+
+	if maxSize > 0 && delta.Len() > maxSize {
+		return nil
+	}
+	return delta.Bytes()
+}
+
+func blockKey(b []byte) uint64 {
+	// 16 bytes folded into 8, collisions are resolved by comparing the data
+	return binary.LittleEndian.Uint64(b[:8]) ^ (binary.LittleEndian.Uint64(b[8:16]) * 0x9e3779b97f4a7c15)
+}
+
+// offsets of the aligned blocks in base, at most deltaMaxCandidates for each key
+func indexBlocks(base []byte) map[uint64][]int {
+	blocks := make(map[uint64][]int, len(base)/deltaBlockSize+1)
+	for off := 0; off+deltaBlockSize <= len(base); off += deltaBlockSize {
+		k := blockKey(base[off:])
+		offsets := blocks[k]
+		if len(offsets) < deltaMaxCandidates {
+			blocks[k] = append(offsets, off)
+		}
+	}
+
+	return blocks
+}
+
+func longestMatch(base, target []byte, pos int, blocks map[uint64][]int) (int, int) {
+	candidates, ok := blocks[blockKey(target[pos:])]
+	if !ok {
+		return 0, 0
+	}
+
+	bestOffset, bestSize := 0, 0
+	for _, off := range candidates {
+		n := 0
+		for off+n < len(base) && pos+n < len(target) && base[off+n] == target[pos+n] {
+			n++
+		}
+		if n > bestSize {
+			bestOffset, bestSize = off, n
+		}
+	}
+
+	return bestOffset, bestSize
+}
+
+func writeDeltaHeaderSize(buf *bytes.Buffer, size int) {
+	for size >= 0x80 {
+		buf.WriteByte(byte(size&0x7f) | 0x80)
+		size >>= 7
+	}
+	buf.WriteByte(byte(size))
+}
+
+func writeInsert(buf *bytes.Buffer, data []byte) {
+	for len(data) > 0 {
+		n := len(data)
+		if n > deltaMaxInsertSize {
+			n = deltaMaxInsertSize
+		}
+		buf.WriteByte(byte(n))
+		buf.Write(data[:n])
+		data = data[n:]
+	}
+}
+
+func writeCopy(buf *bytes.Buffer, offset, size int) {
+	for size > 0 {
+		n := size
+		if n > deltaCopyMaxSize {
+			n = deltaCopyMaxSize
+		}
+
+		cmd := byte(0x80)
+		args := make([]byte, 0, 7)
+		for i := uint(0); i < 4; i++ {
+			if b := byte(offset >> (8 * i)); b != 0 {
+				cmd |= 1 << i
+				args = append(args, b)
+			}
+		}
+		// size 0x10000 is encoded as no size bytes
+		if n != deltaCopyMaxSize {
+			for i := uint(0); i < 3; i++ {
+				if b := byte(n >> (8 * i)); b != 0 {
+					cmd |= 1 << (4 + i)
+					args = append(args, b)
+				}
+			}
+		}
+		buf.WriteByte(cmd)
+		buf.Write(args)
+
+		offset += n
+		size -= n
+	}
+}
@@ -0,0 +1,221 @@
+package pack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/izhujiang/gogit/common"
+)
+
+// Pack index file (.idx) version 2
+// 4 bytes		magic number \377tOc
+// 4 bytes		version number (2)
+// 256 * 4 bytes	fan-out table, entry N is the number of objects whose first byte of object name is less than or equal to N
+// N * 20 bytes		sorted object names
+// N * 4 bytes		CRC32 of the packed object data
+// N * 4 bytes		4-byte offset values, if the MSB is set, the rest 31 bits is an index into the 8-byte offset table
+// M * 8 bytes		8-byte offset entries (empty for pack files less than 2 GiB)
+// 20 bytes		checksum of the corresponding packfile
+// 20 bytes		checksum of all of the above
+
+// Ref: https://git-scm.com/docs/pack-format
+
+const (
+	idx_version_2 = uint32(2)
+
+	idxHeaderSize  = 8
+	idxFanoutSize  = 256 * 4
+	idxOffsetLarge = uint32(1 << 31)
+)
+
+var (
+	idxMagic = []byte{0xff, 't', 'O', 'c'}
+)
+
+var (
+	ErrInvalidPackIndex        = errors.New("This is not a valid pack index file.")
+	ErrInvalidPackIndexVersion = errors.New("The version of this pack index file is not supported.")
+	ErrCorruptedPackIndex      = errors.New("Corrupted pack index file")
+)
+
+// Index is a version 2 pack index loaded into memory. Lookups are done with binary search directly on the raw data.
+type Index struct {
+	fanout [256]uint32
+	count  int
+
+	names   []byte
+	crcs    []byte
+	offsets []byte
+	large   []byte
+
+	PackChecksum common.Hash
+}
+
+func LoadIndex(path string) (*Index, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeIndex(data)
+}
+
+func DecodeIndex(data []byte) (*Index, error) {
+	if len(data) < idxHeaderSize+idxFanoutSize+40 {
+		return nil, ErrInvalidPackIndex
+	}
+	if !bytes.Equal(data[:4], idxMagic) {
+		return nil, ErrInvalidPackIndex
+	}
+	if binary.BigEndian.Uint32(data[4:8]) != idx_version_2 {
+		return nil, ErrInvalidPackIndexVersion
+	}
+
+	idx := &Index{}
+	fanout := data[idxHeaderSize : idxHeaderSize+idxFanoutSize]
+	for i := range idx.fanout {
+		idx.fanout[i] = binary.BigEndian.Uint32(fanout[i*4:])
+		if i > 0 && idx.fanout[i] < idx.fanout[i-1] {
+			return nil, ErrCorruptedPackIndex
+		}
+	}
+	idx.count = int(idx.fanout[255])
+
+	pos := idxHeaderSize + idxFanoutSize
+	// names, crc32s and offsets, followed by the two checksums at least
+	if len(data) < pos+idx.count*28+40 {
+		return nil, ErrCorruptedPackIndex
+	}
+	idx.names = data[pos : pos+idx.count*20]
+	pos += idx.count * 20
+	idx.crcs = data[pos : pos+idx.count*4]
+	pos += idx.count * 4
+	idx.offsets = data[pos : pos+idx.count*4]
+	pos += idx.count * 4
+
+	large := len(data) - 40 - pos
+	if large < 0 || large%8 != 0 {
+		return nil, ErrCorruptedPackIndex
+	}
+	idx.large = data[pos : pos+large]
+	pos += large
+
+	copy(idx.PackChecksum[:], data[pos:pos+20])
+
+	return idx, nil
+}
+
+// Count returns the number of objects in the pack.
+func (idx *Index) Count() int {
+	return idx.count
+}
+
+// Find returns the offset in the packfile of the object identified by oid.
+func (idx *Index) Find(oid common.Hash) (int64, bool) {
+	i, ok := idx.position(oid)
+	if !ok {
+		return 0, false
+	}
+
+	return idx.offsetAt(i), true
+}
+
+// Contains reports whether the object is stored in the pack
+func (idx *Index) Contains(oid common.Hash) bool {
+	_, ok := idx.position(oid)
+	return ok
+}
+
+// CRC32 returns the checksum of the packed (compressed) data of the object
+func (idx *Index) CRC32(oid common.Hash) (uint32, bool) {
+	i, ok := idx.position(oid)
+	if !ok {
+		return 0, false
+	}
+
+	return binary.BigEndian.Uint32(idx.crcs[i*4:]), true
+}
+
+// binary search in the range given by the fan-out table
+func (idx *Index) position(oid common.Hash) (int, bool) {
+	lo := 0
+	if oid[0] > 0 {
+		lo = int(idx.fanout[oid[0]-1])
+	}
+	hi := int(idx.fanout[oid[0]])
+
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		switch c := bytes.Compare(oid[:], idx.nameAt(mid)); {
+		case c == 0:
+			return mid, true
+		case c < 0:
+			hi = mid
+		default:
+			lo = mid + 1
+		}
+	}
+
+	return 0, false
+}
+
+func (idx *Index) nameAt(i int) []byte {
+	return idx.names[i*20 : i*20+20]
+}
+
+func (idx *Index) offsetAt(i int) int64 {
+	off := binary.BigEndian.Uint32(idx.offsets[i*4:])
+	if off&idxOffsetLarge == 0 {
+		return int64(off)
+	}
+
+	// the rest 31 bits is an index into the 8-byte offset table
+	li := int(off &^ idxOffsetLarge)
+	if (li+1)*8 > len(idx.large) {
+		return -1
+	}
+	return int64(binary.BigEndian.Uint64(idx.large[li*8:]))
+}
+
+// FindPrefix returns names of objects in the pack which begin with the hexadecimal prefix, in order
+func (idx *Index) FindPrefix(prefix string) []common.Hash {
+	lo, hi := 0, idx.count
+	if len(prefix) >= 2 {
+		if b, err := hex.DecodeString(prefix[:2]); err == nil {
+			if b[0] > 0 {
+				lo = int(idx.fanout[b[0]-1])
+			}
+			hi = int(idx.fanout[b[0]])
+		}
+	}
+
+	found := make([]common.Hash, 0)
+	for i := lo; i < hi; i++ {
+		name := idx.nameAt(i)
+		if strings.HasPrefix(hex.EncodeToString(name), prefix) {
+			var oid common.Hash
+			copy(oid[:], name)
+			found = append(found, oid)
+		}
+	}
+	return found
+}
+
+type WalkIndexFunc func(oid common.Hash, offset int64) error
+
+// ForEach visits all objects in the pack ordered by object name
+func (idx *Index) ForEach(fn WalkIndexFunc) error {
+	for i := 0; i < idx.count; i++ {
+		var oid common.Hash
+		copy(oid[:], idx.nameAt(i))
+		if err := fn(oid, idx.offsetAt(i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
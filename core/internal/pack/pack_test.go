@@ -0,0 +1,188 @@
+package pack
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core/object"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	// packed by git repack -a -d --window=0 --depth=0, no deltified objects
+	plainPack = "testdata/pack-36ea62a56819b7292d74569eb1418ee4da843a03.pack"
+)
+
+func newHash(s string) common.Hash {
+	h, _ := common.NewHash(s)
+	return h
+}
+
+func TestIndex(t *testing.T) {
+	p, err := Open(plainPack)
+	assert.Nil(t, err)
+	defer p.Close()
+
+	idx := p.Index()
+	assert.Equal(t, 19, idx.Count())
+	assert.Equal(t, "36ea62a56819b7292d74569eb1418ee4da843a03", idx.PackChecksum.String())
+
+	offset, ok := idx.Find(newHash("4bb07e5c0131792d4f30894b539c69576cf3f1e2"))
+	assert.True(t, ok)
+	assert.Equal(t, int64(12), offset)
+
+	offset, ok = idx.Find(newHash("8c1384d825dbbe41309b7dc18ee7991a9085c46e"))
+	assert.True(t, ok)
+	assert.Equal(t, int64(3086), offset)
+
+	assert.False(t, idx.Contains(newHash("4bb07e5c0131792d4f30894b539c69576cf3f1e3")))
+	assert.False(t, idx.Contains(common.ZeroHash))
+
+	n := 0
+	var last common.Hash
+	idx.ForEach(func(oid common.Hash, offset int64) error {
+		assert.True(t, last.String() < oid.String())
+		last = oid
+		n++
+		return nil
+	})
+	assert.Equal(t, 19, n)
+}
+
+func TestGet(t *testing.T) {
+	p, err := Open(plainPack)
+	assert.Nil(t, err)
+	defer p.Close()
+
+	tests := []struct {
+		oid  string
+		kind object.ObjectKind
+		size int64
+	}{
+		{"4bb07e5c0131792d4f30894b539c69576cf3f1e2", object.Kind_Commit, 171},
+		{"62870ccde48ab24365c38f531aa2723e081472b5", object.Kind_Tag, 107},
+		{"ada3efd797562c705488f00159fb992ba2db529c", object.Kind_Tree, 101},
+		{"5bd1145c37fbb91d887edc24f1ea59f79c0a9e8a", object.Kind_Blob, 2292},
+		{"65cd8a60ca396cd54d9a9dcb186106424f9baa4a", object.Kind_Blob, 3},
+	}
+
+	for _, tt := range tests {
+		g, err := p.Get(newHash(tt.oid))
+		assert.Nil(t, err, tt.oid)
+		assert.Equal(t, tt.oid, g.Id().String())
+		assert.Equal(t, tt.kind, g.Kind())
+		assert.Equal(t, tt.size, g.Size())
+	}
+
+	_, err = p.Get(newHash("65cd8a60ca396cd54d9a9dcb186106424f9baa4b"))
+	assert.Equal(t, ErrObjectNotInPack, err)
+}
+
+const (
+	// packed by git repack -a -d -f --window=50 --depth=50, with OFS_DELTA
+	ofsDeltaPack = "testdata/pack-a48dda2fa4339d23ef5f427ec435dce5ea794898.pack"
+	// packed by git pack-objects --window=50 --depth=50, with REF_DELTA
+	refDeltaPack = "testdata/pack-3818b490fda186a0867efdcd00dfe87a81ec20c9.pack"
+)
+
+func TestGetDeltified(t *testing.T) {
+	// blobs at the end of delta chains with length 1, 2 and 3
+	tests := []struct {
+		oid  string
+		size int64
+	}{
+		{"050b4b45ade14d62fbbd7c2c5f463cdab1ce49e4", 13992},
+		{"1a6ebf4df5d88090f163490b6cecafcadbb80ef4", 13968},
+		{"010d11e0e8247d491ae6a539e2719132eae05dd9", 13932},
+		{"b6ac48dffffeb966b2588cca30ed5ebcd29bfd07", 13906},
+	}
+
+	for _, path := range []string{ofsDeltaPack, refDeltaPack} {
+		p, err := Open(path)
+		assert.Nil(t, err)
+
+		for _, tt := range tests {
+			g, err := p.Get(newHash(tt.oid))
+			assert.Nil(t, err, tt.oid)
+			assert.Equal(t, tt.oid, g.Id().String())
+			assert.Equal(t, object.Kind_Blob, g.Kind())
+			assert.Equal(t, tt.size, g.Size())
+		}
+
+		// every object in the pack can be resolved
+		p.Index().ForEach(func(oid common.Hash, offset int64) error {
+			g, err := p.Get(oid)
+			assert.Nil(t, err, oid.String())
+			assert.Equal(t, oid, g.Id())
+			return nil
+		})
+		p.Close()
+	}
+}
+
+func TestPatchDelta(t *testing.T) {
+	base := []byte("hello, world")
+	delta := []byte{
+		12, 15, // base size and result size
+		0x91, 0x07, 0x05, // copy "world"
+		0x02, ',', ' ', // insert ", "
+		0x90, 0x05, // copy "hello" from offset 0
+		0x03, '!', '!', '!', // insert "!!!"
+	}
+
+	got, err := PatchDelta(base, delta)
+	assert.Nil(t, err)
+	assert.Equal(t, "world, hello!!!", string(got))
+
+	_, err = PatchDelta([]byte("short"), delta)
+	assert.Equal(t, ErrInvalidDelta, err)
+
+	_, err = PatchDelta(base, []byte{12, 5, 0x00})
+	assert.Equal(t, ErrInvalidDelta, err)
+}
+
+func TestDiffDelta(t *testing.T) {
+	base := bytes.Repeat([]byte("0123456789abcdef-the quick brown fox\n"), 100)
+	target := append([]byte("header\n"), base[:1200]...)
+	target = append(target, []byte("jumps over the lazy dog\n")...)
+	target = append(target, base[2000:]...)
+
+	delta := DiffDelta(base, target, 0)
+	assert.NotNil(t, delta)
+	assert.Less(t, len(delta), len(target)/10)
+
+	got, err := PatchDelta(base, delta)
+	assert.Nil(t, err)
+	assert.Equal(t, target, got)
+
+	// too large
+	assert.Nil(t, DiffDelta(base, target, 8))
+}
+
+func TestWritePack(t *testing.T) {
+	base := bytes.Repeat([]byte("line of text in a file\n"), 200)
+	modified := append(append([]byte{}, base...), []byte("one more line\n")...)
+
+	objects := make([]*ObjectToPack, 0)
+	for _, c := range [][]byte{base, modified, []byte("small")} {
+		g := object.NewGitObject(object.Kind_Blob, c)
+		objects = append(objects, &ObjectToPack{Oid: g.Id(), Kind: g.Kind(), Content: c, Path: "file.txt"})
+	}
+
+	dir := t.TempDir()
+	path, deltas, err := WritePack(dir, objects, &WriteOption{Window: Default_Window, Depth: Default_Depth})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, deltas)
+
+	p, err := Open(path)
+	assert.Nil(t, err)
+	defer p.Close()
+
+	assert.Equal(t, 3, p.Index().Count())
+	for _, o := range objects {
+		g, err := p.Get(o.Oid)
+		assert.Nil(t, err)
+		assert.Equal(t, string(o.Content), g.Content())
+	}
+}
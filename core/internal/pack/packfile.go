@@ -0,0 +1,390 @@
+package pack
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core/object"
+)
+
+// Packfile (.pack)
+// 4 bytes		signature PACK
+// 4 bytes		version number (2 or 3)
+// 4 bytes		number of objects
+// N entries		object entries, each with a variable length header (type and size) followed by zlib compressed data
+// 20 bytes		checksum of all of the above
+
+const (
+	sign_Pack      = "PACK"
+	pack_version_2 = uint32(2)
+	pack_version_3 = uint32(3)
+
+	packHeaderSize = 12
+
+	// chains deeper than this are treated as corrupted, which may be a loop of REF_DELTA
+	max_delta_chain_depth = 10000
+)
+
+// type of object entries in packfile
+type entryType byte
+
+const (
+	typeCommit   entryType = 1
+	typeTree     entryType = 2
+	typeBlob     entryType = 3
+	typeTag      entryType = 4
+	typeOfsDelta entryType = 6
+	typeRefDelta entryType = 7
+)
+
+var (
+	ErrInvalidPackfile        = errors.New("This is not a valid packfile.")
+	ErrInvalidPackfileVersion = errors.New("The version of this packfile is not supported.")
+	ErrObjectNotInPack        = errors.New("Object is not in the packfile.")
+	ErrCorruptedPackEntry     = errors.New("Corrupted packfile entry")
+	ErrUnresolvedDelta        = errors.New("Deltified object can not be resolved.")
+)
+
+func (t entryType) kind() object.ObjectKind {
+	switch t {
+	case typeCommit:
+		return object.Kind_Commit
+	case typeTree:
+		return object.Kind_Tree
+	case typeBlob:
+		return object.Kind_Blob
+	case typeTag:
+		return object.Kind_Tag
+	default:
+		return object.Kind_Unknow
+	}
+}
+
+func (t entryType) isDelta() bool {
+	return t == typeOfsDelta || t == typeRefDelta
+}
+
+// entry header of an object in packfile
+type entry struct {
+	typ  entryType
+	size int64
+	// offset of the entry header
+	offset int64
+	// offset of the compressed data
+	dataOffset int64
+
+	// base of OFS_DELTA
+	baseOffset int64
+	// base of REF_DELTA
+	baseRef common.Hash
+}
+
+// Packfile gives random access to the objects in a .pack file through its .idx file.
+type Packfile struct {
+	path string
+	idx  *Index
+
+	lock sync.Mutex
+	f    *os.File
+
+	cache *baseCache
+}
+
+// Open a packfile, the index file with the same base name must exist.
+func Open(path string) (*Packfile, error) {
+	idxPath := strings.TrimSuffix(path, ".pack") + ".idx"
+	idx, err := LoadIndex(idxPath)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Packfile{
+		path:  path,
+		idx:   idx,
+		cache: newBaseCache(default_delta_base_cache_limit),
+	}
+
+	return p, nil
+}
+
+func (p *Packfile) Path() string {
+	return p.path
+}
+
+func (p *Packfile) Index() *Index {
+	return p.idx
+}
+
+func (p *Packfile) Has(oid common.Hash) bool {
+	return p.idx.Contains(oid)
+}
+
+func (p *Packfile) Close() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.f == nil {
+		return nil
+	}
+	err := p.f.Close()
+	p.f = nil
+	return err
+}
+
+// Get GitObject from packfile, return ErrObjectNotInPack if the object is not in this pack
+func (p *Packfile) Get(oid common.Hash) (*object.GitObject, error) {
+	offset, ok := p.idx.Find(oid)
+	if !ok {
+		return nil, ErrObjectNotInPack
+	}
+
+	kind, content, err := p.readObjectAt(offset)
+	if err != nil {
+		return nil, err
+	}
+
+	g := object.NewGitObject(kind, content)
+	if g.Id() != oid {
+		return nil, object.ErrGitObjectDataCorruptted
+	}
+
+	return g, nil
+}
+
+// readObjectAt reconstructs the object at offset, following the delta chain (OFS_DELTA and REF_DELTA) to its base
+func (p *Packfile) readObjectAt(offset int64) (object.ObjectKind, []byte, error) {
+	if kind, content, ok := p.cache.get(offset); ok {
+		return kind, content, nil
+	}
+
+	// walk down the chain until a cached object or an undeltified base is found
+	chain := make([]*entry, 0, 8)
+	var kind object.ObjectKind
+	var content []byte
+	for {
+		e, err := p.readEntry(offset)
+		if err != nil {
+			return object.Kind_Unknow, nil, err
+		}
+
+		if !e.typ.isDelta() {
+			content, err = p.inflate(e)
+			if err != nil {
+				return object.Kind_Unknow, nil, err
+			}
+			kind = e.typ.kind()
+			p.cache.put(e.offset, kind, content)
+			break
+		}
+
+		chain = append(chain, e)
+		if len(chain) > max_delta_chain_depth {
+			return object.Kind_Unknow, nil, ErrUnresolvedDelta
+		}
+
+		if e.typ == typeOfsDelta {
+			offset = e.baseOffset
+		} else {
+			var ok bool
+			offset, ok = p.idx.Find(e.baseRef)
+			if !ok {
+				// base of REF_DELTA is out of the pack (thin pack)
+				return object.Kind_Unknow, nil, ErrUnresolvedDelta
+			}
+		}
+
+		var ok bool
+		if kind, content, ok = p.cache.get(offset); ok {
+			break
+		}
+	}
+
+	// apply deltas from the base up
+	for i := len(chain) - 1; i >= 0; i-- {
+		e := chain[i]
+		delta, err := p.inflate(e)
+		if err != nil {
+			return object.Kind_Unknow, nil, err
+		}
+
+		content, err = PatchDelta(content, delta)
+		if err != nil {
+			return object.Kind_Unknow, nil, err
+		}
+		p.cache.put(e.offset, kind, content)
+	}
+
+	return kind, content, nil
+}
+
+func (p *Packfile) file() (*os.File, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.f != nil {
+		return p.f, nil
+	}
+
+	f, err := os.Open(p.path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkPackHeader(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	p.f = f
+	return f, nil
+}
+
+func checkPackHeader(r io.ReaderAt) error {
+	header := make([]byte, packHeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return ErrInvalidPackfile
+	}
+	if !bytes.Equal(header[:4], []byte(sign_Pack)) {
+		return ErrInvalidPackfile
+	}
+	version := binary.BigEndian.Uint32(header[4:8])
+	if version != pack_version_2 && version != pack_version_3 {
+		return ErrInvalidPackfileVersion
+	}
+
+	return nil
+}
+
+// readEntry parses the entry header at offset
+func (p *Packfile) readEntry(offset int64) (*entry, error) {
+	f, err := p.file()
+	if err != nil {
+		return nil, err
+	}
+	if offset < packHeaderSize {
+		return nil, ErrCorruptedPackEntry
+	}
+
+	r := &countingReader{r: bufio.NewReader(io.NewSectionReader(f, offset, 1<<62))}
+
+	typ, size, err := readEntryHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	e := &entry{
+		typ:    typ,
+		size:   size,
+		offset: offset,
+	}
+
+	switch typ {
+	case typeCommit, typeTree, typeBlob, typeTag:
+	case typeOfsDelta:
+		rel, err := readOffset(r)
+		if err != nil {
+			return nil, err
+		}
+		e.baseOffset = offset - rel
+		if e.baseOffset < packHeaderSize || e.baseOffset >= offset {
+			return nil, ErrCorruptedPackEntry
+		}
+	case typeRefDelta:
+		if _, err := io.ReadFull(r, e.baseRef[:]); err != nil {
+			return nil, ErrCorruptedPackEntry
+		}
+	default:
+		return nil, ErrCorruptedPackEntry
+	}
+
+	e.dataOffset = offset + r.n
+	return e, nil
+}
+
+// inflate the compressed data of the entry
+func (p *Packfile) inflate(e *entry) ([]byte, error) {
+	f, err := p.file()
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := zlib.NewReader(bufio.NewReader(io.NewSectionReader(f, e.dataOffset, 1<<62)))
+	if err != nil {
+		return nil, ErrCorruptedPackEntry
+	}
+	defer zr.Close()
+
+	data := make([]byte, e.size)
+	if _, err := io.ReadFull(zr, data); err != nil {
+		return nil, ErrCorruptedPackEntry
+	}
+
+	return data, nil
+}
+
+// first byte: 1-bit MSB, 3-bit type and 4-bit size, following bytes: 1-bit MSB and 7-bit size, little-endian
+func readEntryHeader(r io.ByteReader) (entryType, int64, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, ErrCorruptedPackEntry
+	}
+
+	typ := entryType((c >> 4) & 0x07)
+	size := int64(c & 0x0f)
+	shift := uint(4)
+	for c&0x80 != 0 {
+		c, err = r.ReadByte()
+		if err != nil || shift > 56 {
+			return 0, 0, ErrCorruptedPackEntry
+		}
+		size |= int64(c&0x7f) << shift
+		shift += 7
+	}
+
+	return typ, size, nil
+}
+
+// negative offset of OFS_DELTA, big-endian with 1 added to each continuation
+func readOffset(r io.ByteReader) (int64, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return 0, ErrCorruptedPackEntry
+	}
+
+	off := int64(c & 0x7f)
+	for c&0x80 != 0 {
+		c, err = r.ReadByte()
+		if err != nil || off > (1<<55) {
+			return 0, ErrCorruptedPackEntry
+		}
+		off = ((off + 1) << 7) | int64(c&0x7f)
+	}
+
+	return off, nil
+}
+
+type countingReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(b []byte) (int, error) {
+	n, err := cr.r.Read(b)
+	cr.n += int64(n)
+	return n, err
+}
+
+func (cr *countingReader) ReadByte() (byte, error) {
+	c, err := cr.r.ReadByte()
+	if err == nil {
+		cr.n++
+	}
+	return c, err
+}
@@ -0,0 +1,361 @@
+package pack
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core/object"
+)
+
+const (
+	// same as the default of pack.window and pack.depth
+	Default_Window = 10
+	Default_Depth  = 50
+)
+
+// ObjectToPack is an object to be written into a packfile
+type ObjectToPack struct {
+	Oid     common.Hash
+	Kind    object.ObjectKind
+	Content []byte
+	// path of the object in the tree, used as a hint to choose delta base
+	Path string
+
+	nameHash uint32
+	base     *ObjectToPack
+	delta    []byte
+	depth    int
+
+	written bool
+	offset  int64
+	crc     uint32
+}
+
+type WriteOption struct {
+	// number of objects to be compared as delta base, 0 means no delta compression
+	Window int
+	// maximum delta depth
+	Depth int
+}
+
+// WritePack writes objects into a packfile and its index under dir, named by the checksum of the packfile.
+// It returns the path of the packfile and number of deltified objects.
+func WritePack(dir string, objects []*ObjectToPack, option *WriteOption) (string, int, error) {
+	deltas := deltify(objects, option.Window, option.Depth)
+
+	pf, err := os.CreateTemp(dir, "tmp_pack_")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(pf.Name())
+
+	checksum, err := EncodePack(pf, objects)
+	pf.Close()
+	if err != nil {
+		return "", 0, err
+	}
+
+	xf, err := os.CreateTemp(dir, "tmp_idx_")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(xf.Name())
+
+	err = EncodeIndex(xf, objects, checksum)
+	xf.Close()
+	if err != nil {
+		return "", 0, err
+	}
+
+	name := filepath.Join(dir, "pack-"+checksum.String())
+	os.Chmod(pf.Name(), 0444)
+	os.Chmod(xf.Name(), 0444)
+	// the index is moved at last, the pack is invisible until its index exists
+	if err := os.Rename(pf.Name(), name+".pack"); err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(xf.Name(), name+".idx"); err != nil {
+		return "", 0, err
+	}
+
+	return name + ".pack", deltas, nil
+}
+
+// deltify chooses delta base for objects with a sliding window, objects are sorted by kind, name hash of path and size,
+// so that objects with the same type, similar size and path are compared with each other.
+func deltify(objects []*ObjectToPack, window, depth int) int {
+	if window <= 0 || depth <= 0 {
+		return 0
+	}
+
+	sorted := make([]*ObjectToPack, len(objects))
+	copy(sorted, objects)
+	for _, o := range sorted {
+		o.nameHash = nameHash(o.Path)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.nameHash != b.nameHash {
+			return a.nameHash < b.nameHash
+		}
+		return len(a.Content) > len(b.Content)
+	})
+
+	deltas := 0
+	for i, o := range sorted {
+		if o.Kind == object.Kind_Commit || o.Kind == object.Kind_Tag || len(o.Content) < 64 {
+			continue
+		}
+
+		// delta must save at least half of the size, and the longer chain, the more to save
+		maxSize := len(o.Content)/2 - 20
+		for j := i - 1; j >= 0 && j >= i-window; j-- {
+			b := sorted[j]
+			if b.Kind != o.Kind {
+				break
+			}
+			if b.depth >= depth || len(o.Content) < len(b.Content)/32 {
+				continue
+			}
+			limit := maxSize * (depth - b.depth) / (depth + 1)
+			if limit <= 0 || abs(len(o.Content)-len(b.Content)) >= limit {
+				continue
+			}
+
+			if d := DiffDelta(b.Content, o.Content, limit); d != nil {
+				o.base, o.delta, o.depth = b, d, b.depth+1
+				maxSize = len(d) - 1
+			}
+		}
+
+		if o.base != nil {
+			deltas++
+		}
+	}
+
+	return deltas
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// the last characters of the path are the most important
+func nameHash(path string) uint32 {
+	var h uint32
+	for _, c := range path {
+		if unicode.IsSpace(c) {
+			continue
+		}
+		h = (h >> 2) + (uint32(c) << 24)
+	}
+	return h
+}
+
+// EncodePack writes objects in packfile format, deltified objects are written as OFS_DELTA after their bases.
+func EncodePack(w io.Writer, objects []*ObjectToPack) (common.Hash, error) {
+	sum := sha1.New()
+	pw := &packWriter{w: io.MultiWriter(w, sum)}
+
+	header := make([]byte, packHeaderSize)
+	copy(header, sign_Pack)
+	binary.BigEndian.PutUint32(header[4:], pack_version_2)
+	binary.BigEndian.PutUint32(header[8:], uint32(len(objects)))
+	if err := pw.write(header); err != nil {
+		return common.ZeroHash, err
+	}
+
+	for _, o := range objects {
+		o.written = false
+	}
+	for _, o := range objects {
+		if err := pw.writeObject(o); err != nil {
+			return common.ZeroHash, err
+		}
+	}
+
+	var checksum common.Hash
+	copy(checksum[:], sum.Sum(nil))
+	if _, err := w.Write(checksum[:]); err != nil {
+		return common.ZeroHash, err
+	}
+
+	return checksum, nil
+}
+
+type packWriter struct {
+	w      io.Writer
+	offset int64
+}
+
+func (pw *packWriter) write(b []byte) error {
+	n, err := pw.w.Write(b)
+	pw.offset += int64(n)
+	return err
+}
+
+func (pw *packWriter) writeObject(o *ObjectToPack) error {
+	if o.written {
+		return nil
+	}
+	if o.base != nil && !o.base.written {
+		if err := pw.writeObject(o.base); err != nil {
+			return err
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	data := o.Content
+	if o.base != nil {
+		data = o.delta
+		writeEntryHeader(buf, typeOfsDelta, len(data))
+		writeOffset(buf, pw.offset-o.base.offset)
+	} else {
+		writeEntryHeader(buf, entryTypeOfKind(o.Kind), len(data))
+	}
+
+	zw := zlib.NewWriter(buf)
+	zw.Write(data)
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	o.offset = pw.offset
+	o.crc = crc32.ChecksumIEEE(buf.Bytes())
+	o.written = true
+	return pw.write(buf.Bytes())
+}
+
+func entryTypeOfKind(kind object.ObjectKind) entryType {
+	switch kind {
+	case object.Kind_Commit:
+		return typeCommit
+	case object.Kind_Tree:
+		return typeTree
+	case object.Kind_Blob:
+		return typeBlob
+	case object.Kind_Tag:
+		return typeTag
+	default:
+		return 0
+	}
+}
+
+func writeEntryHeader(buf *bytes.Buffer, typ entryType, size int) {
+	c := byte(typ)<<4 | byte(size&0x0f)
+	size >>= 4
+	for size > 0 {
+		buf.WriteByte(c | 0x80)
+		c = byte(size & 0x7f)
+		size >>= 7
+	}
+	buf.WriteByte(c)
+}
+
+func writeOffset(buf *bytes.Buffer, off int64) {
+	b := make([]byte, 0, 10)
+	b = append(b, byte(off&0x7f))
+	for off >>= 7; off > 0; off >>= 7 {
+		off--
+		b = append(b, byte(off&0x7f)|0x80)
+	}
+	for i := len(b) - 1; i >= 0; i-- {
+		buf.WriteByte(b[i])
+	}
+}
+
+// EncodeIndex writes the version 2 index of objects which have been written by EncodePack
+func EncodeIndex(w io.Writer, objects []*ObjectToPack, packChecksum common.Hash) error {
+	sorted := make([]*ObjectToPack, len(objects))
+	copy(sorted, objects)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].Oid[:], sorted[j].Oid[:]) < 0
+	})
+
+	sum := sha1.New()
+	iw := &indexWriter{w: io.MultiWriter(w, sum), sum: sum}
+
+	iw.write(idxMagic)
+	iw.writeUint32(idx_version_2)
+
+	var fanout [256]uint32
+	for _, o := range sorted {
+		fanout[o.Oid[0]]++
+	}
+	count := uint32(0)
+	for i := range fanout {
+		count += fanout[i]
+		iw.writeUint32(count)
+	}
+
+	for _, o := range sorted {
+		iw.write(o.Oid[:])
+	}
+	for _, o := range sorted {
+		iw.writeUint32(o.crc)
+	}
+
+	large := make([]int64, 0)
+	for _, o := range sorted {
+		if o.offset < int64(idxOffsetLarge) {
+			iw.writeUint32(uint32(o.offset))
+		} else {
+			iw.writeUint32(idxOffsetLarge | uint32(len(large)))
+			large = append(large, o.offset)
+		}
+	}
+	for _, off := range large {
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint64(b, uint64(off))
+		iw.write(b)
+	}
+
+	iw.write(packChecksum[:])
+	if iw.err != nil {
+		return iw.err
+	}
+
+	_, err := w.Write(sum.Sum(nil))
+	return err
+}
+
+type indexWriter struct {
+	w   io.Writer
+	sum hash.Hash
+	err error
+}
+
+func (iw *indexWriter) write(b []byte) {
+	if iw.err == nil {
+		_, iw.err = iw.w.Write(b)
+	}
+}
+
+func (iw *indexWriter) writeUint32(v uint32) {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	iw.write(b)
+}
+
+// IsPackPath reports whether the file is a packfile or its index
+func IsPackPath(path string) bool {
+	base := filepath.Base(path)
+	return strings.HasPrefix(base, "pack-") && (strings.HasSuffix(base, ".pack") || strings.HasSuffix(base, ".idx"))
+}
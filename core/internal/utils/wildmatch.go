@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Wildmatch matches text with a shell glob pattern in which "*" and "?" do not match "/", "**" matches across directories,
+// and "[...]" is a character class. It is used for patterns in config conditions and ignore files.
+func Wildmatch(pattern string, text string, foldCase bool) bool {
+	re, err := CompileWildmatch(pattern, foldCase)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(text)
+}
+
+// CompileWildmatch translates the glob pattern into an anchored regular expression
+func CompileWildmatch(pattern string, foldCase bool) (*regexp.Regexp, error) {
+	var b strings.Builder
+	if foldCase {
+		b.WriteString("(?i)")
+	}
+	b.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				leading := i == 0 || pattern[i-1] == '/'
+				i++
+				if leading && i+1 < len(pattern) && pattern[i+1] == '/' {
+					// "**/" matches zero or more directories
+					i++
+					b.WriteString("(.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				b.WriteString(regexp.QuoteMeta("["))
+				continue
+			}
+			// "]" as the first character of the class is literal
+			if end == 0 || (end == 1 && (pattern[i+1] == '!' || pattern[i+1] == '^')) {
+				next := strings.IndexByte(pattern[i+end+2:], ']')
+				if next < 0 {
+					b.WriteString(regexp.QuoteMeta("["))
+					continue
+				}
+				end += next + 1
+			}
+			class := pattern[i+1 : i+1+end]
+			if class[0] == '!' {
+				class = "^" + class[1:]
+			}
+			class = strings.ReplaceAll(class, `\`, `\\`)
+			class = strings.ReplaceAll(class, "[", `\[`)
+			class = class[:1] + strings.ReplaceAll(class[1:], "]", `\]`)
+			if class[0] == ']' {
+				class = `\]` + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(pattern[i])))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core/object"
+)
+
+// MemoryObjectStorer keeps objects in memory, for unit tests and tools which build throwaway history.
+type MemoryObjectStorer struct {
+	lock    sync.RWMutex
+	objects map[common.Hash]*object.GitObject
+}
+
+func NewMemoryObjectStorer() *MemoryObjectStorer {
+	return &MemoryObjectStorer{
+		objects: make(map[common.Hash]*object.GitObject),
+	}
+}
+
+func (s *MemoryObjectStorer) Has(oid common.Hash) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	_, ok := s.objects[oid]
+	return ok
+}
+
+func (s *MemoryObjectStorer) Get(oid common.Hash) (*object.GitObject, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	g, ok := s.objects[oid]
+	if !ok {
+		return nil, errObjectNotExists
+	}
+	return g, nil
+}
+
+func (s *MemoryObjectStorer) Put(g *object.GitObject) error {
+	if g == nil {
+		return nil
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.objects[g.Id()]; !ok {
+		s.objects[g.Id()] = g
+	}
+	return nil
+}
+
+// Iter visits objects ordered by object id
+func (s *MemoryObjectStorer) Iter(kind object.ObjectKind, fn WalkGitObjectFunc) error {
+	s.lock.RLock()
+	objects := make([]*object.GitObject, 0, len(s.objects))
+	for _, g := range s.objects {
+		if kind == object.Kind_Unknow || g.Kind() == kind {
+			objects = append(objects, g)
+		}
+	}
+	s.lock.RUnlock()
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Id().String() < objects[j].Id().String()
+	})
+	for _, g := range objects {
+		if err := fn(g); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Len returns the number of objects
+func (s *MemoryObjectStorer) Len() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return len(s.objects)
+}
@@ -2,8 +2,6 @@ package object
 
 import (
 	"bytes"
-	"fmt"
-	"io"
 	"strings"
 
 	"github.com/izhujiang/gogit/common"
@@ -13,13 +11,24 @@ type TreePair struct {
 	tree        common.Hash
 	treePointer *Tree
 }
+
+// ExtraHeader is a header of commit other than tree, parent, author and committer, such as encoding, gpgsig and mergetag.
+// Value of a multi-line header is joined with "\n", without the leading space of continuation lines.
+type ExtraHeader struct {
+	Key   string
+	Value string
+}
+
 type Commit struct {
 	oid common.Hash
 	TreePair
 	parents   []common.Hash
-	author    string
-	committer string
-	message   string
+	author    *Signature
+	committer *Signature
+	// extra headers in the order of the object
+	headers []ExtraHeader
+	// message is kept verbatim, including the trailing newline
+	message string
 }
 
 func (c *Commit) Id() common.Hash {
@@ -34,7 +43,12 @@ func EmptyCommit() *Commit {
 	return &Commit{}
 }
 
-func NewCommit(oid common.Hash, treeId common.Hash, parents []common.Hash, author string, committer string, message string) *Commit {
+// NewCommit creates a commit, the message is terminated with a newline if it is not, as git commit-tree does.
+func NewCommit(oid common.Hash, treeId common.Hash, parents []common.Hash, author *Signature, committer *Signature, message string) *Commit {
+	if !strings.HasSuffix(message, "\n") {
+		message += "\n"
+	}
+
 	return &Commit{
 		oid: oid,
 		TreePair: TreePair{
@@ -50,58 +64,118 @@ func NewCommit(oid common.Hash, treeId common.Hash, parents []common.Hash, autho
 func (c *Commit) Tree() common.Hash {
 	return c.tree
 }
+
 func (c *Commit) Parents() []common.Hash {
 	return c.parents
 }
 
-// GitObject ==> Tree,fitll Tree using GotObject from repository
-func GitObjectToCommit(g *GitObject) *Commit {
-	buf := bytes.NewBuffer(g.content)
+func (c *Commit) Author() *Signature {
+	return c.author
+}
+
+func (c *Commit) Committer() *Signature {
+	return c.committer
+}
+
+func (c *Commit) Message() string {
+	return c.message
+}
+
+// Encoding returns the encoding of message, empty if it is UTF-8 by default
+func (c *Commit) Encoding() string {
+	v, _ := c.Header("encoding")
+	return v
+}
 
-	space := string([]byte{common.SPACE})
-	delim := string([]byte{common.DELIM})
+// GpgSig returns the armored signature of commit, empty if the commit is not signed
+func (c *Commit) GpgSig() string {
+	v, _ := c.Header("gpgsig")
+	return v
+}
+
+// MergeTags returns tags of merged parents, which are recorded by git merge of signed tags
+func (c *Commit) MergeTags() []string {
+	tags := make([]string, 0)
+	for _, h := range c.headers {
+		if h.Key == "mergetag" {
+			tags = append(tags, h.Value)
+		}
+	}
+	return tags
+}
+
+// Header returns the value of the first extra header with the key
+func (c *Commit) Header(key string) (string, bool) {
+	for _, h := range c.headers {
+		if h.Key == key {
+			return h.Value, true
+		}
+	}
+	return "", false
+}
+
+func (c *Commit) ExtraHeaders() []ExtraHeader {
+	return c.headers
+}
 
+// AddHeader appends an extra header to commit, its id is changed after Hash
+func (c *Commit) AddHeader(key string, value string) {
+	c.headers = append(c.headers, ExtraHeader{Key: key, Value: value})
+}
+
+// GitObject ==> Commit, headers are kept so that the commit is encoded back byte for byte
+func GitObjectToCommit(g *GitObject) *Commit {
 	c := EmptyCommit()
 	c.oid = g.oid
-	for {
-		line, err := buf.ReadString(common.DELIM)
-		line = strings.Trim(line, delim)
-		if err == io.EOF {
-			break
-		}
 
-		if len(line) == 0 {
+	header, message, _ := strings.Cut(string(g.content), "\n\n")
+	c.message = message
+
+	continued := false
+	for _, line := range strings.Split(header, "\n") {
+		if strings.HasPrefix(line, " ") {
+			// continuation of a multi-line extra header
+			if continued {
+				last := &c.headers[len(c.headers)-1]
+				last.Value += "\n" + line[1:]
+			}
 			continue
 		}
 
-		itemName, itemValue, found := strings.Cut(line, space)
-
-		if found {
-			switch itemName {
-			case "tree":
-				c.tree, _ = common.NewHash(itemValue)
+		continued = false
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "tree":
+			c.tree, _ = common.NewHash(value)
 
-			case "parent":
-				h, _ := common.NewHash(itemValue)
-				c.parents = append(c.parents, h)
+		case "parent":
+			h, _ := common.NewHash(value)
+			c.parents = append(c.parents, h)
 
-			case "author":
-				c.author = itemValue
+		case "author":
+			c.author = parseCommitSignature(value)
 
-			case "committer":
-				c.committer = itemValue
+		case "committer":
+			c.committer = parseCommitSignature(value)
 
-			default: // git comment message
-				c.message = line
-			}
-		} else {
-			c.message = c.message + line
+		default:
+			c.headers = append(c.headers, ExtraHeader{Key: key, Value: value})
+			continued = true
 		}
 	}
 
 	return c
 }
 
+// malformed signature is kept as it is
+func parseCommitSignature(s string) *Signature {
+	sig, err := ParseSignature(s)
+	if err != nil {
+		return &Signature{raw: s, parsed: &Signature{}}
+	}
+	return sig
+}
+
 func (c *Commit) ToGitObject() *GitObject {
 	content := c.contentToBytes()
 	g := NewGitObject(Kind_Commit, content)
@@ -131,37 +205,34 @@ func (c *Commit) contentToBytes() []byte {
 		buf.WriteByte(common.DELIM)
 	}
 
-	buf.WriteString("author")
-	buf.WriteByte(common.SPACE)
-	buf.WriteString(c.author)
-	buf.WriteByte(common.DELIM)
+	if c.author != nil {
+		buf.WriteString("author")
+		buf.WriteByte(common.SPACE)
+		buf.WriteString(c.author.String())
+		buf.WriteByte(common.DELIM)
+	}
 
-	buf.WriteString("committer")
-	buf.WriteByte(common.SPACE)
-	buf.WriteString(c.committer)
-	buf.WriteByte(common.DELIM)
+	if c.committer != nil {
+		buf.WriteString("committer")
+		buf.WriteByte(common.SPACE)
+		buf.WriteString(c.committer.String())
+		buf.WriteByte(common.DELIM)
+	}
+
+	for _, h := range c.headers {
+		buf.WriteString(h.Key)
+		buf.WriteByte(common.SPACE)
+		buf.WriteString(strings.ReplaceAll(h.Value, "\n", "\n "))
+		buf.WriteByte(common.DELIM)
+	}
 
 	buf.WriteByte(common.DELIM)
 	buf.WriteString(c.message)
-	buf.WriteByte(common.DELIM)
 
 	return buf.Bytes()
 }
 
-// TODO: output with format interface
+// Content returns the commit as it is stored, which is what git cat-file -p prints
 func (c *Commit) Content() string {
-	w := &bytes.Buffer{}
-	fmt.Fprintf(w, "%s %s \n", "tree", c.tree)
-	for _, p := range c.parents {
-		fmt.Fprintf(w, "%s %s \n", "parent", p)
-	}
-
-	fmt.Fprintf(w, "%s %s \n", "author", c.author)
-	fmt.Fprintf(w, "%s %s \n", "committer", c.committer)
-
-	fmt.Fprintln(w, "")
-	// fmt.Fprintln(w, "")
-	fmt.Fprintf(w, "%s\n", c.message)
-
-	return string(w.Bytes())
+	return string(c.contentToBytes())
 }
@@ -0,0 +1,85 @@
+package object
+
+import (
+	"testing"
+	"time"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// hashed with git hash-object -t commit --literally
+const signedMergeCommit = "tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n" +
+	"parent 8b80381e99f222fb1ffe69a925f5b10ceace5165\n" +
+	"parent 3c4e9cd789d88d8d89c1073707c3585e41b0e614\n" +
+	"author A U Thor <author@example.com> 1112911993 -0700\n" +
+	"committer C O Mitter  <committer@example.com>  1112912053 -0000\n" +
+	"encoding ISO-8859-1\n" +
+	"mergetag object 3c4e9cd789d88d8d89c1073707c3585e41b0e614\n" +
+	" type commit\n" +
+	" tag v1.0\n" +
+	" tagger T A Gger <tagger@example.com> 1112911993 +0800\n" +
+	" \n" +
+	" release\n" +
+	"gpgsig -----BEGIN PGP SIGNATURE-----\n" +
+	" \n" +
+	" iQEzBAABCAAdFiEE\n" +
+	" -----END PGP SIGNATURE-----\n" +
+	"x-custom first\n" +
+	" second\n" +
+	"\n" +
+	"Subject line\n" +
+	"\n" +
+	"Body without trailing newline"
+
+func TestCommitRoundTrip(t *testing.T) {
+	g := NewGitObject(Kind_Commit, []byte(signedMergeCommit))
+	assert.Equal(t, "b2880c8f711895d8c71e1916b98bf5f304f26b9b", g.Id().String())
+
+	c := GitObjectToCommit(g)
+	assert.Equal(t, 2, len(c.Parents()))
+	assert.Equal(t, "A U Thor", c.Author().Name)
+	assert.Equal(t, "author@example.com", c.Author().Email)
+	assert.Equal(t, int64(1112911993), c.Author().When.Unix())
+	_, offset := c.Author().When.Zone()
+	assert.Equal(t, -7*3600, offset)
+	assert.Equal(t, "C O Mitter", c.Committer().Name)
+	assert.Equal(t, "ISO-8859-1", c.Encoding())
+	assert.Equal(t, "-----BEGIN PGP SIGNATURE-----\n\niQEzBAABCAAdFiEE\n-----END PGP SIGNATURE-----", c.GpgSig())
+	assert.Equal(t, 1, len(c.MergeTags()))
+	v, _ := c.Header("x-custom")
+	assert.Equal(t, "first\nsecond", v)
+	assert.Equal(t, "Subject line\n\nBody without trailing newline", c.Message())
+
+	assert.Equal(t, signedMergeCommit, c.Content())
+	assert.Equal(t, g.Id(), c.Hash())
+
+	// changed signature is encoded in the canonical form
+	c.Committer().Name = "Someone Else"
+	assert.Equal(t, "Someone Else <committer@example.com> 1112912053 +0000", c.Committer().String())
+	assert.NotEqual(t, g.Id(), c.Hash())
+}
+
+func TestSignature(t *testing.T) {
+	sig, err := ParseSignature("A U Thor <author@example.com> 1112911993 +0800")
+	assert.Nil(t, err)
+	assert.Equal(t, "A U Thor", sig.Name)
+	assert.Equal(t, "author@example.com", sig.Email)
+	assert.Equal(t, time.Unix(1112911993, 0).Unix(), sig.When.Unix())
+	assert.Equal(t, "+0800", sig.When.Format("-0700"))
+
+	sig, err = ParseSignature("A U Thor <author@example.com>")
+	assert.Nil(t, err)
+	assert.True(t, sig.When.IsZero())
+
+	_, err = ParseSignature("nobody")
+	assert.Equal(t, ErrInvalidSignature, err)
+
+	when := time.Unix(1112911993, 0).In(common.ParseTimeZone("-0130"))
+	sig = NewSignature("A U Thor", "author@example.com", when)
+	assert.Equal(t, "A U Thor <author@example.com> 1112911993 -0130", sig.String())
+
+	c := NewCommit(common.ZeroHash, common.ZeroHash, nil, sig, sig, "message")
+	assert.Equal(t, "message\n", c.Message())
+	assert.Equal(t, c.Content(), GitObjectToCommit(c.ToGitObject()).Content())
+}
@@ -0,0 +1,92 @@
+package object
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/izhujiang/gogit/common"
+)
+
+var (
+	ErrInvalidSignature = errors.New("Invalid signature, which should be in the form of 'Name <email> 1234567890 +0800'.")
+)
+
+// Signature is the author, committer or tagger of an object, encoded as "Name <email> 1234567890 +0800"
+type Signature struct {
+	Name  string
+	Email string
+	// When is zero if the signature has no date
+	When time.Time
+
+	// the signature as read from an object, which is encoded verbatim unless the fields are changed,
+	// so that objects with malformed or unusual signatures can be written back byte for byte.
+	raw    string
+	parsed *Signature
+}
+
+func NewSignature(name string, email string, when time.Time) *Signature {
+	return &Signature{
+		Name:  name,
+		Email: email,
+		When:  when,
+	}
+}
+
+// ParseSignature parses "Name <email> 1234567890 +0800", the date is optional.
+// Like git, the email is taken up to the last '>' and a malformed date is ignored.
+func ParseSignature(s string) (*Signature, error) {
+	lt := strings.IndexByte(s, '<')
+	gt := strings.LastIndexByte(s, '>')
+	if lt < 0 || gt < lt {
+		return nil, ErrInvalidSignature
+	}
+
+	sig := &Signature{
+		Name:  strings.TrimSpace(s[:lt]),
+		Email: s[lt+1 : gt],
+	}
+
+	fields := strings.Fields(s[gt+1:])
+	if len(fields) > 0 {
+		if sec, err := strconv.ParseInt(fields[0], 10, 64); err == nil {
+			loc := time.UTC
+			if len(fields) > 1 {
+				loc = common.ParseTimeZone(fields[1])
+			}
+			sig.When = time.Unix(sec, 0).In(loc)
+		}
+	}
+
+	sig.raw = s
+	sig.parsed = &Signature{
+		Name:  sig.Name,
+		Email: sig.Email,
+		When:  sig.When,
+	}
+	return sig, nil
+}
+
+// String encodes the signature as in commit and tag objects
+func (s *Signature) String() string {
+	if s.raw != "" && s.unchanged() {
+		return s.raw
+	}
+
+	if s.When.IsZero() {
+		return s.Name + " <" + s.Email + ">"
+	}
+	return s.Name + " <" + s.Email + "> " + common.FormatDate(s.When)
+}
+
+func (s *Signature) unchanged() bool {
+	p := s.parsed
+	if p == nil || p.Name != s.Name || p.Email != s.Email || !p.When.Equal(s.When) {
+		return false
+	}
+
+	_, offset := s.When.Zone()
+	_, parsedOffset := p.When.Zone()
+	return offset == parsedOffset
+}
@@ -2,68 +2,273 @@ package object
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/izhujiang/gogit/common"
 )
 
+var (
+	ErrInvalidTag = errors.New("Invalid tag object.")
+
+	// armored signatures which are appended to the message of tag
+	signatureBegins = []string{
+		"-----BEGIN PGP SIGNATURE-----",
+		"-----BEGIN PGP MESSAGE-----",
+		"-----BEGIN SSH SIGNATURE-----",
+		"-----BEGIN SIGNED MESSAGE-----",
+	}
+)
+
+// Tag is an annotated tag, which points to an object with name, tagger and message
 type Tag struct {
 	oid       common.Hash
 	refObject common.Hash
 	refType   ObjectKind
-	tagger    string
+	name      string
+	tagger    *Signature
+	// headers after tagger in the order of the object
+	headers []ExtraHeader
+	message string
+	// armored signature at the end of message
+	signature string
+	// the blank line before message is omitted, which is allowed when there is no message
+	noDelim bool
 }
 
 func EmptyTag() *Tag {
 	return &Tag{}
 }
 
-func NewTag(oid common.Hash, refObject common.Hash, refType ObjectKind, tagger string) *Tag {
+// NewTag creates an annotated tag, the message is terminated with a newline if it is not empty
+func NewTag(oid common.Hash, refObject common.Hash, refType ObjectKind, name string, tagger *Signature, message string) *Tag {
+	if message != "" && !strings.HasSuffix(message, "\n") {
+		message += "\n"
+	}
+
 	return &Tag{
-		oid,
-		refObject,
-		refType,
-		tagger,
+		oid:       oid,
+		refObject: refObject,
+		refType:   refType,
+		name:      name,
+		tagger:    tagger,
+		message:   message,
 	}
 }
-func (c *Tag) Id() common.Hash {
-	h := c.oid
-	return h
+
+func (t *Tag) Id() common.Hash {
+	return t.oid
+}
+
+func (t *Tag) Kind() ObjectKind {
+	return Kind_Tag
+}
+
+// Object returns the id of the tagged object
+func (t *Tag) Object() common.Hash {
+	return t.refObject
 }
 
-func (c *Tag) Object() common.Hash {
-	return c.refObject
+// Type returns the kind of the tagged object
+func (t *Tag) Type() ObjectKind {
+	return t.refType
 }
 
-func (c *Tag) Type() ObjectKind {
-	return c.refType
+func (t *Tag) Name() string {
+	return t.name
 }
 
-func (c *Tag) Tagger() string {
-	return c.tagger
+// Tagger returns nil if the tag has no tagger, which is allowed in old tags
+func (t *Tag) Tagger() *Signature {
+	return t.tagger
 }
 
-func (c *Tag) FromGitObject(g *GitObject) {
-	panic("Not implemented")
+func (t *Tag) Message() string {
+	return t.message
 }
 
-func (c *Tag) ToGitObject() *GitObject {
-	panic("Not implemented")
+// GpgSig returns the armored signature of tag, empty if the tag is not signed
+func (t *Tag) GpgSig() string {
+	return t.signature
 }
 
-// TODO: output with format interface
-func (c *Tag) Content() string {
-	w := &bytes.Buffer{}
-	// fmt.Fprintf(w, "%s %s \n", "tree", c.tree)
-	// for _, p := range c.parents {
-	// 	fmt.Fprintf(w, "%s %s \n", "parent", p)
-	// }
+// SetGpgSig signs the tag with armored signature, its id is changed after Hash
+func (t *Tag) SetGpgSig(signature string) {
+	t.signature = signature
+}
+
+func (t *Tag) ExtraHeaders() []ExtraHeader {
+	return t.headers
+}
+
+func (t *Tag) FromGitObject(g *GitObject) {
+	*t = *GitObjectToTag(g)
+}
+
+// GitObject ==> Tag, malformed headers are skipped
+func GitObjectToTag(g *GitObject) *Tag {
+	t, _ := parseTag(g.content)
+	t.oid = g.oid
+	return t
+}
+
+// ParseTag parses content of tag object strictly: headers object, type, tag and tagger are required in order.
+// It is used to validate input of git mktag.
+func ParseTag(content []byte) (*Tag, error) {
+	t, err := parseTag(content)
+	if err != nil {
+		return nil, err
+	}
+	if t.tagger == nil {
+		return nil, fmt.Errorf("%w missing \"tagger\" header", ErrInvalidTag)
+	}
+	t.Hash()
+	return t, nil
+}
+
+func parseTag(content []byte) (*Tag, error) {
+	t := EmptyTag()
+	var firstErr error
+	fail := func(format string, a ...any) {
+		if firstErr == nil {
+			firstErr = fmt.Errorf("%w "+format, append([]any{ErrInvalidTag}, a...)...)
+		}
+	}
+
+	// the message and the blank line before it may be omitted
+	header, message, found := strings.Cut(string(content), "\n\n")
+	if !found {
+		header = strings.TrimSuffix(header, "\n")
+		t.noDelim = true
+	}
+	t.message, t.signature = splitSignature(message)
+
+	lines := strings.Split(header, "\n")
+	expected := []string{"object", "type", "tag"}
+	continued := false
+	for i, line := range lines {
+		if strings.HasPrefix(line, " ") {
+			if continued {
+				last := &t.headers[len(t.headers)-1]
+				last.Value += "\n" + line[1:]
+			} else {
+				fail("unexpected continuation line %q", line)
+			}
+			continue
+		}
 
-	// fmt.Fprintf(w, "%s %s \n", "author", c.author)
-	// fmt.Fprintf(w, "%s %s \n", "committer", c.committer)
+		continued = false
+		key, value, found := strings.Cut(line, " ")
+		if i < len(expected) && key != expected[i] {
+			fail("expected %q header in line %d", expected[i], i+1)
+		}
+		switch {
+		case key == "object" && i == 0:
+			oid, err := common.NewHash(value)
+			if err != nil || len(value) != 40 {
+				fail("invalid object id %q", value)
+			}
+			t.refObject = oid
 
-	// fmt.Fprintln(w, "")
-	// // fmt.Fprintln(w, "")
-	// fmt.Fprintf(w, "%s\n", c.message)
+		case key == "type" && i == 1:
+			t.refType = ParseObjectKind(value)
+			if t.refType == Kind_Unknow {
+				fail("invalid object type %q", value)
+			}
+
+		case key == "tag" && i == 2:
+			if value == "" {
+				fail("empty tag name")
+			}
+			t.name = value
+
+		case key == "tagger" && i == 3:
+			sig, err := ParseSignature(value)
+			if err != nil {
+				fail("invalid tagger %q", value)
+				sig = &Signature{raw: value, parsed: &Signature{}}
+			}
+			t.tagger = sig
+
+		case i < len(expected):
+			// reported above
+
+		default:
+			if !found || key == "" {
+				fail("invalid header %q", line)
+			}
+			t.headers = append(t.headers, ExtraHeader{Key: key, Value: value})
+			continued = true
+		}
+	}
+	if len(lines) < len(expected) {
+		fail("missing %q header", expected[len(lines)])
+	}
+
+	return t, firstErr
+}
+
+// splitSignature splits armored signature from the end of message
+func splitSignature(message string) (string, string) {
+	idx := -1
+	for _, begin := range signatureBegins {
+		i := strings.Index(message, begin)
+		for i > 0 && message[i-1] != '\n' {
+			next := strings.Index(message[i+1:], begin)
+			if next < 0 {
+				i = -1
+				break
+			}
+			i += next + 1
+		}
+		if i >= 0 && (idx < 0 || i < idx) {
+			idx = i
+		}
+	}
+
+	if idx < 0 {
+		return message, ""
+	}
+	return message[:idx], message[idx:]
+}
+
+func (t *Tag) ToGitObject() *GitObject {
+	content := t.contentToBytes()
+	g := NewGitObject(Kind_Tag, content)
+
+	return g
+}
+
+func (t *Tag) Hash() common.Hash {
+	content := t.contentToBytes()
+	t.oid = common.HashObject(t.Kind().String(), content)
+	return t.oid
+}
+
+func (t *Tag) contentToBytes() []byte {
+	buf := &bytes.Buffer{}
+
+	fmt.Fprintf(buf, "object %s\n", t.refObject)
+	fmt.Fprintf(buf, "type %s\n", t.refType)
+	fmt.Fprintf(buf, "tag %s\n", t.name)
+	if t.tagger != nil {
+		fmt.Fprintf(buf, "tagger %s\n", t.tagger)
+	}
+	for _, h := range t.headers {
+		fmt.Fprintf(buf, "%s %s\n", h.Key, strings.ReplaceAll(h.Value, "\n", "\n "))
+	}
+
+	if !t.noDelim {
+		buf.WriteByte(common.DELIM)
+	}
+	buf.WriteString(t.message)
+	buf.WriteString(t.signature)
+
+	return buf.Bytes()
+}
 
-	return string(w.Bytes())
+// Content returns the tag as it is stored, which is what git cat-file -p prints
+func (t *Tag) Content() string {
+	return string(t.contentToBytes())
 }
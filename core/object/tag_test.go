@@ -0,0 +1,66 @@
+package object
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const signedTag = "object 06a73c5830948dd1bc99b66c34f8b060134e601f\n" +
+	"type commit\n" +
+	"tag v1.0\n" +
+	"tagger T A Gger <tagger@example.com> 1112911993 -0700\n" +
+	"\n" +
+	"release one\n" +
+	"-----BEGIN PGP SIGNATURE-----\n" +
+	"\n" +
+	"iQEzBAABCAAdFiEE\n" +
+	"-----END PGP SIGNATURE-----\n"
+
+func TestTagRoundTrip(t *testing.T) {
+	g := NewGitObject(Kind_Tag, []byte(signedTag))
+	tag := GitObjectToTag(g)
+
+	assert.Equal(t, "06a73c5830948dd1bc99b66c34f8b060134e601f", tag.Object().String())
+	assert.Equal(t, Kind_Commit, tag.Type())
+	assert.Equal(t, "v1.0", tag.Name())
+	assert.Equal(t, "T A Gger", tag.Tagger().Name)
+	assert.Equal(t, "release one\n", tag.Message())
+	assert.Equal(t, "-----BEGIN PGP SIGNATURE-----\n\niQEzBAABCAAdFiEE\n-----END PGP SIGNATURE-----\n", tag.GpgSig())
+	assert.Equal(t, signedTag, tag.Content())
+	assert.Equal(t, g.Id(), tag.Hash())
+
+	created := NewTag(tag.Object(), tag.Object(), Kind_Commit, "v1.0", tag.Tagger(), "release one")
+	created.SetGpgSig(tag.GpgSig())
+	assert.Equal(t, g.Id(), created.Hash())
+}
+
+func TestParseTag(t *testing.T) {
+	tag, err := ParseTag([]byte(signedTag))
+	assert.Nil(t, err)
+	assert.Equal(t, NewGitObject(Kind_Tag, []byte(signedTag)).Id(), tag.Id())
+
+	bad := []string{
+		"type commit\ntag v1.0\ntagger a <b> 1 +0000\n\nmsg\n",
+		"object 06a73c5830948dd1bc99b66c34f8b060134e601f\ntype commit\ntag v1.0\n\nmsg\n",
+		"object 06a73c58\ntype commit\ntag v1.0\ntagger a <b> 1 +0000\n\nmsg\n",
+		"object 06a73c5830948dd1bc99b66c34f8b060134e601f\ntype bogus\ntag v1.0\ntagger a <b> 1 +0000\n\nmsg\n",
+		"object 06a73c5830948dd1bc99b66c34f8b060134e601f\ntype commit\ntag \ntagger a <b> 1 +0000\n\nmsg\n",
+		"object 06a73c5830948dd1bc99b66c34f8b060134e601f\ntype commit\ntag v1.0\ntagger nobody\n\nmsg\n",
+	}
+	for _, content := range bad {
+		_, err := ParseTag([]byte(content))
+		assert.True(t, errors.Is(err, ErrInvalidTag), content)
+	}
+
+	// the blank line is only written back if it was there
+	noMessage := "object 06a73c5830948dd1bc99b66c34f8b060134e601f\ntype commit\ntag v1.0\ntagger a <b> 1 +0000\n"
+	tag, err = ParseTag([]byte(noMessage))
+	assert.Nil(t, err)
+	assert.Equal(t, noMessage, tag.Content())
+	assert.Equal(t, NewGitObject(Kind_Tag, []byte(noMessage)).Id(), tag.Id())
+	tag, err = ParseTag([]byte(noMessage + "\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, noMessage+"\n", tag.Content())
+}
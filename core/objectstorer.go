@@ -0,0 +1,319 @@
+package core
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core/internal/pack"
+	"github.com/izhujiang/gogit/core/internal/utils"
+	"github.com/izhujiang/gogit/core/object"
+)
+
+type WalkObjectFunc func(oid common.Hash) error
+type WalkGitObjectFunc func(g *object.GitObject) error
+
+// ObjectStorer is the storage of git objects
+type ObjectStorer interface {
+	// Has reports whether the object is stored
+	Has(oid common.Hash) bool
+	// Get GitObject from storage, return nil and error if the object does not exist
+	Get(oid common.Hash) (*object.GitObject, error)
+	// Put GitObject into storage, do nothing if it has already existed
+	Put(g *object.GitObject) error
+	// Iter visits all objects with the kind, Kind_Unknow for objects of all kinds
+	Iter(kind object.ObjectKind, fn WalkGitObjectFunc) error
+}
+
+// FsObjectStorer stores objects in the objects directory of a repository, as loose objects (objects/xx/yyyy) and packfiles (objects/pack).
+type FsObjectStorer struct {
+	path string
+
+	// packfiles in objects/pack, loaded lazily, and the modification time of objects/pack when they were scanned
+	packLock sync.Mutex
+	packs    []*pack.Packfile
+	packed   bool
+	packTime time.Time
+}
+
+func NewFsObjectStorer(objectsPath string) *FsObjectStorer {
+	return &FsObjectStorer{
+		path: objectsPath,
+	}
+}
+
+func (s *FsObjectStorer) ObjectsPath() string {
+	return s.path
+}
+
+func (s *FsObjectStorer) PacksPath() string {
+	return filepath.Join(s.path, "pack")
+}
+
+func (s *FsObjectStorer) Put(g *object.GitObject) error {
+	if g == nil {
+		return nil
+	}
+
+	if utils.FileExists(s.loosePath(g.Id())) || s.findPack(g.Id()) != nil {
+		return nil
+	}
+	return s.putLoose(g)
+}
+
+// putLoose writes g as a loose object whether or not it is in a packfile
+func (s *FsObjectStorer) putLoose(g *object.GitObject) error {
+	path := s.loosePath(g.Id())
+	os.MkdirAll(filepath.Dir(path), 0755)
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	err = g.Save(f)
+	return err
+}
+
+func (s *FsObjectStorer) Get(oid common.Hash) (*object.GitObject, error) {
+	path, err := s.checkObjectExists(oid)
+	if err != nil {
+		return s.getPacked(oid)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	g, err := object.Load(f, oid)
+	return g, err
+}
+
+// Has reports whether the object is stored either as a loose object or in a packfile
+func (s *FsObjectStorer) Has(oid common.Hash) bool {
+	if _, err := s.checkObjectExists(oid); err == nil {
+		return true
+	}
+
+	return s.findPack(oid) != nil
+}
+
+func (s *FsObjectStorer) Iter(kind object.ObjectKind, fn WalkGitObjectFunc) error {
+	seen := make(map[common.Hash]bool)
+	visit := func(oid common.Hash) error {
+		if seen[oid] {
+			return nil
+		}
+		seen[oid] = true
+
+		g, err := s.Get(oid)
+		if err != nil {
+			return err
+		}
+		if kind != object.Kind_Unknow && g.Kind() != kind {
+			return nil
+		}
+		return fn(g)
+	}
+
+	if err := s.ForEachLooseObject(visit); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	s.packLock.Lock()
+	s.loadPacks()
+	packs := append([]*pack.Packfile{}, s.packs...)
+	s.packLock.Unlock()
+
+	for _, p := range packs {
+		err := p.Index().ForEach(func(oid common.Hash, offset int64) error {
+			return visit(oid)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Dump the raw content of a loose object, packed objects are dumped as if they were loose
+func (s *FsObjectStorer) Dump(oid common.Hash, w io.Writer) error {
+	path, err := s.checkObjectExists(oid)
+	if err != nil {
+		g, err := s.getPacked(oid)
+		if err != nil {
+			return err
+		}
+		return dumpGitObject(g, w)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	object.DumpGitObject(f, w)
+	return nil
+}
+
+// ForEachLooseObject visits all loose objects in objects/xx/yyyy
+func (s *FsObjectStorer) ForEachLooseObject(fn WalkObjectFunc) error {
+	dirs, err := os.ReadDir(s.path)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range dirs {
+		if !d.IsDir() || len(d.Name()) != 2 {
+			continue
+		}
+
+		files, _ := os.ReadDir(filepath.Join(s.path, d.Name()))
+		for _, f := range files {
+			oid, err := common.NewHash(d.Name() + f.Name())
+			if err != nil {
+				continue
+			}
+			if err := fn(oid); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// FindPrefix returns ids of loose and packed objects which begin with the hexadecimal prefix, prefix must have 2 characters at least
+func (s *FsObjectStorer) FindPrefix(prefix string) []common.Hash {
+	seen := make(map[common.Hash]bool)
+	found := make([]common.Hash, 0)
+	add := func(oid common.Hash) {
+		if !seen[oid] {
+			seen[oid] = true
+			found = append(found, oid)
+		}
+	}
+
+	files, _ := os.ReadDir(filepath.Join(s.path, prefix[:2]))
+	for _, f := range files {
+		if strings.HasPrefix(f.Name(), prefix[2:]) {
+			if oid, err := common.NewHash(prefix[:2] + f.Name()); err == nil {
+				add(oid)
+			}
+		}
+	}
+
+	s.packLock.Lock()
+	s.loadPacks()
+	packs := append([]*pack.Packfile{}, s.packs...)
+	s.packLock.Unlock()
+	for _, p := range packs {
+		for _, oid := range p.Index().FindPrefix(prefix) {
+			add(oid)
+		}
+	}
+	return found
+}
+
+func (s *FsObjectStorer) loosePath(oid common.Hash) string {
+	name := oid.String()
+	return filepath.Join(s.path, name[:2], name[2:])
+}
+
+func (s *FsObjectStorer) checkObjectExists(oid common.Hash) (string, error) {
+	path := s.loosePath(oid)
+	if !utils.FileExists(path) {
+		return "", errObjectNotExists
+	}
+
+	return path, nil
+}
+
+func (s *FsObjectStorer) getPacked(oid common.Hash) (*object.GitObject, error) {
+	p := s.findPack(oid)
+	if p == nil {
+		return nil, errObjectNotExists
+	}
+
+	return p.Get(oid)
+}
+
+// find the packfile containing the object, objects/pack is rescanned if it has changed, for packs may have been written
+// or removed since
+func (s *FsObjectStorer) findPack(oid common.Hash) *pack.Packfile {
+	s.packLock.Lock()
+	defer s.packLock.Unlock()
+
+	s.loadPacks()
+	for _, p := range s.packs {
+		if p.Has(oid) {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// closePacks closes all opened packfiles, they will be reloaded when needed
+func (s *FsObjectStorer) closePacks() {
+	s.packLock.Lock()
+	defer s.packLock.Unlock()
+
+	for _, p := range s.packs {
+		p.Close()
+	}
+	s.packs = nil
+	s.packed = false
+}
+
+// loadPacks rebuilds the list of packfiles in objects/pack, packs which are gone, such as those deleted by a repack in
+// another process, are closed. objects/pack is only scanned again if its modification time changes, which it does
+// whenever a pack is moved into it or removed from it.
+func (s *FsObjectStorer) loadPacks() {
+	var modTime time.Time
+	if fi, err := os.Stat(s.PacksPath()); err == nil {
+		modTime = fi.ModTime()
+	}
+	if s.packed && modTime.Equal(s.packTime) {
+		return
+	}
+	s.packed, s.packTime = true, modTime
+
+	opened := make(map[string]*pack.Packfile, len(s.packs))
+	for _, p := range s.packs {
+		opened[p.Path()] = p
+	}
+	idxs, _ := filepath.Glob(filepath.Join(s.PacksPath(), "pack-*.idx"))
+	packs := make([]*pack.Packfile, 0, len(idxs))
+	for _, idxPath := range idxs {
+		path := idxPath[:len(idxPath)-len(".idx")] + ".pack"
+		if !utils.FileExists(path) {
+			continue
+		}
+		if p, ok := opened[path]; ok {
+			packs = append(packs, p)
+			delete(opened, path)
+			continue
+		}
+
+		p, err := pack.Open(path)
+		if err != nil {
+			log.Printf("skip invalid packfile %s: %v", path, err)
+			continue
+		}
+		packs = append(packs, p)
+	}
+	for _, p := range opened {
+		p.Close()
+	}
+	s.packs = packs
+}
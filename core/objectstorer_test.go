@@ -0,0 +1,108 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func testObjectStorer(t *testing.T, s ObjectStorer) {
+	blobId, err := HashObjectFromReader(bytes.NewBufferString("test content\n"), object.Kind_Blob, s)
+	assert.Nil(t, err)
+	assert.Equal(t, "d670460b4b4aece5915caf5c68d12f560a9fe3e4", blobId.String())
+	assert.True(t, s.Has(blobId))
+
+	tree := object.EmptyTree()
+	tree.Append(object.NewTreeEntry(blobId, "test.txt", common.Regular))
+	tree.Hash()
+	assert.Nil(t, s.Put(tree.ToGitObject()))
+	// put again does nothing
+	assert.Nil(t, s.Put(tree.ToGitObject()))
+
+	g, err := s.Get(tree.Id())
+	assert.Nil(t, err)
+	assert.Equal(t, object.Kind_Tree, g.Kind())
+
+	loaded, err := LoadTrees(s, tree.Id())
+	assert.Nil(t, err)
+	assert.Equal(t, blobId, loaded.Find("test.txt").Oid)
+
+	_, err = s.Get(common.ZeroHash)
+	assert.NotNil(t, err)
+	assert.False(t, s.Has(common.ZeroHash))
+
+	blobs := 0
+	s.Iter(object.Kind_Blob, func(g *object.GitObject) error {
+		assert.Equal(t, object.Kind_Blob, g.Kind())
+		blobs++
+		return nil
+	})
+	assert.Equal(t, 1, blobs)
+
+	all := 0
+	s.Iter(object.Kind_Unknow, func(g *object.GitObject) error {
+		all++
+		return nil
+	})
+	assert.Equal(t, 2, all)
+}
+
+func TestMemoryObjectStorer(t *testing.T) {
+	testObjectStorer(t, NewMemoryObjectStorer())
+}
+
+func TestFsObjectStorer(t *testing.T) {
+	dir := t.TempDir()
+	s := NewFsObjectStorer(dir)
+	testObjectStorer(t, s)
+
+	// objects are readable after being packed
+	_, err := s.Repack([]common.Hash{hashOf(t, s, object.Kind_Tree)}, &RepackOption{All: true, Delete: true, Window: 10, Depth: 50})
+	assert.Nil(t, err)
+	loose := 0
+	s.ForEachLooseObject(func(oid common.Hash) error {
+		loose++
+		return nil
+	})
+	assert.Equal(t, 0, loose)
+	testObjectStorer(t, s)
+
+	// packs written by others are found once objects/pack changes
+	other := NewFsObjectStorer(dir)
+	blobId, err := HashObjectFromReader(bytes.NewBufferString("more content\n"), object.Kind_Blob, NewMemoryObjectStorer())
+	assert.Nil(t, err)
+	assert.False(t, other.Has(blobId))
+	_, err = HashObjectFromReader(bytes.NewBufferString("more content\n"), object.Kind_Blob, s)
+	assert.Nil(t, err)
+	_, err = s.Repack([]common.Hash{blobId}, &RepackOption{Delete: true, Window: 10, Depth: 50})
+	assert.Nil(t, err)
+	assert.True(t, other.Has(blobId))
+
+	// unreachable objects of the removed packs become loose objects
+	_, err = s.Repack([]common.Hash{hashOf(t, s, object.Kind_Tree)}, &RepackOption{All: true, Delete: true, KeepUnreachable: true, Window: 10, Depth: 50})
+	assert.Nil(t, err)
+	loose = 0
+	s.ForEachLooseObject(func(oid common.Hash) error {
+		assert.Equal(t, blobId, oid)
+		loose++
+		return nil
+	})
+	assert.Equal(t, 1, loose)
+	assert.True(t, s.Has(blobId))
+
+	// packs removed by others are dropped
+	assert.Nil(t, other.findPack(blobId))
+	assert.Equal(t, 1, len(other.packs))
+}
+
+func hashOf(t *testing.T, s ObjectStorer, kind object.ObjectKind) common.Hash {
+	var oid common.Hash
+	s.Iter(kind, func(g *object.GitObject) error {
+		oid = g.Id()
+		return nil
+	})
+	return oid
+}
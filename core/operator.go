@@ -3,37 +3,37 @@ package core
 import (
 	"bytes"
 	"io"
-	"log"
 	"os"
 
 	"github.com/izhujiang/gogit/common"
 	"github.com/izhujiang/gogit/core/object"
 )
 
-func HashObjectFromPath(path string, oType object.ObjectKind, save bool) (common.Hash, error) {
+// HashObjectFromPath computes the object id of the file, and writes the object into s unless s is nil
+func HashObjectFromPath(path string, oType object.ObjectKind, s ObjectStorer) (common.Hash, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return common.ZeroHash, err
 	}
 	defer f.Close()
 
-	return HashObjectFromReader(f, oType, save)
+	return HashObjectFromReader(f, oType, s)
 }
 
-func HashObjectFromReader(r io.Reader, oType object.ObjectKind, save bool) (common.Hash, error) {
+// HashObjectFromReader computes the object id of the content read from r, and writes the object into s unless s is nil
+func HashObjectFromReader(r io.Reader, oType object.ObjectKind, s ObjectStorer) (common.Hash, error) {
 	buf := &bytes.Buffer{}
 	_, err := buf.ReadFrom(r)
 	if err != nil {
-		log.Fatal(err)
+		return common.ZeroHash, err
 	}
 
 	var h common.Hash
-	if save == false {
+	if s == nil {
 		h = common.HashObject(oType.String(), buf.Bytes())
 	} else {
-		repo := GetRepository()
 		g := object.NewGitObject(oType, buf.Bytes())
-		repo.Put(g)
+		err = s.Put(g)
 		h = g.Id()
 	}
 
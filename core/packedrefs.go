@@ -0,0 +1,232 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core/object"
+)
+
+const (
+	packed_refs_file   = "packed-refs"
+	packed_refs_header = "# pack-refs with: peeled fully-peeled sorted "
+)
+
+var (
+	ErrInvalidPackedRefs = errors.New("Invalid packed-refs file.")
+	errRefsLocked        = errors.New("Unable to lock packed-refs, another git process seems to be running in this repository.")
+)
+
+// reference in packed-refs, peeled is the object which an annotated tag points to finally, or ZeroHash
+type packedRef struct {
+	name   string
+	id     common.Hash
+	peeled common.Hash
+}
+
+// readPackedRefs reads packed-refs in order of names, an empty list is returned if it does not exist
+func (r *References) readPackedRefs() ([]*packedRef, error) {
+	b, err := os.ReadFile(filepath.Join(r.root, packed_refs_file))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	refs := make([]*packedRef, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+
+		case strings.HasPrefix(line, "^"):
+			peeled, err := common.NewHash(line[1:])
+			if err != nil || len(refs) == 0 {
+				return nil, fmt.Errorf("%w line %d: %s", ErrInvalidPackedRefs, n, line)
+			}
+			refs[len(refs)-1].peeled = peeled
+
+		default:
+			hex, name, found := strings.Cut(line, " ")
+			id, err := common.NewHash(hex)
+			if !found || err != nil {
+				return nil, fmt.Errorf("%w line %d: %s", ErrInvalidPackedRefs, n, line)
+			}
+			refs = append(refs, &packedRef{name: name, id: id})
+		}
+	}
+
+	sort.SliceStable(refs, func(i, j int) bool { return refs[i].name < refs[j].name })
+	return refs, nil
+}
+
+// writePackedRefs replaces packed-refs with refs through packed-refs.lock, the file is removed if refs is empty
+func (r *References) writePackedRefs(refs []*packedRef) error {
+	lock, err := r.lockPackedRefs()
+	if err != nil {
+		return err
+	}
+	return r.commitPackedRefs(lock, refs)
+}
+
+// lockPackedRefs creates packed-refs.lock, which is held until commitPackedRefs or removed
+func (r *References) lockPackedRefs() (string, error) {
+	lock := filepath.Join(r.root, packed_refs_file) + ".lock"
+	f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return "", errRefsLocked
+		}
+		return "", err
+	}
+	return lock, f.Close()
+}
+
+// commitPackedRefs writes refs into the lock taken by lockPackedRefs and renames it to packed-refs, the file is removed
+// if refs is empty. The lock is released in any case.
+func (r *References) commitPackedRefs(lock string, refs []*packedRef) error {
+	path := filepath.Join(r.root, packed_refs_file)
+	if len(refs) == 0 {
+		defer os.Remove(lock)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	sort.Slice(refs, func(i, j int) bool { return refs[i].name < refs[j].name })
+	buf := &bytes.Buffer{}
+	buf.WriteString(packed_refs_header + "\n")
+	for _, p := range refs {
+		fmt.Fprintf(buf, "%s %s\n", p.id, p.name)
+		if p.peeled != common.ZeroHash {
+			fmt.Fprintf(buf, "^%s\n", p.peeled)
+		}
+	}
+
+	if err := os.WriteFile(lock, buf.Bytes(), 0644); err != nil {
+		os.Remove(lock)
+		return err
+	}
+	return os.Rename(lock, path)
+}
+
+func (r *References) readPackedRef(name string) (common.Hash, error) {
+	p, err := r.findPackedRef(name)
+	if err != nil {
+		return common.ZeroHash, err
+	}
+	return p.id, nil
+}
+
+func (r *References) findPackedRef(name string) (*packedRef, error) {
+	refs, err := r.readPackedRefs()
+	if err != nil {
+		return nil, err
+	}
+
+	i := sort.Search(len(refs), func(i int) bool { return refs[i].name >= name })
+	if i < len(refs) && refs[i].name == name {
+		return refs[i], nil
+	}
+	return nil, ErrRefNotFound
+}
+
+// Peeled returns the object which the packed reference in name points to through annotated tags,
+// it is only known for packed references which are not overridden by loose ones.
+func (r *References) Peeled(name string) (common.Hash, bool) {
+	if _, err := os.Stat(filepath.Join(r.root, filepath.FromSlash(name))); err == nil {
+		return common.ZeroHash, false
+	}
+
+	p, err := r.findPackedRef(name)
+	if err != nil || p.peeled == common.ZeroHash {
+		return common.ZeroHash, false
+	}
+	return p.peeled, true
+}
+
+// PackRefs packs loose references into packed-refs, tags and references which are already packed by default,
+// or all references under refs/ with all. Annotated tags are peeled with objects in s.
+// Loose references which are packed are removed with prune.
+func (r *References) PackRefs(s ObjectStorer, all bool, prune bool) error {
+	packed, err := r.readPackedRefs()
+	if err != nil {
+		return err
+	}
+	refs := make(map[string]*packedRef)
+	for _, p := range packed {
+		refs[p.name] = p
+	}
+
+	loose := make(map[string]string)
+	refsRoot := filepath.Join(r.root, "refs")
+	err = filepath.WalkDir(refsRoot, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		id, err := readRefFile(path)
+		if err != nil {
+			// symbolic or broken references are left loose
+			return nil
+		}
+
+		rel, _ := filepath.Rel(r.root, path)
+		name := filepath.ToSlash(rel)
+		if _, wasPacked := refs[name]; !all && !wasPacked && !strings.HasPrefix(name, RefPrefix_Tags) {
+			return nil
+		}
+		refs[name] = &packedRef{name: name, id: id}
+		loose[name] = path
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	result := make([]*packedRef, 0, len(refs))
+	for _, p := range refs {
+		if p.peeled == common.ZeroHash || loose[p.name] != "" {
+			p.peeled = peelTag(s, p.id)
+		}
+		result = append(result, p)
+	}
+	if err := r.writePackedRefs(result); err != nil {
+		return err
+	}
+
+	if prune {
+		for name, path := range loose {
+			// keep the loose reference if it is updated meanwhile
+			if id, err := readRefFile(path); err == nil && id == refs[name].id {
+				os.Remove(path)
+				r.removeEmptyDirs(path)
+			}
+		}
+	}
+	return nil
+}
+
+// peelTag follows annotated tags from id, ZeroHash is returned if id is not a tag
+func peelTag(s ObjectStorer, id common.Hash) common.Hash {
+	peeled := common.ZeroHash
+	for depth := 0; depth < 10; depth++ {
+		g, err := s.Get(id)
+		if err != nil || g.Kind() != object.Kind_Tag {
+			break
+		}
+		id = object.GitObjectToTag(g).Object()
+		peeled = id
+	}
+	return peeled
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackedRefs(t *testing.T) {
+	ws, err := Init(io.Discard, t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs := ws.References()
+	repo := ws.Repository()
+	gitDir := repo.Path
+
+	sig := object.NewSignature("A U Thor", "author@example.com", time.Unix(1112911993, 0))
+	c := object.NewCommit(common.ZeroHash, common.ZeroHash, nil, sig, sig, "c1").ToGitObject()
+	repo.Put(c)
+	tag := object.NewTag(common.ZeroHash, c.Id(), object.Kind_Commit, "v1", sig, "one").ToGitObject()
+	repo.Put(tag)
+
+	// written by git pack-refs, with an unsorted entry
+	packed := "# pack-refs with: peeled fully-peeled sorted \n" +
+		c.Id().String() + " refs/heads/main\n" +
+		tag.Id().String() + " refs/tags/v1\n" +
+		"^" + c.Id().String() + "\n" +
+		c.Id().String() + " refs/heads/feature\n"
+	os.WriteFile(filepath.Join(gitDir, packed_refs_file), []byte(packed), 0644)
+
+	head, err := refs.HeadCommit()
+	assert.Nil(t, err)
+	assert.Equal(t, c.Id(), head)
+	id, err := refs.Resolve("v1")
+	assert.Nil(t, err)
+	assert.Equal(t, tag.Id(), id)
+	peeled, ok := refs.Peeled("refs/tags/v1")
+	assert.True(t, ok)
+	assert.Equal(t, c.Id(), peeled)
+	branches, _ := refs.Branches()
+	assert.Equal(t, []string{"feature", "main"}, branches)
+
+	// loose references override packed ones and are removed from both
+	assert.Nil(t, refs.WriteRef("refs/heads/feature", tag.Id()))
+	id, _ = refs.ReadRef("refs/heads/feature")
+	assert.Equal(t, tag.Id(), id)
+	assert.Nil(t, refs.DeleteRef("refs/heads/feature"))
+	_, err = refs.ReadRef("refs/heads/feature")
+	assert.Equal(t, ErrRefNotFound, err)
+
+	assert.Nil(t, refs.WriteRef("refs/tags/release/v2", tag.Id()))
+	assert.Nil(t, refs.WriteRef("refs/heads/topic", c.Id()))
+	assert.Nil(t, refs.PackRefs(repo, false, true))
+	assert.FileExists(t, filepath.Join(gitDir, "refs", "heads", "topic"))
+	assert.NoDirExists(t, filepath.Join(gitDir, "refs", "tags", "release"))
+
+	assert.Nil(t, refs.PackRefs(repo, true, true))
+	assert.NoFileExists(t, filepath.Join(gitDir, "refs", "heads", "topic"))
+	b, _ := os.ReadFile(filepath.Join(gitDir, packed_refs_file))
+	want := "# pack-refs with: peeled fully-peeled sorted \n" +
+		c.Id().String() + " refs/heads/main\n" +
+		c.Id().String() + " refs/heads/topic\n" +
+		tag.Id().String() + " refs/tags/release/v2\n" +
+		"^" + c.Id().String() + "\n" +
+		tag.Id().String() + " refs/tags/v1\n" +
+		"^" + c.Id().String() + "\n"
+	assert.Equal(t, want, string(b))
+}
@@ -0,0 +1,235 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core/internal/pack"
+	"github.com/izhujiang/gogit/core/internal/utils"
+	"github.com/izhujiang/gogit/core/object"
+)
+
+const (
+	Default_Pack_Window = pack.Default_Window
+	Default_Pack_Depth  = pack.Default_Depth
+)
+
+type RepackOption struct {
+	// Pack everything referenced into a single pack, instead of packing loose objects only
+	All bool
+	// After packing, remove the redundant packs and the loose objects which have been packed
+	Delete bool
+	// With All and Delete, objects in the removed packs which are not in the new pack are kept as loose objects
+	KeepUnreachable bool
+	// Size of the window and maximum depth used for delta compression
+	Window int
+	Depth  int
+}
+
+type RepackResult struct {
+	// path of the new packfile, empty if nothing is packed
+	Path   string
+	Total  int
+	Deltas int
+	// number of removed packfiles and loose objects
+	RemovedPacks   int
+	RemovedObjects int
+}
+
+// Repack packs objects reachable from roots into a new packfile, only repository stored in filesystem can be repacked.
+func (r *Repository) Repack(roots []common.Hash, option *RepackOption) (*RepackResult, error) {
+	fs, ok := r.ObjectStorer.(*FsObjectStorer)
+	if !ok {
+		return nil, errNotSupported
+	}
+
+	return fs.Repack(roots, option)
+}
+
+// PrunePacked removes loose objects which are already in packfiles, returns the number of removed objects
+func (r *Repository) PrunePacked() (int, error) {
+	fs, ok := r.ObjectStorer.(*FsObjectStorer)
+	if !ok {
+		return 0, errNotSupported
+	}
+
+	return fs.PrunePacked()
+}
+
+// Repack packs objects reachable from roots into a new packfile.
+func (s *FsObjectStorer) Repack(roots []common.Hash, option *RepackOption) (*RepackResult, error) {
+	objects, err := reachableObjects(s, roots)
+	if err != nil {
+		return nil, err
+	}
+
+	if !option.All {
+		unpacked := make([]*pack.ObjectToPack, 0, len(objects))
+		for _, o := range objects {
+			if s.findPack(o.Oid) == nil {
+				unpacked = append(unpacked, o)
+			}
+		}
+		objects = unpacked
+	}
+
+	result := &RepackResult{Total: len(objects)}
+	if len(objects) > 0 {
+		os.MkdirAll(s.PacksPath(), 0755)
+		wo := &pack.WriteOption{
+			Window: option.Window,
+			Depth:  option.Depth,
+		}
+		result.Path, result.Deltas, err = pack.WritePack(s.PacksPath(), objects, wo)
+		if err != nil {
+			return nil, err
+		}
+	}
+	s.closePacks()
+
+	if option.Delete {
+		if option.All && result.Path != "" {
+			result.RemovedPacks = s.removePacksExcept(result.Path, option.KeepUnreachable)
+		}
+		result.RemovedObjects, err = s.PrunePacked()
+	}
+
+	return result, err
+}
+
+// PrunePacked removes loose objects which are already in packfiles, returns the number of removed objects
+func (s *FsObjectStorer) PrunePacked() (int, error) {
+	packed := make([]common.Hash, 0)
+	err := s.ForEachLooseObject(func(oid common.Hash) error {
+		if s.findPack(oid) != nil {
+			packed = append(packed, oid)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, oid := range packed {
+		name := oid.String()
+		dir := filepath.Join(s.path, name[:2])
+		os.Remove(filepath.Join(dir, name[2:]))
+		// remove the directory if it is empty
+		os.Remove(dir)
+	}
+
+	return len(packed), nil
+}
+
+// remove packfiles other than the kept one, packs with .keep file are preserved. Objects of the removed packs which are
+// not in the kept one are written as loose objects first with loosen, and a pack is kept if that fails.
+func (s *FsObjectStorer) removePacksExcept(kept string, loosen bool) int {
+	idxs, _ := filepath.Glob(filepath.Join(s.PacksPath(), "pack-*.idx"))
+	var keptIndex *pack.Index
+	if loosen {
+		idx, err := pack.LoadIndex(strings.TrimSuffix(kept, ".pack") + ".idx")
+		if err != nil {
+			return 0
+		}
+		keptIndex = idx
+	}
+	removed := 0
+	for _, idxPath := range idxs {
+		base := strings.TrimSuffix(idxPath, ".idx")
+		if base+".pack" == kept {
+			continue
+		}
+		if _, err := os.Stat(base + ".keep"); err == nil {
+			continue
+		}
+		if loosen && s.loosenUnpacked(base+".pack", keptIndex) != nil {
+			continue
+		}
+
+		os.Remove(base + ".idx")
+		os.Remove(base + ".pack")
+		removed++
+	}
+
+	return removed
+}
+
+// loosenUnpacked writes objects of the packfile path which are not in kept as loose objects
+func (s *FsObjectStorer) loosenUnpacked(path string, kept *pack.Index) error {
+	p, err := pack.Open(path)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	return p.Index().ForEach(func(oid common.Hash, offset int64) error {
+		if kept.Contains(oid) || utils.FileExists(s.loosePath(oid)) {
+			return nil
+		}
+		g, err := p.Get(oid)
+		if err != nil {
+			return err
+		}
+		return s.putLoose(g)
+	})
+}
+
+// reachableObjects collects commits, trees, blobs and tags reachable from roots, along with their paths
+func reachableObjects(s ObjectStorer, roots []common.Hash) ([]*pack.ObjectToPack, error) {
+	type pending struct {
+		oid  common.Hash
+		path string
+	}
+
+	seen := make(map[common.Hash]bool)
+	objects := make([]*pack.ObjectToPack, 0, 1024)
+	queue := make([]pending, 0, len(roots))
+	for _, root := range roots {
+		queue = append(queue, pending{oid: root})
+	}
+
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if p.oid == common.ZeroHash || seen[p.oid] {
+			continue
+		}
+		seen[p.oid] = true
+
+		g, err := s.Get(p.oid)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, &pack.ObjectToPack{
+			Oid:     g.Id(),
+			Kind:    g.Kind(),
+			Content: []byte(g.Content()),
+			Path:    p.path,
+		})
+
+		switch g.Kind() {
+		case object.Kind_Commit:
+			c := object.GitObjectToCommit(g)
+			queue = append(queue, pending{oid: c.Tree()})
+			for _, parent := range c.Parents() {
+				queue = append(queue, pending{oid: parent})
+			}
+
+		case object.Kind_Tree:
+			t := object.GitObjectToTree(g)
+			t.ForEach(func(e *object.TreeEntry) error {
+				if e.Kind == object.Kind_Blob || e.Kind == object.Kind_Tree {
+					queue = append(queue, pending{oid: e.Oid, path: filepath.Join(p.path, e.Name)})
+				}
+				return nil
+			})
+
+		case object.Kind_Tag:
+			queue = append(queue, pending{oid: object.GitObjectToTag(g).Object()})
+		}
+	}
+
+	return objects, nil
+}
@@ -1,76 +1,418 @@
 package core
 
 import (
-	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core/object"
 )
 
 const (
 	ref_head string = "refs/heads/main"
+
+	RefPrefix_Heads   = "refs/heads/"
+	RefPrefix_Tags    = "refs/tags/"
+	RefPrefix_Remotes = "refs/remotes/"
+)
+
+var (
+	ErrRefNotFound    = errors.New("Reference not found.")
+	ErrInvalidRefName = errors.New("Invalid reference name.")
+	ErrNotSymbolicRef = errors.New("Reference is not a symbolic reference.")
+	ErrBranchExists   = errors.New("A branch with the name already exists.")
+	ErrBranchNotFound = errors.New("No branch with the name.")
 )
 
 type References struct {
-	root     string
-	headpath string
+	root string
+	// returns the committer who signs reflog entries and the mode of core.logAllRefUpdates, reflogs are not written if it is nil
+	reflogConfig func() (*object.Signature, int, error)
 }
 
+// Head returns the name of current branch, or "HEAD" if HEAD is detached
 func (r *References) Head() string {
-	hp := r.activeHead()
-	return filepath.Base(hp)
+	if branch := r.CurrentBranch(); branch != "" {
+		return branch
+	}
+	return "HEAD"
+}
+
+// LastCommit returns the commit which the current branch points to, ErrRefNotFound if the branch is unborn
+func (r *References) LastCommit() (common.Hash, error) {
+	return r.ReadRef("HEAD")
 }
-func (r *References) activeHead() string {
-	f, err := os.Open(r.headpath)
+
+// SaveCommit points the current branch, or HEAD if it is detached, to commit id
+func (r *References) SaveCommit(id common.Hash) error {
+	tx := r.NewTransaction()
+	tx.Update("HEAD", id, common.ZeroHash, false)
+	return tx.Commit()
+}
+
+// UpdateRef points name to newId after verifying it is at oldId, or it does not exist if oldId is ZeroHash.
+// Symbolic references such as HEAD are followed, and msg is recorded in reflogs.
+func (r *References) UpdateRef(name string, newId common.Hash, oldId common.Hash, msg string) error {
+	tx := r.NewTransaction()
+	tx.Queue(RefUpdate{Name: name, NewId: newId, HaveNew: true, OldId: oldId, HaveOld: true, Message: msg})
+	return tx.Commit()
+}
+
+type WalkRefFunc func(name string, id common.Hash) error
+
+// ForEach visits all references under refs/ in order, both loose and packed ones. Name of ref is the path relative to
+// the root of repository, such as refs/heads/main. Loose references override packed ones, and symbolic ones are skipped.
+func (r *References) ForEach(fn WalkRefFunc) error {
+	return r.forEach(fn, false)
+}
+
+// ForEachResolved visits references like ForEach, together with symbolic references under refs/ such as
+// refs/remotes/origin/HEAD, whose ids are those of the references they point to finally
+func (r *References) ForEachResolved(fn WalkRefFunc) error {
+	return r.forEach(fn, true)
+}
+
+func (r *References) forEach(fn WalkRefFunc, symbolic bool) error {
+	ids := make(map[string]common.Hash)
+	packed, err := r.readPackedRefs()
 	if err != nil {
-		return ref_head
+		return err
+	}
+	for _, p := range packed {
+		ids[p.name] = p.id
 	}
-	defer f.Close()
 
-	scanner := bufio.NewScanner(f)
-	scanner.Scan()
-	head := scanner.Text()
+	refsRoot := filepath.Join(r.root, "refs")
+	filepath.WalkDir(refsRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(r.root, path)
+		name := filepath.ToSlash(rel)
+		id, err := readRefFile(path)
+		if err != nil && symbolic {
+			id, err = r.ReadRef(name)
+		}
+		if err != nil {
+			return nil
+		}
+		ids[name] = id
+		return nil
+	})
 
-	_, head, found := strings.Cut(head, ":")
-	if found {
-		return filepath.Join(r.root, strings.Trim(head, " "))
-	} else {
-		return filepath.Join(r.root, head)
+	names := make([]string, 0, len(ids))
+	for name := range ids {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := fn(name, ids[name]); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// func (r *References) headpath() {
+// HeadCommit returns the commit HEAD points to, either by a branch or detached
+func (r *References) HeadCommit() (common.Hash, error) {
+	return r.ReadRef("HEAD")
+}
 
-// }
-func (r *References) LastCommit() (common.Hash, error) {
-	head := r.activeHead()
-	f, err := os.Open(head)
+func readRefFile(path string) (common.Hash, error) {
+	b, err := os.ReadFile(path)
 	if err != nil {
 		return common.ZeroHash, err
 	}
-	defer f.Close()
 
-	scanner := bufio.NewScanner(f)
-	scanner.Scan()
-	lastCommitText := scanner.Text()
-	lastCommitId, err := common.NewHash(lastCommitText)
+	return common.NewHash(strings.TrimSpace(string(b)))
+}
+
+// ReadRef returns the object id of the reference in name, such as HEAD or refs/tags/v1.0, symbolic references are followed.
+// The loose reference is read at first, then packed-refs.
+func (r *References) ReadRef(name string) (common.Hash, error) {
+	for depth := 0; depth < 5; depth++ {
+		b, err := os.ReadFile(filepath.Join(r.root, filepath.FromSlash(name)))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return r.readPackedRef(name)
+			}
+			return common.ZeroHash, err
+		}
+
+		content := strings.TrimSpace(string(b))
+		if strings.HasPrefix(content, "ref:") {
+			name = strings.TrimSpace(content[len("ref:"):])
+			continue
+		}
+		return common.NewHash(content)
+	}
+	return common.ZeroHash, ErrRefNotFound
+}
+
+// WriteRef points the reference in name to id, directories are created if needed
+func (r *References) WriteRef(name string, id common.Hash) error {
+	if !CheckRefName(name) {
+		return ErrInvalidRefName
+	}
+
+	tx := r.NewTransaction()
+	tx.Update(name, id, common.ZeroHash, false)
+	return tx.Commit()
+}
+
+// DeleteRef removes the reference in name from both loose references and packed-refs, along with directories which become empty
+func (r *References) DeleteRef(name string) error {
+	if _, err := os.Lstat(filepath.Join(r.root, filepath.FromSlash(name))); err != nil {
+		if _, err := r.findPackedRef(name); err != nil {
+			return err
+		}
+	}
+
+	tx := r.NewTransaction()
+	tx.Queue(RefUpdate{Name: name, HaveNew: true, NoDeref: true})
+	return tx.Commit()
+}
+
+// removeEmptyDirs removes parent directories of the loose reference in path, directories such as refs/heads and refs/tags are kept
+func (r *References) removeEmptyDirs(path string) {
+	refsRoot := filepath.Join(r.root, "refs")
+	for dir := filepath.Dir(path); len(dir) > len(refsRoot) && filepath.Dir(dir) != refsRoot; dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+}
+
+// Resolve finds the object id of a full object id or a reference name, which is looked up
+// as it is, then in refs/, refs/tags/, refs/heads/ and refs/remotes/ in the order like git.
+// <ref>@{n} and <ref>@{<date>} are resolved through the reflog of ref, the current branch if ref is empty.
+func (r *References) Resolve(name string) (common.Hash, error) {
+	if len(name) == 40 {
+		if id, err := common.NewHash(name); err == nil {
+			return id, nil
+		}
+	}
+	if ref, selector, ok := splitReflogSelector(name); ok {
+		return r.resolveReflog(ref, selector)
+	}
+
+	full, err := r.FullRefName(name)
 	if err != nil {
 		return common.ZeroHash, err
 	}
-	return lastCommitId, nil
+	return r.ReadRef(full)
 }
 
-func (r *References) SaveCommit(id common.Hash) error {
-	head := r.activeHead()
+// FullRefName returns the full name of the reference which name refers to, such as refs/heads/main for main.
+// name is looked up as it is, then in refs/, refs/tags/, refs/heads/ and refs/remotes/ in the order like git.
+func (r *References) FullRefName(name string) (string, error) {
+	if name == "" || name == "@" {
+		name = "HEAD"
+	}
+
+	for _, prefix := range []string{"", "refs/", RefPrefix_Tags, RefPrefix_Heads, RefPrefix_Remotes} {
+		full := prefix + name
+		if prefix == "" && !strings.HasPrefix(name, "refs/") && strings.ToUpper(name) != name {
+			continue
+		}
+		_, err := r.ReadRef(full)
+		if err == nil {
+			return full, nil
+		}
+		if err != ErrRefNotFound {
+			return "", err
+		}
+	}
+	return "", ErrRefNotFound
+}
+
+// CheckRefName reports whether name is a valid reference name by the rules of git check-ref-format
+func CheckRefName(name string) bool {
+	if name == "" || name == "@" || strings.HasSuffix(name, "/") || strings.HasSuffix(name, ".") ||
+		strings.Contains(name, "..") || strings.Contains(name, "@{") || strings.Contains(name, "//") {
+		return false
+	}
+
+	for _, c := range name {
+		if c < 0x20 || c == 0x7f || strings.ContainsRune(" ~^:?*[\\", c) {
+			return false
+		}
+	}
+	for _, component := range strings.Split(name, "/") {
+		if component == "" || strings.HasPrefix(component, ".") || strings.HasSuffix(component, ".lock") {
+			return false
+		}
+	}
+	return true
+}
+
+// SymbolicRef returns the reference which the symbolic reference in name points to, such as refs/heads/main for HEAD
+func (r *References) SymbolicRef(name string) (string, error) {
+	b, err := os.ReadFile(filepath.Join(r.root, filepath.FromSlash(name)))
+	if err != nil {
+		return "", err
+	}
+
+	content := strings.TrimSpace(string(b))
+	if !strings.HasPrefix(content, "ref:") {
+		return "", ErrNotSymbolicRef
+	}
+	return strings.TrimSpace(content[len("ref:"):]), nil
+}
 
-	f, err := os.OpenFile(head, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+// WriteSymbolicRef points the symbolic reference in name to target, such as HEAD to refs/heads/main
+func (r *References) WriteSymbolicRef(name string, target string) error {
+	if !CheckRefName(target) {
+		return ErrInvalidRefName
+	}
+
+	path := filepath.Join(r.root, filepath.FromSlash(name))
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
 	if err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("%w unable to create '%s.lock': File exists.", ErrRefLocked, path)
+		}
 		return err
 	}
-	defer f.Close()
+	_, err = f.WriteString("ref: " + target + "\n")
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path + ".lock")
+		return err
+	}
+	return os.Rename(path+".lock", path)
+}
+
+// SwitchHead points HEAD to the branch refName such as refs/heads/main, or detaches HEAD at id if refName is empty.
+// The move from the commit HEAD was at to id is recorded in the reflog of HEAD with msg.
+func (r *References) SwitchHead(refName string, id common.Hash, msg string) error {
+	if refName == "" {
+		// like git, nothing is logged if the detached HEAD doesn't move
+		if _, err := r.SymbolicRef("HEAD"); err != nil {
+			if old, err := r.ReadRef("HEAD"); err == nil && old == id {
+				return nil
+			}
+		}
+		tx := r.NewTransaction()
+		tx.Queue(RefUpdate{Name: "HEAD", NewId: id, HaveNew: true, NoDeref: true, Message: msg})
+		return tx.Commit()
+	}
+
+	old, err := r.ReadRef("HEAD")
+	if err != nil && err != ErrRefNotFound {
+		return err
+	}
+	if err := r.WriteSymbolicRef("HEAD", refName); err != nil {
+		return err
+	}
+	u := &RefUpdate{Name: "HEAD", NewId: id, HaveNew: true, Message: msg, target: "HEAD", current: old}
+	return r.logUpdates([]*RefUpdate{u}, "HEAD")
+}
+
+// CurrentBranch returns the short name of the branch HEAD points to, empty if HEAD is detached
+func (r *References) CurrentBranch() string {
+	target, err := r.SymbolicRef("HEAD")
+	if err != nil || !strings.HasPrefix(target, RefPrefix_Heads) {
+		return ""
+	}
+	return target[len(RefPrefix_Heads):]
+}
+
+// Branches returns short names of local branches in order
+func (r *References) Branches() ([]string, error) {
+	return r.shortNames(RefPrefix_Heads)
+}
+
+// RemoteBranches returns names of remote-tracking branches in order, such as origin/main
+func (r *References) RemoteBranches() ([]string, error) {
+	return r.shortNames(RefPrefix_Remotes)
+}
+
+func (r *References) shortNames(prefix string) ([]string, error) {
+	names := make([]string, 0)
+	err := r.ForEach(func(name string, id common.Hash) error {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name[len(prefix):])
+		}
+		return nil
+	})
+	sort.Strings(names)
+	return names, err
+}
+
+// CreateBranch creates the branch name at id, an existing branch is overwritten only with force. msg is recorded in reflogs.
+func (r *References) CreateBranch(name string, id common.Hash, force bool, msg string) error {
+	refName := RefPrefix_Heads + name
+	if !CheckRefName(refName) {
+		return ErrInvalidRefName
+	}
+
+	tx := r.NewTransaction()
+	tx.Queue(RefUpdate{Name: refName, NewId: id, HaveNew: true, HaveOld: !force, Message: msg})
+	err := tx.Commit()
+	if errors.Is(err, ErrRefStale) {
+		return ErrBranchExists
+	}
+	return err
+}
 
-	_, err = f.WriteString(id.String())
+// DeleteBranch removes the branch name
+func (r *References) DeleteBranch(name string) error {
+	err := r.DeleteRef(RefPrefix_Heads + name)
+	if err == ErrRefNotFound {
+		return ErrBranchNotFound
+	}
 	return err
 }
+
+// RenameBranch renames branch oldName to newName along with its reflog, HEAD follows the branch if it is the current one
+func (r *References) RenameBranch(oldName string, newName string, force bool) error {
+	oldRef, newRef := RefPrefix_Heads+oldName, RefPrefix_Heads+newName
+	id, err := r.ReadRef(oldRef)
+	if err != nil {
+		return ErrBranchNotFound
+	}
+	if oldName == newName {
+		return nil
+	}
+	if !CheckRefName(newRef) {
+		return ErrInvalidRefName
+	}
+	if _, err := r.ReadRef(newRef); err == nil && !force {
+		return ErrBranchExists
+	}
+
+	current := r.CurrentBranch() == oldName
+	if err := r.DeleteRef(newRef); err != nil && err != ErrRefNotFound {
+		return err
+	}
+	if err := r.renameReflog(oldRef, newRef); err != nil {
+		return err
+	}
+	// HEAD is moved at first, so that the rename is recorded in its reflog too
+	if current {
+		if err := r.WriteSymbolicRef("HEAD", newRef); err != nil {
+			return err
+		}
+	}
+
+	tx := r.NewTransaction()
+	tx.Queue(RefUpdate{Name: oldRef, HaveNew: true, NoDeref: true})
+	tx.Queue(RefUpdate{Name: newRef, NewId: id, HaveNew: true, Message: fmt.Sprintf("Branch: renamed %s to %s", oldRef, newRef)})
+	if err := tx.Commit(); err != nil {
+		if current {
+			r.WriteSymbolicRef("HEAD", oldRef)
+		}
+		r.renameReflog(newRef, oldRef)
+		return err
+	}
+	return nil
+}
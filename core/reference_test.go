@@ -1,13 +1,92 @@
 package core
 
 import (
+	"io"
+	"path/filepath"
 	"testing"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestHead(t *testing.T) {
-	refs := GetReferencs()
+	ws, err := Init(io.Discard, t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refs := ws.References()
 	if refs.Head() != "main" {
 		t.Error("head of refs is not main")
 
 	}
 }
+
+func TestRefs(t *testing.T) {
+	ws, err := Init(io.Discard, t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs := ws.References()
+	id, _ := common.NewHash("06a73c5830948dd1bc99b66c34f8b060134e601f")
+
+	assert.Nil(t, refs.WriteRef("refs/tags/release/v1", id))
+	assert.Nil(t, refs.WriteRef("refs/heads/main", id))
+	for _, name := range []string{"release/v1", "tags/release/v1", "refs/tags/release/v1", "main", "HEAD", "@", id.String()} {
+		got, err := refs.Resolve(name)
+		assert.Nil(t, err, name)
+		assert.Equal(t, id, got, name)
+	}
+	_, err = refs.Resolve("config")
+	assert.Equal(t, ErrRefNotFound, err)
+
+	assert.Nil(t, refs.DeleteRef("refs/tags/release/v1"))
+	assert.Equal(t, ErrRefNotFound, refs.DeleteRef("refs/tags/release/v1"))
+	assert.NoDirExists(t, filepath.Join(ws.Repository().Path, "refs", "tags", "release"))
+	assert.DirExists(t, filepath.Join(ws.Repository().Path, "refs", "tags"))
+
+	for _, name := range []string{"refs/heads/a..b", "refs/heads/a.lock", "refs/heads/.a", "refs/heads/a b", "refs/heads/a~1", "refs/heads/a@{1}", "refs/heads/a/", "refs//a", "@"} {
+		assert.False(t, CheckRefName(name), name)
+	}
+	assert.True(t, CheckRefName("refs/heads/feature/a-1.2"))
+	assert.Equal(t, ErrInvalidRefName, refs.WriteRef("refs/heads/a..b", id))
+}
+
+func TestBranches(t *testing.T) {
+	ws, err := Init(io.Discard, t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs := ws.References()
+	repo := ws.Repository()
+
+	// c1 <- c2 <- c3 on main, c1 <- c4 on topic
+	commit := newTestHistory(t, repo).commit
+	c1 := commit("c1")
+	c2 := commit("c2", c1)
+	c3 := commit("c3", c2)
+	c4 := commit("c4", c1)
+
+	assert.Nil(t, refs.SaveCommit(c3))
+	assert.Equal(t, "main", refs.CurrentBranch())
+	assert.Nil(t, refs.CreateBranch("topic", c4, false, ""))
+	assert.Equal(t, ErrBranchExists, refs.CreateBranch("topic", c3, false, ""))
+	assert.Nil(t, refs.CreateBranch("feature/x", c2, false, ""))
+	branches, _ := refs.Branches()
+	assert.Equal(t, []string{"feature/x", "main", "topic"}, branches)
+
+	assert.Nil(t, refs.RenameBranch("main", "trunk", false))
+	assert.Equal(t, "trunk", refs.CurrentBranch())
+	assert.Equal(t, ErrBranchExists, refs.RenameBranch("trunk", "topic", false))
+	assert.Nil(t, refs.DeleteBranch("feature/x"))
+	assert.Equal(t, ErrBranchNotFound, refs.DeleteBranch("feature/x"))
+
+	merged, err := repo.IsAncestor(c2, c3)
+	assert.Nil(t, err)
+	assert.True(t, merged)
+	merged, _ = repo.IsAncestor(c4, c3)
+	assert.False(t, merged)
+	ahead, behind, err := repo.AheadBehind(c4, c3)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2}, []int{ahead, behind})
+}
@@ -0,0 +1,320 @@
+package core
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core/object"
+)
+
+const (
+	logs_dir = "logs"
+	// the prefix of messages in the reflog of HEAD recording switches of branches
+	checkout_reflog_prefix = "checkout: moving from "
+)
+
+// values of core.logAllRefUpdates
+const (
+	// only existing reflogs are appended
+	reflog_existing = iota
+	// reflogs of HEAD, branches, remote-tracking branches and notes are created
+	reflog_auto
+	// reflogs of all references are created
+	reflog_always
+)
+
+var (
+	ErrReflogNotFound   = errors.New("Reference has no reflog.")
+	ErrInvalidReflogRef = errors.New("Invalid reflog selector.")
+
+	errInvalidReflogEntry = errors.New("Invalid reflog entry.")
+)
+
+// ReflogEntry is a line of the reflog of a reference, which records a change from Old to New
+type ReflogEntry struct {
+	Old       common.Hash
+	New       common.Hash
+	Committer *object.Signature
+	Message   string
+}
+
+// String returns the entry as a line in logs/<ref>, without the trailing newline
+func (e *ReflogEntry) String() string {
+	line := e.Old.String() + " " + e.New.String() + " " + e.Committer.String()
+	if e.Message != "" {
+		line += "\t" + e.Message
+	}
+	return line
+}
+
+func parseReflogEntry(line string) (*ReflogEntry, error) {
+	if len(line) < 83 || line[40] != ' ' || line[81] != ' ' {
+		return nil, errInvalidReflogEntry
+	}
+	oldId, err1 := common.NewHash(line[:40])
+	newId, err2 := common.NewHash(line[41:81])
+	if err1 != nil || err2 != nil {
+		return nil, errInvalidReflogEntry
+	}
+
+	ident, message, _ := strings.Cut(line[82:], "\t")
+	committer, err := object.ParseSignature(ident)
+	if err != nil {
+		return nil, errInvalidReflogEntry
+	}
+	return &ReflogEntry{Old: oldId, New: newId, Committer: committer, Message: message}, nil
+}
+
+// reflogMessage squeezes whitespaces and newlines in msg into single spaces like git
+func reflogMessage(msg string) string {
+	return strings.Join(strings.Fields(msg), " ")
+}
+
+func (r *References) reflogPath(name string) string {
+	return filepath.Join(r.root, logs_dir, filepath.FromSlash(name))
+}
+
+// HasReflog reports whether the reference in name, such as HEAD or refs/heads/main, has a reflog
+func (r *References) HasReflog(name string) bool {
+	info, err := os.Stat(r.reflogPath(name))
+	return err == nil && info.Mode().IsRegular()
+}
+
+// Reflog returns entries in the reflog of the reference in name from the oldest to the newest, malformed lines are skipped.
+// ErrReflogNotFound is returned if the reference has no reflog.
+func (r *References) Reflog(name string) ([]*ReflogEntry, error) {
+	f, err := os.Open(r.reflogPath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrReflogNotFound
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := make([]*ReflogEntry, 0)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 4096), 1<<20)
+	for scanner.Scan() {
+		if e, err := parseReflogEntry(scanner.Text()); err == nil {
+			entries = append(entries, e)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// WriteReflog replaces the reflog of the reference in name with entries, through logs/<ref>.lock
+func (r *References) WriteReflog(name string, entries []*ReflogEntry) error {
+	path := r.reflogPath(name)
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("%w unable to create '%s.lock': File exists.", ErrRefLocked, path)
+		}
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	for _, e := range entries {
+		w.WriteString(e.String() + "\n")
+	}
+	err = w.Flush()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path + ".lock")
+		return err
+	}
+	return os.Rename(path+".lock", path)
+}
+
+// DeleteReflog removes the reflog of the reference in name, it is not an error if there is none
+func (r *References) DeleteReflog(name string) error {
+	path := r.reflogPath(name)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	logsRoot := filepath.Join(r.root, logs_dir, "refs")
+	for dir := filepath.Dir(path); len(dir) > len(logsRoot) && filepath.Dir(dir) != logsRoot; dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// renameReflog moves the reflog of oldName to newName, which replaces the existing one
+func (r *References) renameReflog(oldName string, newName string) error {
+	if !r.HasReflog(oldName) {
+		return nil
+	}
+	newPath := r.reflogPath(newName)
+	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(r.reflogPath(oldName), newPath); err != nil {
+		return err
+	}
+	return r.DeleteReflog(oldName)
+}
+
+// ReflogNames returns names of references which have reflogs in order, such as HEAD and refs/heads/main
+func (r *References) ReflogNames() ([]string, error) {
+	logsRoot := filepath.Join(r.root, logs_dir)
+	names := make([]string, 0)
+	err := filepath.WalkDir(logsRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasSuffix(path, ".lock") {
+			return nil
+		}
+		name, _ := filepath.Rel(logsRoot, path)
+		names = append(names, filepath.ToSlash(name))
+		return nil
+	})
+	sort.Strings(names)
+	return names, err
+}
+
+// appendReflog appends an entry to the reflog of the reference in name, which is created only if mode allows
+func (r *References) appendReflog(name string, e *ReflogEntry, mode int) error {
+	path := r.reflogPath(name)
+	flag := os.O_APPEND | os.O_WRONLY
+	if mode == reflog_always || (mode == reflog_auto && autoCreateReflog(name)) {
+		flag |= os.O_CREATE
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(path, flag, 0644)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	_, err = f.WriteString(e.String() + "\n")
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func autoCreateReflog(name string) bool {
+	return name == "HEAD" || strings.HasPrefix(name, RefPrefix_Heads) ||
+		strings.HasPrefix(name, RefPrefix_Remotes) || strings.HasPrefix(name, "refs/notes/")
+}
+
+// splitReflogSelector splits name like main@{1} or HEAD@{yesterday} into the reference and the selector inside braces
+func splitReflogSelector(name string) (string, string, bool) {
+	idx := strings.LastIndex(name, "@{")
+	if idx < 0 || !strings.HasSuffix(name, "}") {
+		return name, "", false
+	}
+	return name[:idx], name[idx+2 : len(name)-1], true
+}
+
+// ReflogRef returns the full name of the reference whose reflog is selected by ref in <ref>@{...}. HEAD is itself,
+// and an empty ref is the current branch, or HEAD if it is detached.
+func (r *References) ReflogRef(ref string) (string, error) {
+	switch ref {
+	case "HEAD":
+		return ref, nil
+	case "", "@":
+		if branch := r.CurrentBranch(); branch != "" {
+			return RefPrefix_Heads + branch, nil
+		}
+		return "HEAD", nil
+	}
+	return r.FullRefName(ref)
+}
+
+// ReflogIndex parses the selector n in <ref>@{n}, -1 is returned if it is not a number
+func ReflogIndex(selector string) int {
+	n, err := strconv.Atoi(selector)
+	if err != nil || n < 0 || strings.HasPrefix(selector, "+") {
+		return -1
+	}
+	return n
+}
+
+// resolveReflog resolves <ref>@{n}, the value of ref n changes before, or <ref>@{<date>}, the value of ref at the date
+func (r *References) resolveReflog(ref string, selector string) (common.Hash, error) {
+	name, err := r.ReflogRef(ref)
+	if err != nil {
+		return common.ZeroHash, err
+	}
+	entries, err := r.Reflog(name)
+	if err != nil {
+		return common.ZeroHash, err
+	}
+
+	if n := ReflogIndex(selector); n >= 0 {
+		if n >= len(entries) {
+			return common.ZeroHash, fmt.Errorf("%w log for '%s' only has %d entries", ErrInvalidReflogRef, ref, len(entries))
+		}
+		return entries[len(entries)-1-n].New, nil
+	}
+
+	when, err := common.ParseApproxidate(selector, time.Now())
+	if err != nil {
+		return common.ZeroHash, fmt.Errorf("%w %s@{%s}", ErrInvalidReflogRef, ref, selector)
+	}
+	if len(entries) == 0 {
+		return common.ZeroHash, fmt.Errorf("%w log for '%s' is empty", ErrInvalidReflogRef, ref)
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !entries[i].Committer.When.After(when) {
+			return entries[i].New, nil
+		}
+	}
+
+	// the date is before the log begins, it is the value before the oldest change
+	if oldest := entries[0]; oldest.Old != common.ZeroHash {
+		return oldest.Old, nil
+	}
+	return entries[0].New, nil
+}
+
+// PreviousBranch returns the branch, or the detached commit, checked out before the current one n switches ago
+// by "checkout: moving from <old> to <new>" entries in the reflog of HEAD, which is selected by @{-n}
+func (r *References) PreviousBranch(n int) (string, error) {
+	if n <= 0 {
+		return "", fmt.Errorf("%w @{-%d}", ErrInvalidReflogRef, n)
+	}
+	entries, err := r.Reflog("HEAD")
+	if err != nil && err != ErrReflogNotFound {
+		return "", err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		msg := entries[i].Message
+		if !strings.HasPrefix(msg, checkout_reflog_prefix) {
+			continue
+		}
+		from := msg[len(checkout_reflog_prefix):]
+		to := strings.LastIndex(from, " to ")
+		if to < 0 {
+			continue
+		}
+		if n--; n == 0 {
+			return from[:to], nil
+		}
+	}
+	return "", fmt.Errorf("%w not enough branches were checked out", ErrInvalidReflogRef)
+}
@@ -0,0 +1,66 @@
+package core
+
+import (
+	"io"
+	"testing"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReflog(t *testing.T) {
+	t.Setenv("GIT_COMMITTER_NAME", "C O Mitter")
+	t.Setenv("GIT_COMMITTER_EMAIL", "committer@example.com")
+	t.Setenv("GIT_COMMITTER_DATE", "1112911993 +0200")
+	ws, err := Init(io.Discard, t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs := ws.References()
+
+	c1 := common.HashObject("blob", []byte("1"))
+	c2 := common.HashObject("blob", []byte("2"))
+	assert.Nil(t, refs.UpdateRef("HEAD", c1, common.ZeroHash, "commit (initial): first"))
+	assert.Nil(t, refs.UpdateRef("refs/heads/main", c2, c1, "commit:  second\nline"))
+	assert.Nil(t, refs.WriteRef("refs/tags/v1", c1))
+
+	entries, err := refs.Reflog("refs/heads/main")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, common.ZeroHash.String()+" "+c1.String()+" C O Mitter <committer@example.com> 1112911993 +0200\tcommit (initial): first",
+		entries[0].String())
+	assert.Equal(t, "commit: second line", entries[1].Message)
+	head, _ := refs.Reflog("HEAD")
+	assert.Equal(t, entries, head)
+	assert.False(t, refs.HasReflog("refs/tags/v1"))
+
+	for selector, want := range map[string]common.Hash{
+		"HEAD@{0}":          c2,
+		"@{1}":              c1,
+		"main@{1}":          c1,
+		"main@{2005-04-08}": c2,
+		"main@{2005-04-01}": c1,
+	} {
+		id, err := refs.Resolve(selector)
+		assert.Nil(t, err, selector)
+		assert.Equal(t, want, id, selector)
+	}
+	_, err = refs.Resolve("main@{2}")
+	assert.ErrorIs(t, err, ErrInvalidReflogRef)
+
+	// the reflog moves with the branch, and is removed with it
+	assert.Nil(t, refs.RenameBranch("main", "trunk", false))
+	assert.False(t, refs.HasReflog("refs/heads/main"))
+	entries, _ = refs.Reflog("refs/heads/trunk")
+	assert.Equal(t, 3, len(entries))
+	assert.Equal(t, "Branch: renamed refs/heads/main to refs/heads/trunk", entries[2].Message)
+	head, _ = refs.Reflog("HEAD")
+	assert.Equal(t, 3, len(head))
+
+	assert.Nil(t, refs.CreateBranch("topic", c1, false, "branch: Created from HEAD"))
+	assert.True(t, refs.HasReflog("refs/heads/topic"))
+	assert.Nil(t, refs.DeleteBranch("topic"))
+	assert.False(t, refs.HasReflog("refs/heads/topic"))
+	names, _ := refs.ReflogNames()
+	assert.Equal(t, []string{"HEAD", "refs/heads/trunk"}, names)
+}
@@ -1,86 +1,127 @@
 package core
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/izhujiang/gogit/common"
-	"github.com/izhujiang/gogit/core/internal/utils"
 	"github.com/izhujiang/gogit/core/object"
 )
 
 var (
 	errObjectNotExists     = errors.New("Object does not exist.")
 	errRepositoryNotExists = errors.New("Git repository does not exist, which should be initialized.")
+	errNotSupported        = errors.New("Operation is not supported by the object storage.")
+	errNoWorkTree          = errors.New("This operation must be run in a work tree.")
+	errNotACommit          = errors.New("Object is not a commit.")
 )
 
+// Repository is a git repository, objects are stored by its ObjectStorer
 type Repository struct {
 	Name string
-	// relative to the root of workspace
+	// path of the git directory
 	Path string
+
+	ObjectStorer
 }
 
-// Init Git Repository in the path. Default, root == "."
-func (r *Repository) InitRepository(w io.Writer, root string) error {
-	if root == "" {
-		root = "./"
-	} else {
-		os.MkdirAll(root, 0755)
+// NewRepository returns a Repository in path, whose objects are stored in path/objects
+func NewRepository(name string, path string) *Repository {
+	return &Repository{
+		Name:         name,
+		Path:         path,
+		ObjectStorer: NewFsObjectStorer(filepath.Join(path, "objects")),
 	}
+}
 
-	setupRepositoryFramework(w, filepath.Join(root, r.Path))
+// NewMemoryRepository returns a Repository whose objects are kept in memory
+func NewMemoryRepository() *Repository {
+	return &Repository{
+		ObjectStorer: NewMemoryObjectStorer(),
+	}
+}
 
-	return nil
+func (r *Repository) GetAsBlob(oid common.Hash) (*object.Blob, error) {
+	return GetAsBlob(r.ObjectStorer, oid)
 }
 
-func (r *Repository) Put(g *object.GitObject) error {
-	soid := g.Id().String()
+func (r *Repository) GetAsTree(oid common.Hash) (*object.Tree, error) {
+	return GetAsTree(r.ObjectStorer, oid)
+}
 
-	dir := filepath.Join(r.ObjectsPath(), soid[:2])
-	path := filepath.Join(dir, soid[2:])
+func (r *Repository) GetAsCommit(oid common.Hash) (*object.Commit, error) {
+	return GetAsCommit(r.ObjectStorer, oid)
+}
 
-	if g == nil || utils.FileExists(path) {
-		return nil
-	}
+// PeelToCommit returns the commit which oid points to, annotated tags are followed
+func (r *Repository) PeelToCommit(oid common.Hash) (*object.Commit, error) {
+	for {
+		g, err := r.Get(oid)
+		if err != nil {
+			return nil, err
+		}
 
-	os.MkdirAll(dir, 0755)
-	f, err := os.Create(path)
-	if err != nil {
-		return err
+		switch g.Kind() {
+		case object.Kind_Commit:
+			return object.GitObjectToCommit(g), nil
+		case object.Kind_Tag:
+			oid = object.GitObjectToTag(g).Object()
+		default:
+			return nil, fmt.Errorf("%w %s is a %s", errNotACommit, oid, g.Kind())
+		}
 	}
-	defer f.Close()
+}
 
-	err = g.Save(f)
-	return err
+// Load multiple Trees led by rootId from repository
+func (r *Repository) LoadTrees(rootId common.Hash) (*object.Tree, error) {
+	return LoadTrees(r.ObjectStorer, rootId)
 }
 
-// Get GitObject from Repository, return nil and error if oid is invalide
-func (r *Repository) Get(oid common.Hash) (*object.GitObject, error) {
-	path, err := r.checkObjectExists(oid)
-	if err != nil {
-		return nil, err
+func (r *Repository) Dump(oid common.Hash, w io.Writer) error {
+	if fs, ok := r.ObjectStorer.(*FsObjectStorer); ok {
+		return fs.Dump(oid, w)
 	}
 
-	f, err := os.Open(path)
+	g, err := r.Get(oid)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	return dumpGitObject(g, w)
+}
+
+// FindObjects returns ids of objects which begin with the hexadecimal prefix, which has 2 characters at least
+func (r *Repository) FindObjects(prefix string) []common.Hash {
+	prefix = strings.ToLower(prefix)
+	if fs, ok := r.ObjectStorer.(*FsObjectStorer); ok {
+		return fs.FindPrefix(prefix)
 	}
-	defer f.Close()
 
-	g, err := object.Load(f, oid)
-	return g, err
+	found := make([]common.Hash, 0)
+	r.Iter(object.Kind_Unknow, func(g *object.GitObject) error {
+		if strings.HasPrefix(g.Id().String(), prefix) {
+			found = append(found, g.Id())
+		}
+		return nil
+	})
+	return found
 }
 
-func (r *Repository) GetAsBlob(oid common.Hash) (*object.Blob, error) {
+func (r *Repository) ObjectsPath() string {
+	return filepath.Join(r.Path, "objects")
+}
+
+func GetAsBlob(s ObjectStorer, oid common.Hash) (*object.Blob, error) {
 	if oid == common.ZeroHash {
 		return nil, errObjectNotExists
 	}
 
-	g, err := r.Get(oid)
+	g, err := s.Get(oid)
 	if err != nil {
 		return nil, err
 	}
@@ -88,12 +129,12 @@ func (r *Repository) GetAsBlob(oid common.Hash) (*object.Blob, error) {
 	return b, nil
 }
 
-func (r *Repository) GetAsTree(oid common.Hash) (*object.Tree, error) {
+func GetAsTree(s ObjectStorer, oid common.Hash) (*object.Tree, error) {
 	if oid == common.ZeroHash {
 		return nil, errObjectNotExists
 	}
 
-	g, err := r.Get(oid)
+	g, err := s.Get(oid)
 	if err != nil {
 		return nil, err
 	}
@@ -101,9 +142,24 @@ func (r *Repository) GetAsTree(oid common.Hash) (*object.Tree, error) {
 	return t, nil
 }
 
-// Load multiple Trees led by rootId from repository
-func (r *Repository) LoadTrees(rootId common.Hash) (*object.Tree, error) {
-	root, err := r.GetAsTree(rootId)
+func GetAsCommit(s ObjectStorer, oid common.Hash) (*object.Commit, error) {
+	if oid == common.ZeroHash {
+		return nil, errObjectNotExists
+	}
+
+	g, err := s.Get(oid)
+	if err != nil {
+		return nil, err
+	}
+	if g.Kind() != object.Kind_Commit {
+		return nil, fmt.Errorf("%w %s is a %s", errNotACommit, oid, g.Kind())
+	}
+	return object.GitObjectToCommit(g), nil
+}
+
+// Load multiple Trees led by rootId from storage
+func LoadTrees(s ObjectStorer, rootId common.Hash) (*object.Tree, error) {
+	root, err := GetAsTree(s, rootId)
 	if err != nil {
 		return nil, err
 	}
@@ -118,7 +174,7 @@ func (r *Repository) LoadTrees(rootId common.Hash) (*object.Tree, error) {
 
 		t.ForEach(func(e *object.TreeEntry) error {
 			if e.Kind == object.Kind_Tree {
-				sub_t, err := r.GetAsTree(e.Oid)
+				sub_t, err := GetAsTree(s, e.Oid)
 				if err == nil {
 					e.Pointer = sub_t
 					tq.Enqueue(sub_t)
@@ -132,42 +188,19 @@ func (r *Repository) LoadTrees(rootId common.Hash) (*object.Tree, error) {
 	return root, nil
 }
 
-func (r *Repository) Dump(oid common.Hash, w io.Writer) error {
-	path, err := r.checkObjectExists(oid)
-	if err != nil {
+// dump object as if it were a loose object
+func dumpGitObject(g *object.GitObject, w io.Writer) error {
+	buf := &bytes.Buffer{}
+	if err := g.Save(buf); err != nil {
 		return err
 	}
-
-	f, err := os.Open(path)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer f.Close()
-
-	object.DumpGitObject(f, w)
-	return err
-}
-
-func (r *Repository) checkObjectExists(oid common.Hash) (string, error) {
-	name := oid.String()
-	dir := filepath.Join(r.ObjectsPath(), name[:2])
-	path := filepath.Join(dir, name[2:])
-
-	if !utils.FileExists(path) {
-		return "", errObjectNotExists
-	}
-
-	return path, nil
-}
-
-func (r *Repository) ObjectsPath() string {
-	return filepath.Join(r.Path, "objects")
+	object.DumpGitObject(buf, w)
+	return nil
 }
 
 // --------------------------------------------------------------------------
-func setupRepositoryFramework(w io.Writer, path string) {
-	_, err := os.Stat(path)
-	if os.IsNotExist(err) {
+func setupRepositoryFramework(w io.Writer, path string, bare bool) {
+	if !isGitDir(path) {
 		os.MkdirAll(path, 0755)
 
 		os.Mkdir(filepath.Join(path, "hooks"), 0755)
@@ -182,13 +215,14 @@ func setupRepositoryFramework(w io.Writer, path string) {
 		head := "ref: refs/heads/main"
 		os.WriteFile(filepath.Join(path, "HEAD"), []byte(head), 0644)
 
-		config := `[core]
+		config := fmt.Sprintf(`[core]
 	repositoryformatversion = 0
 	filemode = true
-	bare = false
-	logallrefupdates = true
+	bare = %t
+	logallrefupdates = %t
 	ignorecase = true
-	precomposeunicode = true`
+	precomposeunicode = true
+`, bare, !bare)
 		os.WriteFile(filepath.Join(path, "config"), []byte(config), 0644)
 
 		desc := "Unnamed repository; edit this file 'description' to name the repository."
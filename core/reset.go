@@ -0,0 +1,65 @@
+package core
+
+import (
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core/internal/index"
+)
+
+// ResetIndex sets index entries of files matching paths, which are relative to the root of the work tree, to those
+// in the tree treeId, the empty tree if it is ZeroHash. All entries are reset if paths are empty. Files not in the tree
+// are removed from the index, and stat data of entries which stay the same is kept.
+func (ws *Workspace) ResetIndex(treeId common.Hash, paths []string) error {
+	sa := ws.StagingArea()
+	sa.Load()
+	old := make(map[string]*index.IndexEntry)
+	sa.Foreach(func(e *index.IndexEntry) {
+		if e.Stage() == 0 {
+			old[e.Path()] = e
+		}
+	})
+	unchanged := func(e *index.IndexEntry, oid common.Hash, mode common.FileMode) bool {
+		return e != nil && e.Oid() == oid && normalizeFileMode(e.Mode()) == mode
+	}
+
+	if len(paths) == 0 {
+		sa.Reset()
+		if treeId != common.ZeroHash {
+			if err := sa.ReadTree(ws.repository, treeId, "", true); err != nil {
+				return err
+			}
+		}
+		sa.Foreach(func(e *index.IndexEntry) {
+			if o := old[e.Path()]; unchanged(o, e.Oid(), e.Mode()) {
+				*e = *o
+			}
+		})
+		return sa.Save()
+	}
+
+	files, err := ws.repository.treeFileMap(treeId)
+	if err != nil {
+		return err
+	}
+	matched := make([]string, 0)
+	sa.Foreach(func(e *index.IndexEntry) {
+		if MatchPathspec(e.Path(), paths) {
+			matched = append(matched, e.Path())
+		}
+	})
+	for _, p := range matched {
+		sa.Delete(p)
+	}
+	for _, p := range sortedKeys(files) {
+		f := files[p]
+		if !MatchPathspec(p, paths) {
+			continue
+		}
+		if o := old[p]; unchanged(o, f.Oid, f.Mode) {
+			sa.Put(o)
+		} else {
+			sa.Put(index.NewIndexEntry(f.Oid, f.Mode, p))
+		}
+	}
+	sa.Sort()
+	return sa.Save()
+}
@@ -0,0 +1,46 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResetIndex(t *testing.T) {
+	ws := newTestWorkspace(t)
+	blob, indexed := ws.blob, ws.indexed
+	stage := func(path string, content string) {
+		ws.write(path, content)
+		ws.stage(path)
+	}
+
+	d := object.EmptyTree()
+	d.Append(object.NewTreeEntry(blob("b\n"), "b", common.Regular))
+	tree := object.EmptyTree()
+	tree.Append(object.NewTreeEntry(blob("a\n"), "a", common.Regular))
+	tree.Append(object.NewTreeEntry(ws.putTree(d), "d", common.Dir))
+	ws.putTree(tree)
+
+	stage("a", "a\n")
+	stage("d/b", "changed\n")
+	stage("n", "new\n")
+
+	// only matching entries are reset, and those not in the tree are removed
+	assert.Nil(t, ws.ResetIndex(tree.Id(), []string{"d", "n"}))
+	assert.Equal(t, map[string]common.Hash{"a": blob("a\n"), "d/b": blob("b\n")}, indexed())
+
+	stage("n", "new\n")
+	assert.Nil(t, ws.ResetIndex(tree.Id(), nil))
+	assert.Equal(t, map[string]common.Hash{"a": blob("a\n"), "d/b": blob("b\n")}, indexed())
+
+	status, err := ws.Status([]string{"a"}, Untracked_No, false)
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(status.Files)) {
+		assert.Equal(t, Status_Unmodified, status.Files[0].Worktree)
+	}
+
+	assert.Nil(t, ws.ResetIndex(common.ZeroHash, nil))
+	assert.Empty(t, indexed())
+}
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core/internal/index"
+)
+
+// Restore restores files matching paths, which are relative to the root of the work tree, from the tree treeId into
+// the work tree with worktree, and into the index with staged. The source is the index if treeId is ZeroHash and only
+// the work tree is restored, otherwise it is the empty tree. Tracked files which are not in the source are removed,
+// and every path must match a file in the source or the index.
+func (ws *Workspace) Restore(treeId common.Hash, paths []string, staged bool, worktree bool) error {
+	if err := ws.CheckWorkTree(); err != nil {
+		return err
+	}
+	sa := ws.StagingArea()
+	sa.Load()
+	entries := make(map[string]*index.IndexEntry)
+	unmerged := make(map[string]bool)
+	sa.Foreach(func(e *index.IndexEntry) {
+		if e.Stage() > 0 {
+			unmerged[e.Path()] = true
+			return
+		}
+		entries[e.Path()] = e
+	})
+
+	fromIndex := treeId == common.ZeroHash && !staged
+	files := make(map[string]*common.NameHashPair)
+	if fromIndex {
+		for p, e := range entries {
+			files[p] = &common.NameHashPair{Oid: e.Oid(), Name: p, Mode: normalizeFileMode(e.Mode())}
+		}
+	} else {
+		var err error
+		if files, err = ws.repository.treeFileMap(treeId); err != nil {
+			return err
+		}
+	}
+
+	// all paths must match before any file is touched
+	for _, spec := range paths {
+		found := false
+		for _, m := range []map[string]bool{keySet(files), keySet(entries), unmerged} {
+			for p := range m {
+				if MatchPathspec(p, []string{spec}) {
+					if fromIndex && unmerged[p] {
+						return fmt.Errorf("path '%s' is unmerged", p)
+					}
+					found = true
+				}
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w '%s'", ErrPathspecNotMatched, spec)
+		}
+	}
+
+	if worktree {
+		// files in the way of those to be written, such as d/b of the file d, are removed first
+		for p := range unmerged {
+			if _, ok := entries[p]; !ok {
+				entries[p] = nil
+			}
+		}
+		for _, p := range sortedKeys(entries) {
+			if _, ok := files[p]; ok || !MatchPathspec(p, paths) {
+				continue
+			}
+			if err := ws.removeWorktreeFile(p); err != nil {
+				return err
+			}
+		}
+
+		indexTime := ws.indexModTime()
+		for _, p := range sortedKeys(files) {
+			f, e := files[p], entries[p]
+			if !MatchPathspec(p, paths) {
+				continue
+			}
+			if !unmerged[p] && entryMatches(e, f) {
+				if code, _, err := ws.worktreeChange(e, f.Mode, indexTime); err == nil && code == Status_Unmodified {
+					continue
+				}
+			}
+			if _, err := ws.checkoutFile(p, f.Oid, f.Mode); err != nil {
+				return err
+			}
+		}
+	}
+
+	if staged {
+		return ws.ResetIndex(treeId, paths)
+	}
+	return nil
+}
+
+func keySet[T any](m map[string]T) map[string]bool {
+	keys := make(map[string]bool, len(m))
+	for k := range m {
+		keys[k] = true
+	}
+	return keys
+}
@@ -0,0 +1,51 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestore(t *testing.T) {
+	ws := newTestWorkspace(t)
+	blob, read, write, indexed := ws.blob, ws.read, ws.write, ws.indexed
+
+	// a and d/b, then a changed and d replaced by a file
+	d := object.EmptyTree()
+	d.Append(object.NewTreeEntry(blob("b\n"), "b", common.Regular))
+	t1 := object.EmptyTree()
+	t1.Append(object.NewTreeEntry(blob("a1\n"), "a", common.Regular))
+	t1.Append(object.NewTreeEntry(ws.putTree(d), "d", common.Dir))
+	ws.putTree(t1)
+	t2 := object.EmptyTree()
+	t2.Append(object.NewTreeEntry(blob("a2\n"), "a", common.Regular))
+	t2.Append(object.NewTreeEntry(blob("d\n"), "d", common.Regular))
+	ws.putTree(t2)
+	assert.Nil(t, ws.CheckoutTree(common.ZeroHash, t1.Id(), false))
+
+	// the work tree is restored from the index, which is kept
+	write("a", "local\n")
+	write("n", "untracked\n")
+	assert.Nil(t, ws.Restore(common.ZeroHash, []string{"."}, false, true))
+	assert.Equal(t, "a1\n", read("a"))
+	assert.Equal(t, "untracked\n", read("n"))
+
+	err := ws.Restore(common.ZeroHash, []string{"n"}, false, true)
+	assert.True(t, errors.Is(err, ErrPathspecNotMatched))
+
+	// tracked files not in the source are removed from the work tree only
+	assert.Nil(t, ws.Restore(t2.Id(), []string{"d"}, false, true))
+	assert.Equal(t, "d\n", read("d"))
+	assert.Equal(t, map[string]common.Hash{"a": blob("a1\n"), "d/b": blob("b\n")}, indexed())
+
+	assert.Nil(t, ws.Restore(t2.Id(), []string{"a", "d"}, true, false))
+	assert.Equal(t, map[string]common.Hash{"a": blob("a2\n"), "d": blob("d\n")}, indexed())
+	assert.Equal(t, "a1\n", read("a"))
+
+	assert.Nil(t, ws.Restore(t1.Id(), []string{"."}, true, true))
+	assert.Equal(t, map[string]common.Hash{"a": blob("a1\n"), "d/b": blob("b\n")}, indexed())
+	assert.Equal(t, "b\n", read("d/b"))
+}
@@ -0,0 +1,495 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core/object"
+)
+
+const (
+	// short object ids have 4 hexadecimal digits at least
+	min_abbrev = 4
+)
+
+var (
+	ErrUnknownRevision   = errors.New("Unknown revision or path not in the working tree.")
+	ErrAmbiguousRevision = errors.New("Short object id is ambiguous.")
+	ErrInvalidUpstream   = errors.New("Invalid upstream.")
+)
+
+// ResolveRevision resolves rev in the syntax of gitrevisions to an object id:
+//
+//	<sha1>, <short sha1>       full object id, or a unique prefix of it with 4 digits at least
+//	<refname>, @               reference looked up in refs/, refs/tags/, refs/heads/ and refs/remotes/, @ is HEAD
+//	[<ref>]@{<n>}, @{<date>}   the value of ref, the current branch if it is empty, n changes before or at the date
+//	@{-<n>}                    the n-th branch checked out before the current one
+//	[<branch>]@{upstream}, @{u} the upstream of branch, the current branch if it is empty
+//	<rev>^<n>, <rev>~<n>       the n-th parent of rev, and the n-th generation ancestor by first parents
+//	<rev>^{<type>}, <rev>^{}   rev peeled to the type, or tags are peeled until a non-tag object
+//	<rev>^{/<regex>}, :/<regex> the youngest commit with message matching regex, reachable from rev or any reference
+//	<rev>:<path>, :[<n>:]<path> the blob or tree at path in the tree-ish rev, or in the index at stage n
+func (ws *Workspace) ResolveRevision(rev string) (common.Hash, error) {
+	if strings.HasPrefix(rev, ":/") {
+		return ws.searchCommit(ws.allTips(), rev[2:])
+	}
+	if strings.HasPrefix(rev, ":") {
+		return ws.resolveIndexPath(rev[1:])
+	}
+	if i := revisionPathIndex(rev); i >= 0 {
+		treeId, err := ws.ResolveRevisionAs(rev[:i], object.Kind_Tree)
+		if err != nil {
+			return common.ZeroHash, err
+		}
+		return ws.resolveTreePath(treeId, rev[:i], rev[i+1:])
+	}
+
+	end := revisionBaseEnd(rev)
+	oid, err := ws.resolveRevisionBase(rev[:end])
+	if err != nil {
+		return common.ZeroHash, err
+	}
+
+	repo := ws.Repository()
+	for rest := rev[end:]; rest != ""; {
+		switch {
+		case strings.HasPrefix(rest, "^{"):
+			// a regex may have "}" in it, and ends before the next peel like git, which finds the last "^{" first
+			close := strings.Index(rest, "}")
+			if strings.HasPrefix(rest, "^{/") {
+				limit := rest
+				if next := strings.Index(rest[2:], "^{"); next >= 0 {
+					limit = rest[:2+next]
+				}
+				close = strings.LastIndex(limit, "}")
+			}
+			if close < 0 {
+				return common.ZeroHash, fmt.Errorf("%w %s", ErrUnknownRevision, rev)
+			}
+			spec := rest[2:close]
+			rest = rest[close+1:]
+			if strings.HasPrefix(spec, "/") {
+				commit, err := repo.PeelToCommit(oid)
+				if err != nil {
+					return common.ZeroHash, err
+				}
+				oid, err = ws.searchCommit([]common.Hash{commit.Id()}, spec[1:])
+			} else {
+				oid, err = peelRevision(repo, oid, spec)
+			}
+
+		case rest[0] == '^' || rest[0] == '~':
+			n, used := leadingNumber(rest[1:])
+			op := rest[0]
+			rest = rest[1+used:]
+			if op == '^' {
+				oid, err = nthParent(repo, oid, n)
+				break
+			}
+			for ; n > 0 && err == nil; n-- {
+				oid, err = nthParent(repo, oid, 1)
+			}
+
+		default:
+			return common.ZeroHash, fmt.Errorf("%w %s", ErrUnknownRevision, rev)
+		}
+		if err != nil {
+			return common.ZeroHash, fmt.Errorf("%w %s", ErrUnknownRevision, rev)
+		}
+	}
+	return oid, nil
+}
+
+// ResolveRevisionAs resolves rev and peels it to an object of kind, such as the tree of a commit for Kind_Tree
+func (ws *Workspace) ResolveRevisionAs(rev string, kind object.ObjectKind) (common.Hash, error) {
+	oid, err := ws.ResolveRevision(rev)
+	if err != nil {
+		return common.ZeroHash, err
+	}
+	peeled, err := peelRevision(ws.Repository(), oid, kind.String())
+	if err != nil {
+		return common.ZeroHash, fmt.Errorf("%w %s is not a %s", ErrUnknownRevision, rev, kind)
+	}
+	return peeled, nil
+}
+
+// RevisionRefName returns the full name of the reference which rev names, such as refs/heads/main for HEAD or main,
+// and refs/remotes/origin/main for main@{u}. It is empty if rev is not a reference.
+func (ws *Workspace) RevisionRefName(rev string) (string, error) {
+	refs := ws.References()
+	if rev == "HEAD" || rev == "@" {
+		if target, err := refs.SymbolicRef("HEAD"); err == nil {
+			return target, nil
+		}
+		return "HEAD", nil
+	}
+	if ref, selector, ok := splitReflogSelector(rev); ok && revisionBaseEnd(rev) == len(rev) {
+		switch {
+		case isUpstreamSelector(selector):
+			return ws.upstreamOf(ref)
+		case strings.HasPrefix(selector, "-") && ref == "":
+			n, err := strconv.Atoi(selector[1:])
+			if err != nil {
+				return "", fmt.Errorf("%w %s", ErrUnknownRevision, rev)
+			}
+			branch, err := refs.PreviousBranch(n)
+			if err != nil {
+				return "", err
+			}
+			return ws.RevisionRefName(branch)
+		}
+		return "", nil
+	}
+	if name, err := refs.FullRefName(rev); err == nil {
+		return name, nil
+	}
+	return "", nil
+}
+
+// ShortRefName returns the shortest name of the full reference name which refers to it unambiguously, such as main for refs/heads/main
+func (r *References) ShortRefName(name string) string {
+	for _, prefix := range []string{RefPrefix_Heads, RefPrefix_Tags, RefPrefix_Remotes, "refs/"} {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		short := name[len(prefix):]
+		if full, err := r.FullRefName(short); err == nil && full == name {
+			return short
+		}
+	}
+	if strings.HasPrefix(name, "refs/") {
+		return name[len("refs/"):]
+	}
+	return name
+}
+
+// ShortId returns the shortest unique prefix of oid with n digits at least
+func (r *Repository) ShortId(oid common.Hash, n int) string {
+	name := oid.String()
+	if n < min_abbrev {
+		n = min_abbrev
+	}
+	for ; n < len(name); n++ {
+		if len(r.FindObjects(name[:n])) <= 1 {
+			break
+		}
+	}
+	return name[:n]
+}
+
+// resolveRevisionBase resolves rev without suffixes such as ^ and ~
+func (ws *Workspace) resolveRevisionBase(rev string) (common.Hash, error) {
+	refs := ws.References()
+	if rev == "" {
+		return common.ZeroHash, fmt.Errorf("%w %s", ErrUnknownRevision, rev)
+	}
+	if len(rev) == 40 {
+		if id, err := common.NewHash(rev); err == nil {
+			return id, nil
+		}
+	}
+
+	if ref, selector, ok := splitReflogSelector(rev); ok {
+		switch {
+		case isUpstreamSelector(selector):
+			upstream, err := ws.upstreamOf(ref)
+			if err != nil {
+				return common.ZeroHash, err
+			}
+			return refs.ReadRef(upstream)
+		case strings.HasPrefix(selector, "-") && ref == "":
+			n, err := strconv.Atoi(selector[1:])
+			if err != nil {
+				return common.ZeroHash, fmt.Errorf("%w %s", ErrUnknownRevision, rev)
+			}
+			branch, err := refs.PreviousBranch(n)
+			if err != nil {
+				return common.ZeroHash, err
+			}
+			return ws.resolveRevisionBase(branch)
+		}
+		return refs.Resolve(rev)
+	}
+
+	id, err := refs.Resolve(rev)
+	if err != ErrRefNotFound {
+		return id, err
+	}
+	if len(rev) >= min_abbrev && isHex(rev) {
+		switch found := ws.Repository().FindObjects(rev); len(found) {
+		case 0:
+		case 1:
+			return found[0], nil
+		default:
+			return common.ZeroHash, fmt.Errorf("%w %s", ErrAmbiguousRevision, rev)
+		}
+	}
+	return common.ZeroHash, fmt.Errorf("%w %s", ErrUnknownRevision, rev)
+}
+
+// upstreamOf returns the upstream reference of branch, the current branch if it is empty
+func (ws *Workspace) upstreamOf(branch string) (string, error) {
+	refs := ws.References()
+	switch {
+	case branch == "" || branch == "@" || branch == "HEAD":
+		if branch = refs.CurrentBranch(); branch == "" {
+			return "", fmt.Errorf("%w HEAD does not point to a branch", ErrInvalidUpstream)
+		}
+	case strings.HasPrefix(branch, RefPrefix_Heads):
+		branch = branch[len(RefPrefix_Heads):]
+	}
+	if _, err := refs.ReadRef(RefPrefix_Heads + branch); err != nil {
+		return "", fmt.Errorf("%w no such branch: '%s'", ErrInvalidUpstream, branch)
+	}
+
+	c, err := ws.Config()
+	if err != nil {
+		return "", err
+	}
+	upstream, ok := UpstreamRef(c, branch)
+	if !ok {
+		return "", fmt.Errorf("%w no upstream configured for branch '%s'", ErrInvalidUpstream, branch)
+	}
+	return upstream, nil
+}
+
+// resolveIndexPath resolves [<n>:]<path> in the index, paths led by ./ or ../ are relative to the current directory
+func (ws *Workspace) resolveIndexPath(spec string) (common.Hash, error) {
+	stage := 0
+	if len(spec) >= 2 && spec[1] == ':' && spec[0] >= '0' && spec[0] <= '3' {
+		stage = int(spec[0] - '0')
+		spec = spec[2:]
+	}
+	path, err := ws.revisionPath(spec)
+	if err != nil {
+		return common.ZeroHash, err
+	}
+
+	sa := ws.StagingArea()
+	sa.Load()
+	if oid, ok := sa.Entry(path, stage); ok {
+		return oid, nil
+	}
+	return common.ZeroHash, fmt.Errorf("%w path '%s' does not exist in the index at stage %d", ErrUnknownRevision, path, stage)
+}
+
+// resolveTreePath finds the object at path in the tree, the tree itself if path is empty
+func (ws *Workspace) resolveTreePath(treeId common.Hash, rev string, path string) (common.Hash, error) {
+	path, err := ws.revisionPath(path)
+	if err != nil {
+		return common.ZeroHash, err
+	}
+
+	oid := treeId
+	repo := ws.Repository()
+	for _, name := range strings.Split(path, "/") {
+		if name == "" {
+			continue
+		}
+		tree, err := repo.GetAsTree(oid)
+		if err != nil {
+			return common.ZeroHash, fmt.Errorf("%w path '%s' does not exist in '%s'", ErrUnknownRevision, path, rev)
+		}
+		entry := tree.Find(name)
+		if entry == nil {
+			return common.ZeroHash, fmt.Errorf("%w path '%s' does not exist in '%s'", ErrUnknownRevision, path, rev)
+		}
+		oid = entry.Oid
+	}
+	return oid, nil
+}
+
+// revisionPath converts path in revisions to the path relative to the root of the work tree
+func (ws *Workspace) revisionPath(path string) (string, error) {
+	if path != "." && path != ".." && !strings.HasPrefix(path, "./") && !strings.HasPrefix(path, "../") {
+		return path, nil
+	}
+	rel, err := ws.RelPath(path)
+	if err != nil {
+		return "", err
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == "." {
+		rel = ""
+	}
+	return rel, nil
+}
+
+// allTips returns commits referenced by HEAD and all references
+func (ws *Workspace) allTips() []common.Hash {
+	refs := ws.References()
+	tips := make([]common.Hash, 0)
+	if head, err := refs.HeadCommit(); err == nil {
+		tips = append(tips, head)
+	}
+	refs.ForEach(func(name string, id common.Hash) error {
+		tips = append(tips, id)
+		return nil
+	})
+	return tips
+}
+
+// searchCommit finds the youngest commit reachable from tips, whose message matches pattern.
+// Pattern led by "!-" is negated, and a literal "!" at the beginning is "!!".
+func (ws *Workspace) searchCommit(tips []common.Hash, pattern string) (common.Hash, error) {
+	negate := false
+	switch {
+	case strings.HasPrefix(pattern, "!-"):
+		negate, pattern = true, pattern[2:]
+	case strings.HasPrefix(pattern, "!!"):
+		pattern = pattern[1:]
+	case strings.HasPrefix(pattern, "!"):
+		return common.ZeroHash, fmt.Errorf("%w :/%s", ErrUnknownRevision, pattern)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return common.ZeroHash, fmt.Errorf("%w invalid regex %s", ErrUnknownRevision, pattern)
+	}
+
+	repo := ws.Repository()
+	seen := make(map[common.Hash]bool)
+	queue := make([]*object.Commit, 0, len(tips))
+	for _, tip := range tips {
+		if c, err := repo.PeelToCommit(tip); err == nil && !seen[c.Id()] {
+			seen[c.Id()] = true
+			queue = append(queue, c)
+		}
+	}
+
+	// commits are visited from the youngest by committer date
+	for len(queue) > 0 {
+		sort.SliceStable(queue, func(i, j int) bool {
+			return queue[i].Committer().When.After(queue[j].Committer().When)
+		})
+		c := queue[0]
+		queue = queue[1:]
+		if re.MatchString(c.Message()) != negate {
+			return c.Id(), nil
+		}
+		for _, p := range c.Parents() {
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			if pc, err := repo.GetAsCommit(p); err == nil {
+				queue = append(queue, pc)
+			}
+		}
+	}
+	return common.ZeroHash, fmt.Errorf("%w no commit message matches %s", ErrUnknownRevision, pattern)
+}
+
+// peelRevision peels oid to an object of the type, tags are followed and commits are peeled to their trees.
+// An empty type peels tags until a non-tag object, and "object" only checks the object exists.
+func peelRevision(repo *Repository, oid common.Hash, typ string) (common.Hash, error) {
+	kind := object.ParseObjectKind(typ)
+	if typ != "" && typ != "object" && kind == object.Kind_Unknow {
+		return common.ZeroHash, fmt.Errorf("%w invalid object type %s", ErrUnknownRevision, typ)
+	}
+
+	for {
+		g, err := repo.Get(oid)
+		if err != nil {
+			return common.ZeroHash, err
+		}
+		switch {
+		case typ == "object" || g.Kind() == kind:
+			return oid, nil
+		case g.Kind() == object.Kind_Tag:
+			oid = object.GitObjectToTag(g).Object()
+		case typ == "":
+			return oid, nil
+		case g.Kind() == object.Kind_Commit && kind == object.Kind_Tree:
+			oid = object.GitObjectToCommit(g).Tree()
+		default:
+			return common.ZeroHash, fmt.Errorf("%w %s is a %s, not a %s", ErrUnknownRevision, oid, g.Kind(), typ)
+		}
+	}
+}
+
+// nthParent returns the n-th parent of the commit which oid peels to, the commit itself if n is 0
+func nthParent(repo *Repository, oid common.Hash, n int) (common.Hash, error) {
+	c, err := repo.PeelToCommit(oid)
+	if err != nil {
+		return common.ZeroHash, err
+	}
+	if n == 0 {
+		return c.Id(), nil
+	}
+	parents := c.Parents()
+	if n > len(parents) {
+		return common.ZeroHash, fmt.Errorf("%w %s has no parent %d", ErrUnknownRevision, oid, n)
+	}
+	return parents[n-1], nil
+}
+
+// leadingNumber parses digits at the beginning of s, 1 if there are none, and returns the number of digits used
+func leadingNumber(s string) (int, int) {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	if i == 0 {
+		return 1, 0
+	}
+	n, _ := strconv.Atoi(s[:i])
+	return n, i
+}
+
+// revisionBaseEnd returns the index where suffixes ^ and ~ begin in rev, braces of @{...} are skipped
+func revisionBaseEnd(rev string) int {
+	for i := 0; i < len(rev); i++ {
+		switch rev[i] {
+		case '@':
+			if i+1 < len(rev) && rev[i+1] == '{' {
+				if close := strings.IndexByte(rev[i:], '}'); close > 0 {
+					i += close
+				}
+			}
+		case '^', '~':
+			return i
+		}
+	}
+	return len(rev)
+}
+
+// revisionPathIndex returns the index of ':' which separates rev and path in <rev>:<path>, -1 if there is none.
+// Colons inside braces such as @{2005-04-07 22:13:13} and ^{/fix: typo} are skipped.
+func revisionPathIndex(rev string) int {
+	depth := 0
+	for i := 0; i < len(rev); i++ {
+		switch rev[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth > 0 {
+				depth--
+			}
+		case ':':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isUpstreamSelector(selector string) bool {
+	s := strings.ToLower(selector)
+	return s == "u" || s == "upstream"
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
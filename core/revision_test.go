@@ -0,0 +1,94 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveRevision(t *testing.T) {
+	ws, err := Init(io.Discard, t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs := ws.References()
+	repo := ws.Repository()
+
+	blobId, _ := HashObjectFromReader(bytes.NewBufferString("content\n"), object.Kind_Blob, repo)
+	dir := object.EmptyTree()
+	dir.Append(object.NewTreeEntry(blobId, "f.txt", common.Regular))
+	dir.Hash()
+	repo.Put(dir.ToGitObject())
+	root := object.EmptyTree()
+	root.Append(object.NewTreeEntry(dir.Id(), "dir", common.Dir))
+	root.Hash()
+	repo.Put(root.ToGitObject())
+
+	// c1 <- c2 <- c3 on main, merging c4 which is on topic
+	history := newTestHistory(t, repo)
+	history.tree = root.Id()
+	commit := history.commit
+	c1 := commit("first")
+	c2 := commit("second", c1)
+	c4 := commit("side", c1)
+	c3 := commit("merge", c2, c4)
+	tag := object.NewTag(common.ZeroHash, c2, object.Kind_Commit, "v1", history.sig(), "one").ToGitObject()
+	repo.Put(tag)
+
+	assert.Nil(t, refs.UpdateRef("HEAD", c3, common.ZeroHash, "commit: merge"))
+	assert.Nil(t, refs.WriteRef("refs/heads/topic", c4))
+	assert.Nil(t, refs.WriteRef("refs/tags/v1", tag.Id()))
+	assert.Nil(t, refs.UpdateRef("HEAD", c3, c3, "checkout: moving from topic to main"))
+
+	for rev, want := range map[string]common.Hash{
+		"HEAD":                c3,
+		"@":                   c3,
+		"main~0":              c3,
+		"HEAD^":               c2,
+		"HEAD^2":              c4,
+		"HEAD~2":              c1,
+		"HEAD^2~1":            c1,
+		"v1":                  tag.Id(),
+		"v1^{}":               c2,
+		"v1^0":                c2,
+		"v1^{commit}":         c2,
+		"v1^{tree}":           root.Id(),
+		"HEAD:dir":            dir.Id(),
+		"HEAD~1:dir/f.txt":    blobId,
+		"HEAD^{/^fir}":        c1,
+		"v1^{}^{tree}":        root.Id(),
+		"v1^{commit}^{tree}":  root.Id(),
+		"v1^{}~1":             c1,
+		"HEAD^{/^fi[a-z]{3}}": c1,
+		"HEAD^{/^fir}^{tree}": root.Id(),
+		":/side":              c4,
+		":/!-e":               c1,
+		"@{-1}":               c4,
+		"@{1}":                c3,
+		"main@{1}~1":          c2,
+		c4.String()[:7]:       c4,
+		c4.String()[:7] + "^": c1,
+	} {
+		id, err := ws.ResolveRevision(rev)
+		assert.Nil(t, err, rev)
+		assert.Equal(t, want, id, rev)
+	}
+
+	for _, rev := range []string{"nope", "HEAD~3", "HEAD^3", "HEAD^{blob}", "HEAD:nope", "abc", "@{u}"} {
+		_, err := ws.ResolveRevision(rev)
+		assert.NotNil(t, err, rev)
+	}
+
+	id, err := ws.ResolveRevisionAs("v1", object.Kind_Tree)
+	assert.Nil(t, err)
+	assert.Equal(t, root.Id(), id)
+
+	name, _ := ws.RevisionRefName("@{-1}")
+	assert.Equal(t, "refs/heads/topic", name)
+	assert.Equal(t, "main", refs.ShortRefName("refs/heads/main"))
+	assert.Equal(t, c3.String()[:7], repo.ShortId(c3, 7))
+}
@@ -0,0 +1,724 @@
+package core
+
+import (
+	"container/heap"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core/object"
+)
+
+// RevOrder is the order in which RevWalk yields commits
+type RevOrder int
+
+const (
+	// reverse chronological order by commit date, parents may come before children if clocks are skewed
+	Order_Default RevOrder = iota
+	// no parents before all of their children, otherwise in commit date order
+	Order_Date
+	// no parents before all of their children, and lines of history are not intermixed
+	Order_Topo
+)
+
+// flags of commits in a walk
+const (
+	walk_seen = 1 << iota
+	// reachable from an excluded commit
+	walk_uninteresting
+	// parents have been queued
+	walk_added
+	// no changes in paths of the walk from the parent which it is simplified to
+	walk_treesame
+	// excluded directly rather than reachable from an excluded commit
+	walk_bottom
+)
+
+// the number of extra commits examined after all queued ones become uninteresting, in case of clock skew
+const walk_slop = 5
+
+type walkCommit struct {
+	*object.Commit
+	flags int
+	// parents simplified by paths of the walk, or rewritten to those shown
+	parents []common.Hash
+	// the number of children yet to be shown in topological order
+	indegree int
+}
+
+// relevant reports whether the commit is interesting, or excluded directly, to simplify history by paths
+func (wc *walkCommit) relevant() bool {
+	return wc.flags&walk_uninteresting == 0 || wc.flags&walk_bottom != 0
+}
+
+// RevWalk walks the history from included commits, leaving out commits reachable from excluded ones, like git rev-list.
+// Commits are loaded as they are yielded, unless the walk needs all of them to sort or to limit, such as with exclusions,
+// Order_Date, Order_Topo, Reverse or RewriteParents.
+type RevWalk struct {
+	repo *Repository
+
+	Order RevOrder
+	// yield commits in reverse order, after MaxCount is applied
+	Reverse bool
+	// follow only the first parent of merge commits
+	FirstParent bool
+	// stop after MaxCount commits, no limit if it is not positive
+	MaxCount int
+	// show only commits changing files in Paths, see MatchPathspec
+	Paths []string
+	// show only commits for which Filter returns true if it is not nil
+	Filter func(c *object.Commit) bool
+	// stop at commits older than Since, and skip those newer than Until, if they are not zero
+	Since time.Time
+	Until time.Time
+	// rewrite parents of commits to the nearest ones which are shown, see Parents
+	RewriteParents bool
+
+	commits  map[common.Hash]*walkCommit
+	queue    *walkQueue
+	limited  bool
+	prepared bool
+	// commits to yield in order if the walk is limited, and those to show if reversed
+	list     []*walkCommit
+	count    int
+	reversed bool
+}
+
+// NewRevWalk returns a walk of commits in repo, which yields nothing until commits are pushed
+func (r *Repository) NewRevWalk() *RevWalk {
+	return &RevWalk{
+		repo:    r,
+		commits: make(map[common.Hash]*walkCommit),
+		queue:   &walkQueue{},
+	}
+}
+
+// PushRevisions resolves revisions in args and adds them to the walk:
+//
+//	<rev>          include rev and its ancestors
+//	^<rev>         exclude rev and its ancestors
+//	<r1>..<r2>     include r2, but exclude r1, either of which is HEAD if it is empty
+//	<r1>...<r2>    include both r1 and r2, but exclude their merge bases
+//	<rev>^@        include all parents of rev, but not rev itself
+//	<rev>^!        include rev, but exclude all of its parents
+//	<rev>^-[<n>]   include rev, but exclude its n-th parent, the first one by default
+func (ws *Workspace) PushRevisions(w *RevWalk, args []string) error {
+	resolve := func(rev string) (common.Hash, error) {
+		if rev == "" {
+			rev = "HEAD"
+		}
+		return ws.ResolveRevision(rev)
+	}
+
+	for _, arg := range args {
+		if oid, err := ws.ResolveRevision(arg); err == nil {
+			if err := w.Push(oid); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var include, exclude []common.Hash
+		switch from, to, symmetric, found := splitRevisionRange(arg); {
+		case found:
+			a, err := resolve(from)
+			if err != nil {
+				return err
+			}
+			b, err := resolve(to)
+			if err != nil {
+				return err
+			}
+			include = []common.Hash{b}
+			exclude = []common.Hash{a}
+			if symmetric {
+				include = append(include, a)
+				if exclude, err = ws.mergeBasesOf(a, b); err != nil {
+					return err
+				}
+			}
+
+		case strings.HasPrefix(arg, "^"):
+			oid, err := ws.ResolveRevision(arg[1:])
+			if err != nil {
+				return err
+			}
+			exclude = []common.Hash{oid}
+
+		case strings.HasSuffix(arg, "^@"), strings.HasSuffix(arg, "^!"):
+			c, err := ws.resolveCommit(arg[:len(arg)-2])
+			if err != nil {
+				return err
+			}
+			if strings.HasSuffix(arg, "^@") {
+				include = c.Parents()
+			} else {
+				include, exclude = []common.Hash{c.Id()}, c.Parents()
+			}
+
+		case strings.Contains(arg, "^-"):
+			i := strings.LastIndex(arg, "^-")
+			n := 1
+			if arg[i+2:] != "" {
+				var err error
+				if n, err = strconv.Atoi(arg[i+2:]); err != nil || n <= 0 {
+					return fmt.Errorf("%w %s", ErrUnknownRevision, arg)
+				}
+			}
+			c, err := ws.resolveCommit(arg[:i])
+			if err != nil {
+				return err
+			}
+			if n > len(c.Parents()) {
+				return fmt.Errorf("%w %s", ErrUnknownRevision, arg)
+			}
+			include, exclude = []common.Hash{c.Id()}, []common.Hash{c.Parents()[n-1]}
+
+		default:
+			_, err := ws.ResolveRevision(arg)
+			return err
+		}
+
+		for _, oid := range exclude {
+			if err := w.Hide(oid); err != nil {
+				return err
+			}
+		}
+		for _, oid := range include {
+			if err := w.Push(oid); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (ws *Workspace) resolveCommit(rev string) (*object.Commit, error) {
+	oid, err := ws.ResolveRevision(rev)
+	if err != nil {
+		return nil, err
+	}
+	return ws.Repository().PeelToCommit(oid)
+}
+
+func (ws *Workspace) mergeBasesOf(a common.Hash, b common.Hash) ([]common.Hash, error) {
+	repo := ws.Repository()
+	ca, err := repo.PeelToCommit(a)
+	if err != nil {
+		return nil, err
+	}
+	cb, err := repo.PeelToCommit(b)
+	if err != nil {
+		return nil, err
+	}
+	return repo.MergeBases(ca.Id(), cb.Id())
+}
+
+// splitRevisionRange splits <r1>..<r2> or <r1>...<r2>, symmetric is true for the latter
+func splitRevisionRange(arg string) (string, string, bool, bool) {
+	if from, to, found := strings.Cut(arg, "..."); found {
+		return from, to, true, true
+	}
+	if from, to, found := strings.Cut(arg, ".."); found {
+		return from, to, false, true
+	}
+	return "", "", false, false
+}
+
+// Push includes the commit which oid peels to and its ancestors in the walk
+func (w *RevWalk) Push(oid common.Hash) error {
+	return w.add(oid, 0)
+}
+
+// Hide excludes the commit which oid peels to and its ancestors from the walk
+func (w *RevWalk) Hide(oid common.Hash) error {
+	w.limited = true
+	return w.add(oid, walk_uninteresting|walk_bottom)
+}
+
+func (w *RevWalk) add(oid common.Hash, flags int) error {
+	c, err := w.repo.PeelToCommit(oid)
+	if err != nil {
+		return err
+	}
+	wc, err := w.lookup(c.Id())
+	if err != nil {
+		return err
+	}
+	if flags&walk_uninteresting != 0 {
+		w.markUninteresting(wc)
+	}
+	wc.flags |= flags & walk_bottom
+	if wc.flags&walk_seen == 0 {
+		wc.flags |= walk_seen
+		w.queue.push(wc)
+	}
+	return nil
+}
+
+func (w *RevWalk) lookup(oid common.Hash) (*walkCommit, error) {
+	if wc, ok := w.commits[oid]; ok {
+		return wc, nil
+	}
+	c, err := w.repo.GetAsCommit(oid)
+	if err != nil {
+		return nil, err
+	}
+	wc := &walkCommit{Commit: c, parents: c.Parents()}
+	w.commits[oid] = wc
+	return wc, nil
+}
+
+// Next returns the next commit of the walk, or io.EOF after the last one
+func (w *RevWalk) Next() (*object.Commit, error) {
+	if !w.prepared {
+		w.prepared = true
+		if w.limited || w.Order != Order_Default || w.Reverse || w.RewriteParents {
+			if err := w.prepareList(); err != nil {
+				return nil, err
+			}
+		}
+		if w.Reverse {
+			shown := make([]*walkCommit, 0)
+			for {
+				wc, err := w.next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					return nil, err
+				}
+				shown = append(shown, wc)
+			}
+			for i, j := 0, len(shown)-1; i < j; i, j = i+1, j-1 {
+				shown[i], shown[j] = shown[j], shown[i]
+			}
+			w.list = shown
+			w.reversed = true
+		}
+	}
+
+	if w.reversed {
+		if len(w.list) == 0 {
+			return nil, io.EOF
+		}
+		wc := w.list[0]
+		w.list = w.list[1:]
+		return wc.Commit, nil
+	}
+	wc, err := w.next()
+	if err != nil {
+		return nil, err
+	}
+	return wc.Commit, nil
+}
+
+// next returns the next commit to show, until MaxCount commits are shown
+func (w *RevWalk) next() (*walkCommit, error) {
+	for w.MaxCount <= 0 || w.count < w.MaxCount {
+		wc, err := w.nextCandidate()
+		if err != nil {
+			return nil, err
+		}
+		if !w.shown(wc) {
+			continue
+		}
+		if w.RewriteParents && len(w.Paths) > 0 {
+			if err := w.rewriteParents(wc); err != nil {
+				return nil, err
+			}
+		}
+		w.count++
+		return wc, nil
+	}
+	return nil, io.EOF
+}
+
+// nextCandidate returns the next interesting commit from the prepared list, or walks to it
+func (w *RevWalk) nextCandidate() (*walkCommit, error) {
+	if w.list != nil {
+		if len(w.list) == 0 {
+			return nil, io.EOF
+		}
+		wc := w.list[0]
+		w.list = w.list[1:]
+		return wc, nil
+	}
+
+	for w.queue.Len() > 0 {
+		wc := w.queue.pop()
+		if w.tooOld(wc) {
+			continue
+		}
+		if err := w.addParents(wc); err != nil {
+			return nil, err
+		}
+		if wc.flags&walk_uninteresting == 0 {
+			return wc, nil
+		}
+	}
+	return nil, io.EOF
+}
+
+// shown reports whether the interesting commit wc is shown, like get_commit_action of git
+func (w *RevWalk) shown(wc *walkCommit) bool {
+	if wc.flags&walk_uninteresting != 0 {
+		return false
+	}
+	if !w.Until.IsZero() && wc.Committer().When.After(w.Until) {
+		return false
+	}
+	if w.Filter != nil && !w.Filter(wc.Commit) {
+		return false
+	}
+	if len(w.Paths) > 0 && wc.flags&walk_treesame != 0 {
+		// merges joining lines of history which are shown are kept to draw them
+		if !w.RewriteParents {
+			return false
+		}
+		relevant := 0
+		for _, p := range wc.parents {
+			if pc, ok := w.commits[p]; ok && pc.relevant() {
+				relevant++
+			}
+		}
+		return relevant > 1
+	}
+	return true
+}
+
+func (w *RevWalk) tooOld(wc *walkCommit) bool {
+	return !w.Since.IsZero() && wc.Committer().When.Before(w.Since)
+}
+
+// Shows reports whether the commit oid is shown by the walk, regardless of MaxCount, if it is found in the walk
+func (w *RevWalk) Shows(oid common.Hash) bool {
+	wc, ok := w.commits[oid]
+	return ok && wc.flags&walk_added != 0 && w.shown(wc)
+}
+
+// Parents returns parents of the commit c in the walk, which are simplified by Paths, and rewritten to those
+// shown if RewriteParents is set.
+func (w *RevWalk) Parents(c *object.Commit) []common.Hash {
+	if wc, ok := w.commits[c.Id()]; ok {
+		return wc.parents
+	}
+	return c.Parents()
+}
+
+// ForEach calls fn with commits of the walk in order, until fn returns an error which is returned
+func (w *RevWalk) ForEach(fn func(c *object.Commit) error) error {
+	for {
+		c, err := w.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+}
+
+// addParents queues parents of wc, which are uninteresting if wc is
+func (w *RevWalk) addParents(wc *walkCommit) error {
+	if wc.flags&walk_added != 0 {
+		return nil
+	}
+	wc.flags |= walk_added
+
+	// exclusions are propagated to all parents, even if only the first ones are followed
+	uninteresting := wc.flags&walk_uninteresting != 0
+	parents := wc.Parents()
+	if !uninteresting && len(w.Paths) > 0 {
+		if err := w.simplify(wc); err != nil {
+			return err
+		}
+		parents = wc.parents
+	}
+	for i, p := range parents {
+		if w.FirstParent && i > 0 && !uninteresting {
+			break
+		}
+		pc, err := w.lookup(p)
+		if err != nil {
+			return err
+		}
+		if uninteresting {
+			w.markUninteresting(pc)
+		}
+		if pc.flags&walk_seen == 0 {
+			pc.flags |= walk_seen
+			w.queue.push(pc)
+		}
+	}
+	return nil
+}
+
+// markUninteresting marks wc, and its ancestors whose parents have been queued, reachable from an excluded commit
+func (w *RevWalk) markUninteresting(wc *walkCommit) {
+	stack := []*walkCommit{wc}
+	for len(stack) > 0 {
+		c := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		c.flags |= walk_uninteresting
+		if c.flags&walk_added == 0 {
+			continue
+		}
+		for _, p := range c.Parents() {
+			if pc, ok := w.commits[p]; ok && pc.flags&walk_uninteresting == 0 {
+				stack = append(stack, pc)
+			}
+		}
+	}
+}
+
+// prepareList walks until all queued commits are uninteresting, and sorts commits left to yield
+func (w *RevWalk) prepareList() error {
+	list := make([]*walkCommit, 0)
+	slop := walk_slop
+	for w.queue.Len() > 0 {
+		wc := w.queue.pop()
+		if w.tooOld(wc) {
+			w.markUninteresting(wc)
+		}
+		if err := w.addParents(wc); err != nil {
+			return err
+		}
+		if wc.flags&walk_uninteresting != 0 {
+			if w.queue.everybodyUninteresting() {
+				if slop--; slop == 0 {
+					break
+				}
+			} else {
+				slop = walk_slop
+			}
+			continue
+		}
+		list = append(list, wc)
+	}
+
+	// commits may be found uninteresting after they have been listed
+	w.list = make([]*walkCommit, 0, len(list))
+	for _, wc := range list {
+		if wc.flags&walk_uninteresting == 0 {
+			w.list = append(w.list, wc)
+		}
+	}
+	if w.Order != Order_Default {
+		w.sortTopologically()
+	}
+	return nil
+}
+
+// simplify reduces parents of the interesting commit wc to the first relevant one without changes in Paths, and
+// marks wc treesame if there is one, or if no parents are found changing them. Irrelevant parents are not taken into
+// account if there are relevant ones.
+func (w *RevWalk) simplify(wc *walkCommit) error {
+	if len(wc.parents) == 0 {
+		changes, err := w.repo.DiffTrees(common.ZeroHash, wc.Tree(), w.Paths)
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			wc.flags |= walk_treesame
+		}
+		return nil
+	}
+
+	relevantParents := 0
+	relevantChange, irrelevantChange := false, false
+	for i, p := range wc.parents {
+		pc, err := w.lookup(p)
+		if err != nil {
+			return err
+		}
+		relevant := pc.relevant()
+		if relevant {
+			relevantParents++
+		}
+		// the second parent still counts as git does, though it is not compared
+		if w.FirstParent && i > 0 {
+			break
+		}
+		changes, err := w.repo.DiffTrees(pc.Tree(), wc.Tree(), w.Paths)
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			if relevant {
+				wc.parents = []common.Hash{p}
+				wc.flags |= walk_treesame
+				return nil
+			}
+			continue
+		}
+		if relevant {
+			relevantChange = true
+		} else {
+			irrelevantChange = true
+		}
+	}
+	if (relevantParents > 0 && !relevantChange) || (relevantParents == 0 && !irrelevantChange) {
+		wc.flags |= walk_treesame
+	}
+	return nil
+}
+
+// rewriteParents replaces each parent of wc with its nearest ancestor which is uninteresting or changes Paths,
+// following treesame commits, and drops parents leading to none of them
+func (w *RevWalk) rewriteParents(wc *walkCommit) error {
+	parents := make([]common.Hash, 0, len(wc.parents))
+	seen := make(map[common.Hash]bool)
+	for _, p := range wc.parents {
+		for {
+			pc, err := w.lookup(p)
+			if err != nil {
+				return err
+			}
+			if pc.flags&walk_uninteresting != 0 || pc.flags&walk_treesame == 0 {
+				break
+			}
+			if len(pc.parents) == 0 {
+				p = common.ZeroHash
+				break
+			}
+			next, ok := w.relevantParent(pc)
+			if !ok {
+				break
+			}
+			p = next
+		}
+		if p != common.ZeroHash && !seen[p] {
+			seen[p] = true
+			parents = append(parents, p)
+		}
+	}
+	wc.parents = parents
+	return nil
+}
+
+// relevantParent returns the only parent, the first one with FirstParent, or the only relevant one of wc
+func (w *RevWalk) relevantParent(wc *walkCommit) (common.Hash, bool) {
+	if w.FirstParent || len(wc.parents) == 1 {
+		return wc.parents[0], true
+	}
+	found, ok := common.ZeroHash, false
+	for _, p := range wc.parents {
+		if pc, exists := w.commits[p]; exists && pc.relevant() {
+			if ok {
+				return common.ZeroHash, false
+			}
+			found, ok = p, true
+		}
+	}
+	return found, ok
+}
+
+// sortTopologically sorts the list so that no parents come before their children. Commits ready to show are taken
+// by commit date in Order_Date, or the last one first in Order_Topo to keep lines of history together.
+func (w *RevWalk) sortTopologically() {
+	for _, wc := range w.list {
+		wc.indegree = 1
+	}
+	for _, wc := range w.list {
+		for _, p := range wc.parents {
+			if pc, ok := w.commits[p]; ok && pc.indegree > 0 {
+				pc.indegree++
+			}
+		}
+	}
+
+	ready := &walkQueue{lifo: w.Order == Order_Topo}
+	// tips are taken in the order they are listed
+	tips := make([]*walkCommit, 0)
+	for _, wc := range w.list {
+		if wc.indegree == 1 {
+			tips = append(tips, wc)
+		}
+	}
+	for i := range tips {
+		if ready.lifo {
+			ready.push(tips[len(tips)-1-i])
+		} else {
+			ready.push(tips[i])
+		}
+	}
+
+	sorted := make([]*walkCommit, 0, len(w.list))
+	for ready.Len() > 0 {
+		wc := ready.pop()
+		for _, p := range wc.parents {
+			pc, ok := w.commits[p]
+			if !ok || pc.indegree == 0 {
+				continue
+			}
+			if pc.indegree--; pc.indegree == 1 {
+				ready.push(pc)
+			}
+		}
+		wc.indegree = 0
+		sorted = append(sorted, wc)
+	}
+	w.list = sorted
+}
+
+// walkQueue is a priority queue of commits from the newest by commit date, commits of the same date are taken in the
+// order they are pushed. With lifo, it is a stack.
+type walkQueue struct {
+	items []walkItem
+	lifo  bool
+	ctr   int
+}
+
+type walkItem struct {
+	commit *walkCommit
+	ctr    int
+}
+
+func (q *walkQueue) Len() int { return len(q.items) }
+
+func (q *walkQueue) Less(i, j int) bool {
+	a, b := q.items[i], q.items[j]
+	if q.lifo {
+		return a.ctr > b.ctr
+	}
+	ta, tb := a.commit.Committer().When, b.commit.Committer().When
+	if !ta.Equal(tb) {
+		return ta.After(tb)
+	}
+	return a.ctr < b.ctr
+}
+
+func (q *walkQueue) Swap(i, j int) { q.items[i], q.items[j] = q.items[j], q.items[i] }
+
+func (q *walkQueue) Push(x any) { q.items = append(q.items, x.(walkItem)) }
+
+func (q *walkQueue) Pop() any {
+	last := q.items[len(q.items)-1]
+	q.items = q.items[:len(q.items)-1]
+	return last
+}
+
+func (q *walkQueue) push(wc *walkCommit) {
+	heap.Push(q, walkItem{commit: wc, ctr: q.ctr})
+	q.ctr++
+}
+
+func (q *walkQueue) pop() *walkCommit {
+	return heap.Pop(q).(walkItem).commit
+}
+
+func (q *walkQueue) everybodyUninteresting() bool {
+	for _, it := range q.items {
+		if it.commit.flags&walk_uninteresting == 0 {
+			return false
+		}
+	}
+	return true
+}
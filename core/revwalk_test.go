@@ -0,0 +1,142 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevWalk(t *testing.T) {
+	ws, err := Init(io.Discard, t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs := ws.References()
+	repo := ws.Repository()
+
+	// a <- b <- m on main, a <- t1 <- t2 on topic merged into m, and t2 is committed with a skewed clock
+	history := newTestHistory(t, repo)
+	a := history.commit("a")
+	t1 := history.commit("t1", a)
+	b := history.commit("b", a)
+	t2 := history.commitTree("t2", common.ZeroHash, -1000, t1)
+	m := history.commit("m", b, t2)
+	assert.Nil(t, refs.WriteRef("refs/heads/main", m))
+	assert.Nil(t, refs.WriteRef("refs/heads/topic", t2))
+
+	list := func(w *RevWalk, args ...string) []common.Hash {
+		assert.Nil(t, ws.PushRevisions(w, args))
+		ids := make([]common.Hash, 0)
+		assert.Nil(t, w.ForEach(func(c *object.Commit) error {
+			ids = append(ids, c.Id())
+			return nil
+		}))
+		return ids
+	}
+
+	assert.Equal(t, []common.Hash{m, b, a, t2, t1}, list(repo.NewRevWalk(), "main"))
+	w := repo.NewRevWalk()
+	w.Order = Order_Date
+	assert.Equal(t, []common.Hash{m, b, t2, t1, a}, list(w, "main"))
+	w = repo.NewRevWalk()
+	w.Order = Order_Topo
+	assert.Equal(t, []common.Hash{m, t2, t1, b, a}, list(w, "main"))
+	w = repo.NewRevWalk()
+	w.FirstParent = true
+	w.Reverse = true
+	assert.Equal(t, []common.Hash{a, b, m}, list(w, "main"))
+	w = repo.NewRevWalk()
+	w.MaxCount = 2
+	assert.Equal(t, []common.Hash{m, b}, list(w, "main"))
+
+	assert.Equal(t, []common.Hash{m, b}, list(repo.NewRevWalk(), "topic..main"))
+	assert.Equal(t, []common.Hash{m, b}, list(repo.NewRevWalk(), "main", "^topic"))
+	assert.Equal(t, []common.Hash{b, t2, t1}, list(repo.NewRevWalk(), "main^1...topic"))
+	assert.Equal(t, []common.Hash{m}, list(repo.NewRevWalk(), "main^!"))
+	assert.Equal(t, []common.Hash{b, a, t2, t1}, list(repo.NewRevWalk(), "main^@"))
+	assert.Equal(t, []common.Hash{m, t2, t1}, list(repo.NewRevWalk(), "main^-"))
+
+	bases, err := repo.MergeBases(b, t2)
+	assert.Nil(t, err)
+	assert.Equal(t, []common.Hash{a}, bases)
+}
+
+func TestRevWalkPaths(t *testing.T) {
+	ws, err := Init(io.Discard, t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := ws.Repository()
+
+	// trees with README and src/x.go
+	tree := func(readme string, x string) common.Hash {
+		readmeId, _ := HashObjectFromReader(bytes.NewBufferString(readme), object.Kind_Blob, repo)
+		xId, _ := HashObjectFromReader(bytes.NewBufferString(x), object.Kind_Blob, repo)
+		src := object.EmptyTree()
+		src.Append(object.NewTreeEntry(xId, "x.go", common.Regular))
+		src.Hash()
+		repo.Put(src.ToGitObject())
+		root := object.EmptyTree()
+		root.Append(object.NewTreeEntry(readmeId, "README", common.Regular))
+		root.Append(object.NewTreeEntry(src.Id(), "src", common.Dir))
+		root.Hash()
+		repo.Put(root.ToGitObject())
+		return root.Id()
+	}
+	history := newTestHistory(t, repo)
+
+	// a <- b changing src and a <- s changing README are merged into m, followed by c changing README
+	ta, tb, tc := tree("r1\n", "x1\n"), tree("r1\n", "x2\n"), tree("r3\n", "x2\n")
+	a := history.commitTree("a", ta, 0)
+	b := history.commitTree("b", tb, 0, a)
+	s := history.commitTree("s", tree("r2\n", "x1\n"), 0, a)
+	m := history.commitTree("m", tree("r2\n", "x2\n"), 0, b, s)
+	c := history.commitTree("c", tc, 0, m)
+
+	changes, err := repo.DiffTrees(ta, tc, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(changes))
+	assert.Equal(t, "README", changes[0].To.Name)
+	assert.Equal(t, "src/x.go", changes[1].From.Name)
+	changes, err = repo.DiffTrees(common.ZeroHash, tb, []string{"src"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(changes))
+	assert.Nil(t, changes[0].From)
+
+	list := func(w *RevWalk) []common.Hash {
+		assert.Nil(t, w.Push(c))
+		ids := make([]common.Hash, 0)
+		assert.Nil(t, w.ForEach(func(c *object.Commit) error {
+			ids = append(ids, c.Id())
+			return nil
+		}))
+		return ids
+	}
+
+	w := repo.NewRevWalk()
+	w.Paths = []string{"src"}
+	assert.Equal(t, []common.Hash{b, a}, list(w))
+	w = repo.NewRevWalk()
+	w.Paths = []string{"README"}
+	assert.Equal(t, []common.Hash{c, s, a}, list(w))
+
+	// parents are rewritten to those shown, leaving out m and b
+	w = repo.NewRevWalk()
+	w.Paths = []string{"README"}
+	w.RewriteParents = true
+	w.Order = Order_Topo
+	assert.Equal(t, []common.Hash{c, s, a}, list(w))
+	cc, _ := repo.GetAsCommit(c)
+	assert.Equal(t, []common.Hash{s}, w.Parents(cc))
+	assert.False(t, w.Shows(m))
+
+	w = repo.NewRevWalk()
+	w.Filter = func(c *object.Commit) bool { return c.Message() != "b\n" }
+	w.Since = time.Unix(history.at-300, 0)
+	assert.Equal(t, []common.Hash{c, m, s}, list(w))
+}
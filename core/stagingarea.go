@@ -12,6 +12,8 @@ import (
 
 type StagingArea struct {
 	path string
+	// root of the work tree, paths in the index are relative to it
+	root string
 	index.Index
 }
 
@@ -27,11 +29,12 @@ func (s *StagingArea) Save() error {
 	// return s.Index.Save(s.path)
 }
 
-func (s *StagingArea) Stage(paths []string) error {
+// Stage writes contents of files into storer and add them to the index
+func (s *StagingArea) Stage(storer ObjectStorer, paths []string) error {
 	idx := &s.Index
 
 	for _, fp := range paths {
-		s.updateIndex(idx, fp)
+		s.updateIndex(storer, idx, fp)
 	}
 
 	idx.Sort()
@@ -52,15 +55,14 @@ func (s *StagingArea) Unstage(paths []string, recursive bool) {
 // }
 
 // Reads tree information into the index
-func (s *StagingArea) ReadTree(treeId common.Hash, prefix string, eraseOriginal bool) error {
+func (s *StagingArea) ReadTree(storer ObjectStorer, treeId common.Hash, prefix string, eraseOriginal bool) error {
 	idx := &s.Index
 	if eraseOriginal == true {
 		idx.Reset()
 	}
-	repo := GetRepository()
 
-	// load trees led by root from repo and add to CacheTree
-	root, err := repo.LoadTrees(treeId)
+	// load trees led by root from storer and add to CacheTree
+	root, err := LoadTrees(storer, treeId)
 	if err != nil {
 		return err
 	}
@@ -78,7 +80,7 @@ func (s *StagingArea) ReadTree(treeId common.Hash, prefix string, eraseOriginal
 			t.Hash()
 
 			g := t.ToGitObject()
-			repo.Put(g)
+			storer.Put(g)
 		}
 
 		return nil
@@ -115,10 +117,9 @@ func updateRootWithPrefix(root *object.Tree, prefix string) *object.Tree {
 	return root
 }
 
-// read .git/index file and using files to build and save trees
-func (s *StagingArea) WriteTree() (common.Hash, error) {
+// read .git/index file and using files to build and save trees into storer
+func (s *StagingArea) WriteTree(storer ObjectStorer) (common.Hash, error) {
 	idx := &s.Index
-	repo := GetRepository()
 
 	idx.UpdateCacheTree()
 	idx.CacheTree.DFWalk(func(path string, t *object.Tree) error {
@@ -128,7 +129,7 @@ func (s *StagingArea) WriteTree() (common.Hash, error) {
 			t.Sort()
 			t.Hash()
 			g := t.ToGitObject()
-			repo.Put(g)
+			storer.Put(g)
 		}
 
 		return nil
@@ -137,13 +138,17 @@ func (s *StagingArea) WriteTree() (common.Hash, error) {
 	return idx.CacheTree.Root().Id(), nil
 }
 
-func (s *StagingArea) updateIndex(idx *index.Index, path string) error {
+func (s *StagingArea) updateIndex(storer ObjectStorer, idx *index.Index, path string) error {
 	e := idx.Find(path)
-	fi, _ := os.Stat(path)
+	fp := filepath.Join(s.root, path)
+	fi, err := os.Stat(fp)
+	if err != nil {
+		return err
+	}
 
 	// file has not existed in idx of has been modified
 	if e == nil {
-		oid, err := HashObjectFromPath(path, object.Kind_Blob, true)
+		oid, err := HashObjectFromPath(fp, object.Kind_Blob, storer)
 		if err != nil {
 			return err
 		}
@@ -152,7 +157,7 @@ func (s *StagingArea) updateIndex(idx *index.Index, path string) error {
 		idx.Append(e)
 
 	} else if e.ModTime().Before(fi.ModTime()) {
-		oid, err := HashObjectFromPath(path, object.Kind_Blob, true)
+		oid, err := HashObjectFromPath(fp, object.Kind_Blob, storer)
 		if err != nil {
 			return err
 		}
@@ -165,9 +170,9 @@ func (s *StagingArea) updateIndex(idx *index.Index, path string) error {
 }
 
 // UpdateIndexEntry add or replace IndexEntry identified by path, and Invalidate all entries in TreeCache covered by path
-func (s *StagingArea) UpdateIndex(path string) {
+func (s *StagingArea) UpdateIndex(storer ObjectStorer, path string) {
 	idx := &s.Index
-	s.updateIndex(idx, path)
+	s.updateIndex(storer, idx, path)
 	idx.Sort()
 }
 
@@ -193,6 +198,26 @@ func (s *StagingArea) UpdateIndexRemove(path string) {
 	idx.Remove(path, false)
 }
 
+// ForEachEntry visits object id and path of all entries in the index
+func (s *StagingArea) ForEachEntry(fn func(oid common.Hash, path string)) {
+	idx := &s.Index
+	idx.Foreach(func(e *index.IndexEntry) {
+		fn(e.Oid(), e.Path())
+	})
+}
+
+// Entry returns the object id of path at the merge stage in the index, stage 0 for normal entries
+func (s *StagingArea) Entry(path string, stage int) (common.Hash, bool) {
+	oid, found := common.ZeroHash, false
+	idx := &s.Index
+	idx.Foreach(func(e *index.IndexEntry) {
+		if !found && e.Path() == path && int(e.Stage()) == stage {
+			oid, found = e.Oid(), true
+		}
+	})
+	return oid, found
+}
+
 func (s *StagingArea) Dump(w io.Writer) {
 	idx := &s.Index
 	idx.Dump(w)
@@ -0,0 +1,494 @@
+package core
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core/internal/index"
+	"github.com/izhujiang/gogit/core/object"
+)
+
+// StatusCode is the state of a file in the index or the work tree, as in the short format of git status
+type StatusCode byte
+
+const (
+	Status_Unmodified  StatusCode = ' '
+	Status_Modified    StatusCode = 'M'
+	Status_TypeChanged StatusCode = 'T'
+	Status_Added       StatusCode = 'A'
+	Status_Deleted     StatusCode = 'D'
+	Status_Unmerged    StatusCode = 'U'
+	Status_Untracked   StatusCode = '?'
+	Status_Ignored     StatusCode = '!'
+)
+
+// UntrackedMode is how untracked files are shown by Status
+type UntrackedMode int
+
+const (
+	// untracked files are not shown
+	Untracked_No UntrackedMode = iota
+	// untracked directories are shown as a whole, such as "build/"
+	Untracked_Normal
+	// all files in untracked directories are shown
+	Untracked_All
+)
+
+// FileStatus is a tracked file which differs between HEAD, the index and the work tree
+type FileStatus struct {
+	// relative to the root of the work tree
+	Path string
+	// the change from HEAD to the index, and the one from the index to the work tree, both Status_Unmerged for
+	// unmerged files except those added or deleted by one side
+	Staging  StatusCode
+	Worktree StatusCode
+
+	// modes and ids in HEAD and the index, zero if the file is absent, ids of files in the work tree are not computed
+	HeadMode     common.FileMode
+	IndexMode    common.FileMode
+	WorktreeMode common.FileMode
+	HeadOid      common.Hash
+	IndexOid     common.Hash
+
+	// modes and ids of the base, ours and theirs of unmerged files, zero if the stage is absent
+	StageModes [3]common.FileMode
+	StageOids  [3]common.Hash
+}
+
+// Unmerged reports whether the file has conflicts recorded in the index
+func (s *FileStatus) Unmerged() bool {
+	return s.StageModes != [3]common.FileMode{}
+}
+
+// Status is the state of the work tree and the index compared with HEAD
+type Status struct {
+	// tracked files with changes sorted by paths
+	Files []*FileStatus
+	// untracked and ignored paths sorted, directories end with "/"
+	Untracked []string
+	Ignored   []string
+}
+
+// Status compares the tree of HEAD with the index, and the index with the work tree. Files whose stat data in the
+// index is up to date are not hashed again. Only paths matching paths are shown if any are given, see MatchPathspec.
+// Untracked files are found as untracked says, and ignored files are listed only with ignored.
+func (ws *Workspace) Status(paths []string, untracked UntrackedMode, ignored bool) (*Status, error) {
+	if err := ws.CheckWorkTree(); err != nil {
+		return nil, err
+	}
+	repo := ws.Repository()
+	sa := ws.StagingArea()
+	sa.Load()
+
+	head := make(common.NameHashPairs, 0)
+	if oid, err := ws.References().HeadCommit(); err == nil {
+		c, err := repo.GetAsCommit(oid)
+		if err != nil {
+			return nil, err
+		}
+		if err := repo.treeFiles(c.Tree(), "", &head); err != nil {
+			return nil, err
+		}
+	}
+
+	files := make(map[string]*FileStatus)
+	fileOf := func(p string) *FileStatus {
+		s, ok := files[p]
+		if !ok {
+			s = &FileStatus{Path: p, Staging: Status_Unmodified, Worktree: Status_Unmodified}
+			files[p] = s
+		}
+		return s
+	}
+
+	// entries of unmerged files are compared with neither HEAD nor the work tree
+	staged := make(common.NameHashPairs, 0)
+	entries := make([]*index.IndexEntry, 0)
+	sa.Foreach(func(e *index.IndexEntry) {
+		if e.Stage() > 0 {
+			s := fileOf(e.Path())
+			s.StageModes[e.Stage()-1] = normalizeFileMode(e.Mode())
+			s.StageOids[e.Stage()-1] = e.Oid()
+			return
+		}
+		entries = append(entries, e)
+		staged = append(staged, &common.NameHashPair{Oid: e.Oid(), Name: e.Path(), Mode: normalizeFileMode(e.Mode())})
+	})
+	unmerged := make(map[string]bool)
+	for p, s := range files {
+		unmerged[p] = true
+		s.Staging, s.Worktree = unmergedCodes(s.StageModes)
+	}
+
+	changes := common.CompareOrderedNameHashPairs(withoutPaths(head, unmerged), withoutPaths(staged, unmerged))
+	for _, c := range changes.Create {
+		s := fileOf(c.To.Name)
+		s.Staging = Status_Added
+		s.IndexMode, s.IndexOid = c.To.Mode, c.To.Oid
+	}
+	for _, c := range changes.Remove {
+		s := fileOf(c.From.Name)
+		s.Staging = Status_Deleted
+		s.HeadMode, s.HeadOid = c.From.Mode, c.From.Oid
+	}
+	for _, c := range changes.Modify {
+		s := fileOf(c.To.Name)
+		s.Staging = Status_Modified
+		if c.From.Mode&^0777 != c.To.Mode&^0777 {
+			s.Staging = Status_TypeChanged
+		}
+		s.HeadMode, s.HeadOid = c.From.Mode, c.From.Oid
+		s.IndexMode, s.IndexOid = c.To.Mode, c.To.Oid
+	}
+
+	// files changed after the index is written are racily clean, and always hashed
+	indexTime, tracked := ws.indexModTime(), make(map[string]bool)
+	for _, e := range entries {
+		tracked[e.Path()] = true
+		mode := normalizeFileMode(e.Mode())
+		code, wtMode, err := ws.worktreeChange(e, mode, indexTime)
+		if err != nil {
+			return nil, err
+		}
+		s, ok := files[e.Path()]
+		if !ok && code == Status_Unmodified {
+			continue
+		}
+		if !ok {
+			s = fileOf(e.Path())
+			s.HeadMode, s.HeadOid = mode, e.Oid()
+			s.IndexMode, s.IndexOid = mode, e.Oid()
+		}
+		s.Worktree, s.WorktreeMode = code, wtMode
+	}
+	for p := range unmerged {
+		tracked[p] = true
+		if fi, err := os.Lstat(filepath.Join(ws.root, p)); err == nil {
+			files[p].WorktreeMode = worktreeFileMode(fi)
+		}
+	}
+
+	status := &Status{Files: make([]*FileStatus, 0, len(files))}
+	for p, s := range files {
+		if MatchPathspec(p, paths) {
+			status.Files = append(status.Files, s)
+		}
+	}
+	sort.Slice(status.Files, func(i, j int) bool { return status.Files[i].Path < status.Files[j].Path })
+
+	if untracked != Untracked_No {
+		matcher, err := ws.IgnoreMatcher()
+		if err != nil {
+			return nil, err
+		}
+		untrack, ignoredFiles, err := ws.untrackedFiles(tracked, untracked, matcher)
+		if err != nil {
+			return nil, err
+		}
+		status.Untracked = filterPathspec(untrack, paths)
+		if ignored {
+			status.Ignored = filterPathspec(ignoredFiles, paths)
+		}
+		sort.Strings(status.Untracked)
+		sort.Strings(status.Ignored)
+	}
+	return status, nil
+}
+
+// treeFiles collects files in the tree oid with their paths led by prefix, in the order of paths
+func (r *Repository) treeFiles(oid common.Hash, prefix string, files *common.NameHashPairs) error {
+	entries, err := r.treeEntries(oid)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.Filemode == common.Dir {
+			if err := r.treeFiles(e.Oid, prefix+e.Name+"/", files); err != nil {
+				return err
+			}
+			continue
+		}
+		*files = append(*files, &common.NameHashPair{Oid: e.Oid, Name: prefix + e.Name, Mode: e.Filemode})
+	}
+	return nil
+}
+
+// worktreeChange compares the file in the work tree with the index entry e, whose mode is given
+func (ws *Workspace) worktreeChange(e *index.IndexEntry, mode common.FileMode, indexTime int64) (StatusCode, common.FileMode, error) {
+	if mode == common.Submodule {
+		return Status_Unmodified, mode, nil
+	}
+	fp := filepath.Join(ws.root, e.Path())
+	fi, err := os.Lstat(fp)
+	if os.IsNotExist(err) || (err == nil && fi.IsDir()) {
+		return Status_Deleted, common.Empty, nil
+	}
+	if err != nil {
+		return Status_Unmodified, common.Empty, err
+	}
+
+	wtMode := worktreeFileMode(fi)
+	switch {
+	case wtMode&^0777 != mode&^0777:
+		return Status_TypeChanged, wtMode, nil
+	case wtMode != mode:
+		return Status_Modified, wtMode, nil
+	}
+	if e.ModTime().Equal(fi.ModTime()) && e.Size() == int64(uint32(fi.Size())) && e.ModTime().UnixNano() < indexTime {
+		return Status_Unmodified, wtMode, nil
+	}
+
+	var oid common.Hash
+	if wtMode == common.Symlink {
+		target, err := os.Readlink(fp)
+		if err != nil {
+			return Status_Unmodified, wtMode, err
+		}
+		oid = common.HashObject(object.Kind_Blob.String(), []byte(target))
+	} else if oid, err = HashObjectFromPath(fp, object.Kind_Blob, nil); err != nil {
+		return Status_Unmodified, wtMode, err
+	}
+	if oid != e.Oid() {
+		return Status_Modified, wtMode, nil
+	}
+	return Status_Unmodified, wtMode, nil
+}
+
+// indexModTime returns the time the index is written in nanoseconds, 0 if it does not exist
+func (ws *Workspace) indexModTime() int64 {
+	fi, err := os.Stat(ws.stageingArea.path)
+	if err != nil {
+		return 0
+	}
+	return fi.ModTime().UnixNano()
+}
+
+// normalizeFileMode converts modes in the index, which may be those of stat(2), into the modes git records
+func normalizeFileMode(m common.FileMode) common.FileMode {
+	switch m & 0170000 {
+	case common.Symlink:
+		return common.Symlink
+	case common.Submodule:
+		return common.Submodule
+	case common.Dir:
+		return common.Dir
+	}
+	if m&0111 != 0 {
+		return common.Executable
+	}
+	return common.Regular
+}
+
+// worktreeFileMode is the mode git records for the file in the work tree
+func worktreeFileMode(fi fs.FileInfo) common.FileMode {
+	switch {
+	case fi.Mode()&fs.ModeSymlink != 0:
+		return common.Symlink
+	case fi.IsDir():
+		return common.Dir
+	case fi.Mode()&0111 != 0:
+		return common.Executable
+	}
+	return common.Regular
+}
+
+// unmergedCodes returns the status codes of an unmerged file by the stages present, which are base, ours and theirs
+func unmergedCodes(stages [3]common.FileMode) (StatusCode, StatusCode) {
+	base, ours, theirs := stages[0] != 0, stages[1] != 0, stages[2] != 0
+	switch {
+	case !base && ours && !theirs:
+		return Status_Added, Status_Unmerged
+	case !base && !ours && theirs:
+		return Status_Unmerged, Status_Added
+	case !base && ours && theirs:
+		return Status_Added, Status_Added
+	case base && !ours && !theirs:
+		return Status_Deleted, Status_Deleted
+	case base && ours && !theirs:
+		return Status_Unmerged, Status_Deleted
+	case base && !ours && theirs:
+		return Status_Deleted, Status_Unmerged
+	}
+	return Status_Unmerged, Status_Unmerged
+}
+
+func withoutPaths(pairs common.NameHashPairs, excluded map[string]bool) common.NameHashPairs {
+	if len(excluded) == 0 {
+		return pairs
+	}
+	result := make(common.NameHashPairs, 0, len(pairs))
+	for _, p := range pairs {
+		if !excluded[p.Name] {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// trackedDirs returns all directories which have tracked files in them
+func trackedDirs(tracked map[string]bool) map[string]bool {
+	dirs := make(map[string]bool)
+	for p := range tracked {
+		for dir := path.Dir(p); dir != "." && !dirs[dir]; dir = path.Dir(dir) {
+			dirs[dir] = true
+		}
+	}
+	return dirs
+}
+
+// filterPathspec keeps paths matching any of pathspec, and directories which may have files matching them
+func filterPathspec(paths []string, pathspec []string) []string {
+	if len(pathspec) == 0 {
+		return paths
+	}
+	result := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if strings.HasSuffix(p, "/") && matchPathspecDir(strings.TrimSuffix(p, "/"), pathspec) ||
+			MatchPathspec(p, pathspec) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// UntrackedFiles returns files in the work tree which are not in the index, collected as mode says, and those
+// ignored by matcher separately. Nothing is ignored if matcher is nil. Both are sorted, directories end with "/".
+func (ws *Workspace) UntrackedFiles(mode UntrackedMode, matcher *IgnoreMatcher) ([]string, []string, error) {
+	if err := ws.CheckWorkTree(); err != nil {
+		return nil, nil, err
+	}
+	sa := ws.StagingArea()
+	sa.Load()
+	tracked := make(map[string]bool)
+	sa.Foreach(func(e *index.IndexEntry) {
+		tracked[e.Path()] = true
+	})
+	untracked, ignored, err := ws.untrackedFiles(tracked, mode, matcher)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Strings(untracked)
+	sort.Strings(ignored)
+	return untracked, ignored, nil
+}
+
+func (ws *Workspace) untrackedFiles(tracked map[string]bool, mode UntrackedMode, matcher *IgnoreMatcher) ([]string, []string, error) {
+	if mode == Untracked_No {
+		return []string{}, []string{}, nil
+	}
+	w := &untrackedWalker{
+		root:    ws.root,
+		mode:    mode,
+		matcher: matcher,
+		tracked: tracked,
+		dirs:    trackedDirs(tracked),
+		untrack: make([]string, 0),
+		ignored: make([]string, 0),
+	}
+	if err := w.walk("", false); err != nil {
+		return nil, nil, err
+	}
+	return w.untrack, w.ignored, nil
+}
+
+// untrackedWalker finds untracked and ignored files in the work tree
+type untrackedWalker struct {
+	root    string
+	mode    UntrackedMode
+	matcher *IgnoreMatcher
+	// tracked files and directories which have them
+	tracked map[string]bool
+	dirs    map[string]bool
+
+	untrack []string
+	ignored []string
+}
+
+// walk collects untracked and ignored files in dir, all of which are ignored if dirIgnored. Untracked directories
+// are collected as a whole unless in Untracked_All, and so are ignored directories, or those which have ignored
+// files only.
+func (w *untrackedWalker) walk(dir string, dirIgnored bool) error {
+	entries, err := os.ReadDir(filepath.Join(w.root, filepath.FromSlash(dir)))
+	if err != nil {
+		return err
+	}
+	for _, d := range entries {
+		p := d.Name()
+		if dir != "" {
+			p = dir + "/" + d.Name()
+		}
+		if d.IsDir() {
+			if dir == "" && d.Name() == repositoryName {
+				continue
+			}
+			if w.dirs[p] {
+				if err := w.walk(p, dirIgnored); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := w.walkUntrackedDir(p, dirIgnored || w.isIgnored(p, true)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if w.tracked[p] {
+			continue
+		}
+		if dirIgnored || w.isIgnored(p, false) {
+			w.ignored = append(w.ignored, p)
+		} else {
+			w.untrack = append(w.untrack, p)
+		}
+	}
+	return nil
+}
+
+// isIgnored reports whether p is ignored by its own rules, leading directories are checked while walking
+func (w *untrackedWalker) isIgnored(p string, isDir bool) bool {
+	return w.matcher != nil && w.matcher.ignored(p, isDir)
+}
+
+// walkUntrackedDir collects the directory p which has no tracked files
+func (w *untrackedWalker) walkUntrackedDir(p string, ignored bool) error {
+	// another repository is not looked into
+	if _, err := os.Lstat(filepath.Join(w.root, filepath.FromSlash(p), repositoryName)); err == nil {
+		if ignored {
+			w.ignored = append(w.ignored, p+"/")
+		} else {
+			w.untrack = append(w.untrack, p+"/")
+		}
+		return nil
+	}
+	if w.mode == Untracked_All {
+		return w.walk(p, ignored)
+	}
+
+	// nested untracked directories are collapsed in the same way
+	sub := &untrackedWalker{
+		root:    w.root,
+		mode:    w.mode,
+		matcher: w.matcher,
+		tracked: w.tracked,
+		dirs:    w.dirs,
+		untrack: make([]string, 0),
+		ignored: make([]string, 0),
+	}
+	if err := sub.walk(p, ignored); err != nil {
+		return err
+	}
+	switch {
+	case len(sub.untrack) > 0:
+		w.untrack = append(w.untrack, p+"/")
+		w.ignored = append(w.ignored, sub.ignored...)
+	case len(sub.ignored) > 0:
+		w.ignored = append(w.ignored, p+"/")
+	}
+	return nil
+}
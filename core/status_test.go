@@ -0,0 +1,66 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatus(t *testing.T) {
+	ws := newTestWorkspace(t)
+	repo := ws.Repository()
+	write, stage := ws.write, ws.stage
+
+	write("a", "a\n")
+	write("b", "b\n")
+	write("d/c", "c\n")
+	write(".gitignore", "*.o\nbuild/\n!keep.o\n")
+	stage("a", "b", "d/c", ".gitignore")
+
+	// nothing is committed yet
+	status, err := ws.Status(nil, Untracked_Normal, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(status.Files))
+	assert.Equal(t, Status_Added, status.Files[0].Staging)
+	assert.Equal(t, ".gitignore", status.Files[0].Path)
+
+	treeId, err := ws.StagingArea().WriteTree(repo)
+	assert.Nil(t, err)
+	assert.Nil(t, ws.References().WriteRef("refs/heads/main", newTestHistory(t, repo).commitTree("init", treeId, 0)))
+
+	status, err = ws.Status(nil, Untracked_Normal, true)
+	assert.Nil(t, err)
+	assert.Empty(t, status.Files)
+	assert.Empty(t, status.Untracked)
+
+	write("a", "changed\n")
+	assert.Nil(t, os.Remove(filepath.Join(ws.Root(), "b")))
+	write("n", "n\n")
+	stage("n")
+	write("u/x", "x\n")
+	write("u/x.o", "o\n")
+	write("z.o", "o\n")
+	write("keep.o", "k\n")
+	write("build/out", "b\n")
+	write("d/e.o", "o\n")
+	write("u/deep/y.o", "o\n")
+
+	status, err = ws.Status(nil, Untracked_Normal, true)
+	assert.Nil(t, err)
+	codes := make(map[string]string)
+	for _, s := range status.Files {
+		codes[s.Path] = string([]byte{byte(s.Staging), byte(s.Worktree)})
+	}
+	assert.Equal(t, map[string]string{"a": " M", "b": " D", "n": "A "}, codes)
+	assert.Equal(t, []string{"keep.o", "u/"}, status.Untracked)
+	// u/deep has ignored files only, so it is collapsed as an ignored directory
+	assert.Equal(t, []string{"build/", "d/e.o", "u/deep/", "u/x.o", "z.o"}, status.Ignored)
+
+	status, err = ws.Status([]string{"u"}, Untracked_All, false)
+	assert.Nil(t, err)
+	assert.Empty(t, status.Files)
+	assert.Equal(t, []string{"u/x"}, status.Untracked)
+	assert.Empty(t, status.Ignored)
+}
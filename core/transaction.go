@@ -0,0 +1,340 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/izhujiang/gogit/common"
+)
+
+var (
+	ErrRefLocked        = errors.New("Unable to lock reference, another git process seems to be running in this repository.")
+	ErrRefStale         = errors.New("Reference is not at the expected value.")
+	ErrTransactionState = errors.New("Reference transaction is already prepared or closed.")
+)
+
+// RefUpdate is a change of a reference in a transaction. It verifies the reference without HaveNew,
+// deletes the reference if NewId is ZeroHash, and checks OldId before the change with HaveOld,
+// in which case ZeroHash means the reference must not exist.
+type RefUpdate struct {
+	Name    string
+	NewId   common.Hash
+	HaveNew bool
+	OldId   common.Hash
+	HaveOld bool
+	// update the symbolic reference itself, rather than the one it points to
+	NoDeref bool
+	// the reason of the update recorded in reflogs
+	Message string
+	// don't record the update in reflogs
+	SkipReflog bool
+
+	// the reference to be changed after symbolic references are followed
+	target string
+	lock   string
+	// the value before the update, ZeroHash if the reference does not exist
+	current common.Hash
+}
+
+const (
+	tx_open = iota
+	tx_prepared
+	tx_closed
+)
+
+// Transaction changes multiple references all together or none of them. References are locked by <ref>.lock files
+// and checked against their old values in Prepare, and the lock files are renamed to the references in Commit.
+type Transaction struct {
+	refs    *References
+	updates []*RefUpdate
+	state   int
+	// packed-refs.lock, which is taken in Prepare if any packed reference is deleted
+	packedLock string
+	// the content of packed-refs after the deletions
+	packedRefs []*packedRef
+}
+
+func (r *References) NewTransaction() *Transaction {
+	return &Transaction{
+		refs:    r,
+		updates: make([]*RefUpdate, 0, 4),
+	}
+}
+
+// Queue adds an update to the transaction
+func (tx *Transaction) Queue(u RefUpdate) error {
+	if tx.state != tx_open {
+		return ErrTransactionState
+	}
+	tx.updates = append(tx.updates, &u)
+	return nil
+}
+
+// Update sets name to newId, after verifying it is at oldId with haveOld
+func (tx *Transaction) Update(name string, newId common.Hash, oldId common.Hash, haveOld bool) error {
+	return tx.Queue(RefUpdate{Name: name, NewId: newId, HaveNew: true, OldId: oldId, HaveOld: haveOld})
+}
+
+// Create sets name to newId, after verifying it does not exist
+func (tx *Transaction) Create(name string, newId common.Hash) error {
+	return tx.Queue(RefUpdate{Name: name, NewId: newId, HaveNew: true, HaveOld: true})
+}
+
+// Delete removes name, after verifying it is at oldId with haveOld
+func (tx *Transaction) Delete(name string, oldId common.Hash, haveOld bool) error {
+	return tx.Queue(RefUpdate{Name: name, HaveNew: true, OldId: oldId, HaveOld: haveOld})
+}
+
+// Verify checks name is at oldId, or does not exist if oldId is ZeroHash
+func (tx *Transaction) Verify(name string, oldId common.Hash) error {
+	return tx.Queue(RefUpdate{Name: name, OldId: oldId, HaveOld: true})
+}
+
+// Prepare locks all references and checks their old values, the transaction is aborted if any of them fails
+func (tx *Transaction) Prepare() error {
+	if tx.state != tx_open {
+		return ErrTransactionState
+	}
+
+	// duplicates are found before anything is locked, or the second update would fail on the lock of the first
+	targets := make(map[string]bool)
+	for _, u := range tx.updates {
+		u.target = u.Name
+		if !u.NoDeref {
+			u.target = tx.refs.followSymbolicRef(u.Name)
+		}
+		if targets[u.target] {
+			tx.Abort()
+			return fmt.Errorf("multiple updates for ref '%s' not allowed", u.target)
+		}
+		targets[u.target] = true
+	}
+
+	for _, u := range tx.updates {
+		if err := tx.prepareUpdate(u); err != nil {
+			tx.Abort()
+			return err
+		}
+	}
+	if err := tx.preparePackedRefs(); err != nil {
+		tx.Abort()
+		return err
+	}
+
+	tx.state = tx_prepared
+	return nil
+}
+
+// prepareUpdate locks the target of u, which has been resolved, and checks its old value
+func (tx *Transaction) prepareUpdate(u *RefUpdate) error {
+	r := tx.refs
+	if u.target != "HEAD" && !CheckRefName(u.target) {
+		return fmt.Errorf("%w: %s", ErrInvalidRefName, u.target)
+	}
+
+	path := filepath.Join(r.root, filepath.FromSlash(u.target))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("%w unable to create '%s.lock': File exists.", ErrRefLocked, path)
+		}
+		return err
+	}
+	u.lock = path + ".lock"
+
+	if u.HaveNew && u.NewId != common.ZeroHash {
+		_, err = f.WriteString(u.NewId.String() + "\n")
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	current, err := r.ReadRef(u.target)
+	if err == ErrRefNotFound {
+		current, err = common.ZeroHash, nil
+	}
+	if err != nil {
+		return err
+	}
+	u.current = current
+
+	if !u.HaveOld {
+		return nil
+	}
+	switch {
+	case current == u.OldId:
+		return nil
+	case u.OldId == common.ZeroHash:
+		return fmt.Errorf("%w cannot lock ref '%s': reference already exists", ErrRefStale, u.Name)
+	case current == common.ZeroHash:
+		return fmt.Errorf("%w cannot lock ref '%s': unable to resolve reference '%s'", ErrRefStale, u.Name, u.target)
+	default:
+		return fmt.Errorf("%w cannot lock ref '%s': is at %s but expected %s", ErrRefStale, u.Name, current, u.OldId)
+	}
+}
+
+// preparePackedRefs locks packed-refs if any of the deleted references is packed, packed-refs is rewritten without them
+// in Commit before loose references are removed, so a failure leaves all references unchanged
+func (tx *Transaction) preparePackedRefs() error {
+	deleted := make(map[string]bool)
+	for _, u := range tx.updates {
+		if u.HaveNew && u.NewId == common.ZeroHash {
+			deleted[u.target] = true
+		}
+	}
+	if len(deleted) == 0 {
+		return nil
+	}
+
+	r := tx.refs
+	lock, err := r.lockPackedRefs()
+	if err != nil {
+		return err
+	}
+	refs, err := r.readPackedRefs()
+	if err != nil {
+		os.Remove(lock)
+		return err
+	}
+	kept := refs[:0]
+	for _, p := range refs {
+		if !deleted[p.name] {
+			kept = append(kept, p)
+		}
+	}
+	if len(kept) == len(refs) {
+		os.Remove(lock)
+		return nil
+	}
+
+	tx.packedLock, tx.packedRefs = lock, kept
+	return nil
+}
+
+// Commit applies all updates, the transaction is prepared at first if it is not
+func (tx *Transaction) Commit() error {
+	if tx.state == tx_open {
+		if err := tx.Prepare(); err != nil {
+			return err
+		}
+	}
+	if tx.state != tx_prepared {
+		return ErrTransactionState
+	}
+
+	r := tx.refs
+	if tx.packedLock != "" {
+		err := r.commitPackedRefs(tx.packedLock, tx.packedRefs)
+		tx.packedLock = ""
+		if err != nil {
+			tx.Abort()
+			return err
+		}
+	}
+	tx.state = tx_closed
+
+	head := r.followSymbolicRef("HEAD")
+	logged := make([]*RefUpdate, 0, len(tx.updates))
+	var result error
+	for _, u := range tx.updates {
+		path := strings.TrimSuffix(u.lock, ".lock")
+		switch {
+		case !u.HaveNew:
+			os.Remove(u.lock)
+
+		case u.NewId == common.ZeroHash:
+			err := os.Remove(path)
+			if err != nil && !os.IsNotExist(err) && result == nil {
+				result = err
+			}
+			os.Remove(u.lock)
+			r.removeEmptyDirs(path)
+			if err := r.DeleteReflog(u.target); err != nil && result == nil {
+				result = err
+			}
+
+		default:
+			if err := os.Rename(u.lock, path); err != nil {
+				os.Remove(u.lock)
+				if result == nil {
+					result = err
+				}
+				continue
+			}
+			if !u.SkipReflog {
+				logged = append(logged, u)
+			}
+		}
+	}
+
+	if err := r.logUpdates(logged, head); err != nil && result == nil {
+		result = err
+	}
+	return result
+}
+
+// logUpdates appends the updates to reflogs of the references, and to the reflog of HEAD if they are made through HEAD
+// or to the branch HEAD points to. Nothing is logged if the committer is unknown.
+func (r *References) logUpdates(updates []*RefUpdate, head string) error {
+	if len(updates) == 0 || r.reflogConfig == nil {
+		return nil
+	}
+	committer, mode, err := r.reflogConfig()
+	if err != nil {
+		return nil
+	}
+
+	for _, u := range updates {
+		e := &ReflogEntry{Old: u.current, New: u.NewId, Committer: committer, Message: reflogMessage(u.Message)}
+		if err := r.appendReflog(u.target, e, mode); err != nil {
+			return err
+		}
+		if u.target != "HEAD" && (u.Name == "HEAD" || u.target == head) {
+			if err := r.appendReflog("HEAD", e, mode); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Abort releases all locks without changing references
+func (tx *Transaction) Abort() error {
+	if tx.state == tx_closed {
+		return ErrTransactionState
+	}
+	tx.state = tx_closed
+
+	for _, u := range tx.updates {
+		if u.lock != "" {
+			os.Remove(u.lock)
+			u.lock = ""
+		}
+	}
+	if tx.packedLock != "" {
+		os.Remove(tx.packedLock)
+		tx.packedLock = ""
+	}
+	return nil
+}
+
+// followSymbolicRef returns the reference which name points to finally, name itself if it is not symbolic
+func (r *References) followSymbolicRef(name string) string {
+	for depth := 0; depth < 5; depth++ {
+		target, err := r.SymbolicRef(name)
+		if err != nil {
+			break
+		}
+		name = target
+	}
+	return name
+}
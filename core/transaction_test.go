@@ -0,0 +1,116 @@
+package core
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransaction(t *testing.T) {
+	ws, err := Init(io.Discard, t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs := ws.References()
+	gitDir := ws.Repository().Path
+
+	c1 := common.HashObject("blob", []byte("1"))
+	c2 := common.HashObject("blob", []byte("2"))
+
+	// HEAD is followed to the unborn branch
+	assert.Nil(t, refs.UpdateRef("HEAD", c1, common.ZeroHash, ""))
+	id, _ := refs.ReadRef("refs/heads/main")
+	assert.Equal(t, c1, id)
+	err = refs.UpdateRef("HEAD", c2, c2, "")
+	assert.True(t, errors.Is(err, ErrRefStale))
+	assert.Nil(t, refs.UpdateRef("HEAD", c2, c1, ""))
+
+	// all or nothing, and locks are released
+	tx := refs.NewTransaction()
+	tx.Create("refs/heads/a", c1)
+	tx.Update("refs/heads/main", c1, c1, true)
+	err = tx.Commit()
+	assert.True(t, errors.Is(err, ErrRefStale))
+	_, err = refs.ReadRef("refs/heads/a")
+	assert.Equal(t, ErrRefNotFound, err)
+	_, err = os.Stat(filepath.Join(gitDir, "refs", "heads", "a.lock"))
+	assert.True(t, os.IsNotExist(err))
+
+	tx = refs.NewTransaction()
+	tx.Create("refs/heads/a", c1)
+	tx.Verify("refs/heads/main", c2)
+	assert.Nil(t, tx.Prepare())
+	assert.Equal(t, ErrTransactionState, tx.Update("refs/heads/b", c1, common.ZeroHash, false))
+	assert.Nil(t, tx.Commit())
+	id, _ = refs.ReadRef("refs/heads/a")
+	assert.Equal(t, c1, id)
+
+	// a locked reference can't be changed
+	lock := filepath.Join(gitDir, "refs", "heads", "a.lock")
+	os.WriteFile(lock, nil, 0644)
+	err = refs.UpdateRef("refs/heads/a", c2, c1, "")
+	assert.True(t, errors.Is(err, ErrRefLocked))
+	os.Remove(lock)
+
+	// a reference can't be updated twice, even through a symbolic reference, and nothing is locked
+	tx = refs.NewTransaction()
+	tx.Delete("refs/heads/a", c1, true)
+	tx.Update("refs/heads/a", c2, common.ZeroHash, false)
+	err = tx.Commit()
+	assert.False(t, errors.Is(err, ErrRefLocked))
+	assert.EqualError(t, err, "multiple updates for ref 'refs/heads/a' not allowed")
+	_, err = os.Stat(lock)
+	assert.True(t, os.IsNotExist(err))
+
+	tx = refs.NewTransaction()
+	tx.Update("HEAD", c1, common.ZeroHash, false)
+	tx.Update("refs/heads/main", c2, common.ZeroHash, false)
+	assert.EqualError(t, tx.Prepare(), "multiple updates for ref 'refs/heads/main' not allowed")
+
+	tx = refs.NewTransaction()
+	tx.Delete("refs/heads/a", c1, true)
+	assert.Nil(t, tx.Prepare())
+	assert.Nil(t, tx.Abort())
+	id, _ = refs.ReadRef("refs/heads/a")
+	assert.Equal(t, c1, id)
+
+	tx = refs.NewTransaction()
+	tx.Delete("refs/heads/a", c1, true)
+	assert.Nil(t, tx.Commit())
+	_, err = refs.ReadRef("refs/heads/a")
+	assert.Equal(t, ErrRefNotFound, err)
+
+	// packed references are deleted through packed-refs.lock, nothing is removed if it is held by others
+	assert.Nil(t, refs.WriteRef("refs/heads/a", c1))
+	assert.Nil(t, refs.WriteRef("refs/heads/b", c2))
+	assert.Nil(t, refs.PackRefs(ws.Repository(), true, false))
+	packedLock := filepath.Join(gitDir, "packed-refs.lock")
+	os.WriteFile(packedLock, nil, 0644)
+	tx = refs.NewTransaction()
+	tx.Delete("refs/heads/a", c1, true)
+	tx.Delete("refs/heads/b", c2, true)
+	assert.Equal(t, errRefsLocked, tx.Commit())
+	id, _ = refs.ReadRef("refs/heads/a")
+	assert.Equal(t, c1, id)
+	_, err = os.Stat(filepath.Join(gitDir, "refs", "heads", "b.lock"))
+	assert.True(t, os.IsNotExist(err))
+	os.Remove(packedLock)
+
+	tx = refs.NewTransaction()
+	tx.Delete("refs/heads/a", c1, true)
+	tx.Delete("refs/heads/b", c2, true)
+	assert.Nil(t, tx.Commit())
+	for _, name := range []string{"refs/heads/a", "refs/heads/b"} {
+		_, err = refs.ReadRef(name)
+		assert.Equal(t, ErrRefNotFound, err)
+		_, err = refs.findPackedRef(name)
+		assert.Equal(t, ErrRefNotFound, err)
+	}
+	_, err = os.Stat(packedLock)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,156 @@
+package core
+
+import (
+	"strings"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core/object"
+)
+
+// DiffTrees compares files in the tree from with those in the tree to, ZeroHash is an empty tree. Changes are
+// ordered by paths, with From nil for created files and To nil for removed ones. Only files matching paths are
+// compared if any are given, see MatchPathspec.
+func (r *Repository) DiffTrees(from common.Hash, to common.Hash, paths []string) ([]*common.Change, error) {
+	changes := make([]*common.Change, 0)
+	err := r.diffTrees(from, to, "", paths, &changes)
+	return changes, err
+}
+
+func (r *Repository) diffTrees(from common.Hash, to common.Hash, prefix string, paths []string, changes *[]*common.Change) error {
+	if from == to {
+		return nil
+	}
+	a, err := r.treeEntries(from)
+	if err != nil {
+		return err
+	}
+	b, err := r.treeEntries(to)
+	if err != nil {
+		return err
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		var ea, eb *object.TreeEntry
+		switch {
+		case j == len(b):
+			ea = a[i]
+		case i == len(a):
+			eb = b[j]
+		default:
+			ka, kb := treeEntryKey(a[i]), treeEntryKey(b[j])
+			switch {
+			case ka < kb:
+				ea = a[i]
+			case ka > kb:
+				eb = b[j]
+			default:
+				ea, eb = a[i], b[j]
+			}
+		}
+		if ea != nil {
+			i++
+		}
+		if eb != nil {
+			j++
+		}
+
+		if ea != nil && eb != nil && ea.Oid == eb.Oid && ea.Filemode == eb.Filemode {
+			continue
+		}
+		name := prefix
+		if ea != nil {
+			name += ea.Name
+		} else {
+			name += eb.Name
+		}
+		if err := r.diffEntries(ea, eb, name, paths, changes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffEntries records changes between entries with the same name, either of which may be nil, and trees are compared by their files
+func (r *Repository) diffEntries(ea *object.TreeEntry, eb *object.TreeEntry, name string, paths []string, changes *[]*common.Change) error {
+	isTree := func(e *object.TreeEntry) bool { return e != nil && e.Filemode == common.Dir }
+
+	if isTree(ea) || isTree(eb) {
+		if !matchPathspecDir(name, paths) {
+			return nil
+		}
+		from, to := common.ZeroHash, common.ZeroHash
+		if isTree(ea) {
+			from = ea.Oid
+		}
+		if isTree(eb) {
+			to = eb.Oid
+		}
+		return r.diffTrees(from, to, name+"/", paths, changes)
+	}
+
+	if !MatchPathspec(name, paths) {
+		return nil
+	}
+	c := &common.Change{}
+	if ea != nil {
+		c.From = &common.NameHashPair{Oid: ea.Oid, Name: name, Mode: ea.Filemode}
+	}
+	if eb != nil {
+		c.To = &common.NameHashPair{Oid: eb.Oid, Name: name, Mode: eb.Filemode}
+	}
+	*changes = append(*changes, c)
+	return nil
+}
+
+func (r *Repository) treeEntries(oid common.Hash) ([]*object.TreeEntry, error) {
+	entries := make([]*object.TreeEntry, 0)
+	if oid == common.ZeroHash {
+		return entries, nil
+	}
+	tree, err := r.GetAsTree(oid)
+	if err != nil {
+		return nil, err
+	}
+	tree.ForEach(func(e *object.TreeEntry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	return entries, nil
+}
+
+// treeEntryKey is the name by which entries are sorted in trees, in which directories end with '/'
+func treeEntryKey(e *object.TreeEntry) string {
+	if e.Filemode == common.Dir {
+		return e.Name + "/"
+	}
+	return e.Name
+}
+
+// MatchPathspec reports whether path relative to the root of the work tree matches any of paths, which are files
+// or directories including all files in them. All paths match if paths are empty, or any of them is ".".
+func MatchPathspec(path string, paths []string) bool {
+	if len(paths) == 0 {
+		return true
+	}
+	for _, p := range paths {
+		p = strings.TrimSuffix(p, "/")
+		if p == "" || p == "." || path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPathspecDir reports whether files in the directory dir may match any of paths
+func matchPathspecDir(dir string, paths []string) bool {
+	if MatchPathspec(dir, paths) {
+		return true
+	}
+	for _, p := range paths {
+		if strings.HasPrefix(p, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package core
+
+import (
+	"strings"
+
+	"github.com/izhujiang/gogit/core/config"
+)
+
+// UpstreamRef returns the upstream of branch as a reference, such as refs/remotes/origin/main or refs/heads/main
+func UpstreamRef(c *config.Config, branch string) (string, bool) {
+	remote, _ := c.Get("branch." + branch + ".remote")
+	merge, _ := c.Get("branch." + branch + ".merge")
+	if remote == "" || merge == "" {
+		return "", false
+	}
+	if remote == "." {
+		return merge, true
+	}
+
+	for _, spec := range c.GetAll("remote." + remote + ".fetch") {
+		if ref, ok := MapRefspec(spec, merge, false); ok {
+			return ref, true
+		}
+	}
+	return "", false
+}
+
+// MapRefspec maps ref by refspec such as +refs/heads/*:refs/remotes/origin/*, from destination to source with reverse
+func MapRefspec(spec string, ref string, reverse bool) (string, bool) {
+	src, dst, found := strings.Cut(strings.TrimPrefix(spec, "+"), ":")
+	if !found {
+		return "", false
+	}
+	if reverse {
+		src, dst = dst, src
+	}
+
+	prefix, suffix, wildcard := strings.Cut(src, "*")
+	if !wildcard {
+		return dst, ref == src
+	}
+	if len(ref) < len(prefix)+len(suffix) || !strings.HasPrefix(ref, prefix) || !strings.HasSuffix(ref, suffix) {
+		return "", false
+	}
+	return strings.Replace(dst, "*", ref[len(prefix):len(ref)-len(suffix)], 1), true
+}
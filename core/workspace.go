@@ -2,79 +2,186 @@ package core
 
 import (
 	"io"
-	"log"
 	"os"
 	"path/filepath"
-	"sync"
+	"strings"
 
-	"github.com/izhujiang/gogit/core/internal/utils"
+	"github.com/izhujiang/gogit/core/config"
+	"github.com/izhujiang/gogit/core/object"
 )
 
 // workspace include working area, staging area and git repository
 
 const (
 	repositoryName = ".git"
-	repositoryRoot = ".git"
 )
 
+// Workspace is a work tree with its own repository, staging area and references,
+// multiple workspaces can be opened independently in one process.
 type Workspace struct {
+	// empty for bare repositories
+	root string
+	// the directory where the workspace is opened or discovered from, relative paths given by users are relative to it
+	cwd string
+
 	repository   *Repository
 	stageingArea *StagingArea
 	workingArea  *WorkingArea
 	references   *References
 }
 
-var lock = &sync.Mutex{}
-var singleInstance *Workspace
-
-func GetWorkspace() (*Workspace, error) {
-	if singleInstance == nil {
-		lock.Lock()
-		defer lock.Unlock()
-
-		if singleInstance == nil {
-			singleInstance = &Workspace{
-				repository: &Repository{Name: repositoryName, Path: repositoryRoot},
-				stageingArea: &StagingArea{
-					path: filepath.Join(repositoryRoot, "index"),
-				},
-				workingArea: &WorkingArea{},
-				references: &References{
-					root:     repositoryRoot,
-					headpath: filepath.Join(repositoryRoot, "HEAD"),
-				},
-			}
+// Open the workspace whose work tree is root, the repository is root/.git or the directory which the .git file points to.
+// If root itself is a git directory, it is opened as a bare repository.
+func Open(root string) (*Workspace, error) {
+	if root == "" {
+		root = "."
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	gitDir, err := resolveDotGit(root)
+	if err != nil {
+		return nil, err
+	}
+	if gitDir == "" {
+		if isGitDir(root) {
+			return newWorkspace("", root), nil
 		}
+		return nil, errRepositoryNotExists
+	}
+
+	return newWorkspace(root, gitDir), nil
+}
+
+// OpenWithStorer opens the workspace like Open, but objects are read from and written into s instead of the objects
+// directory, such as a MemoryObjectStorer for throwaway history
+func OpenWithStorer(root string, s ObjectStorer) (*Workspace, error) {
+	ws, err := Open(root)
+	if err != nil {
+		return nil, err
 	}
-	return singleInstance, nil
+	ws.repository.ObjectStorer = s
+	return ws, nil
 }
 
-// TODO: log and return err
-func (ws *Workspace) InitWorkspace(w io.Writer, root string) {
+// Init a git repository in root/.git and open the workspace, an existing repository is reopened.
+// A bare repository is laid out in root directly without work tree.
+func Init(w io.Writer, root string, bare bool) (*Workspace, error) {
 	if root == "" {
-		root = "./"
-	} else {
-		os.MkdirAll(root, 0755)
+		root = "."
 	}
-	ws.repository.InitRepository(w, root)
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+
+	gitDir := filepath.Join(root, repositoryName)
+	if bare {
+		gitDir = root
+	}
+	setupRepositoryFramework(w, gitDir, bare)
+
+	return Open(root)
+}
+
+func newWorkspace(root string, gitDir string) *Workspace {
+	ws := &Workspace{
+		root:       root,
+		cwd:        root,
+		repository: NewRepository(repositoryName, gitDir),
+		stageingArea: &StagingArea{
+			path: filepath.Join(gitDir, "index"),
+			root: root,
+		},
+		workingArea: &WorkingArea{
+			root: root,
+		},
+		references: &References{
+			root: gitDir,
+		},
+	}
+	ws.references.reflogConfig = ws.reflogConfig
+	return ws
+}
+
+// Root returns the absolute path of the work tree, empty for bare repositories
+func (ws *Workspace) Root() string {
+	return ws.root
+}
 
+// IsBare reports whether the repository has no work tree
+func (ws *Workspace) IsBare() bool {
+	return ws.root == ""
 }
 
-func GetRepository() *Repository {
-	w, _ := GetWorkspace()
-	if !utils.DirectoryExists(w.repository.Path) {
-		log.Fatal(errRepositoryNotExists)
+// CheckWorkTree returns an error for bare repositories, it is called by operations which need a work tree
+func (ws *Workspace) CheckWorkTree() error {
+	if ws.IsBare() {
+		return errNoWorkTree
 	}
+	return nil
+}
+
+// RelPath converts path, which is relative to the directory the workspace is discovered from, into the path relative to the root of the work tree
+func (ws *Workspace) RelPath(path string) (string, error) {
+	if err := ws.CheckWorkTree(); err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(ws.cwd, path)
+	}
+
+	rel, err := filepath.Rel(ws.root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errOutsideWorkTree
+	}
+	return rel, nil
+}
+
+// Config loads the config files of system, global and local scopes
+func (ws *Workspace) Config() (*config.Config, error) {
+	return config.Load(ws.repository.Path)
+}
+
+// LocalConfig reads the config file of the repository for rewriting
+func (ws *Workspace) LocalConfig() (*config.File, error) {
+	path := config.LocalConfigPath(ws.repository.Path)
+	f, err := config.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config.NewFile(path), nil
+	}
+	return f, err
+}
+
+// reflogConfig returns the committer and core.logAllRefUpdates, which is true by default in repositories with work trees
+func (ws *Workspace) reflogConfig() (*object.Signature, int, error) {
+	committer, err := ws.Identity(Role_Committer)
+	if err != nil {
+		return nil, reflog_existing, err
+	}
+	c, err := ws.Config()
+	if err != nil {
+		return nil, reflog_existing, err
+	}
+
+	if v, _ := c.Get("core.logAllRefUpdates"); strings.EqualFold(v, "always") {
+		return committer, reflog_always, nil
+	}
+	if auto, _ := c.GetBool("core.logAllRefUpdates", !ws.IsBare()); auto {
+		return committer, reflog_auto, nil
+	}
+	return committer, reflog_existing, nil
+}
 
-	return w.repository
+func (ws *Workspace) Repository() *Repository {
+	return ws.repository
 }
 
-func GetStagingArea() *StagingArea {
-	w, _ := GetWorkspace()
-	return w.stageingArea
+func (ws *Workspace) StagingArea() *StagingArea {
+	return ws.stageingArea
 }
 
-func GetReferencs() *References {
-	w, _ := GetWorkspace()
-	return w.references
+func (ws *Workspace) References() *References {
+	return ws.references
 }
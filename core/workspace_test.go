@@ -0,0 +1,118 @@
+package core
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core/internal/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenWorkspaces(t *testing.T) {
+	_, err := Open(t.TempDir())
+	assert.Equal(t, errRepositoryNotExists, err)
+
+	roots := []string{t.TempDir(), t.TempDir()}
+	workspaces := make([]*Workspace, 0, len(roots))
+	for i, root := range roots {
+		_, err := Init(io.Discard, root, false)
+		assert.Nil(t, err)
+		os.WriteFile(filepath.Join(root, "a.txt"), []byte{byte('a' + i)}, 0644)
+
+		ws, err := Open(root)
+		assert.Nil(t, err)
+		workspaces = append(workspaces, ws)
+	}
+
+	for _, ws := range workspaces {
+		sa := ws.StagingArea()
+		sa.Load()
+		assert.Nil(t, sa.Stage(ws.Repository(), []string{"a.txt"}))
+		assert.Nil(t, sa.Save())
+	}
+
+	// objects staged in one workspace are not visible in the other
+	for i, ws := range workspaces {
+		other := workspaces[1-i]
+		ws.StagingArea().ForEachEntry(func(oid common.Hash, path string) {
+			assert.True(t, ws.Repository().Has(oid))
+			assert.False(t, other.Repository().Has(oid))
+		})
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	t.Setenv(env_git_dir, "")
+	t.Setenv(env_git_work_tree, "")
+	t.Setenv(env_git_ceiling_directories, "")
+
+	root, _ := filepath.EvalSymlinks(t.TempDir())
+	_, err := Init(io.Discard, root, false)
+	assert.Nil(t, err)
+	sub := filepath.Join(root, "a", "b")
+	os.MkdirAll(sub, 0755)
+
+	ws, err := Discover(sub)
+	assert.Nil(t, err)
+	assert.Equal(t, root, ws.Root())
+	assert.Equal(t, filepath.Join(root, ".git"), ws.Repository().Path)
+	rel, err := ws.RelPath("c.txt")
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join("a", "b", "c.txt"), rel)
+	_, err = ws.RelPath("../../..")
+	assert.Equal(t, errOutsideWorkTree, err)
+
+	// stop at ceiling directories
+	t.Setenv(env_git_ceiling_directories, filepath.Join(root, "a"))
+	_, err = Discover(sub)
+	assert.Equal(t, errRepositoryNotExists, err)
+	t.Setenv(env_git_ceiling_directories, "")
+
+	// .git file pointing to the git directory
+	linked := filepath.Join(root, "a", "linked")
+	os.MkdirAll(linked, 0755)
+	os.WriteFile(filepath.Join(linked, ".git"), []byte("gitdir: ../../.git\n"), 0644)
+	ws, err = Discover(linked)
+	assert.Nil(t, err)
+	assert.Equal(t, linked, ws.Root())
+	assert.Equal(t, filepath.Join(root, ".git"), ws.Repository().Path)
+
+	// GIT_DIR and GIT_WORK_TREE
+	t.Setenv(env_git_dir, filepath.Join(root, ".git"))
+	t.Setenv(env_git_work_tree, sub)
+	ws, err = Discover(t.TempDir())
+	assert.Nil(t, err)
+	assert.Equal(t, sub, ws.Root())
+	assert.Equal(t, filepath.Join(root, ".git"), ws.Repository().Path)
+}
+
+func TestBareRepository(t *testing.T) {
+	t.Setenv(env_git_dir, "")
+	t.Setenv(env_git_work_tree, "")
+
+	root, _ := filepath.EvalSymlinks(t.TempDir())
+	ws, err := Init(io.Discard, root, true)
+	assert.Nil(t, err)
+	assert.True(t, ws.IsBare())
+	assert.Equal(t, root, ws.Repository().Path)
+	assert.False(t, utils.DirectoryExists(filepath.Join(root, ".git")))
+	assert.True(t, isBareRepository(root))
+
+	os.MkdirAll(filepath.Join(root, "refs", "heads", "topic"), 0755)
+	ws, err = Discover(filepath.Join(root, "refs", "heads", "topic"))
+	assert.Nil(t, err)
+	assert.True(t, ws.IsBare())
+	assert.Equal(t, root, ws.Repository().Path)
+	assert.Equal(t, errNoWorkTree, ws.CheckWorkTree())
+	_, err = ws.RelPath("a.txt")
+	assert.Equal(t, errNoWorkTree, err)
+
+	// a bare repository with GIT_DIR has no work tree either
+	t.Setenv(env_git_dir, root)
+	ws, err = Discover(t.TempDir())
+	assert.Nil(t, err)
+	assert.True(t, ws.IsBare())
+}
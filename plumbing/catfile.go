@@ -16,8 +16,8 @@ type CatFileOption struct {
 	PrintContent bool
 }
 
-func CatFile(w io.Writer, oid common.Hash, option *CatFileOption) error {
-	repo := core.GetRepository()
+func CatFile(ws *core.Workspace, w io.Writer, oid common.Hash, option *CatFileOption) error {
+	repo := ws.Repository()
 
 	g, err := repo.Get(oid)
 	if err != nil {
@@ -45,6 +45,9 @@ func CatFile(w io.Writer, oid common.Hash, option *CatFileOption) error {
 		case object.Kind_Commit:
 			commit := object.GitObjectToCommit(g)
 			fmt.Fprintf(w, "%s", commit.Content())
+		case object.Kind_Tag:
+			tag := object.GitObjectToTag(g)
+			fmt.Fprintf(w, "%s", tag.Content())
 		default:
 			panic("Not implemented")
 
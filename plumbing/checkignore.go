@@ -0,0 +1,101 @@
+package plumbing
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core"
+)
+
+var (
+	// ErrNotIgnored is returned by CheckIgnore if none of the paths is ignored
+	ErrNotIgnored = errors.New("No path is ignored.")
+)
+
+type CheckIgnoreOption struct {
+	// output the rule matching each path, including negative ones which re-include paths
+	Verbose bool
+	// output paths matching no rules as well, only valid with Verbose
+	NonMatching bool
+	// don't output anything, only the exit status tells whether any path is ignored
+	Quiet bool
+	// don't skip paths in the index
+	NoIndex bool
+}
+
+// CheckIgnore outputs paths, which are relative to the current directory, ignored by .gitignore files and exclude
+// files. Paths in the index are never ignored unless NoIndex is set.
+func CheckIgnore(ws *core.Workspace, w io.Writer, paths []string, option *CheckIgnoreOption) error {
+	if option.Quiet && option.Verbose {
+		return errors.New("cannot have both --quiet and --verbose")
+	}
+	if option.NonMatching && !option.Verbose {
+		return errors.New("--non-matching is only valid with --verbose")
+	}
+	if len(paths) == 0 {
+		return errors.New("no path specified")
+	}
+	if option.Quiet && len(paths) > 1 {
+		return errors.New("--quiet is only valid with a single pathname")
+	}
+
+	matcher, err := ws.IgnoreMatcher()
+	if err != nil {
+		return err
+	}
+	tracked := make(map[string]bool)
+	if !option.NoIndex {
+		sa := ws.StagingArea()
+		sa.Load()
+		sa.ForEachEntry(func(_ common.Hash, path string) {
+			tracked[path] = true
+		})
+	}
+
+	ignored := 0
+	for _, path := range paths {
+		rel, err := ws.RelPath(path)
+		if err != nil {
+			return fmt.Errorf("%s: '%s' is outside repository at '%s'", path, path, ws.Root())
+		}
+		p := filepath.ToSlash(rel)
+		if p == "." {
+			p = ""
+		}
+
+		var rule *core.IgnoreRule
+		if p != "" && !tracked[p] {
+			isDir := strings.HasSuffix(path, "/")
+			if fi, err := os.Lstat(filepath.Join(ws.Root(), rel)); err == nil && fi.IsDir() {
+				isDir = true
+			}
+			rule = matcher.Match(p, isDir)
+			if rule != nil && rule.Negative() && !option.Verbose {
+				rule = nil
+			}
+		}
+
+		if rule != nil {
+			ignored++
+		}
+		switch {
+		case option.Quiet:
+		case rule != nil && option.Verbose:
+			fmt.Fprintf(w, "%s:%d:%s\t%s\n", rule.Source, rule.Line, rule.Pattern, path)
+		case rule != nil:
+			fmt.Fprintln(w, path)
+		case option.NonMatching:
+			fmt.Fprintf(w, "::\t%s\n", path)
+		}
+	}
+
+	if ignored == 0 {
+		return ErrNotIgnored
+	}
+	return nil
+}
@@ -1,9 +1,6 @@
 package plumbing
 
 import (
-	"fmt"
-	"time"
-
 	"github.com/izhujiang/gogit/common"
 	"github.com/izhujiang/gogit/core"
 	"github.com/izhujiang/gogit/core/object"
@@ -14,21 +11,22 @@ type CommitTreeOption struct {
 	Parents []common.Hash
 	// A paragraph in the commit log message.
 	Message string
+	// Override the author in the form of "Name <email>", and the author date in formats of RFC 2822, ISO 8601 or "@<unix> <zone>"
+	Author string
+	Date   string
 }
 
-// Reads tree information into the index.
-func CommitTree(tree common.Hash, option *CommitTreeOption) (common.Hash, error) {
-	t := time.Now()
-	u := t.Unix()
-	if u < 0 {
-		u = 0
+// CommitTree creates a new commit object based on the provided tree object and writes it into the repository of ws.
+// Author and committer are taken from environment variables and config, see core.Workspace.Identity.
+func CommitTree(ws *core.Workspace, tree common.Hash, option *CommitTreeOption) (common.Hash, error) {
+	author, err := commitIdent(ws, core.Role_Author, option.Author, option.Date)
+	if err != nil {
+		return common.ZeroHash, err
+	}
+	committer, err := commitIdent(ws, core.Role_Committer, "", "")
+	if err != nil {
+		return common.ZeroHash, err
 	}
-
-	// TODO: using config info
-	s := fmt.Sprintf("%d %s", u, t.Format("-0700"))
-	email := "Jiang Zhu <m.zhujiang@gmail.com>"
-	author := fmt.Sprintf("%s %s", email, s)
-	committer := fmt.Sprintf("%s %s", email, s)
 	parents := option.Parents
 
 	c := object.NewCommit(
@@ -39,9 +37,35 @@ func CommitTree(tree common.Hash, option *CommitTreeOption) (common.Hash, error)
 		committer,
 		option.Message)
 
-	repo := core.GetRepository()
 	g := c.ToGitObject()
-	repo.Put(g)
+	err = ws.Repository().Put(g)
+
+	return g.Id(), err
+}
 
-	return g.Id(), nil
+// signature of the role, ident and date override the ones from environment and config if they are not empty
+func commitIdent(ws *core.Workspace, role string, ident string, date string) (*object.Signature, error) {
+	var sig *object.Signature
+	var err error
+	if ident != "" {
+		sig, err = object.ParseSignature(ident)
+		if err == nil && sig.Name == "" {
+			err = object.ErrInvalidSignature
+		}
+		if err == nil {
+			sig.When, err = core.IdentityDate(role)
+		}
+	} else {
+		sig, err = ws.Identity(role)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if date != "" {
+		if sig.When, err = common.ParseDate(date); err != nil {
+			return nil, err
+		}
+	}
+	return sig, nil
 }
@@ -0,0 +1,41 @@
+package plumbing
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/izhujiang/gogit/core"
+	"github.com/izhujiang/gogit/core/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommitTreeInMemory(t *testing.T) {
+	root := t.TempDir()
+	_, err := core.Init(io.Discard, root, false)
+	assert.Nil(t, err)
+	s := core.NewMemoryObjectStorer()
+	ws, err := core.OpenWithStorer(root, s)
+	assert.Nil(t, err)
+
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "a"), []byte("a\n"), 0644))
+	sa := ws.StagingArea()
+	sa.Load()
+	assert.Nil(t, sa.Stage(ws.Repository(), []string{"a"}))
+	assert.Nil(t, sa.Save())
+
+	treeId, err := WriteTree(ws, &WriteTreeOption{})
+	assert.Nil(t, err)
+	commitId, err := CommitTree(ws, treeId, &CommitTreeOption{Message: "one\n", Author: "A U Thor <author@example.com>", Date: "@1112911993 +0000"})
+	assert.Nil(t, err)
+
+	// objects are kept in the storer, none is written into the repository
+	g, err := s.Get(commitId)
+	assert.Nil(t, err)
+	assert.Equal(t, treeId, object.GitObjectToCommit(g).Tree())
+	disk, err := core.Open(root)
+	assert.Nil(t, err)
+	assert.False(t, disk.Repository().Has(treeId))
+	assert.False(t, disk.Repository().Has(commitId))
+}
@@ -10,15 +10,15 @@ import (
 type DumpOption struct {
 }
 
-func DumpObject(w io.Writer, oid common.Hash, option *DumpOption) error {
-	repo := core.GetRepository()
+func DumpObject(ws *core.Workspace, w io.Writer, oid common.Hash, option *DumpOption) error {
+	repo := ws.Repository()
 
 	repo.Dump(oid, w)
 	return nil
 }
 
-func DumpIndex(w io.Writer, option *DumpOption) error {
-	sa := core.GetStagingArea()
+func DumpIndex(ws *core.Workspace, w io.Writer, option *DumpOption) error {
+	sa := ws.StagingArea()
 	sa.Load()
 	sa.Dump(w)
 
@@ -13,6 +13,10 @@ type HashObjectOption struct {
 	Write      bool
 }
 
-func HashObject(r io.Reader, option *HashObjectOption) (common.Hash, error) {
-	return core.HashObjectFromReader(r, option.ObjectType, option.Write)
+// HashObject computes the object id, and writes the object into s with option.Write
+func HashObject(s core.ObjectStorer, r io.Reader, option *HashObjectOption) (common.Hash, error) {
+	if !option.Write {
+		s = nil
+	}
+	return core.HashObjectFromReader(r, option.ObjectType, s)
 }
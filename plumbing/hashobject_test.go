@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core"
 	"github.com/izhujiang/gogit/core/object"
 )
 
@@ -16,9 +17,19 @@ func TestFunction(t *testing.T) {
 		Write:      false,
 	}
 
-	got, _ := HashObject(buf, option)
+	s := core.NewMemoryObjectStorer()
+	got, _ := HashObject(s, buf, option)
 	expect, _ := common.NewHash("d670460b4b4aece5915caf5c68d12f560a9fe3e4")
 	if got != expect {
 		t.Fatal("Hash object expect ", expect, "got ", got)
 	}
+	if s.Has(expect) {
+		t.Fatal("Hash object should not write without option.Write")
+	}
+
+	option.Write = true
+	got, _ = HashObject(s, bytes.NewBufferString(input), option)
+	if got != expect || !s.Has(expect) {
+		t.Fatal("Hash object should write ", expect, " into the storer")
+	}
 }
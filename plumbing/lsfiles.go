@@ -1,8 +1,11 @@
 package plumbing
 
 import (
+	"errors"
+	"fmt"
 	"io"
 
+	"github.com/izhujiang/gogit/common"
 	"github.com/izhujiang/gogit/core"
 )
 
@@ -16,14 +19,57 @@ type LsFilesOption struct {
 	Directory bool
 	Unmerged  bool
 	Killed    bool
+	// exclude files by .gitignore files, $GIT_DIR/info/exclude and core.excludesFile
+	ExcludeStandard bool
 }
 
-func LsFiles(w io.Writer, option *LsFilesOption) error {
+func LsFiles(ws *core.Workspace, w io.Writer, option *LsFilesOption) error {
+	var matcher *core.IgnoreMatcher
+	if option.Ignored {
+		if !option.Others && !option.Cached {
+			return errors.New("ls-files -i must be used with either -o or -c")
+		}
+		if !option.ExcludeStandard {
+			return errors.New("ls-files --ignored needs some exclude pattern")
+		}
+	}
+	if option.ExcludeStandard {
+		m, err := ws.IgnoreMatcher()
+		if err != nil {
+			return err
+		}
+		matcher = m
+	}
+
+	// Show other (i.e. untracked) files, only ignored ones with Ignored
+	if option.Others {
+		mode := core.Untracked_All
+		if option.Directory {
+			mode = core.Untracked_Normal
+		}
+		untracked, ignored, err := ws.UntrackedFiles(mode, matcher)
+		if err != nil {
+			return err
+		}
+		if option.Ignored {
+			untracked = ignored
+		}
+		for _, p := range untracked {
+			fmt.Fprintln(w, p)
+		}
+	}
+
 	// Show cached files in the output (default)
-	sa := core.GetStagingArea()
+	sa := ws.StagingArea()
 	sa.Load()
 
-	if option.Cached {
+	if option.Cached && option.Ignored {
+		sa.ForEachEntry(func(_ common.Hash, path string) {
+			if matcher.Ignored(path, false) {
+				fmt.Fprintln(w, path)
+			}
+		})
+	} else if option.Cached {
 		sa.ListIndex(w, false)
 	}
 
@@ -16,8 +16,8 @@ type LsTreeOption struct {
 
 // LsTree list the contents of a tree object.
 // Lists the contents of a given tree object, like what "/bin/ls -a" does in the current working directory.
-func LsTree(w io.Writer, oid common.Hash, option *LsTreeOption) error {
-	repo := core.GetRepository()
+func LsTree(ws *core.Workspace, w io.Writer, oid common.Hash, option *LsTreeOption) error {
+	repo := ws.Repository()
 
 	gObj, err := repo.Get(oid)
 	if gObj.Kind() == object.Kind_Tree {
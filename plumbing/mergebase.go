@@ -0,0 +1,154 @@
+package plumbing
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core"
+	"github.com/izhujiang/gogit/core/object"
+)
+
+var (
+	// ErrNoMergeBase is returned by MergeBase if no commits are found, which is not reported as an error by git
+	ErrNoMergeBase = errors.New("No merge base found.")
+	// ErrNotAncestor is returned by MergeBase with IsAncestor if the first commit is not an ancestor of the second
+	ErrNotAncestor = errors.New("Not an ancestor.")
+
+	errMergeBaseUsage = errors.New(`usage: git merge-base [-a | --all] <commit> <commit>...
+   or: git merge-base [-a | --all] --octopus <commit>...
+   or: git merge-base --is-ancestor <commit> <commit>
+   or: git merge-base --independent <commit>...
+   or: git merge-base --fork-point <ref> [<commit>]`)
+)
+
+type MergeBaseOption struct {
+	// output all merge bases instead of the first one
+	All bool
+	// find the best common ancestors of all commits for an n-way merge
+	Octopus bool
+	// list commits which are not reachable from any others
+	Independent bool
+	// check whether the first commit is an ancestor of the second
+	IsAncestor bool
+	// find the point at which a commit forked from any value a reference has had
+	ForkPoint bool
+}
+
+// MergeBase finds the best common ancestors of the first commit in args and a hypothetical merge of the others,
+// or answers other queries of ancestry selected by option
+func MergeBase(ws *core.Workspace, w io.Writer, args []string, option *MergeBaseOption) error {
+	repo := ws.Repository()
+
+	switch {
+	case option.IsAncestor:
+		if option.All {
+			return errors.New("options '--is-ancestor' and '--all' cannot be used together")
+		}
+		if len(args) != 2 {
+			return errors.New("--is-ancestor takes exactly two commits")
+		}
+		commits, err := resolveCommits(ws, args)
+		if err != nil {
+			return err
+		}
+		ok, err := repo.IsAncestor(commits[0], commits[1])
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrNotAncestor
+		}
+		return nil
+
+	case option.ForkPoint:
+		if len(args) < 1 || len(args) > 2 {
+			return errMergeBaseUsage
+		}
+		commit := "HEAD"
+		if len(args) == 2 {
+			commit = args[1]
+		}
+		derived, err := ws.ResolveRevisionAs(commit, object.Kind_Commit)
+		if err != nil {
+			return fmt.Errorf("Not a valid object name: '%s'", commit)
+		}
+		oid, ok, err := ws.ForkPoint(args[0], derived)
+		if errors.Is(err, core.ErrRefNotFound) {
+			return fmt.Errorf("No such ref: '%s'", args[0])
+		}
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrNoMergeBase
+		}
+		fmt.Fprintln(w, oid)
+		return nil
+	}
+
+	var bases []common.Hash
+	switch {
+	case option.Independent:
+		if option.All {
+			return errors.New("options '--independent' and '--all' cannot be used together")
+		}
+		commits, err := resolveCommits(ws, args)
+		if err != nil {
+			return err
+		}
+		if bases, err = repo.IndependentCommits(commits); err != nil {
+			return err
+		}
+		// all of them are listed
+		option = &MergeBaseOption{All: true}
+	case option.Octopus:
+		commits, err := resolveCommits(ws, args)
+		if err != nil {
+			return err
+		}
+		if bases, err = repo.OctopusMergeBases(commits); err != nil {
+			return err
+		}
+	default:
+		if len(args) < 2 {
+			return errMergeBaseUsage
+		}
+		commits, err := resolveCommits(ws, args)
+		if err != nil {
+			return err
+		}
+		if bases, err = repo.MergeBasesMany(commits[0], commits[1:]); err != nil {
+			return err
+		}
+	}
+
+	if len(bases) == 0 {
+		return ErrNoMergeBase
+	}
+	if !option.All {
+		bases = bases[:1]
+	}
+	for _, oid := range bases {
+		fmt.Fprintln(w, oid)
+	}
+	return nil
+}
+
+// resolveCommits resolves args into commits which they peel to
+func resolveCommits(ws *core.Workspace, args []string) ([]common.Hash, error) {
+	commits := make([]common.Hash, 0, len(args))
+	for _, arg := range args {
+		oid, err := ws.ResolveRevision(arg)
+		if err != nil {
+			return nil, fmt.Errorf("Not a valid object name %s", arg)
+		}
+		c, err := ws.Repository().PeelToCommit(oid)
+		if err != nil {
+			return nil, fmt.Errorf("Not a valid commit name %s", arg)
+		}
+		commits = append(commits, c.Id())
+	}
+	return commits, nil
+}
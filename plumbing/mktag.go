@@ -0,0 +1,38 @@
+package plumbing
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core"
+	"github.com/izhujiang/gogit/core/object"
+)
+
+// MakeTag reads a tag object from r, validates it and writes it into the repository of ws as it is.
+// The tagged object must exist and have the type given in the tag.
+func MakeTag(ws *core.Workspace, r io.Reader) (common.Hash, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return common.ZeroHash, err
+	}
+
+	tag, err := object.ParseTag(content)
+	if err != nil {
+		return common.ZeroHash, err
+	}
+
+	repo := ws.Repository()
+	tagged, err := repo.Get(tag.Object())
+	if err != nil {
+		return common.ZeroHash, fmt.Errorf("%w could not read tagged object '%s'", object.ErrInvalidTag, tag.Object())
+	}
+	if tagged.Kind() != tag.Type() {
+		return common.ZeroHash, fmt.Errorf("%w object '%s' tagged as '%s', but is a '%s' type", object.ErrInvalidTag, tag.Object(), tag.Type(), tagged.Kind())
+	}
+
+	g := object.NewGitObject(object.Kind_Tag, content)
+	err = repo.Put(g)
+
+	return g.Id(), err
+}
@@ -0,0 +1,17 @@
+package plumbing
+
+import (
+	"github.com/izhujiang/gogit/core"
+)
+
+type PackRefsOption struct {
+	// pack all references under refs/, instead of tags and references which are already packed
+	All bool
+	// remove loose references after packing them
+	Prune bool
+}
+
+// PackRefs packs references into a single packed-refs file, annotated tags are recorded with the objects they peel to
+func PackRefs(ws *core.Workspace, option *PackRefsOption) error {
+	return ws.References().PackRefs(ws.Repository(), option.All, option.Prune)
+}
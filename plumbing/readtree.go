@@ -13,8 +13,8 @@ type ReadTreeOption struct {
 }
 
 // Reads tree information into the index.
-func ReadTree(w io.Writer, oid common.Hash, option *ReadTreeOption) error {
-	sa := core.GetStagingArea()
+func ReadTree(ws *core.Workspace, w io.Writer, oid common.Hash, option *ReadTreeOption) error {
+	sa := ws.StagingArea()
 
 	var eraseOriginal bool
 	if option.HasPrefix == false {
@@ -22,7 +22,7 @@ func ReadTree(w io.Writer, oid common.Hash, option *ReadTreeOption) error {
 
 	}
 	sa.Load()
-	err := sa.ReadTree(oid, option.Prefix, eraseOriginal)
+	err := sa.ReadTree(ws.Repository(), oid, option.Prefix, eraseOriginal)
 
 	if err != nil {
 		return err
@@ -0,0 +1,79 @@
+package plumbing
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core"
+)
+
+type RepackOption struct {
+	// Pack everything referenced into a single pack
+	All bool
+	// Remove redundant packs and loose objects which are already packed
+	Delete bool
+	// With All and Delete, unreachable objects in the removed packs become loose objects instead of being dropped
+	KeepUnreachable bool
+	Window          int
+	Depth           int
+}
+
+// Repack packs unpacked objects (or all objects with option.All) reachable from refs, HEAD and the index into a new packfile
+func Repack(ws *core.Workspace, w io.Writer, option *RepackOption) error {
+	repo := ws.Repository()
+
+	ro := &core.RepackOption{
+		All:             option.All,
+		Delete:          option.Delete,
+		KeepUnreachable: option.KeepUnreachable,
+		Window:          option.Window,
+		Depth:           option.Depth,
+	}
+	result, err := repo.Repack(reachableRoots(ws), ro)
+	if err != nil {
+		return err
+	}
+
+	if result.Total == 0 {
+		fmt.Fprintln(w, "Nothing new to pack.")
+	} else {
+		fmt.Fprintf(w, "Total %d (delta %d)\n", result.Total, result.Deltas)
+	}
+
+	return nil
+}
+
+// tips of history referenced by refs, HEAD and reflogs, and blobs staged in the index
+func reachableRoots(ws *core.Workspace) []common.Hash {
+	roots := make([]common.Hash, 0, 16)
+
+	refs := ws.References()
+	refs.ForEach(func(name string, id common.Hash) error {
+		roots = append(roots, id)
+		return nil
+	})
+	if head, err := refs.HeadCommit(); err == nil {
+		roots = append(roots, head)
+	}
+	names, _ := refs.ReflogNames()
+	for _, name := range names {
+		entries, _ := refs.Reflog(name)
+		// both sides of an entry must stay readable, like git
+		for _, e := range entries {
+			for _, id := range []common.Hash{e.Old, e.New} {
+				if id != common.ZeroHash {
+					roots = append(roots, id)
+				}
+			}
+		}
+	}
+
+	sa := ws.StagingArea()
+	sa.Load()
+	sa.ForEachEntry(func(oid common.Hash, path string) {
+		roots = append(roots, oid)
+	})
+
+	return roots
+}
@@ -0,0 +1,49 @@
+package plumbing
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core"
+	"github.com/izhujiang/gogit/core/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepackKeepsReflogOldIds(t *testing.T) {
+	ws, err := core.Init(io.Discard, t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := ws.Repository()
+	refs := ws.References()
+
+	tree := object.EmptyTree()
+	tree.Hash()
+	assert.Nil(t, repo.Put(tree.ToGitObject()))
+	sig := object.NewSignature("A U Thor", "author@example.com", time.Unix(1112911993, 0))
+	commit := func(msg string) common.Hash {
+		g := object.NewCommit(common.ZeroHash, tree.Id(), nil, sig, sig, msg).ToGitObject()
+		assert.Nil(t, repo.Put(g))
+		return g.Id()
+	}
+	old, tip := commit("old"), commit("tip")
+	assert.Nil(t, refs.UpdateRef("refs/heads/main", tip, common.ZeroHash, ""))
+
+	// old is only referenced as the old id of an entry in the reflog of HEAD
+	assert.Nil(t, refs.WriteReflog("HEAD", []*core.ReflogEntry{
+		{Old: old, New: tip, Committer: sig, Message: "checkout: moving from old to main"},
+	}))
+
+	option := &RepackOption{All: true, Delete: true, Window: core.Default_Pack_Window, Depth: core.Default_Pack_Depth}
+	assert.Nil(t, Repack(ws, io.Discard, option))
+	loose := 0
+	repo.ObjectStorer.(*core.FsObjectStorer).ForEachLooseObject(func(oid common.Hash) error {
+		loose++
+		return nil
+	})
+	assert.Equal(t, 0, loose)
+	assert.True(t, repo.Has(old))
+	assert.True(t, repo.Has(tip))
+}
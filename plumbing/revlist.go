@@ -0,0 +1,160 @@
+package plumbing
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core"
+	"github.com/izhujiang/gogit/core/object"
+)
+
+var (
+	errRevListUsage = errors.New("usage: git rev-list [<options>] <commit>... [--] [<path>...]")
+)
+
+type RevListOption struct {
+	// limit the number of commits to output, no limit if it is not positive
+	MaxCount int
+	// output commits in reverse order
+	Reverse bool
+	// follow only the first parent of merge commits
+	FirstParent bool
+	// show no parents before all of their children, and avoid intermixing lines of history
+	TopoOrder bool
+	// show no parents before all of their children, otherwise in commit date order
+	DateOrder bool
+	// pretend all references and HEAD are listed on the command line
+	All bool
+	// print the number of commits instead of them
+	Count bool
+	// limit commits to those changing files in paths, which are relative to the current directory
+	Paths []string
+	// limit commits to those with authors matching the regular expression Author
+	Author string
+	// limit commits to those with messages matching the regular expression Grep
+	Grep string
+	// limit commits to those more recent than Since, and older than Until, in dates like "2.weeks.ago"
+	Since string
+	Until string
+}
+
+// RevList lists commits reachable from revisions in args, but not from those given with ^ in front of them,
+// in reverse chronological order by default
+func RevList(ws *core.Workspace, w io.Writer, args []string, option *RevListOption) error {
+	if len(args) == 0 && !option.All {
+		return errRevListUsage
+	}
+	walk, err := WalkRevisions(ws, args, option)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	err = walk.ForEach(func(c *object.Commit) error {
+		count++
+		if !option.Count {
+			fmt.Fprintf(w, "%s\n", c.Id())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if option.Count {
+		fmt.Fprintf(w, "%d\n", count)
+	}
+	return nil
+}
+
+// WalkRevisions prepares a walk of commits from revisions in args with ordering and limiting options
+func WalkRevisions(ws *core.Workspace, args []string, option *RevListOption) (*core.RevWalk, error) {
+	walk := ws.Repository().NewRevWalk()
+	walk.MaxCount = option.MaxCount
+	walk.Reverse = option.Reverse
+	walk.FirstParent = option.FirstParent
+	switch {
+	case option.TopoOrder:
+		walk.Order = core.Order_Topo
+	case option.DateOrder:
+		walk.Order = core.Order_Date
+	}
+
+	if err := limitRevisions(ws, walk, option); err != nil {
+		return nil, err
+	}
+
+	if option.All {
+		refs := ws.References()
+		if head, err := refs.HeadCommit(); err == nil {
+			if err := walk.Push(head); err != nil {
+				return nil, err
+			}
+		}
+		err := refs.ForEach(func(name string, id common.Hash) error {
+			// references to objects other than commits, such as tags of trees, are skipped
+			walk.Push(id)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := ws.PushRevisions(walk, args); err != nil {
+		return nil, err
+	}
+	return walk, nil
+}
+
+// limitRevisions applies limits on paths, authors, messages and dates of commits in option to the walk
+func limitRevisions(ws *core.Workspace, walk *core.RevWalk, option *RevListOption) error {
+	for _, path := range option.Paths {
+		rel, err := ws.RelPath(path)
+		if err != nil {
+			return err
+		}
+		walk.Paths = append(walk.Paths, filepath.ToSlash(rel))
+	}
+
+	now := time.Now()
+	var err error
+	if option.Since != "" {
+		if walk.Since, err = common.ParseApproxidate(option.Since, now); err != nil {
+			return err
+		}
+	}
+	if option.Until != "" {
+		if walk.Until, err = common.ParseApproxidate(option.Until, now); err != nil {
+			return err
+		}
+	}
+
+	var author, grep *regexp.Regexp
+	if option.Author != "" {
+		if author, err = regexp.Compile(option.Author); err != nil {
+			return err
+		}
+	}
+	if option.Grep != "" {
+		if grep, err = regexp.Compile(option.Grep); err != nil {
+			return err
+		}
+	}
+	if author != nil || grep != nil {
+		walk.Filter = func(c *object.Commit) bool {
+			if author != nil {
+				sig := c.Author()
+				if !author.MatchString(fmt.Sprintf("%s <%s>", sig.Name, sig.Email)) {
+					return false
+				}
+			}
+			return grep == nil || grep.MatchString(c.Message())
+		}
+	}
+	return nil
+}
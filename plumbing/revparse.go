@@ -0,0 +1,119 @@
+package plumbing
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core"
+)
+
+var (
+	ErrNeedSingleRevision = errors.New("Needed a single revision")
+)
+
+type RevParseOption struct {
+	// verify exactly one argument is a valid revision
+	Verify bool
+	// with Verify, fail silently without reporting the error
+	Quiet bool
+	// like Verify, but shorten object ids to the unique prefix with Short digits at least
+	Short int
+	// print the short unambiguous names of references instead of object ids, such as main for HEAD
+	AbbrevRef bool
+	// print the full names of references instead of object ids, such as refs/heads/main for HEAD
+	SymbolicFullName bool
+}
+
+// RevParse resolves revisions in args to object ids, and writes them one per line. ^<rev> is written as ^<id>,
+// and <rev1>..<rev2> is written as the id of rev2 followed by ^<id> of rev1, either of which is HEAD if it is empty.
+func RevParse(ws *core.Workspace, w io.Writer, args []string, option *RevParseOption) error {
+	if option.Verify || option.Short > 0 {
+		if len(args) != 1 {
+			return ErrNeedSingleRevision
+		}
+		oid, err := ws.ResolveRevision(args[0])
+		if err != nil {
+			return ErrNeedSingleRevision
+		}
+		return writeRevision(ws, w, args[0], oid, "", option)
+	}
+
+	for _, arg := range args {
+		if from, to, found := strings.Cut(arg, ".."); found && !strings.HasPrefix(to, ".") {
+			if from == "" {
+				from = "HEAD"
+			}
+			if to == "" {
+				to = "HEAD"
+			}
+			toId, err := resolveArgument(ws, to)
+			if err != nil {
+				return err
+			}
+			fromId, err := resolveArgument(ws, from)
+			if err != nil {
+				return err
+			}
+			if err := writeRevision(ws, w, to, toId, "", option); err != nil {
+				return err
+			}
+			if err := writeRevision(ws, w, from, fromId, "^", option); err != nil {
+				return err
+			}
+			continue
+		}
+
+		rev, prefix := arg, ""
+		if strings.HasPrefix(arg, "^") {
+			rev, prefix = arg[1:], "^"
+		}
+		oid, err := resolveArgument(ws, rev)
+		if err != nil {
+			return err
+		}
+		if err := writeRevision(ws, w, rev, oid, prefix, option); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func resolveArgument(ws *core.Workspace, rev string) (common.Hash, error) {
+	oid, err := ws.ResolveRevision(rev)
+	if errors.Is(err, core.ErrInvalidUpstream) || errors.Is(err, core.ErrAmbiguousRevision) {
+		return common.ZeroHash, err
+	}
+	if err != nil {
+		return common.ZeroHash, fmt.Errorf("ambiguous argument '%s': unknown revision or path not in the working tree.", rev)
+	}
+	return oid, nil
+}
+
+// writeRevision writes the object id of rev led by prefix, or the name of the reference with option.AbbrevRef and
+// option.SymbolicFullName, nothing is written in that case if rev is not a reference
+func writeRevision(ws *core.Workspace, w io.Writer, rev string, oid common.Hash, prefix string, option *RevParseOption) error {
+	if option.AbbrevRef || option.SymbolicFullName {
+		name, err := ws.RevisionRefName(rev)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			return nil
+		}
+		if option.AbbrevRef {
+			name = ws.References().ShortRefName(name)
+		}
+		fmt.Fprintf(w, "%s%s\n", prefix, name)
+		return nil
+	}
+
+	if option.Short > 0 {
+		fmt.Fprintf(w, "%s%s\n", prefix, ws.Repository().ShortId(oid, option.Short))
+		return nil
+	}
+	fmt.Fprintf(w, "%s%s\n", prefix, oid)
+	return nil
+}
@@ -15,9 +15,9 @@ import (
 // For trees, it shows the names (equivalent to git ls-tree with --name-only).
 // For plain blobs, it shows the plain contents.
 // The command takes options applicable to the git diff-tree command to control how the changes the commit introduces are shown.
-func Show(w io.Writer, oid common.Hash) error {
+func Show(ws *core.Workspace, w io.Writer, oid common.Hash) error {
 	// TODO: need to be refactored
-	repo := core.GetRepository()
+	repo := ws.Repository()
 
 	g, err := repo.Get(oid)
 	switch g.Kind() {
@@ -29,9 +29,17 @@ func Show(w io.Writer, oid common.Hash) error {
 		fmt.Fprintln(w, tree.Content())
 	case object.Kind_Commit:
 		commit := object.GitObjectToCommit(g)
-		fmt.Fprintln(w, commit.Content())
+		fmt.Fprint(w, commit.Content())
 	case object.Kind_Tag:
-		panic("Not implemented")
+		tag := object.GitObjectToTag(g)
+		fmt.Fprintf(w, "tag %s\n", tag.Name())
+		if tagger := tag.Tagger(); tagger != nil {
+			fmt.Fprintf(w, "Tagger: %s <%s>\n", tagger.Name, tagger.Email)
+			fmt.Fprintf(w, "Date:   %s\n", tagger.When.Format(common.DefaultDateLayout))
+		}
+		fmt.Fprintf(w, "\n%s%s\n", tag.Message(), tag.GpgSig())
+		// followed by the tagged object
+		return Show(ws, w, tag.Object())
 	default:
 		panic("Not implemented")
 	}
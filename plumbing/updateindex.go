@@ -2,6 +2,7 @@ package plumbing
 
 import (
 	"os"
+	"path/filepath"
 
 	"github.com/izhujiang/gogit/common"
 	"github.com/izhujiang/gogit/core"
@@ -14,15 +15,15 @@ type UpdateIndexOption struct {
 }
 
 // Register file contents in the working tree to the index
-func UpdateIndex(option *UpdateIndexOption) error {
-	sa := core.GetStagingArea()
+func UpdateIndex(ws *core.Workspace, option *UpdateIndexOption) error {
+	sa := ws.StagingArea()
 	sa.Load()
 
 	switch option.Op {
 	case "replace", "add":
 		if option.Path != "" {
 			path := option.Path
-			sa.UpdateIndex(path)
+			sa.UpdateIndex(ws.Repository(), path)
 		} else {
 			oid, err := common.NewHash(option.Args["oid"])
 			if err != nil {
@@ -38,7 +39,7 @@ func UpdateIndex(option *UpdateIndexOption) error {
 		}
 
 	case "remove":
-		_, err := os.Stat(option.Path)
+		_, err := os.Stat(filepath.Join(ws.Root(), option.Path))
 		if err == nil { // file exist
 			return nil
 		}
@@ -0,0 +1,361 @@
+package plumbing
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core"
+)
+
+var (
+	errUpdateRefUsage = errors.New("usage: git update-ref [<options>] -d <refname> [<old-val>]\n   or: git update-ref [<options>]    <refname> <new-val> [<old-val>]\n   or: git update-ref [<options>] --stdin [-z]")
+)
+
+type UpdateRefOption struct {
+	// delete the reference after verifying it still contains the old value if it is given
+	Delete bool
+	// update the symbolic reference itself, rather than the one it points to
+	NoDeref bool
+	// read commands from stdin, instead of arguments
+	Stdin bool
+	// NUL-terminated format for Stdin
+	Zero bool
+	// the reason of the update recorded in reflogs
+	Message string
+}
+
+// UpdateRef updates the reference args[0] to args[1] safely, after verifying it is at args[2] if it is given,
+// or deletes it with option.Delete. With option.Stdin, commands are read from r and all the changes are made
+// in transactions, see updateRefStdin for the format.
+func UpdateRef(ws *core.Workspace, w io.Writer, r io.Reader, args []string, option *UpdateRefOption) error {
+	refs := ws.References()
+	if option.Stdin {
+		if len(args) > 0 || option.Delete {
+			return errUpdateRefUsage
+		}
+		return updateRefStdin(refs, w, r, option)
+	}
+	if option.Zero {
+		return errors.New("-z only makes sense with --stdin")
+	}
+
+	u := core.RefUpdate{NoDeref: option.NoDeref, HaveNew: true, Message: option.Message}
+	var oldValue string
+	switch {
+	case option.Delete && (len(args) == 1 || len(args) == 2):
+		u.Name = args[0]
+		if len(args) == 2 {
+			oldValue = args[1]
+		}
+	case !option.Delete && (len(args) == 2 || len(args) == 3):
+		u.Name = args[0]
+		id, err := resolveRefValue(refs, args[1])
+		if err != nil {
+			return err
+		}
+		if id == common.ZeroHash {
+			return fmt.Errorf("%s: not a valid SHA1", args[1])
+		}
+		u.NewId = id
+		if len(args) == 3 {
+			oldValue = args[2]
+		}
+	default:
+		return errUpdateRefUsage
+	}
+
+	if oldValue != "" {
+		id, err := resolveRefValue(refs, oldValue)
+		if err != nil {
+			return fmt.Errorf("%s: not a valid old SHA1", oldValue)
+		}
+		u.OldId, u.HaveOld = id, true
+	}
+	if option.Delete && u.HaveOld && u.OldId == common.ZeroHash {
+		return errors.New("delete: zero <old-oid>")
+	}
+
+	tx := refs.NewTransaction()
+	tx.Queue(u)
+	return tx.Commit()
+}
+
+// states of update-ref --stdin in the order they move forward
+const (
+	stdin_open = iota
+	stdin_started
+	stdin_prepared
+	stdin_closed
+)
+
+// updateRefStdin reads commands from r, one per line, or NUL-terminated with option.Zero:
+//
+//	update SP <ref> SP <new-oid> [SP <old-oid>]
+//	create SP <ref> SP <new-oid>
+//	delete SP <ref> [SP <old-oid>]
+//	verify SP <ref> [SP <old-oid>]
+//	option SP no-deref
+//	start, prepare, commit, abort
+//
+// Updates queued without start are committed together at the end of input, while a transaction begun by start
+// is aborted if it is not committed. start, prepare, commit and abort are reported to w.
+func updateRefStdin(refs *core.References, w io.Writer, r io.Reader, option *UpdateRefOption) error {
+	in := &stdinReader{reader: bufio.NewReader(r), zero: option.Zero}
+	tx := refs.NewTransaction()
+	state := stdin_open
+	noDeref := option.NoDeref
+
+	for {
+		cmd, err := in.command()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		next := stdin_open
+		switch cmd {
+		case "update", "create", "delete", "verify", "option":
+		case "start":
+			next = stdin_started
+		case "prepare":
+			next = stdin_prepared
+		case "commit", "abort":
+			next = stdin_closed
+		default:
+			tx.Abort()
+			return fmt.Errorf("unknown command: %s", cmd)
+		}
+
+		switch state {
+		case stdin_open, stdin_started:
+			if state == stdin_started && next == stdin_started {
+				tx.Abort()
+				return errors.New("cannot restart ongoing transaction")
+			}
+			// a transaction is never downgraded
+			if next > state {
+				state = next
+			}
+		case stdin_prepared:
+			if next != stdin_closed {
+				tx.Abort()
+				return errors.New("prepared transactions can only be closed")
+			}
+			state = next
+		case stdin_closed:
+			if next != stdin_started {
+				return errors.New("transaction is closed")
+			}
+			state = next
+			tx = refs.NewTransaction()
+		}
+
+		switch cmd {
+		case "start", "prepare", "commit", "abort":
+			if err := in.endCommand(cmd); err != nil {
+				tx.Abort()
+				return err
+			}
+			switch cmd {
+			case "prepare":
+				err = tx.Prepare()
+			case "commit":
+				err = tx.Commit()
+			case "abort":
+				err = tx.Abort()
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "%s: ok\n", cmd)
+
+		case "option":
+			opt, err := in.lastArg(cmd)
+			if err == nil && opt != "no-deref" {
+				err = fmt.Errorf("option unknown: %s", opt)
+			}
+			if err != nil {
+				tx.Abort()
+				return err
+			}
+			noDeref = true
+
+		default:
+			u, err := in.update(refs, cmd)
+			if err != nil {
+				tx.Abort()
+				return err
+			}
+			// no-deref applies to the next command only, unless it is given on the command line
+			u.NoDeref = noDeref
+			u.Message = option.Message
+			noDeref = option.NoDeref
+			tx.Queue(*u)
+		}
+	}
+
+	switch state {
+	case stdin_open:
+		return tx.Commit()
+	case stdin_started, stdin_prepared:
+		return tx.Abort()
+	}
+	return nil
+}
+
+// stdinReader reads commands of update-ref --stdin, in which arguments are separated by SP and commands are
+// terminated by LF, or with zero, the first argument is separated by SP and all arguments are terminated by NUL.
+type stdinReader struct {
+	reader *bufio.Reader
+	zero   bool
+	// arguments left in the current line
+	line []string
+}
+
+// command returns the name of the next command, and leaves its arguments to be read
+func (s *stdinReader) command() (string, error) {
+	if s.zero {
+		field, err := s.reader.ReadString(0)
+		if err == io.EOF && field == "" {
+			return "", io.EOF
+		}
+		field = strings.TrimSuffix(field, "\x00")
+		cmd, arg, found := strings.Cut(field, " ")
+		s.line = nil
+		if found {
+			s.line = []string{arg}
+		}
+		return cmd, nil
+	}
+
+	line, err := s.reader.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return "", io.EOF
+	}
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	line = strings.TrimSuffix(line, "\n")
+	if line == "" {
+		return "", errors.New("empty command in input")
+	}
+	fields := strings.Split(line, " ")
+	s.line = fields[1:]
+	return fields[0], nil
+}
+
+// arg returns the next argument of cmd, ok is false if there are no more ones in the line. In NUL-terminated format,
+// arguments after the first one are read from the following fields.
+func (s *stdinReader) arg(cmd string) (string, bool, error) {
+	if len(s.line) > 0 {
+		a := s.line[0]
+		s.line = s.line[1:]
+		return a, true, nil
+	}
+	if !s.zero {
+		return "", false, nil
+	}
+
+	field, err := s.reader.ReadBytes(0)
+	if err != nil {
+		return "", false, fmt.Errorf("%s: unexpected end of input", cmd)
+	}
+	return string(bytes.TrimSuffix(field, []byte{0})), true, nil
+}
+
+func (s *stdinReader) lastArg(cmd string) (string, error) {
+	a, ok, err := s.arg(cmd)
+	if err == nil && !ok {
+		err = fmt.Errorf("%s: missing argument", cmd)
+	}
+	if err == nil {
+		err = s.endCommand(cmd)
+	}
+	return a, err
+}
+
+func (s *stdinReader) endCommand(cmd string) error {
+	if len(s.line) > 0 {
+		return fmt.Errorf("%s: extra input: %s", cmd, strings.Join(s.line, " "))
+	}
+	return nil
+}
+
+// update parses arguments of update, create, delete and verify
+func (s *stdinReader) update(refs *core.References, cmd string) (*core.RefUpdate, error) {
+	name, ok, err := s.arg(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || name == "" {
+		return nil, fmt.Errorf("%s: missing <ref>", cmd)
+	}
+	u := &core.RefUpdate{Name: name}
+
+	// value reads an optional argument, an empty one is missing in NUL-terminated format
+	value := func(what string) (common.Hash, bool, error) {
+		a, ok, err := s.arg(cmd)
+		if err != nil || !ok || (s.zero && a == "") {
+			return common.ZeroHash, false, err
+		}
+		id, err := resolveRefValue(refs, a)
+		if err != nil {
+			return common.ZeroHash, false, fmt.Errorf("%s %s: invalid <%s>: %s", cmd, name, what, a)
+		}
+		return id, true, nil
+	}
+
+	switch cmd {
+	case "update", "create":
+		if u.NewId, u.HaveNew, err = value("new-oid"); err != nil {
+			return nil, err
+		}
+		if !u.HaveNew {
+			return nil, fmt.Errorf("%s %s: missing <new-oid>", cmd, name)
+		}
+		if cmd == "create" {
+			if u.NewId == common.ZeroHash {
+				return nil, fmt.Errorf("create %s: zero <new-oid>", name)
+			}
+			u.HaveOld = true
+		} else if u.OldId, u.HaveOld, err = value("old-oid"); err != nil {
+			return nil, err
+		}
+
+	case "delete":
+		u.HaveNew = true
+		if u.OldId, u.HaveOld, err = value("old-oid"); err != nil {
+			return nil, err
+		}
+		if u.HaveOld && u.OldId == common.ZeroHash {
+			return nil, fmt.Errorf("delete %s: zero <old-oid>", name)
+		}
+
+	case "verify":
+		// the reference must not exist if old-oid is missing
+		if u.OldId, _, err = value("old-oid"); err != nil {
+			return nil, err
+		}
+		u.HaveOld = true
+	}
+
+	if err := s.endCommand(cmd); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// resolveRefValue resolves an object id or a reference name, the empty string or 40 "0" is ZeroHash
+func resolveRefValue(refs *core.References, value string) (common.Hash, error) {
+	if value == "" {
+		return common.ZeroHash, nil
+	}
+	return refs.Resolve(value)
+}
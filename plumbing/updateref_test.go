@@ -0,0 +1,94 @@
+package plumbing
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateRefStdin(t *testing.T) {
+	c1 := common.HashObject("blob", []byte("1"))
+	c2 := common.HashObject("blob", []byte("2"))
+	zero := strings.Repeat("0", 40)
+
+	cases := []struct {
+		input string
+		zero  bool
+		out   string
+		err   string
+		// expected values of references afterwards, ZeroHash if they don't exist
+		refs map[string]common.Hash
+	}{
+		// updates without start are committed together at the end
+		{"create refs/heads/a $1\nupdate refs/heads/main $2 $1\n", false, "", "",
+			map[string]common.Hash{"refs/heads/a": c1, "refs/heads/main": c2}},
+		{"create refs/heads/a $1\nupdate refs/heads/main $2 $2\n", false, "", "is at",
+			map[string]common.Hash{"refs/heads/a": common.ZeroHash, "refs/heads/main": c1}},
+		{"verify refs/heads/main $1\nverify refs/heads/none\n", false, "", "", map[string]common.Hash{"refs/heads/main": c1}},
+		{"verify refs/heads/main $2\n", false, "", "is at", map[string]common.Hash{"refs/heads/main": c1}},
+		{"update refs/heads/main $2\ndelete refs/heads/main\n", false, "", "multiple updates for ref 'refs/heads/main' not allowed",
+			map[string]common.Hash{"refs/heads/main": c1}},
+		{"option no-deref\nupdate HEAD $2\n", false, "", "", map[string]common.Hash{"HEAD": c2, "refs/heads/main": c1}},
+
+		// transactions with start, prepare, commit and abort
+		{"start\ncreate refs/heads/a $1\nprepare\ncommit\n", false, "start: ok\nprepare: ok\ncommit: ok\n", "",
+			map[string]common.Hash{"refs/heads/a": c1}},
+		{"start\ncreate refs/heads/a $1\n", false, "start: ok\n", "", map[string]common.Hash{"refs/heads/a": common.ZeroHash}},
+		{"start\ndelete refs/heads/main $1\nabort\n", false, "start: ok\nabort: ok\n", "", map[string]common.Hash{"refs/heads/main": c1}},
+		{"start\ncreate refs/heads/a $1\ncommit\nstart\ncreate refs/heads/b $2\ncommit\n", false,
+			"start: ok\ncommit: ok\nstart: ok\ncommit: ok\n", "", map[string]common.Hash{"refs/heads/a": c1, "refs/heads/b": c2}},
+		{"start\nstart\n", false, "start: ok\n", "cannot restart ongoing transaction", nil},
+		{"start\nprepare\ncreate refs/heads/a $1\n", false, "start: ok\nprepare: ok\n", "prepared transactions can only be closed",
+			map[string]common.Hash{"refs/heads/a": common.ZeroHash}},
+		{"commit\ncreate refs/heads/a $1\n", false, "commit: ok\n", "transaction is closed", map[string]common.Hash{"refs/heads/a": common.ZeroHash}},
+
+		// malformed input
+		{"\n", false, "", "empty command in input", nil},
+		{"frob\n", false, "", "unknown command: frob", nil},
+		{"option frob\n", false, "", "option unknown: frob", nil},
+		{"start now\n", false, "", "start: extra input: now", nil},
+		{"delete refs/heads/main $1 x\n", false, "", "delete: extra input: x", map[string]common.Hash{"refs/heads/main": c1}},
+		{"update refs/heads/a\n", false, "", "update refs/heads/a: missing <new-oid>", nil},
+		{"update refs/heads/a nope\n", false, "", "update refs/heads/a: invalid <new-oid>: nope", nil},
+		{"create refs/heads/a " + zero + "\n", false, "", "create refs/heads/a: zero <new-oid>", nil},
+		{"delete refs/heads/main " + zero + "\n", false, "", "delete refs/heads/main: zero <old-oid>", nil},
+
+		// NUL-terminated format, where an empty old-oid is missing
+		{"create refs/heads/a\x00$1\x00update refs/heads/main\x00$2\x00\x00", true, "", "",
+			map[string]common.Hash{"refs/heads/a": c1, "refs/heads/main": c2}},
+		{"start\x00delete refs/heads/main\x00$1\x00commit\x00", true, "start: ok\ncommit: ok\n", "",
+			map[string]common.Hash{"refs/heads/main": common.ZeroHash}},
+		{"update refs/heads/main\x00", true, "", "update: unexpected end of input", map[string]common.Hash{"refs/heads/main": c1}},
+	}
+
+	for _, c := range cases {
+		ws, err := core.Init(io.Discard, t.TempDir(), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		refs := ws.References()
+		assert.Nil(t, refs.UpdateRef("refs/heads/main", c1, common.ZeroHash, ""))
+
+		input := strings.NewReplacer("$1", c1.String(), "$2", c2.String()).Replace(c.input)
+		out := &bytes.Buffer{}
+		err = updateRefStdin(refs, out, strings.NewReader(input), &UpdateRefOption{Stdin: true, Zero: c.zero})
+		if c.err == "" {
+			assert.Nil(t, err, c.input)
+		} else if assert.NotNil(t, err, c.input) {
+			assert.Contains(t, err.Error(), c.err, c.input)
+		}
+		assert.Equal(t, c.out, out.String(), c.input)
+		for name, expected := range c.refs {
+			id, err := refs.ReadRef(name)
+			if err == core.ErrRefNotFound {
+				id = common.ZeroHash
+			}
+			assert.Equal(t, expected, id, c.input+" "+name)
+		}
+	}
+}
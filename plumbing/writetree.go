@@ -9,11 +9,11 @@ type WriteTreeOption struct {
 	// prefix string
 }
 
-// WriteTree create a tree object from the current index (.git/index file)
-func WriteTree(option *WriteTreeOption) (common.Hash, error) {
-	sa := core.GetStagingArea()
+// WriteTree create a tree object from the current index (.git/index file), trees are written into the repository of ws
+func WriteTree(ws *core.Workspace, option *WriteTreeOption) (common.Hash, error) {
+	sa := ws.StagingArea()
 	sa.Load()
-	tid, err := sa.WriteTree()
+	tid, err := sa.WriteTree(ws.Repository())
 	if err != nil {
 		return tid, err
 	}
@@ -1,38 +1,102 @@
 package porcelain
 
 import (
+	"fmt"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
 
+	"github.com/izhujiang/gogit/common"
 	"github.com/izhujiang/gogit/core"
 )
 
-// Add file to repository and add index to stage area
-func Add(paths []string) error {
+type AddOption struct {
+	// add ignored files as well
+	Force bool
+}
+
+// Add file to repository and add index to stage area, paths are relative to the directory where the workspace is discovered.
+// Ignored files found in directories are skipped unless they are tracked, and naming them fails without Force after
+// others are added.
+func Add(ws *core.Workspace, paths []string, option *AddOption) error {
+	if err := ws.CheckWorkTree(); err != nil {
+		return err
+	}
+
+	matcher, err := ws.IgnoreMatcher()
+	if err != nil {
+		return err
+	}
+	sa := ws.StagingArea()
+	sa.Load()
+	// tracked files and directories which have them are updated even if they are ignored
+	tracked := make(map[string]bool)
+	sa.ForEachEntry(func(_ common.Hash, p string) {
+		for ; p != "." && !tracked[p]; p = path.Dir(p) {
+			tracked[p] = true
+		}
+	})
+	ignored := func(rel string, isDir bool) bool {
+		p := filepath.ToSlash(rel)
+		return !option.Force && !tracked[p] && matcher.Ignored(p, isDir)
+	}
+
+	root := ws.Root()
 	expandedPaths := make([]string, 0, 64)
+	ignoredPaths := make([]string, 0)
 	for _, path := range paths {
-		fi, err := os.Stat(path)
+		path, err := ws.RelPath(path)
+		if err != nil {
+			return err
+		}
+		fi, err := os.Stat(filepath.Join(root, path))
 		if err != nil {
 			continue
 		}
 
 		if fi.IsDir() {
-			filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
-				if d.Type().IsRegular() {
-					expandedPaths = append(expandedPaths, path)
+			if path != "." && ignored(path, true) {
+				ignoredPaths = append(ignoredPaths, filepath.ToSlash(path))
+				continue
+			}
+			filepath.WalkDir(filepath.Join(root, path), func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return nil
+				}
+				if d.IsDir() && d.Name() == ".git" {
+					return filepath.SkipDir
+				}
+				rel, _ := filepath.Rel(root, path)
+				if d.IsDir() {
+					if rel != "." && ignored(rel, true) {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				if d.Type().IsRegular() && !ignored(rel, false) {
+					expandedPaths = append(expandedPaths, rel)
 				}
 				return nil
 			})
+		} else if ignored(path, false) {
+			ignoredPaths = append(ignoredPaths, filepath.ToSlash(path))
 		} else {
-			expandedPaths = append(expandedPaths, path)
+			expandedPaths = append(expandedPaths, filepath.Clean(path))
 		}
 	}
 
-	sa := core.GetStagingArea()
-	sa.Load()
-	sa.Stage(expandedPaths)
+	sa.Stage(ws.Repository(), expandedPaths)
 	sa.Save()
 
+	// other paths are added anyway
+	if len(ignoredPaths) > 0 {
+		sort.Strings(ignoredPaths)
+		return fmt.Errorf("The following paths are ignored by one of your .gitignore files:\n%s\nhint: Use -f if you really want to add them.",
+			strings.Join(ignoredPaths, "\n"))
+	}
+
 	return nil
 }
@@ -0,0 +1,410 @@
+package porcelain
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core"
+	"github.com/izhujiang/gogit/core/config"
+)
+
+var (
+	errBranchForceCurrent   = errors.New("Cannot force update the current branch.")
+	errBranchRenameDetached = errors.New("Cannot rename the current branch while not on any.")
+	errBranchDetached       = errors.New("HEAD (detached) does not point to any branch.")
+)
+
+type BranchOption struct {
+	// 1 shows id and subject of tips along with ahead/behind counts, 2 shows names of upstream branches as well
+	Verbose int
+	// list remote-tracking branches with Remotes, both local and remote-tracking ones with All
+	Remotes bool
+	All     bool
+	// delete unmerged branches, rename or create over existing ones
+	Force bool
+	// set up upstream of the new branch to the start point, which is done for remote-tracking start points by default
+	Track bool
+}
+
+// branch in the list of git branch
+type branchItem struct {
+	label   string
+	refName string
+	current bool
+	local   bool
+}
+
+// ListBranches writes branches in order, only those matching any of the shell patterns if patterns are given
+func ListBranches(ws *core.Workspace, w io.Writer, patterns []string, option *BranchOption) error {
+	refs := ws.References()
+	repo := ws.Repository()
+
+	items := make([]branchItem, 0)
+	current := refs.CurrentBranch()
+	if current == "" && !option.Remotes {
+		if head, err := refs.ReadRef("HEAD"); err == nil {
+			items = append(items, branchItem{label: fmt.Sprintf("(HEAD detached at %s)", head.String()[:7]), refName: "HEAD", current: true})
+		}
+	}
+
+	if !option.Remotes || option.All {
+		branches, err := refs.Branches()
+		if err != nil {
+			return err
+		}
+		for _, b := range branches {
+			if matchPatterns(patterns, b) {
+				items = append(items, branchItem{label: b, refName: core.RefPrefix_Heads + b, current: b == current, local: true})
+			}
+		}
+	}
+	if option.Remotes || option.All {
+		branches, err := refs.RemoteBranches()
+		if err != nil {
+			return err
+		}
+		for _, b := range branches {
+			label := b
+			if option.All {
+				label = "remotes/" + b
+			}
+			if matchPatterns(patterns, b) {
+				items = append(items, branchItem{label: label, refName: core.RefPrefix_Remotes + b})
+			}
+		}
+	}
+
+	c, err := ws.Config()
+	if err != nil {
+		return err
+	}
+
+	width := 0
+	for _, item := range items {
+		if len(item.label) > width {
+			width = len(item.label)
+		}
+	}
+
+	for _, item := range items {
+		prefix := "  "
+		if item.current {
+			prefix = "* "
+		}
+		if option.Verbose == 0 {
+			fmt.Fprintf(w, "%s%s\n", prefix, item.label)
+			continue
+		}
+
+		oid, err := refs.ReadRef(item.refName)
+		if err != nil {
+			return err
+		}
+		commit, err := repo.PeelToCommit(oid)
+		if err != nil {
+			return err
+		}
+
+		tracking := ""
+		if item.local {
+			if tracking, err = trackingInfo(ws, c, item.label, oid, option.Verbose > 1); err != nil {
+				return err
+			}
+		}
+		subject, _, _ := strings.Cut(commit.Message(), "\n")
+		fmt.Fprintf(w, "%s%-*s %s %s%s\n", prefix, width, item.label, oid.String()[:7], tracking, subject)
+	}
+
+	return nil
+}
+
+// trackingInfo formats the relationship between branch and its upstream, such as "[origin/main: ahead 1, behind 2] "
+func trackingInfo(ws *core.Workspace, c *config.Config, branch string, oid common.Hash, withName bool) (string, error) {
+	upstream, ok := core.UpstreamRef(c, branch)
+	if !ok {
+		return "", nil
+	}
+
+	counts := ""
+	tip, err := ws.References().ReadRef(upstream)
+	if err != nil {
+		counts = "gone"
+	} else {
+		ahead, behind, err := ws.Repository().AheadBehind(oid, tip)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case ahead > 0 && behind > 0:
+			counts = fmt.Sprintf("ahead %d, behind %d", ahead, behind)
+		case ahead > 0:
+			counts = fmt.Sprintf("ahead %d", ahead)
+		case behind > 0:
+			counts = fmt.Sprintf("behind %d", behind)
+		}
+	}
+
+	switch {
+	case withName && counts != "":
+		return fmt.Sprintf("[%s: %s] ", shortRefName(upstream), counts), nil
+	case withName:
+		return fmt.Sprintf("[%s] ", shortRefName(upstream)), nil
+	case counts != "":
+		return fmt.Sprintf("[%s] ", counts), nil
+	default:
+		return "", nil
+	}
+}
+
+// CreateBranch creates branch name at startPoint, which is any commit-ish such as a branch, tag or commit id, HEAD if it is empty
+func CreateBranch(ws *core.Workspace, w io.Writer, name string, startPoint string, option *BranchOption) error {
+	refs := ws.References()
+	if option.Force && name == refs.CurrentBranch() {
+		return errBranchForceCurrent
+	}
+
+	rev := startPoint
+	if rev == "" {
+		rev = "HEAD"
+	}
+	oid, err := ws.ResolveRevision(rev)
+	if err != nil {
+		return fmt.Errorf("not a valid object name: '%s'.", startPoint)
+	}
+	commit, err := ws.Repository().PeelToCommit(oid)
+	if err != nil {
+		return err
+	}
+
+	msg := "branch: Created from "
+	if _, err := refs.ReadRef(core.RefPrefix_Heads + name); err == nil {
+		msg = "branch: Reset to "
+	}
+	switch {
+	case startPoint != "":
+		msg += startPoint
+	case refs.CurrentBranch() != "":
+		msg += refs.CurrentBranch()
+	default:
+		msg += "HEAD"
+	}
+
+	switch err := refs.CreateBranch(name, commit.Id(), option.Force, msg); err {
+	case nil:
+	case core.ErrBranchExists:
+		return fmt.Errorf("a branch named '%s' already exists", name)
+	case core.ErrInvalidRefName:
+		return fmt.Errorf("'%s' is not a valid branch name.", name)
+	default:
+		return err
+	}
+
+	if option.Track {
+		return SetUpstream(ws, w, name, startPoint)
+	}
+
+	// branches from remote-tracking ones track them by default
+	if _, err := refs.ReadRef(core.RefPrefix_Remotes + startPoint); err == nil && startPoint != "" {
+		c, err := ws.Config()
+		if err != nil {
+			return err
+		}
+		if auto, _ := c.GetBool("branch.autoSetupMerge", true); auto {
+			if remote, _, ok := remoteOfTrackingRef(c, core.RefPrefix_Remotes+startPoint); ok && remote != "" {
+				return SetUpstream(ws, w, name, startPoint)
+			}
+		}
+	}
+	return nil
+}
+
+// DeleteBranches deletes branches of names along with their config. Branches which are not merged into their upstream,
+// or HEAD if they have no upstream, are kept unless option.Force is set. The last error is reported after all names are tried.
+func DeleteBranches(ws *core.Workspace, w io.Writer, names []string, option *BranchOption) error {
+	refs := ws.References()
+	repo := ws.Repository()
+	c, err := ws.Config()
+	if err != nil {
+		return err
+	}
+	f, err := ws.LocalConfig()
+	if err != nil {
+		return err
+	}
+
+	var result error
+	for _, name := range names {
+		if name == refs.CurrentBranch() {
+			location := ws.Root()
+			if ws.IsBare() {
+				location = repo.Path
+			}
+			result = fmt.Errorf("Cannot delete branch '%s' checked out at '%s'", name, location)
+			continue
+		}
+
+		oid, err := refs.ReadRef(core.RefPrefix_Heads + name)
+		if err != nil {
+			result = fmt.Errorf("branch '%s' not found.", name)
+			continue
+		}
+
+		if !option.Force {
+			target, err := refs.ReadRef("HEAD")
+			if upstream, ok := core.UpstreamRef(c, name); ok {
+				if tip, e := refs.ReadRef(upstream); e == nil {
+					target, err = tip, nil
+				}
+			}
+			merged := false
+			if err == nil {
+				merged, _ = repo.IsAncestor(oid, target)
+			}
+			if !merged {
+				result = fmt.Errorf("The branch '%s' is not fully merged.\nIf you are sure you want to delete it, run 'gg branch -D %s'.", name, name)
+				continue
+			}
+		}
+
+		if err := refs.DeleteBranch(name); err != nil {
+			result = err
+			continue
+		}
+		f.RemoveSection("branch." + name)
+		fmt.Fprintf(w, "Deleted branch %s (was %s).\n", name, oid.String()[:7])
+	}
+
+	if err := f.Save(); err != nil {
+		return err
+	}
+	return result
+}
+
+// RenameBranch renames branch oldName, the current branch if it is empty, to newName along with its config
+func RenameBranch(ws *core.Workspace, w io.Writer, oldName string, newName string, option *BranchOption) error {
+	refs := ws.References()
+	if oldName == "" {
+		oldName = refs.CurrentBranch()
+		if oldName == "" {
+			return errBranchRenameDetached
+		}
+	}
+
+	switch err := refs.RenameBranch(oldName, newName, option.Force); err {
+	case nil:
+	case core.ErrBranchNotFound:
+		return fmt.Errorf("No branch named '%s'.", oldName)
+	case core.ErrBranchExists:
+		return fmt.Errorf("a branch named '%s' already exists", newName)
+	case core.ErrInvalidRefName:
+		return fmt.Errorf("'%s' is not a valid branch name.", newName)
+	default:
+		return err
+	}
+	if oldName == newName {
+		return nil
+	}
+
+	f, err := ws.LocalConfig()
+	if err != nil {
+		return err
+	}
+	f.RemoveSection("branch." + newName)
+	f.RenameSection("branch."+oldName, "branch."+newName)
+	return f.Save()
+}
+
+// SetUpstream sets upstream of branch name, the current branch if it is empty, to a local or remote-tracking branch
+// by branch.<name>.remote and branch.<name>.merge in config
+func SetUpstream(ws *core.Workspace, w io.Writer, name string, upstream string) error {
+	refs := ws.References()
+	if name == "" {
+		if name = refs.CurrentBranch(); name == "" {
+			return errBranchDetached
+		}
+	}
+	if _, err := refs.ReadRef(core.RefPrefix_Heads + name); err != nil {
+		return fmt.Errorf("branch '%s' does not exist", name)
+	}
+	if upstream == "" {
+		upstream = refs.CurrentBranch()
+	}
+
+	remote, merge := "", ""
+	if _, err := refs.ReadRef(core.RefPrefix_Heads + upstream); err == nil {
+		remote, merge = ".", core.RefPrefix_Heads+upstream
+	} else if _, err := refs.ReadRef(core.RefPrefix_Remotes + upstream); err == nil {
+		c, err := ws.Config()
+		if err != nil {
+			return err
+		}
+		var ok bool
+		if remote, merge, ok = remoteOfTrackingRef(c, core.RefPrefix_Remotes+upstream); !ok {
+			return fmt.Errorf("cannot set up tracking information; starting point '%s' is not a branch", upstream)
+		}
+	} else {
+		return fmt.Errorf("the requested upstream branch '%s' does not exist", upstream)
+	}
+
+	f, err := ws.LocalConfig()
+	if err != nil {
+		return err
+	}
+	if err := f.Set("branch."+name+".remote", remote); err != nil {
+		return err
+	}
+	if err := f.Set("branch."+name+".merge", merge); err != nil {
+		return err
+	}
+	if err := f.Save(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "branch '%s' set up to track '%s'.\n", name, upstream)
+	return nil
+}
+
+// UnsetUpstream removes upstream of branch name, the current branch if it is empty
+func UnsetUpstream(ws *core.Workspace, name string) error {
+	if name == "" {
+		name = ws.References().CurrentBranch()
+	}
+
+	f, err := ws.LocalConfig()
+	if err != nil {
+		return err
+	}
+	n, _ := f.UnsetAll("branch." + name + ".remote")
+	m, _ := f.UnsetAll("branch." + name + ".merge")
+	if n+m == 0 {
+		return fmt.Errorf("Branch '%s' has no upstream information", name)
+	}
+	return f.Save()
+}
+
+// remoteOfTrackingRef finds the remote whose fetch refspec maps a branch of it to the remote-tracking ref,
+// and returns the remote along with the branch on it
+func remoteOfTrackingRef(c *config.Config, ref string) (string, string, bool) {
+	for _, e := range c.Entries() {
+		if !strings.HasPrefix(e.Name, "remote.") || !strings.HasSuffix(e.Name, ".fetch") {
+			continue
+		}
+		if merge, ok := core.MapRefspec(e.Value, ref, true); ok {
+			return e.Name[len("remote.") : len(e.Name)-len(".fetch")], merge, true
+		}
+	}
+	return "", "", false
+}
+
+// shortRefName strips refs/heads/, refs/tags/ or refs/remotes/ from ref
+func shortRefName(ref string) string {
+	for _, prefix := range []string{core.RefPrefix_Heads, core.RefPrefix_Tags, core.RefPrefix_Remotes} {
+		if strings.HasPrefix(ref, prefix) {
+			return ref[len(prefix):]
+		}
+	}
+	return ref
+}
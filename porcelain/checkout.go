@@ -0,0 +1,525 @@
+package porcelain
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core"
+	"github.com/izhujiang/gogit/core/object"
+)
+
+const (
+	// commits left behind by leaving a detached HEAD which are listed, the rest are counted
+	orphan_cutoff = 4
+)
+
+var (
+	errCheckoutMissingBranch = errors.New("missing branch or commit argument")
+	errCheckoutUpdatingPaths = errors.New("'-b', '-B' and '--detach' cannot be used with updating paths")
+)
+
+type CheckoutOption struct {
+	// create the branch NewBranch at the target and switch to it, an existing one is reset with ForceCreate
+	NewBranch   string
+	ForceCreate bool
+	// detach HEAD at the target even if it is a branch
+	Detach bool
+	// discard local changes and overwrite untracked files in the way
+	Force bool
+}
+
+// checkoutTarget is the branch or commit to switch to
+type checkoutTarget struct {
+	// the revision as given, with @{-n} and "-" expanded to the branch or commit checked out before
+	name string
+	// the local branch, empty if HEAD is to be detached
+	branch string
+	commit *object.Commit
+	// no revision is given, so that HEAD is the start point of a new branch
+	implicit bool
+}
+
+// Checkout switches to a branch, or detaches HEAD at a commit, by args[0] and updates the index and the work tree to
+// its tree. Local changes are carried over unless they would be overwritten. paths are those given after "--", nil
+// without "--", and if there are any, files matching them are restored from the tree-ish args[0], or from the index
+// if args are empty. Without "--", args are paths unless args[0] is a revision, and args following it are paths.
+func Checkout(ws *core.Workspace, w io.Writer, args []string, paths []string, option *CheckoutOption) error {
+	if err := ws.CheckWorkTree(); err != nil {
+		return err
+	}
+	updatesBranch := option.NewBranch != "" || option.Detach
+
+	if paths != nil && len(args) > 1 {
+		return fmt.Errorf("only one reference expected, %d given.", len(args))
+	}
+	if len(paths) > 0 {
+		if updatesBranch {
+			return errCheckoutUpdatingPaths
+		}
+		source := ""
+		if len(args) == 1 {
+			source = args[0]
+		}
+		return checkoutPaths(ws, w, source, paths, false)
+	}
+
+	if len(args) == 0 || (args[0] == "HEAD" && len(args) == 1 && !updatesBranch) {
+		if updatesBranch {
+			return checkoutDefault(ws, w, option)
+		}
+		return checkoutHead(ws, w, option)
+	}
+
+	t, err := resolveCheckoutTarget(ws, args[0])
+	if err != nil {
+		switch {
+		case option.NewBranch != "":
+			return fmt.Errorf("'%s' is not a commit and a branch '%s' cannot be created from it", args[0], option.NewBranch)
+		case paths != nil:
+			return fmt.Errorf("invalid reference: %s", args[0])
+		case len(args) == 1 && !option.Detach:
+			if remote := uniqueRemoteBranch(ws, args[0]); remote != "" {
+				return switchToRemoteBranch(ws, w, args[0], remote, option)
+			}
+		}
+		return checkoutPaths(ws, w, "", args, true)
+	}
+	if len(args) > 1 {
+		if updatesBranch {
+			return errCheckoutUpdatingPaths
+		}
+		return checkoutPaths(ws, w, t.name, args[1:], true)
+	}
+	return switchBranch(ws, w, t, option)
+}
+
+// Switch switches to the branch args[0] like Checkout. A commit is only accepted to detach HEAD, and NewBranch is
+// created at args[0], or HEAD if args are empty. A branch which doesn't exist is created if exactly one remote has it.
+func Switch(ws *core.Workspace, w io.Writer, args []string, option *CheckoutOption) error {
+	if err := ws.CheckWorkTree(); err != nil {
+		return err
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("only one reference expected, %d given.", len(args))
+	}
+
+	if option.NewBranch != "" || option.Detach {
+		if len(args) == 0 {
+			return checkoutDefault(ws, w, option)
+		}
+		t, err := resolveCheckoutTarget(ws, args[0])
+		if err != nil {
+			return fmt.Errorf("invalid reference: %s", args[0])
+		}
+		return switchBranch(ws, w, t, option)
+	}
+
+	if len(args) == 0 {
+		return errCheckoutMissingBranch
+	}
+	t, err := resolveCheckoutTarget(ws, args[0])
+	if err != nil {
+		if remote := uniqueRemoteBranch(ws, args[0]); remote != "" {
+			return switchToRemoteBranch(ws, w, args[0], remote, option)
+		}
+		return fmt.Errorf("invalid reference: %s", args[0])
+	}
+	if t.branch == "" {
+		kind := "commit"
+		if ref, err := ws.RevisionRefName(t.name); err == nil {
+			switch {
+			case strings.HasPrefix(ref, core.RefPrefix_Tags):
+				kind = "tag"
+			case strings.HasPrefix(ref, core.RefPrefix_Remotes):
+				kind = "remote branch"
+			}
+		}
+		return fmt.Errorf("a branch is expected, got %s '%s'\nhint: If you want to detach HEAD at the commit, try again with the --detach option.", kind, args[0])
+	}
+	return switchBranch(ws, w, t, option)
+}
+
+// resolveCheckoutTarget resolves rev to a commit, which is on the local branch of the same name if it exists
+func resolveCheckoutTarget(ws *core.Workspace, rev string) (*checkoutTarget, error) {
+	refs := ws.References()
+	t := &checkoutTarget{name: rev}
+	if rev == "-" {
+		t.name = "@{-1}"
+	}
+	if strings.HasPrefix(t.name, "@{-") && strings.HasSuffix(t.name, "}") {
+		n, err := strconv.Atoi(t.name[3 : len(t.name)-1])
+		if err != nil {
+			return nil, err
+		}
+		if t.name, err = refs.PreviousBranch(n); err != nil {
+			return nil, err
+		}
+	}
+
+	oid, err := refs.ReadRef(core.RefPrefix_Heads + t.name)
+	if err == nil && core.CheckRefName(core.RefPrefix_Heads+t.name) {
+		t.branch = t.name
+	} else if oid, err = ws.ResolveRevision(t.name); err != nil {
+		return nil, err
+	}
+	if t.commit, err = ws.Repository().PeelToCommit(oid); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// uniqueRemoteBranch returns the remote-tracking branch such as origin/name if only one remote has the branch name
+func uniqueRemoteBranch(ws *core.Workspace, name string) string {
+	branches, err := ws.References().RemoteBranches()
+	if err != nil {
+		return ""
+	}
+	c, err := ws.Config()
+	if err != nil {
+		return ""
+	}
+	found := ""
+	for _, b := range branches {
+		if _, merge, ok := remoteOfTrackingRef(c, core.RefPrefix_Remotes+b); ok && merge == core.RefPrefix_Heads+name {
+			if found != "" {
+				return ""
+			}
+			found = b
+		}
+	}
+	return found
+}
+
+// switchToRemoteBranch creates the branch name tracking the remote-tracking branch remote, and switches to it
+func switchToRemoteBranch(ws *core.Workspace, w io.Writer, name string, remote string, option *CheckoutOption) error {
+	t, err := resolveCheckoutTarget(ws, remote)
+	if err != nil {
+		return err
+	}
+	o := *option
+	o.NewBranch = name
+	return switchBranch(ws, w, t, &o)
+}
+
+// checkoutDefault switches to a new branch at HEAD, or detaches HEAD, as no revision is given
+func checkoutDefault(ws *core.Workspace, w io.Writer, option *CheckoutOption) error {
+	refs := ws.References()
+	if _, err := refs.HeadCommit(); err != nil && option.NewBranch != "" {
+		// HEAD is unborn, so it points to the new branch which is unborn as well
+		refName := core.RefPrefix_Heads + option.NewBranch
+		if !core.CheckRefName(refName) {
+			return fmt.Errorf("'%s' is not a valid branch name.", option.NewBranch)
+		}
+		if _, err := refs.ReadRef(refName); err == nil {
+			return fmt.Errorf("a branch named '%s' already exists", option.NewBranch)
+		}
+		if err := refs.WriteSymbolicRef("HEAD", refName); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Switched to a new branch '%s'\n", option.NewBranch)
+		return nil
+	}
+
+	t, err := resolveCheckoutTarget(ws, "HEAD")
+	if err != nil {
+		return fmt.Errorf("invalid reference: %s", "HEAD")
+	}
+	t.implicit = true
+	return switchBranch(ws, w, t, option)
+}
+
+// checkoutHead stays on the current branch or commit, while local changes are shown, or discarded with Force
+func checkoutHead(ws *core.Workspace, w io.Writer, option *CheckoutOption) error {
+	if option.Force {
+		if oid, err := ws.References().HeadCommit(); err == nil {
+			commit, err := ws.Repository().PeelToCommit(oid)
+			if err != nil {
+				return err
+			}
+			if err := ws.CheckoutTree(commit.Tree(), commit.Tree(), true); err != nil {
+				return err
+			}
+		}
+	} else if err := writeLocalChanges(ws, w); err != nil {
+		return err
+	}
+
+	b, err := readStatusBranch(ws)
+	if err != nil {
+		return err
+	}
+	writeTracking(w, b)
+	return nil
+}
+
+// switchBranch checks out the tree of the target, creates NewBranch if it is set, and moves HEAD to the branch or
+// detaches it at the commit. The switch is recorded in the reflog of HEAD.
+func switchBranch(ws *core.Workspace, w io.Writer, t *checkoutTarget, option *CheckoutOption) error {
+	refs := ws.References()
+	repo := ws.Repository()
+
+	oldBranch := refs.CurrentBranch()
+	oldHead, err := refs.HeadCommit()
+	hasOldHead := err == nil
+	oldTree := common.ZeroHash
+	if hasOldHead {
+		old, err := repo.PeelToCommit(oldHead)
+		if err != nil {
+			return err
+		}
+		oldTree = old.Tree()
+	}
+
+	if option.Detach {
+		t.branch = ""
+	}
+	newBranchExists := false
+	if option.NewBranch != "" {
+		if !core.CheckRefName(core.RefPrefix_Heads + option.NewBranch) {
+			return fmt.Errorf("'%s' is not a valid branch name.", option.NewBranch)
+		}
+		if _, err := refs.ReadRef(core.RefPrefix_Heads + option.NewBranch); err == nil {
+			if !option.ForceCreate {
+				return fmt.Errorf("a branch named '%s' already exists", option.NewBranch)
+			}
+			newBranchExists = true
+		}
+	}
+
+	// a new branch at HEAD is created without touching the index and the work tree
+	trivial := option.NewBranch != "" && t.implicit && !option.Force
+	if !trivial {
+		if err := ws.CheckoutTree(oldTree, t.commit.Tree(), option.Force); err != nil {
+			return err
+		}
+	}
+
+	if option.NewBranch != "" {
+		if option.NewBranch == oldBranch {
+			if err := refs.CreateBranch(oldBranch, t.commit.Id(), true, "branch: Reset to "+t.name); err != nil {
+				return err
+			}
+		} else if err := CreateBranch(ws, w, option.NewBranch, t.name, &BranchOption{Force: option.ForceCreate}); err != nil {
+			return err
+		}
+		t.branch = option.NewBranch
+	}
+
+	from, to, refName := oldBranch, t.name, ""
+	if from == "" {
+		from = oldHead.String()
+	}
+	if t.branch != "" {
+		to, refName = t.branch, core.RefPrefix_Heads+t.branch
+	}
+	if err := refs.SwitchHead(refName, t.commit.Id(), "checkout: moving from "+from+" to "+to); err != nil {
+		return err
+	}
+
+	if !option.Force && !trivial {
+		if err := writeLocalChanges(ws, w); err != nil {
+			return err
+		}
+	}
+	if oldBranch == "" && hasOldHead && oldHead != t.commit.Id() {
+		if err := writeOrphanedCommits(ws, w, oldHead, t.commit.Id()); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case t.branch != "" && t.branch == oldBranch && option.NewBranch != "":
+		fmt.Fprintf(w, "Reset branch '%s'\n", t.branch)
+	case t.branch != "" && t.branch == oldBranch:
+		fmt.Fprintf(w, "Already on '%s'\n", t.branch)
+	case option.NewBranch != "" && newBranchExists:
+		fmt.Fprintf(w, "Switched to and reset branch '%s'\n", t.branch)
+	case option.NewBranch != "":
+		fmt.Fprintf(w, "Switched to a new branch '%s'\n", t.branch)
+	case t.branch != "":
+		fmt.Fprintf(w, "Switched to branch '%s'\n", t.branch)
+	default:
+		if oldBranch != "" && !option.Detach {
+			if err := writeDetachAdvice(ws, w, t.name); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(w, "HEAD is now at %s %s\n", repo.ShortId(t.commit.Id(), default_abbrev), commitSubject(t.commit.Message()))
+		return nil
+	}
+
+	// like git, upstreams just set up for new branches are not reported
+	if option.NewBranch != "" && !newBranchExists {
+		return nil
+	}
+	b, err := readStatusBranch(ws)
+	if err != nil {
+		return err
+	}
+	writeTracking(w, b)
+	return nil
+}
+
+// writeLocalChanges lists files which differ between HEAD and the work tree, such as "M\tpath"
+func writeLocalChanges(ws *core.Workspace, w io.Writer) error {
+	status, err := ws.Status(nil, core.Untracked_No, false)
+	if err != nil {
+		return err
+	}
+	for _, s := range status.Files {
+		code := s.Worktree
+		switch {
+		case s.Unmerged():
+			code = core.Status_Unmerged
+		case s.Staging == core.Status_Added && s.Worktree == core.Status_Deleted:
+			continue
+		case s.Staging == core.Status_Added || s.Worktree == core.Status_Unmodified:
+			code = s.Staging
+		}
+		fmt.Fprintf(w, "%c\t%s\n", code, s.Path)
+	}
+	return nil
+}
+
+// writeOrphanedCommits warns about commits which become unreachable from any reference by leaving the detached HEAD
+// old for newHead, or tells where HEAD was if there are none
+func writeOrphanedCommits(ws *core.Workspace, w io.Writer, old common.Hash, newHead common.Hash) error {
+	repo := ws.Repository()
+	walk := repo.NewRevWalk()
+	if err := walk.Push(old); err != nil {
+		return err
+	}
+	if err := walk.Hide(newHead); err != nil {
+		return err
+	}
+	err := ws.References().ForEach(func(name string, oid common.Hash) error {
+		if c, err := repo.PeelToCommit(oid); err == nil {
+			return walk.Hide(c.Id())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	describe := func(c *object.Commit) string {
+		return fmt.Sprintf("  %s %s\n", repo.ShortId(c.Id(), default_abbrev), commitSubject(c.Message()))
+	}
+	sb := &strings.Builder{}
+	lost := 0
+	var last *object.Commit
+	err = walk.ForEach(func(c *object.Commit) error {
+		if lost < orphan_cutoff {
+			sb.WriteString(describe(c))
+		}
+		last = c
+		lost++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if lost == 0 {
+		c, err := repo.PeelToCommit(old)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Previous HEAD position was %s %s\n", repo.ShortId(old, default_abbrev), commitSubject(c.Message()))
+		return nil
+	}
+	switch more := lost - orphan_cutoff; {
+	case more == 1:
+		sb.WriteString(describe(last))
+	case more > 1:
+		fmt.Fprintf(sb, " ... and %d more.\n", more)
+	}
+
+	commits, it := "commits", "them"
+	if lost == 1 {
+		commits, it = "commit", "it"
+	}
+	fmt.Fprintf(w, "Warning: you are leaving %d %s behind, not connected to\nany of your branches:\n\n%s\n", lost, commits, sb.String())
+	if advice, err := detachedHeadAdvice(ws); err != nil {
+		return err
+	} else if advice {
+		fmt.Fprintf(w, "If you want to keep %s by creating a new branch, this may be a good time\nto do so with:\n\n git branch <new-branch-name> %s\n\n",
+			it, repo.ShortId(old, default_abbrev))
+	}
+	return nil
+}
+
+// writeDetachAdvice explains the detached HEAD at name, unless advice.detachedHead is turned off
+func writeDetachAdvice(ws *core.Workspace, w io.Writer, name string) error {
+	if advice, err := detachedHeadAdvice(ws); err != nil || !advice {
+		return err
+	}
+	fmt.Fprintf(w, `Note: switching to '%s'.
+
+You are in 'detached HEAD' state. You can look around, make experimental
+changes and commit them, and you can discard any commits you make in this
+state without impacting any branches by switching back to a branch.
+
+If you want to create a new branch to retain commits you create, you may
+do so (now or later) by using -c with the switch command. Example:
+
+  git switch -c <new-branch-name>
+
+Or undo this operation with:
+
+  git switch -
+
+Turn off this advice by setting config variable advice.detachedHead to false
+
+`, name)
+	return nil
+}
+
+func detachedHeadAdvice(ws *core.Workspace) (bool, error) {
+	c, err := ws.Config()
+	if err != nil {
+		return false, err
+	}
+	return c.GetBool("advice.detachedHead", true)
+}
+
+// checkoutPaths restores files matching paths, which are relative to the current directory, from the tree-ish source
+// into the index and the work tree, or from the index into the work tree if source is empty. The number of files
+// written is reported if report is set.
+func checkoutPaths(ws *core.Workspace, w io.Writer, source string, paths []string, report bool) error {
+	treeId, from := common.ZeroHash, "the index"
+	if source != "" {
+		oid, err := ws.ResolveRevisionAs(source, object.Kind_Tree)
+		if err != nil {
+			return fmt.Errorf("invalid reference: %s", source)
+		}
+		treeId, from = oid, ws.Repository().ShortId(oid, default_abbrev)
+	}
+
+	specs := make([]string, 0, len(paths))
+	for _, p := range paths {
+		rel, err := ws.RelPath(p)
+		if err != nil {
+			return err
+		}
+		specs = append(specs, filepath.ToSlash(rel))
+	}
+	n, err := ws.CheckoutPaths(treeId, specs)
+	if err != nil {
+		return err
+	}
+
+	if report {
+		plural := "s"
+		if n == 1 {
+			plural = ""
+		}
+		fmt.Fprintf(w, "Updated %d path%s from %s\n", n, plural, from)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package porcelain
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/izhujiang/gogit/core"
+)
+
+var (
+	errCleanRequireForce    = errors.New("clean.requireForce defaults to true and neither -i, -n, nor -f given; refusing to clean")
+	errCleanRequireForceSet = errors.New("clean.requireForce set to true and neither -i, -n, nor -f given; refusing to clean")
+)
+
+type CleanOption struct {
+	// only show what would be removed
+	DryRun bool
+	// remove files, which is required unless clean.requireForce is false, other repositories are removed if it is
+	// given twice
+	Force int
+	// remove untracked directories as well
+	Dirs bool
+	// remove ignored files as well, or only ignored files with OnlyIgnored
+	NoIgnore    bool
+	OnlyIgnored bool
+	// don't report removed files
+	Quiet bool
+}
+
+// Clean removes untracked files matching paths, which are relative to the current directory, and those in the current
+// directory if there are none. Ignored files are kept unless option.NoIgnore or option.OnlyIgnored is set, and nothing
+// is removed without option.Force unless clean.requireForce is false.
+func Clean(ws *core.Workspace, w io.Writer, paths []string, option *CleanOption) error {
+	if err := ws.CheckWorkTree(); err != nil {
+		return err
+	}
+	if option.NoIgnore && option.OnlyIgnored {
+		return errors.New("-x and -X cannot be used together")
+	}
+	if !option.DryRun && option.Force == 0 {
+		c, err := ws.Config()
+		if err != nil {
+			return err
+		}
+		_, set := c.Get("clean.requireForce")
+		required, err := c.GetBool("clean.requireForce", true)
+		if err != nil {
+			return err
+		}
+		switch {
+		case required && set:
+			return errCleanRequireForceSet
+		case required:
+			return errCleanRequireForce
+		}
+	}
+
+	prefix, err := ws.RelPath(".")
+	if err != nil {
+		return err
+	}
+	if prefix = filepath.ToSlash(prefix); prefix == "." {
+		prefix = ""
+	}
+	specs := make([]string, 0, len(paths))
+	for _, p := range paths {
+		rel, err := ws.RelPath(p)
+		if err != nil {
+			return err
+		}
+		specs = append(specs, filepath.ToSlash(rel))
+	}
+	if len(specs) == 0 && prefix != "" {
+		specs = append(specs, prefix)
+	}
+
+	mode := core.Clean_Untracked
+	switch {
+	case option.NoIgnore:
+		mode = core.Clean_All
+	case option.OnlyIgnored:
+		mode = core.Clean_Ignored
+	}
+	files, err := ws.CleanFiles(mode, option.Dirs, option.Force > 1, specs)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range files {
+		name := quotePath(relativeStatusPath(p, prefix), false)
+		if option.DryRun {
+			fmt.Fprintf(w, "Would remove %s\n", name)
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(ws.Root(), filepath.FromSlash(p))); err != nil {
+			return err
+		}
+		if !option.Quiet {
+			fmt.Fprintf(w, "Removing %s\n", name)
+		}
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"strings"
 
 	"github.com/izhujiang/gogit/common"
 	"github.com/izhujiang/gogit/core"
@@ -13,16 +14,24 @@ import (
 
 type CommitOption struct {
 	Message string
+	// Override the author and the author date
+	Author string
+	Date   string
 }
 
-func Commit(w io.Writer, option *CommitOption) error {
+func Commit(ws *core.Workspace, w io.Writer, option *CommitOption) error {
 	// TODO: do nothing if nothing new in the StagingArea
 	// if (nothing new){
 	// return nil
 	// }
 
+	if err := ws.CheckWorkTree(); err != nil {
+		return err
+	}
+
+	repo := ws.Repository()
 	wto := &plumbing.WriteTreeOption{}
-	treeId, err := plumbing.WriteTree(wto)
+	treeId, err := plumbing.WriteTree(ws, wto)
 
 	if err != nil { // fail to WriteTree, including trees in cache are already valid
 		// TODO: promote as git status
@@ -30,7 +39,7 @@ func Commit(w io.Writer, option *CommitOption) error {
 		return err
 
 	} else {
-		refs := core.GetReferencs()
+		refs := ws.References()
 		lastCommitId, err := refs.LastCommit()
 
 		parents := make([]common.Hash, 0)
@@ -42,18 +51,25 @@ func Commit(w io.Writer, option *CommitOption) error {
 		ctOption := &plumbing.CommitTreeOption{
 			Parents: parents,
 			Message: option.Message,
+			Author:  option.Author,
+			Date:    option.Date,
 		}
-		commitId, err := plumbing.CommitTree(treeId, ctOption)
+		commitId, err := plumbing.CommitTree(ws, treeId, ctOption)
 		if err == nil {
-			// save commit id to ref/head/{branch}
-			ref := core.GetReferencs()
-			err = ref.SaveCommit(commitId)
+			// move the current branch to commit id, unless someone else has moved it meanwhile
+			oldId, reason := common.ZeroHash, "commit (initial)"
+			if len(parents) > 0 {
+				oldId, reason = lastCommitId, "commit"
+			}
+			subject, _, _ := strings.Cut(strings.TrimSpace(ctOption.Message), "\n")
+			if err = refs.UpdateRef("HEAD", commitId, oldId, reason+": "+subject); err != nil {
+				return err
+			}
 			headMsg := fmt.Sprintf("[%s %s] %s\n", refs.Head(), commitId, ctOption.Message)
 			w.Write([]byte(headMsg))
 		}
 
 		// TODO: list all commited files
-		repo := core.GetRepository()
 		g, _ := repo.Get(lastCommitId)
 		var changes *common.Changes
 		if g != nil {
@@ -61,9 +77,9 @@ func Commit(w io.Writer, option *CommitOption) error {
 			lastCommit := object.GitObjectToCommit(g)
 			lastTreeId := lastCommit.Tree()
 
-			changes, err = compareTrees(lastTreeId, treeId)
+			changes, err = compareTrees(repo, lastTreeId, treeId)
 		} else {
-			changes, err = compareTrees(common.ZeroHash, treeId)
+			changes, err = compareTrees(repo, common.ZeroHash, treeId)
 		}
 
 		for _, c := range changes.Create {
@@ -87,9 +103,7 @@ func Commit(w io.Writer, option *CommitOption) error {
 	return err
 }
 
-func compareTrees(lastTreeId common.Hash, thisTreeId common.Hash) (*common.Changes, error) {
-	repo := core.GetRepository()
-
+func compareTrees(repo *core.Repository, lastTreeId common.Hash, thisTreeId common.Hash) (*common.Changes, error) {
 	thisTree, err := repo.LoadTrees(thisTreeId)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,319 @@
+package porcelain
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/izhujiang/gogit/core"
+	"github.com/izhujiang/gogit/core/config"
+)
+
+var (
+	errConfigNoRepository = errors.New("Not in a git directory, use --global or --file to choose the config file.")
+	errConfigWrongArgs    = errors.New("Wrong number of arguments.")
+	errConfigInvalidType  = errors.New("Unknown type, which should be bool, int or path.")
+)
+
+// operations of git config
+const (
+	Config_Get       = "get"
+	Config_GetAll    = "get-all"
+	Config_GetRegexp = "get-regexp"
+	Config_Set       = "set"
+	Config_Add       = "add"
+	Config_Unset     = "unset"
+	Config_UnsetAll  = "unset-all"
+	Config_List      = "list"
+)
+
+type ConfigOption struct {
+	// Op is one of Config_* operations, Config_Get or Config_Set is guessed from the number of arguments if it is empty
+	Op string
+	// only one config file is used for reading if any of System, Global, Local and File is set, files are written into local config by default
+	System bool
+	Global bool
+	Local  bool
+	File   string
+	// bool, int or path, values are checked and canonicalized
+	Type       string
+	ShowOrigin bool
+}
+
+// Config gets and sets options in config files. ws may be nil if the command is run outside of a repository,
+// in which case only system, global and the given file are available.
+func Config(ws *core.Workspace, w io.Writer, args []string, option *ConfigOption) error {
+	op := option.Op
+	if op == "" {
+		switch len(args) {
+		case 1:
+			op = Config_Get
+		case 2:
+			op = Config_Set
+		default:
+			return errConfigWrongArgs
+		}
+	}
+
+	gitDir := ""
+	if ws != nil {
+		gitDir = ws.Repository().Path
+	}
+
+	switch op {
+	case Config_Get, Config_GetAll, Config_GetRegexp, Config_List:
+		c, err := loadConfig(gitDir, option)
+		if err != nil {
+			return err
+		}
+		return readConfig(c, w, op, args, option)
+
+	case Config_Set, Config_Add, Config_Unset, Config_UnsetAll:
+		path, err := configPathToWrite(gitDir, option)
+		if err != nil {
+			return err
+		}
+		f, err := config.ReadFile(path)
+		if os.IsNotExist(err) {
+			f, err = config.NewFile(path), nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := writeConfig(f, op, args, option); err != nil {
+			return err
+		}
+		return f.Save()
+
+	default:
+		return fmt.Errorf("config %s not implemented", op)
+	}
+}
+
+func loadConfig(gitDir string, option *ConfigOption) (*config.Config, error) {
+	switch {
+	case option.File != "":
+		return config.LoadFile(option.File, gitDir)
+	case option.System:
+		return config.LoadScope(config.ScopeSystem, gitDir)
+	case option.Global:
+		return config.LoadScope(config.ScopeGlobal, gitDir)
+	case option.Local:
+		if gitDir == "" {
+			return nil, errConfigNoRepository
+		}
+		return config.LoadScope(config.ScopeLocal, gitDir)
+	default:
+		return config.Load(gitDir)
+	}
+}
+
+func configPathToWrite(gitDir string, option *ConfigOption) (string, error) {
+	switch {
+	case option.File != "":
+		return option.File, nil
+	case option.System:
+		return config.ScopePath(config.ScopeSystem, gitDir), nil
+	case option.Global:
+		return config.ScopePath(config.ScopeGlobal, gitDir), nil
+	default:
+		if gitDir == "" {
+			return "", errConfigNoRepository
+		}
+		return config.ScopePath(config.ScopeLocal, gitDir), nil
+	}
+}
+
+func readConfig(c *config.Config, w io.Writer, op string, args []string, option *ConfigOption) error {
+	var entries []*config.Entry
+	withName := false
+
+	switch op {
+	case Config_List:
+		entries = c.Entries()
+		for _, e := range entries {
+			value, err := formatConfigValue(e, option.Type)
+			if err != nil {
+				return err
+			}
+			line := e.Name
+			if !e.NoValue || option.Type != "" {
+				line += "=" + value
+			}
+			printConfigLine(w, e, line, option)
+		}
+		return nil
+
+	case Config_Get, Config_GetAll:
+		if len(args) < 1 || len(args) > 2 {
+			return errConfigWrongArgs
+		}
+		entries = c.LookupAll(args[0])
+
+	case Config_GetRegexp:
+		if len(args) < 1 || len(args) > 2 {
+			return errConfigWrongArgs
+		}
+		re, err := regexp.Compile(args[0])
+		if err != nil {
+			return err
+		}
+		for _, e := range c.Entries() {
+			if re.MatchString(e.Name) {
+				entries = append(entries, e)
+			}
+		}
+		withName = true
+	}
+
+	if len(args) == 2 {
+		match, err := valuePattern(args[1])
+		if err != nil {
+			return err
+		}
+		filtered := entries[:0]
+		for _, e := range entries {
+			if match(e.Value) {
+				filtered = append(filtered, e)
+			}
+		}
+		entries = filtered
+	}
+
+	if len(entries) == 0 {
+		return config.ErrKeyNotFound
+	}
+	if op == Config_Get {
+		entries = entries[len(entries)-1:]
+	}
+
+	for _, e := range entries {
+		value, err := formatConfigValue(e, option.Type)
+		if err != nil {
+			return err
+		}
+		line := value
+		if withName {
+			line = e.Name
+			if !e.NoValue || option.Type != "" {
+				line += " " + value
+			}
+		}
+		printConfigLine(w, e, line, option)
+	}
+
+	return nil
+}
+
+func printConfigLine(w io.Writer, e *config.Entry, line string, option *ConfigOption) {
+	if option.ShowOrigin {
+		fmt.Fprintf(w, "file:%s\t%s\n", e.Origin, line)
+	} else {
+		fmt.Fprintln(w, line)
+	}
+}
+
+func writeConfig(f *config.File, op string, args []string, option *ConfigOption) error {
+	switch op {
+	case Config_Set, Config_Add:
+		if len(args) != 2 {
+			return errConfigWrongArgs
+		}
+		value, err := canonicalConfigValue(args[1], option.Type)
+		if err != nil {
+			return err
+		}
+		if op == Config_Add {
+			return f.Add(args[0], value)
+		}
+		return f.Set(args[0], value)
+
+	case Config_Unset, Config_UnsetAll:
+		if len(args) < 1 || len(args) > 2 {
+			return errConfigWrongArgs
+		}
+		match := func(string) bool { return true }
+		if len(args) == 2 {
+			var err error
+			if match, err = valuePattern(args[1]); err != nil {
+				return err
+			}
+		}
+
+		if op == Config_Unset {
+			n := 0
+			for _, v := range f.GetAll(args[0]) {
+				if match(v) {
+					n++
+				}
+			}
+			if n > 1 {
+				return config.ErrMultipleValues
+			}
+		}
+		n, err := f.UnsetMatch(args[0], match)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return config.ErrKeyNotFound
+		}
+	}
+
+	return nil
+}
+
+// valuePattern compiles the value regex, which is negated with leading "!"
+func valuePattern(pattern string) (func(string) bool, error) {
+	negated := strings.HasPrefix(pattern, "!")
+	if negated {
+		pattern = pattern[1:]
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(value string) bool {
+		return re.MatchString(value) != negated
+	}, nil
+}
+
+// formatConfigValue formats the value of entry with its type for output
+func formatConfigValue(e *config.Entry, typ string) (string, error) {
+	switch typ {
+	case "":
+		return e.Value, nil
+	case "bool":
+		b, err := e.Bool()
+		return strconv.FormatBool(b), err
+	case "int":
+		n, err := e.Int()
+		return strconv.FormatInt(n, 10), err
+	case "path":
+		return e.Path()
+	default:
+		return "", errConfigInvalidType
+	}
+}
+
+// canonicalConfigValue checks the value with its type before it is written
+func canonicalConfigValue(value string, typ string) (string, error) {
+	switch typ {
+	case "", "path":
+		return value, nil
+	case "bool":
+		b, err := config.ParseBool(value)
+		return strconv.FormatBool(b), err
+	case "int":
+		n, err := config.ParseInt(value)
+		return strconv.FormatInt(n, 10), err
+	default:
+		return "", errConfigInvalidType
+	}
+}
@@ -0,0 +1,254 @@
+package porcelain
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core"
+	"github.com/izhujiang/gogit/utils/diff/lcs"
+)
+
+const (
+	// the width of diffstat if the output is not a terminal
+	stat_width = 80
+	// bytes in the beginning of files looked into for NUL to tell binary files
+	binary_check_size = 8000
+)
+
+// fileStat counts lines changed in a file, or bytes before and after changes of a binary file
+type fileStat struct {
+	name    string
+	added   int
+	deleted int
+	binary  bool
+}
+
+// diffStats counts changes to files in the same order
+func diffStats(repo *core.Repository, changes []*common.Change) ([]*fileStat, error) {
+	stats := make([]*fileStat, 0, len(changes))
+	for _, c := range changes {
+		from, err := changedContent(repo, c.From)
+		if err != nil {
+			return nil, err
+		}
+		to, err := changedContent(repo, c.To)
+		if err != nil {
+			return nil, err
+		}
+
+		s := &fileStat{}
+		if c.To != nil {
+			s.name = c.To.Name
+		} else {
+			s.name = c.From.Name
+		}
+		if isBinary(from) || isBinary(to) {
+			s.binary = true
+			s.added, s.deleted = len(to), len(from)
+		} else {
+			s.added, s.deleted = countLineChanges(from, to)
+		}
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+func changedContent(repo *core.Repository, p *common.NameHashPair) (string, error) {
+	if p == nil {
+		return "", nil
+	}
+	blob, err := repo.GetAsBlob(p.Oid)
+	if err != nil {
+		return "", err
+	}
+	return blob.Content(), nil
+}
+
+func isBinary(content string) bool {
+	if len(content) > binary_check_size {
+		content = content[:binary_check_size]
+	}
+	return strings.IndexByte(content, 0) >= 0
+}
+
+// countLineChanges counts lines inserted and deleted to change from into to, a last line without newline included
+func countLineChanges(from string, to string) (int, int) {
+	// lines are diffed as runes which stand for distinct lines
+	ids := make(map[string]rune)
+	toRunes := func(s string) []rune {
+		lines := strings.SplitAfter(s, "\n")
+		if lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+		runes := make([]rune, len(lines))
+		for i, line := range lines {
+			id, ok := ids[line]
+			if !ok {
+				id = rune(len(ids))
+				ids[line] = id
+			}
+			runes[i] = id
+		}
+		return runes
+	}
+
+	added, deleted := 0, 0
+	for _, d := range lcs.DiffRunes(toRunes(from), toRunes(to)) {
+		deleted += d.End - d.Start
+		added += d.ReplEnd - d.ReplStart
+	}
+	return added, deleted
+}
+
+// writeNumStat writes lines inserted and deleted of each file in decimal as git diff --numstat, each line led by prefix
+func writeNumStat(w io.Writer, stats []*fileStat, prefix string) {
+	for _, s := range stats {
+		if s.binary {
+			fmt.Fprintf(w, "%s-\t-\t%s\n", prefix, s.name)
+		} else {
+			fmt.Fprintf(w, "%s%d\t%d\t%s\n", prefix, s.added, s.deleted, s.name)
+		}
+	}
+}
+
+// writeDiffStat writes a histogram of changes to each file and a summary as git diff --stat, in width columns
+// minus those of prefix, which leads each line
+func writeDiffStat(w io.Writer, stats []*fileStat, prefix string) {
+	if len(stats) == 0 {
+		return
+	}
+
+	maxLen, maxChange, numberWidth, binWidth := 0, 0, 0, 0
+	for _, s := range stats {
+		if len(s.name) > maxLen {
+			maxLen = len(s.name)
+		}
+		if s.binary {
+			// "Bin XXX -> YYY bytes"
+			binWidth = max(binWidth, 14+decimalWidth(s.added)+decimalWidth(s.deleted))
+			numberWidth = 3
+			continue
+		}
+		maxChange = max(maxChange, s.added+s.deleted)
+	}
+
+	width := stat_width - len(prefix)
+	numberWidth = max(numberWidth, decimalWidth(maxChange))
+	// at least 6 columns for the graph and 10 for names
+	width = max(width, 16+6+numberWidth)
+
+	graphWidth := maxChange
+	if maxChange+4 <= binWidth {
+		graphWidth = binWidth - 4
+	}
+	nameWidth := maxLen
+	if nameWidth+numberWidth+6+graphWidth > width {
+		if graphWidth > width*3/8-numberWidth-6 {
+			graphWidth = max(width*3/8-numberWidth-6, 6)
+		}
+		if nameWidth > width-numberWidth-6-graphWidth {
+			nameWidth = width - numberWidth - 6 - graphWidth
+		} else {
+			graphWidth = width - numberWidth - 6 - nameWidth
+		}
+	}
+
+	adds, dels := 0, 0
+	for _, s := range stats {
+		// long names are cut at the beginning, from a slash if possible
+		name, lead := s.name, ""
+		if len(name) > nameWidth {
+			lead = "..."
+			keep := max(nameWidth-3, 0)
+			name = name[len(name)-keep:]
+			if i := strings.IndexByte(name, '/'); i >= 0 {
+				name = name[i:]
+			}
+		}
+		padding := strings.Repeat(" ", max(nameWidth-len(lead)-len(name), 0))
+
+		if s.binary {
+			fmt.Fprintf(w, "%s %s%s%s | %*s", prefix, lead, name, padding, numberWidth, "Bin")
+			if s.added == 0 && s.deleted == 0 {
+				fmt.Fprintln(w)
+			} else {
+				fmt.Fprintf(w, " %d -> %d bytes\n", s.deleted, s.added)
+			}
+			continue
+		}
+
+		adds += s.added
+		dels += s.deleted
+		add, del := s.added, s.deleted
+		if graphWidth <= maxChange {
+			total := scaleLinear(add+del, graphWidth, maxChange)
+			if total < 2 && add > 0 && del > 0 {
+				total = 2
+			}
+			if add < del {
+				add = scaleLinear(add, graphWidth, maxChange)
+				del = total - add
+			} else {
+				del = scaleLinear(del, graphWidth, maxChange)
+				add = total - del
+			}
+		}
+		space := ""
+		if s.added+s.deleted > 0 {
+			space = " "
+		}
+		fmt.Fprintf(w, "%s %s%s%s | %*d%s%s%s\n", prefix, lead, name, padding, numberWidth, s.added+s.deleted, space,
+			strings.Repeat("+", add), strings.Repeat("-", del))
+	}
+
+	fmt.Fprintf(w, "%s%s\n", prefix, statSummary(len(stats), adds, dels))
+}
+
+// statSummary is like " 1 file changed, 2 insertions(+), 1 deletion(-)", changes of the kind are omitted if there
+// are none but those of the other kind
+func statSummary(files int, insertions int, deletions int) string {
+	var sb bytes.Buffer
+	if files == 1 {
+		sb.WriteString(" 1 file changed")
+	} else {
+		fmt.Fprintf(&sb, " %d files changed", files)
+	}
+	if insertions > 0 || deletions == 0 {
+		if insertions == 1 {
+			sb.WriteString(", 1 insertion(+)")
+		} else {
+			fmt.Fprintf(&sb, ", %d insertions(+)", insertions)
+		}
+	}
+	if deletions > 0 || insertions == 0 {
+		if deletions == 1 {
+			sb.WriteString(", 1 deletion(-)")
+		} else {
+			fmt.Fprintf(&sb, ", %d deletions(-)", deletions)
+		}
+	}
+	return sb.String()
+}
+
+// scaleLinear scales it changes of at most maxChange to width, in which at least one column is for any change
+func scaleLinear(it int, width int, maxChange int) int {
+	if it == 0 {
+		return 0
+	}
+	return 1 + it*(width-1)/maxChange
+}
+
+func decimalWidth(n int) int {
+	return len(strconv.Itoa(n))
+}
+
+func max(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,126 @@
+package porcelain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogStat(t *testing.T) {
+	h := newTestHistory(t)
+	long := "dir/a-rather-long-directory-name/and-an-even-longer-file-name-for-the-stat.txt"
+	lines := func(n int, s string) string {
+		return strings.Repeat(s+"\n", n)
+	}
+
+	a := h.commit("add", h.tree(map[string]string{
+		"a":   "1\n2\n3\n",
+		"bin": "\x00b",
+		long:  lines(30, "x"),
+		"big": lines(200, "y"),
+	}))
+	b := h.commit("change", h.tree(map[string]string{
+		"a":   "1\ntwo\n3\n4",
+		"bin": "\x00bc",
+		long:  lines(10, "x") + lines(20, "z"),
+		"big": lines(100, "y"),
+		"new": "",
+	}), a)
+	c := h.commit("remove", h.tree(map[string]string{
+		"bin": "\x00bc",
+		long:  lines(10, "x") + lines(20, "z"),
+		"big": lines(100, "y"),
+		"new": "",
+	}), b)
+	m := h.commit("merge", h.tree(nil), c, a)
+	h.ref("refs/heads/main", m)
+
+	assert.Equal(t, `merge
+remove
+
+ a | 4 ----
+ 1 file changed, 4 deletions(-)
+change
+
+ a                                                  |   3 +-
+ big                                                | 100 ---------------------
+ bin                                                | Bin 2 -> 3 bytes
+ .../and-an-even-longer-file-name-for-the-stat.txt  |  40 ++++-----
+ new                                                |   0
+ 5 files changed, 22 insertions(+), 121 deletions(-)
+add
+
+ a                                                  |   3 +
+ big                                                | 200 +++++++++++++++++++++
+ bin                                                | Bin 0 -> 2 bytes
+ .../and-an-even-longer-file-name-for-the-stat.txt  |  30 ++++
+ 4 files changed, 233 insertions(+)
+`, h.log(nil, &LogOption{Format: "%s", Stat: true}))
+	assert.Equal(t, `merge
+remove
+
+0	4	a
+change
+
+2	1	a
+0	100	big
+-	-	bin
+20	20	dir/a-rather-long-directory-name/and-an-even-longer-file-name-for-the-stat.txt
+0	0	new
+add
+
+3	0	a
+200	0	big
+-	-	bin
+30	0	dir/a-rather-long-directory-name/and-an-even-longer-file-name-for-the-stat.txt
+`, h.log(nil, &LogOption{Format: "%s", NumStat: true}))
+	assert.Equal(t, `9502547 merge
+4125bc7 remove
+0	4	a
+ a | 4 ----
+ 1 file changed, 4 deletions(-)
+`, h.log(nil, &LogOption{Oneline: true, Stat: true, NumStat: true, MaxCount: 2}))
+	assert.Equal(t, `*   merge
+|\  
+* | remove
+| | 
+| |  a | 4 ----
+| |  1 file changed, 4 deletions(-)
+* | change
+|/  
+|   
+|    a                                                |   3 +-
+|    big                                              | 100 -------------------
+|    bin                                              | Bin 2 -> 3 bytes
+|    ...and-an-even-longer-file-name-for-the-stat.txt |  40 ++++----
+|    new                                              |   0
+|    5 files changed, 22 insertions(+), 121 deletions(-)
+* add
+  
+   a                                                 |   3 +
+   big                                               | 200 ++++++++++++++++++++
+   bin                                               | Bin 0 -> 2 bytes
+   .../and-an-even-longer-file-name-for-the-stat.txt |  30 +++
+   4 files changed, 233 insertions(+)
+`, h.log(nil, &LogOption{Graph: true, Format: "%s", Stat: true}))
+	assert.Equal(t, `merge
+
+ .../and-an-even-longer-file-name-for-the-stat.txt  | 30 ----------------------
+ 1 file changed, 30 deletions(-)
+remove
+
+ a | 4 ----
+ 1 file changed, 4 deletions(-)
+change
+
+ a                                                  |  3 +-
+ .../and-an-even-longer-file-name-for-the-stat.txt  | 40 +++++++++++-----------
+ 2 files changed, 22 insertions(+), 21 deletions(-)
+add
+
+ a                                                  |  3 +++
+ .../and-an-even-longer-file-name-for-the-stat.txt  | 30 ++++++++++++++++++++++
+ 2 files changed, 33 insertions(+)
+`, h.log(nil, &LogOption{FirstParent: true, Format: "%s", Stat: true, Paths: []string{"a", "dir"}}))
+}
@@ -0,0 +1,39 @@
+package porcelain
+
+import (
+	"io"
+
+	"github.com/izhujiang/gogit/core"
+	"github.com/izhujiang/gogit/plumbing"
+)
+
+type GcOption struct {
+	// More aggressively optimize the repository at the expense of taking much more time
+	Aggressive bool
+}
+
+// Gc cleanup unnecessary files and optimize the local repository, references are packed into packed-refs, old reflog entries
+// are pruned, all reachable objects are packed into a single pack and the loose objects which have been packed are removed.
+// Unreachable objects in the old packs are kept as loose objects like git gc, but unlike git they are never pruned, since
+// there is no gc.pruneExpire yet.
+func Gc(ws *core.Workspace, w io.Writer, option *GcOption) error {
+	if err := plumbing.PackRefs(ws, &plumbing.PackRefsOption{All: true, Prune: true}); err != nil {
+		return err
+	}
+	if err := ReflogExpire(ws, w, nil, &ReflogExpireOption{All: true}); err != nil {
+		return err
+	}
+
+	ro := &plumbing.RepackOption{
+		All:             true,
+		Delete:          true,
+		KeepUnreachable: true,
+		Window:          core.Default_Pack_Window,
+		Depth:           core.Default_Pack_Depth,
+	}
+	if option.Aggressive {
+		ro.Window = 250
+	}
+
+	return plumbing.Repack(ws, w, ro)
+}
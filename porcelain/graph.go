@@ -0,0 +1,602 @@
+package porcelain
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core"
+	"github.com/izhujiang/gogit/core/object"
+)
+
+// states of the graph, in which the next line for the current commit is drawn
+type graphState int
+
+const (
+	graph_padding graphState = iota
+	graph_skip
+	graph_pre_commit
+	graph_commit
+	graph_post_merge
+	graph_collapsing
+)
+
+// characters of edges from a merge to its parents, by the layout of the merge
+var graphMergeChars = []byte{'/', '|', '\\'}
+
+// logGraph draws the history of commits in ASCII next to them as git log --graph. Commits are drawn in columns,
+// each of which is a line of history waiting for the commit in it. A nil logGraph draws nothing.
+type logGraph struct {
+	walk *core.RevWalk
+
+	commit  *object.Commit
+	parents []common.Hash
+	state   graphState
+	// the state in which the previous line is drawn
+	prevState graphState
+	// the width in characters of lines for the current commit
+	width int
+	// the number of lines drawn before the commit to make room for an octopus merge
+	expansionRow int
+	// the column of the current commit, and of the previous one
+	commitIndex     int
+	prevCommitIndex int
+	// how edges to parents of a merge are drawn: 0 if the first parent is to the left of the merge, 1 otherwise
+	mergeLayout int
+	// the number of columns added for the current commit, -1 if its edge joins the one of the last column, and the
+	// number for the previous commit
+	edgesAdded     int
+	prevEdgesAdded int
+
+	// commits in columns before and after the current commit
+	columns    []common.Hash
+	newColumns []common.Hash
+	// characters in the line mapped to indexes of newColumns, or -1 if they are blank, in the first mappingSize
+	// entries of mapping, and those before collapsing
+	mapping     []int
+	oldMapping  []int
+	mappingSize int
+}
+
+func newLogGraph(walk *core.RevWalk) *logGraph {
+	return &logGraph{walk: walk}
+}
+
+// interestingParents returns parents of the current commit which are shown, only the first one with FirstParent
+func (g *logGraph) interestingParents(c *object.Commit) []common.Hash {
+	parents := make([]common.Hash, 0)
+	for i, p := range g.walk.Parents(c) {
+		if g.walk.FirstParent && i > 0 {
+			break
+		}
+		if g.walk.Shows(p) {
+			parents = append(parents, p)
+		}
+	}
+	return parents
+}
+
+// update moves the graph to the commit c, which is shown next
+func (g *logGraph) update(c *object.Commit) {
+	if g == nil {
+		return
+	}
+	g.commit = c
+	g.parents = g.interestingParents(c)
+	g.prevCommitIndex = g.commitIndex
+	g.updateColumns()
+	g.expansionRow = 0
+
+	// the previous commit may not have finished its output, which is left out
+	switch {
+	case g.state != graph_padding:
+		g.state = graph_skip
+	case g.needsPreCommitLine():
+		g.state = graph_pre_commit
+	default:
+		g.state = graph_commit
+	}
+}
+
+func (g *logGraph) updateColumns() {
+	g.columns, g.newColumns = g.newColumns, g.columns[:0]
+
+	maxNewColumns := len(g.columns) + len(g.parents)
+	g.ensureCapacity(maxNewColumns)
+	g.mappingSize = 2 * maxNewColumns
+	for i := 0; i < g.mappingSize; i++ {
+		g.mapping[i] = -1
+	}
+
+	g.width = 0
+	g.prevEdgesAdded = g.edgesAdded
+	g.edgesAdded = 0
+
+	// commits in columns are kept, the current commit is replaced with its parents
+	id := g.commit.Id()
+	seen := false
+	for i := 0; i <= len(g.columns); i++ {
+		var col common.Hash
+		if i == len(g.columns) {
+			if seen {
+				break
+			}
+			col = id
+		} else {
+			col = g.columns[i]
+		}
+
+		if col == id {
+			seen = true
+			g.commitIndex = i
+			g.mergeLayout = -1
+			for _, p := range g.parents {
+				g.insertIntoNewColumns(p, i)
+			}
+			// the commit takes up at least 2 characters, even if it has no parents
+			if len(g.parents) == 0 {
+				g.width += 2
+			}
+		} else {
+			g.insertIntoNewColumns(col, -1)
+		}
+	}
+
+	for g.mappingSize > 1 && g.mapping[g.mappingSize-1] < 0 {
+		g.mappingSize--
+	}
+}
+
+// ensureCapacity makes room in mappings for n columns
+func (g *logGraph) ensureCapacity(n int) {
+	if len(g.mapping) >= 2*n {
+		return
+	}
+	grow := func(m []int) []int {
+		grown := make([]int, 2*n)
+		for i := range grown {
+			grown[i] = -1
+		}
+		copy(grown, m)
+		return grown
+	}
+	g.mapping = grow(g.mapping)
+	g.oldMapping = grow(g.oldMapping)
+}
+
+// insertIntoNewColumns puts the commit oid into newColumns if it is not there, and maps the next character of the
+// line to it. idx is the column of the current commit if oid is one of its parents, or -1.
+func (g *logGraph) insertIntoNewColumns(oid common.Hash, idx int) {
+	i := -1
+	for j, col := range g.newColumns {
+		if col == oid {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		i = len(g.newColumns)
+		g.newColumns = append(g.newColumns, oid)
+	}
+
+	var mappingIdx int
+	switch {
+	case len(g.parents) > 1 && idx > -1 && g.mergeLayout == -1:
+		// the layout of a merge depends on whether the first parent is in a column to the left of the merge
+		dist := idx - i
+		shift := 1
+		if dist > 1 {
+			shift = 2*dist - 3
+		}
+		if dist > 0 {
+			g.mergeLayout = 0
+		} else {
+			g.mergeLayout = 1
+		}
+		g.edgesAdded = len(g.parents) + g.mergeLayout - 2
+		mappingIdx = g.width + (g.mergeLayout-1)*shift
+		g.width += 2 * g.mergeLayout
+	case g.edgesAdded > 0 && i == g.mapping[g.width-2]:
+		// the edge joins the one of the last column at once
+		mappingIdx = g.width - 2
+		g.edgesAdded = -1
+	default:
+		mappingIdx = g.width
+		g.width += 2
+	}
+	g.mapping[mappingIdx] = i
+}
+
+func (g *logGraph) numDashedParents() int {
+	return len(g.parents) + g.mergeLayout - 3
+}
+
+func (g *logGraph) numExpansionRows() int {
+	return g.numDashedParents() * 2
+}
+
+func (g *logGraph) needsPreCommitLine() bool {
+	return len(g.parents) >= 3 && g.commitIndex < len(g.columns)-1 && g.expansionRow < g.numExpansionRows()
+}
+
+// isMappingCorrect reports whether all edges are in their columns, or 1 character to the right to be drawn as '/'
+func (g *logGraph) isMappingCorrect() bool {
+	for i := 0; i < g.mappingSize; i++ {
+		if target := g.mapping[i]; target >= 0 && target != i/2 {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *logGraph) updateState(s graphState) {
+	g.prevState = g.state
+	g.state = s
+}
+
+// nextLine returns the next line of the graph, and whether the line is the one of the current commit
+func (g *logGraph) nextLine() (string, bool) {
+	var sb strings.Builder
+	isCommitLine := false
+	switch g.state {
+	case graph_padding:
+		for range g.newColumns {
+			sb.WriteString("| ")
+		}
+	case graph_skip:
+		sb.WriteString("...")
+		if g.needsPreCommitLine() {
+			g.updateState(graph_pre_commit)
+		} else {
+			g.updateState(graph_commit)
+		}
+	case graph_pre_commit:
+		g.writePreCommitLine(&sb)
+	case graph_commit:
+		g.writeCommitLine(&sb)
+		isCommitLine = true
+	case graph_post_merge:
+		g.writePostMergeLine(&sb)
+	case graph_collapsing:
+		g.writeCollapsingLine(&sb)
+	}
+	return g.padHorizontally(sb.String()), isCommitLine
+}
+
+func (g *logGraph) padHorizontally(line string) string {
+	if len(line) < g.width {
+		line += strings.Repeat(" ", g.width-len(line))
+	}
+	return line
+}
+
+// writePreCommitLine expands edges to the right of an octopus merge to make room for it
+func (g *logGraph) writePreCommitLine(sb *strings.Builder) {
+	seen := false
+	for i, col := range g.columns {
+		switch {
+		case col == g.commit.Id():
+			seen = true
+			sb.WriteByte('|')
+			sb.WriteString(strings.Repeat(" ", g.expansionRow))
+		case seen && g.expansionRow == 0:
+			// edges drawn as '\' after the previous merge are continued
+			if g.prevState == graph_post_merge && g.prevCommitIndex < i {
+				sb.WriteByte('\\')
+			} else {
+				sb.WriteByte('|')
+			}
+		case seen && g.expansionRow > 0:
+			sb.WriteByte('\\')
+		default:
+			sb.WriteByte('|')
+		}
+		sb.WriteByte(' ')
+	}
+
+	g.expansionRow++
+	if !g.needsPreCommitLine() {
+		g.updateState(graph_commit)
+	}
+}
+
+func (g *logGraph) writeCommitLine(sb *strings.Builder) {
+	id := g.commit.Id()
+	seen := false
+	for i := 0; i <= len(g.columns); i++ {
+		var col common.Hash
+		if i == len(g.columns) {
+			if seen {
+				break
+			}
+			col = id
+		} else {
+			col = g.columns[i]
+		}
+
+		switch {
+		case col == id:
+			seen = true
+			sb.WriteByte('*')
+			if len(g.parents) > 2 {
+				g.writeOctopusMerge(sb)
+			}
+		case seen && g.edgesAdded > 1:
+			sb.WriteByte('\\')
+		case seen && g.edgesAdded == 1:
+			// no pre-commit lines are drawn, so edges drawn as '\' after the previous merge are continued
+			if g.prevState == graph_post_merge && g.prevEdgesAdded > 0 && g.prevCommitIndex < i {
+				sb.WriteByte('\\')
+			} else {
+				sb.WriteByte('|')
+			}
+		case g.prevState == graph_collapsing && g.oldMapping[2*i+1] == i && g.mapping[2*i] < i:
+			sb.WriteByte('/')
+		default:
+			sb.WriteByte('|')
+		}
+		sb.WriteByte(' ')
+	}
+
+	switch {
+	case len(g.parents) > 1:
+		g.updateState(graph_post_merge)
+	case g.isMappingCorrect():
+		g.updateState(graph_padding)
+	default:
+		g.updateState(graph_collapsing)
+	}
+}
+
+// writeOctopusMerge draws dashes to parents of a merge beyond the second one
+func (g *logGraph) writeOctopusMerge(sb *strings.Builder) {
+	dashed := g.numDashedParents()
+	for i := 0; i < dashed; i++ {
+		sb.WriteByte('-')
+		if i == dashed-1 {
+			sb.WriteByte('.')
+		} else {
+			sb.WriteByte('-')
+		}
+	}
+}
+
+func (g *logGraph) writePostMergeLine(sb *strings.Builder) {
+	id := g.commit.Id()
+	firstParent := g.parents[0]
+	parentCol := false
+	seen := false
+	for i := 0; i <= len(g.columns); i++ {
+		var col common.Hash
+		if i == len(g.columns) {
+			if seen {
+				break
+			}
+			col = id
+		} else {
+			col = g.columns[i]
+		}
+
+		switch {
+		case col == id:
+			// edges to parents of the merge
+			seen = true
+			idx := g.mergeLayout
+			for j := range g.parents {
+				sb.WriteByte(graphMergeChars[idx])
+				if idx == 2 {
+					if g.edgesAdded > 0 || j < len(g.parents)-1 {
+						sb.WriteByte(' ')
+					}
+				} else {
+					idx++
+				}
+			}
+			if g.edgesAdded == 0 {
+				sb.WriteByte(' ')
+			}
+		case seen:
+			if g.edgesAdded > 0 {
+				sb.WriteByte('\\')
+			} else {
+				sb.WriteByte('|')
+			}
+			sb.WriteByte(' ')
+		default:
+			sb.WriteByte('|')
+			if g.mergeLayout != 0 || i != g.commitIndex-1 {
+				if parentCol {
+					sb.WriteByte('_')
+				} else {
+					sb.WriteByte(' ')
+				}
+			}
+		}
+
+		if col == firstParent {
+			parentCol = true
+		}
+	}
+
+	if g.isMappingCorrect() {
+		g.updateState(graph_padding)
+	} else {
+		g.updateState(graph_collapsing)
+	}
+}
+
+// writeCollapsingLine moves edges to the left towards their columns, one character at a time except for a
+// horizontal edge
+func (g *logGraph) writeCollapsingLine(sb *strings.Builder) {
+	usedHorizontal := false
+	horizontalEdge, horizontalEdgeTarget := -1, -1
+
+	g.mapping, g.oldMapping = g.oldMapping, g.mapping
+	for i := 0; i < g.mappingSize; i++ {
+		g.mapping[i] = -1
+	}
+
+	for i := 0; i < g.mappingSize; i++ {
+		target := g.oldMapping[i]
+		if target < 0 {
+			continue
+		}
+		switch {
+		case target*2 == i:
+			// already in its column
+			g.mapping[i] = target
+		case g.mapping[i-1] < 0:
+			// nothing to the left, it moves to the left by one
+			g.mapping[i-1] = target
+			if horizontalEdge == -1 {
+				horizontalEdge = i
+				horizontalEdgeTarget = target
+				for j := target*2 + 3; j < i-2; j += 2 {
+					g.mapping[j] = target
+				}
+			}
+		case g.mapping[i-1] == target:
+			// the edge to the left leads to the same commit, they are combined
+		default:
+			// it crosses over the edge to the left, beyond which is its target
+			g.mapping[i-2] = target
+			if horizontalEdge == -1 {
+				horizontalEdgeTarget = target
+				horizontalEdge = i - 1
+				for j := target*2 + 3; j < i-2; j += 2 {
+					g.mapping[j] = target
+				}
+			}
+		}
+	}
+
+	copy(g.oldMapping, g.mapping[:g.mappingSize])
+	// the new mapping may be 1 smaller than the old one
+	if g.mapping[g.mappingSize-1] < 0 {
+		g.mappingSize--
+	}
+
+	for i := 0; i < g.mappingSize; i++ {
+		target := g.mapping[i]
+		switch {
+		case target < 0:
+			sb.WriteByte(' ')
+		case target*2 == i:
+			sb.WriteByte('|')
+		case target == horizontalEdgeTarget && i != horizontalEdge-1:
+			// all but the first segment of the horizontal edge are not continued into the next line
+			if i != target*2+3 {
+				g.mapping[i] = -1
+			}
+			usedHorizontal = true
+			sb.WriteByte('_')
+		default:
+			if usedHorizontal && i < horizontalEdge {
+				g.mapping[i] = -1
+			}
+			sb.WriteByte('/')
+		}
+	}
+
+	if g.isMappingCorrect() {
+		g.updateState(graph_padding)
+	}
+}
+
+// paddingLine returns a line leaving all edges unchanged, or the next line if the commit line has been drawn
+func (g *logGraph) paddingLine() string {
+	if g == nil {
+		return ""
+	}
+	if g.state != graph_commit {
+		line, _ := g.nextLine()
+		return line
+	}
+
+	var sb strings.Builder
+	for _, col := range g.columns {
+		sb.WriteByte('|')
+		if col == g.commit.Id() && len(g.parents) > 2 {
+			sb.WriteString(strings.Repeat(" ", (len(g.parents)-2)*2))
+		} else {
+			sb.WriteByte(' ')
+		}
+	}
+	g.prevState = graph_padding
+	return g.padHorizontally(sb.String())
+}
+
+func (g *logGraph) isCommitFinished() bool {
+	return g.state == graph_padding
+}
+
+// showCommit writes lines of the graph up to the one of the current commit, which is not terminated
+func (g *logGraph) showCommit(w io.Writer) {
+	if g == nil {
+		return
+	}
+	if g.isCommitFinished() {
+		fmt.Fprint(w, g.paddingLine())
+		return
+	}
+	for !g.isCommitFinished() {
+		line, isCommitLine := g.nextLine()
+		fmt.Fprint(w, line)
+		if isCommitLine {
+			return
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func (g *logGraph) showOneline(w io.Writer) {
+	if g == nil {
+		return
+	}
+	line, _ := g.nextLine()
+	fmt.Fprint(w, line)
+}
+
+func (g *logGraph) showPadding(w io.Writer) {
+	fmt.Fprint(w, g.paddingLine())
+}
+
+// showRemainder writes the lines left for the current commit, the last of which is not terminated
+func (g *logGraph) showRemainder(w io.Writer) {
+	for !g.isCommitFinished() {
+		line, _ := g.nextLine()
+		fmt.Fprint(w, line)
+		if !g.isCommitFinished() {
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// showCommitMsg writes msg with the graph before each line but the first, and the rest of the graph of the commit
+// after it. The output ends with a newline only if msg does.
+func (g *logGraph) showCommitMsg(w io.Writer, msg string) {
+	for rest := msg; len(rest) > 0; {
+		i := strings.IndexByte(rest, '\n')
+		if i < 0 {
+			fmt.Fprint(w, rest)
+			break
+		}
+		fmt.Fprint(w, rest[:i+1])
+		if rest = rest[i+1:]; len(rest) > 0 {
+			g.showOneline(w)
+		}
+	}
+	if g == nil || g.isCommitFinished() {
+		return
+	}
+
+	terminated := strings.HasSuffix(msg, "\n")
+	if !terminated {
+		fmt.Fprintln(w)
+	}
+	g.showRemainder(w)
+	if terminated {
+		fmt.Fprintln(w)
+	}
+}
@@ -0,0 +1,104 @@
+package porcelain
+
+import (
+	"testing"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogGraphMerge(t *testing.T) {
+	h := newTestHistory(t)
+	a := h.commit("a", common.ZeroHash)
+	b := h.commit("b", common.ZeroHash, a)
+	c1 := h.commit("c1", common.ZeroHash, a)
+	c2 := h.commit("c2", common.ZeroHash, c1)
+	m := h.commit("merge c", common.ZeroHash, b, c2)
+	side := h.commit("side", common.ZeroHash, b)
+	d := h.commit("d", common.ZeroHash, m)
+	h.ref("refs/heads/main", d)
+	h.ref("refs/heads/side", side)
+
+	assert.Equal(t, `* d
+*   merge c
+|\  
+| * c2
+| * c1
+* | b
+|/  
+* a
+`, h.log(nil, &LogOption{Graph: true, Format: "%s"}))
+	assert.Equal(t, `* d
+*   merge c
+|\  
+| * c2
+| * c1
+| | * side
+| |/  
+|/|   
+* | b
+|/  
+* a
+`, h.log([]string{"main", "side"}, &LogOption{Graph: true, Format: "%s"}))
+	assert.Equal(t, `* d
+|   
+*   merge c
+|\  
+| | 
+| * c2
+| | 
+| * c1
+| | 
+* | b
+|/  
+| 
+* a
+`, h.log(nil, &LogOption{Graph: true, Pretty: "format:%s%n%b"}))
+	assert.Equal(t, `* d
+* merge c
+* b
+* a
+`, h.log(nil, &LogOption{Graph: true, FirstParent: true, Format: "%s"}))
+}
+
+func TestLogGraphCrissCross(t *testing.T) {
+	h := newTestHistory(t)
+	a := h.commit("a", common.ZeroHash)
+	b := h.commit("b", common.ZeroHash, a)
+	c := h.commit("c", common.ZeroHash, a)
+	m1 := h.commit("m1", common.ZeroHash, b, c)
+	m2 := h.commit("m2", common.ZeroHash, c, b)
+	o := h.commit("octopus", common.ZeroHash, m1, m2, a)
+	h.ref("refs/heads/main", h.commit("top", common.ZeroHash, o))
+
+	assert.Equal(t, `* top
+*-.   octopus
+|\ \  
+| * \   m2
+| |\ \  
+* | | | m1
+|\| | | 
+| |/ /  
+|/| |   
+| * | c
+| |/  
+* / b
+|/  
+* a
+`, h.log(nil, &LogOption{Graph: true, Format: "%s"}))
+	assert.Equal(t, `* f3a8e02 top
+*-.   af17032 octopus
+|\ \  
+| * \   56892ec m2
+| |\ \  
+* | | | 99b0a94 m1
+|\| | | 
+| |/ /  
+|/| |   
+| * | ca2d4f4 c
+| |/  
+* / 0a466a3 b
+|/  
+* 64e0c33 a
+`, h.log(nil, &LogOption{Graph: true, Oneline: true}))
+}
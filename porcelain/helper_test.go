@@ -0,0 +1,90 @@
+package porcelain
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core"
+	"github.com/izhujiang/gogit/core/object"
+	"github.com/stretchr/testify/assert"
+)
+
+// testHistory creates commits in a new repository for tests, each one a hundred seconds after the previous one
+type testHistory struct {
+	t  *testing.T
+	ws *core.Workspace
+	// the time of the last commit
+	at int64
+}
+
+func newTestHistory(t *testing.T) *testHistory {
+	ws, err := core.Init(io.Discard, t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testHistory{t: t, ws: ws, at: 1112911993}
+}
+
+// tree stores files, whose contents are mapped from their paths, and returns the id of the root tree
+func (h *testHistory) tree(files map[string]string) common.Hash {
+	repo := h.ws.Repository()
+	entries := make(map[string]string)
+	dirs := make(map[string]map[string]string)
+	for p, content := range files {
+		if i := strings.IndexByte(p, '/'); i >= 0 {
+			if dirs[p[:i]] == nil {
+				dirs[p[:i]] = make(map[string]string)
+			}
+			dirs[p[:i]][p[i+1:]] = content
+			continue
+		}
+		entries[p] = content
+	}
+
+	t := object.EmptyTree()
+	for name, content := range entries {
+		oid, err := core.HashObjectFromReader(bytes.NewBufferString(content), object.Kind_Blob, repo)
+		assert.Nil(h.t, err)
+		t.Append(object.NewTreeEntry(oid, name, common.Regular))
+	}
+	for name, sub := range dirs {
+		t.Append(object.NewTreeEntry(h.tree(sub), name, common.Dir))
+	}
+	t.Sort()
+	t.Hash()
+	assert.Nil(h.t, repo.Put(t.ToGitObject()))
+	return t.Id()
+}
+
+// sig returns the signature of the next commit
+func (h *testHistory) sig() *object.Signature {
+	return object.NewSignature("A U Thor", "author@example.com", time.Unix(h.at+100, 0).UTC())
+}
+
+// commit creates a commit of tree, the empty tree if it is ZeroHash
+func (h *testHistory) commit(msg string, tree common.Hash, parents ...common.Hash) common.Hash {
+	if tree == common.ZeroHash {
+		tree = h.tree(nil)
+	}
+	sig := h.sig()
+	h.at += 100
+	g := object.NewCommit(common.ZeroHash, tree, parents, sig, sig, msg).ToGitObject()
+	assert.Nil(h.t, h.ws.Repository().Put(g))
+	return g.Id()
+}
+
+// ref points the reference name to id
+func (h *testHistory) ref(name string, id common.Hash) {
+	assert.Nil(h.t, h.ws.References().WriteRef(name, id))
+}
+
+// log returns the output of Log
+func (h *testHistory) log(revisions []string, option *LogOption) string {
+	var buf bytes.Buffer
+	assert.Nil(h.t, Log(h.ws, &buf, revisions, option))
+	return buf.String()
+}
@@ -6,11 +6,12 @@ import (
 	"github.com/izhujiang/gogit/core"
 )
 
-// Init
-func Init(w io.Writer, root string) error {
-	wkspace, _ := core.GetWorkspace()
-
-	wkspace.InitWorkspace(w, root)
+type InitOption struct {
+	// Create a bare repository in root instead of root/.git
+	Bare bool
+}
 
-	return nil
+// Init creates an empty git repository in root, or reinitializes an existing one, and returns its workspace
+func Init(w io.Writer, root string, option *InitOption) (*core.Workspace, error) {
+	return core.Init(w, root, option.Bare)
 }
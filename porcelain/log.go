@@ -3,28 +3,186 @@ package porcelain
 import (
 	"fmt"
 	"io"
-	"log"
+	"strings"
 
 	"github.com/izhujiang/gogit/common"
 	"github.com/izhujiang/gogit/core"
 	"github.com/izhujiang/gogit/core/object"
+	"github.com/izhujiang/gogit/plumbing"
 )
 
 type LogOption struct {
-	Stat bool
+	// show the number of lines changed in each file, as a histogram or in decimal
+	Stat    bool
+	NumStat bool
+	// limit the number of commits to show, no limit if it is not positive
+	MaxCount int
+	// show commits in reverse order
+	Reverse bool
+	// follow only the first parent of merge commits
+	FirstParent bool
+	// show no parents before all of their children, and avoid intermixing lines of history
+	TopoOrder bool
+	// show no parents before all of their children, otherwise in commit date order
+	DateOrder bool
+	// pretend all references and HEAD are listed as revisions
+	All bool
+	// show each commit on a single line, the same as Pretty "oneline" with abbreviated commit ids
+	Oneline bool
+	// the format of commits, see parsePretty, which is medium by default
+	Pretty string
+	// the format of commits, the same as Pretty "tformat:<Format>" unless it names a built-in format
+	Format string
+	// draw the history of commits next to them
+	Graph bool
+	// show names of references pointing to commits after their ids in built-in formats
+	Decorate bool
+	// limit commits to those with authors or messages matching regular expressions
+	Author string
+	Grep   string
+	// limit commits to those more recent than Since, and older than Until, in dates like "2.weeks.ago"
+	Since string
+	Until string
+	// limit commits to those changing files in paths, which are relative to the current directory
+	Paths []string
 }
 
-func Log(w io.Writer, oid common.Hash, option *LogOption) error {
-	repo := core.GetRepository()
+// Log shows commits reachable from revisions, HEAD if there are none, from the newest like git log
+func Log(ws *core.Workspace, w io.Writer, revisions []string, option *LogOption) error {
+	pretty, err := logPretty(option)
+	if err != nil {
+		return err
+	}
+	pretty.decorate = option.Decorate
+	if pretty.decorates() {
+		if err := pretty.loadDecorations(ws); err != nil {
+			return err
+		}
+	}
+	if len(revisions) == 0 && !option.All {
+		if _, err := ws.References().HeadCommit(); err != nil {
+			return fmt.Errorf("your current branch '%s' does not have any commits yet", ws.References().CurrentBranch())
+		}
+		revisions = []string{"HEAD"}
+	}
 
-	g, err := repo.Get(oid)
+	walk, err := plumbing.WalkRevisions(ws, revisions, &plumbing.RevListOption{
+		MaxCount:    option.MaxCount,
+		Reverse:     option.Reverse,
+		FirstParent: option.FirstParent,
+		// the graph needs parents after all of their children
+		TopoOrder: option.TopoOrder || (option.Graph && !option.DateOrder),
+		DateOrder: option.DateOrder,
+		All:       option.All,
+		Paths:     option.Paths,
+		Author:    option.Author,
+		Grep:      option.Grep,
+		Since:     option.Since,
+		Until:     option.Until,
+	})
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	var graph *logGraph
+	if option.Graph {
+		walk.RewriteParents = true
+		graph = newLogGraph(walk)
 	}
 
-	if g.Kind() == object.Kind_Commit {
-		commit := object.GitObjectToCommit(g)
-		fmt.Fprintf(w, "%s", commit.Content())
+	repo := ws.Repository()
+	shownOne, missingNewline := false, false
+	return walk.ForEach(func(c *object.Commit) error {
+		graph.update(c)
+		parents := walk.Parents(c)
+		var stats []*fileStat
+		if option.Stat || option.NumStat {
+			if stats, err = commitStats(repo, walk, c, parents); err != nil {
+				return err
+			}
+		}
+
+		// entries are separated by newlines, after the graph unless the previous entry is not terminated
+		if shownOne && !pretty.terminator {
+			if !missingNewline {
+				graph.showPadding(w)
+			}
+			fmt.Fprintln(w)
+		}
+		shownOne = true
+
+		graph.showCommit(w)
+		msg := pretty.formatCommit(repo, c, parents)
+		missingNewline = !strings.HasSuffix(msg, "\n")
+		graph.showCommitMsg(w, msg)
+		if pretty.terminator && !pretty.empty() {
+			if !missingNewline {
+				graph.showPadding(w)
+			}
+			fmt.Fprintln(w)
+		}
+
+		if len(stats) > 0 {
+			if pretty.name != pretty_oneline && !pretty.empty() {
+				fmt.Fprintln(w, graph.paddingLine())
+			}
+			if option.NumStat {
+				writeNumStat(w, stats, graph.paddingLine())
+			}
+			if option.Stat {
+				writeDiffStat(w, stats, graph.paddingLine())
+			}
+		}
+		return nil
+	})
+}
+
+func logPretty(option *LogOption) (*prettyFormat, error) {
+	switch {
+	case option.Format != "":
+		if f, err := parsePretty(option.Format); err == nil && f.name != pretty_user {
+			return f, nil
+		}
+		return parsePretty("tformat:" + option.Format)
+	case option.Pretty != "":
+		return parsePretty(option.Pretty)
+	case option.Oneline:
+		return &prettyFormat{name: pretty_oneline, terminator: true, abbrev: true}, nil
+	}
+	return parsePretty(pretty_medium)
+}
+
+// commitStats counts changes of the commit c from its first parent in paths of the walk, which are not shown for
+// merges unless only first parents are followed
+func commitStats(repo *core.Repository, walk *core.RevWalk, c *object.Commit, parents []common.Hash) ([]*fileStat, error) {
+	if len(parents) > 1 && !walk.FirstParent {
+		return nil, nil
+	}
+	from := common.ZeroHash
+	if len(parents) > 0 {
+		parent, err := repo.GetAsCommit(parents[0])
+		if err != nil {
+			return nil, err
+		}
+		from = parent.Tree()
+	}
+	changes, err := repo.DiffTrees(from, c.Tree(), walk.Paths)
+	if err != nil {
+		return nil, err
+	}
+	return diffStats(repo, changes)
+}
+
+// messageLines splits the message into lines, without leading and trailing blank lines and trailing whitespace
+func messageLines(msg string) []string {
+	lines := strings.Split(strings.TrimRight(msg, "\n"), "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
 	}
-	return nil
+	return lines
 }
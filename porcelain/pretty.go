@@ -0,0 +1,348 @@
+package porcelain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core"
+	"github.com/izhujiang/gogit/core/object"
+)
+
+const (
+	pretty_oneline = "oneline"
+	pretty_short   = "short"
+	pretty_medium  = "medium"
+	pretty_full    = "full"
+	pretty_fuller  = "fuller"
+	pretty_user    = ""
+
+	default_abbrev = 7
+)
+
+// prettyFormat is a format of commits in git log, either built in, or given with placeholders
+type prettyFormat struct {
+	name string
+	// format with placeholders of a user format
+	format string
+	// entries are terminated by newlines rather than separated by them
+	terminator bool
+	// commit ids are abbreviated in the oneline format, as --oneline does
+	abbrev bool
+	// names of references are shown after commit ids in built-in formats, as --decorate does
+	decorate bool
+	// names of references pointing to commits, loaded for %d and %D
+	decorations map[common.Hash][]string
+}
+
+// parsePretty parses the format given by --pretty=<format> as git log does. A named format, format:<string>
+// separating entries with newlines, or tformat:<string> terminating entries with them, and a string with % in
+// it is taken as tformat:<string>.
+func parsePretty(s string) (*prettyFormat, error) {
+	switch s {
+	case "":
+		return &prettyFormat{name: pretty_medium}, nil
+	case pretty_oneline:
+		return &prettyFormat{name: pretty_oneline, terminator: true}, nil
+	case pretty_short, pretty_medium, pretty_full, pretty_fuller:
+		return &prettyFormat{name: s}, nil
+	}
+	if strings.HasPrefix(s, "format:") {
+		return &prettyFormat{name: pretty_user, format: s[len("format:"):]}, nil
+	}
+	if strings.HasPrefix(s, "tformat:") {
+		return &prettyFormat{name: pretty_user, format: s[len("tformat:"):], terminator: true}, nil
+	}
+	if strings.Contains(s, "%") {
+		return &prettyFormat{name: pretty_user, format: s, terminator: true}, nil
+	}
+	return nil, fmt.Errorf("invalid --pretty format: %s", s)
+}
+
+// empty reports whether the format writes nothing for commits
+func (f *prettyFormat) empty() bool {
+	return f.name == pretty_user && f.format == ""
+}
+
+// decorates reports whether the format shows names of references
+func (f *prettyFormat) decorates() bool {
+	if f.name != pretty_user {
+		return f.decorate
+	}
+	return strings.Contains(f.format, "%d") || strings.Contains(f.format, "%D")
+}
+
+// loadDecorations maps commits to names of branches, remote-tracking branches, tags and the stash pointing to them
+// like git log, HEAD goes first, followed by the branch it points to as "HEAD -> main", and then the others in the
+// reverse order of their full names. Annotated tags are peeled, and symbolic references such as origin/HEAD are shown
+// at the commits they resolve to.
+func (f *prettyFormat) loadDecorations(ws *core.Workspace) error {
+	repo, refs := ws.Repository(), ws.References()
+	f.decorations = make(map[common.Hash][]string)
+	err := refs.ForEachResolved(func(name string, id common.Hash) error {
+		switch {
+		case strings.HasPrefix(name, core.RefPrefix_Heads):
+			name = name[len(core.RefPrefix_Heads):]
+		case strings.HasPrefix(name, core.RefPrefix_Remotes):
+			name = name[len(core.RefPrefix_Remotes):]
+		case strings.HasPrefix(name, core.RefPrefix_Tags):
+			name = "tag: " + name[len(core.RefPrefix_Tags):]
+		case name != "refs/stash":
+			return nil
+		}
+		c, err := repo.PeelToCommit(id)
+		if err != nil {
+			// references to other objects are not shown
+			return nil
+		}
+		f.decorations[c.Id()] = append([]string{name}, f.decorations[c.Id()]...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	head, err := refs.HeadCommit()
+	if err != nil {
+		return nil
+	}
+	names, decoration := f.decorations[head], "HEAD"
+	if branch := refs.CurrentBranch(); branch != "" {
+		for i, name := range names {
+			if name == branch {
+				names = append(names[:i:i], names[i+1:]...)
+				decoration = "HEAD -> " + branch
+				break
+			}
+		}
+	}
+	f.decorations[head] = append([]string{decoration}, names...)
+	return nil
+}
+
+// formatCommit formats the commit c, whose parents are given, and built-in formats but oneline end with a newline
+func (f *prettyFormat) formatCommit(repo *core.Repository, c *object.Commit, parents []common.Hash) string {
+	if f.name == pretty_user {
+		return expandFormat(repo, c, parents, f.decorations[c.Id()], f.format)
+	}
+
+	decoration := ""
+	if names := f.decorations[c.Id()]; len(names) > 0 {
+		decoration = " (" + strings.Join(names, ", ") + ")"
+	}
+	msg := c.Message()
+	if f.name == pretty_oneline {
+		id := c.Id().String()
+		if f.abbrev {
+			id = repo.ShortId(c.Id(), default_abbrev)
+		}
+		return id + decoration + " " + commitSubject(msg)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "commit %s%s\n", c.Id(), decoration)
+	if len(parents) > 1 {
+		short := make([]string, 0, len(parents))
+		for _, p := range parents {
+			short = append(short, repo.ShortId(p, default_abbrev))
+		}
+		fmt.Fprintf(&sb, "Merge: %s\n", strings.Join(short, " "))
+	}
+	author, committer := c.Author(), c.Committer()
+	switch f.name {
+	case pretty_short:
+		fmt.Fprintf(&sb, "Author: %s <%s>\n", author.Name, author.Email)
+	case pretty_medium:
+		fmt.Fprintf(&sb, "Author: %s <%s>\n", author.Name, author.Email)
+		fmt.Fprintf(&sb, "Date:   %s\n", author.When.Format(common.DefaultDateLayout))
+	case pretty_full:
+		fmt.Fprintf(&sb, "Author: %s <%s>\n", author.Name, author.Email)
+		fmt.Fprintf(&sb, "Commit: %s <%s>\n", committer.Name, committer.Email)
+	case pretty_fuller:
+		fmt.Fprintf(&sb, "Author:     %s <%s>\n", author.Name, author.Email)
+		fmt.Fprintf(&sb, "AuthorDate: %s\n", author.When.Format(common.DefaultDateLayout))
+		fmt.Fprintf(&sb, "Commit:     %s <%s>\n", committer.Name, committer.Email)
+		fmt.Fprintf(&sb, "CommitDate: %s\n", committer.When.Format(common.DefaultDateLayout))
+	}
+
+	lines := messageLines(msg)
+	if f.name == pretty_short {
+		for i, line := range lines {
+			if line == "" {
+				lines = lines[:i]
+				break
+			}
+		}
+	}
+	sb.WriteString("\n")
+	for _, line := range lines {
+		fmt.Fprintf(&sb, "    %s\n", line)
+	}
+	return sb.String()
+}
+
+// expandFormat expands placeholders in format for the commit c, which names of references in decorations point to,
+// unknown ones are kept as they are
+func expandFormat(repo *core.Repository, c *object.Commit, parents []common.Hash, decorations []string, format string) string {
+	var sb strings.Builder
+	for {
+		i := strings.IndexByte(format, '%')
+		if i < 0 {
+			sb.WriteString(format)
+			return sb.String()
+		}
+		sb.WriteString(format[:i])
+		format = format[i:]
+
+		n := expandPlaceholder(&sb, repo, c, parents, decorations, format[1:])
+		if n == 0 {
+			sb.WriteByte('%')
+		}
+		format = format[1+n:]
+	}
+}
+
+// expandPlaceholder writes the value of the placeholder at the start of p, which follows %, and returns its length,
+// or 0 if it is unknown
+func expandPlaceholder(sb *strings.Builder, repo *core.Repository, c *object.Commit, parents []common.Hash,
+	decorations []string, p string) int {
+	if p == "" {
+		return 0
+	}
+	switch p[0] {
+	case 'H':
+		sb.WriteString(c.Id().String())
+		return 1
+	case 'h':
+		sb.WriteString(repo.ShortId(c.Id(), default_abbrev))
+		return 1
+	case 'T':
+		sb.WriteString(c.Tree().String())
+		return 1
+	case 't':
+		sb.WriteString(repo.ShortId(c.Tree(), default_abbrev))
+		return 1
+	case 'P', 'p':
+		ids := make([]string, 0, len(parents))
+		for _, parent := range parents {
+			if p[0] == 'P' {
+				ids = append(ids, parent.String())
+			} else {
+				ids = append(ids, repo.ShortId(parent, default_abbrev))
+			}
+		}
+		sb.WriteString(strings.Join(ids, " "))
+		return 1
+	case 'a', 'c':
+		sig := c.Author()
+		if p[0] == 'c' {
+			sig = c.Committer()
+		}
+		if len(p) < 2 {
+			return 0
+		}
+		return expandSignature(sb, sig, p[1])
+	case 'd':
+		if len(decorations) > 0 {
+			fmt.Fprintf(sb, " (%s)", strings.Join(decorations, ", "))
+		}
+		return 1
+	case 'D':
+		sb.WriteString(strings.Join(decorations, ", "))
+		return 1
+	case 's':
+		sb.WriteString(commitSubject(c.Message()))
+		return 1
+	case 'b':
+		sb.WriteString(commitBody(c.Message()))
+		return 1
+	case 'B':
+		sb.WriteString(c.Message())
+		return 1
+	case 'n':
+		sb.WriteByte('\n')
+		return 1
+	case '%':
+		sb.WriteByte('%')
+		return 1
+	case 'x':
+		if len(p) < 3 {
+			return 0
+		}
+		b, err := strconv.ParseUint(p[1:3], 16, 8)
+		if err != nil {
+			return 0
+		}
+		sb.WriteByte(byte(b))
+		return 3
+	case 'C':
+		// colors are not written
+		if strings.HasPrefix(p, "C(") {
+			if end := strings.IndexByte(p, ')'); end > 0 {
+				return end + 1
+			}
+			return 0
+		}
+		for _, color := range []string{"red", "green", "blue", "reset"} {
+			if strings.HasPrefix(p[1:], color) {
+				return 1 + len(color)
+			}
+		}
+	}
+	return 0
+}
+
+// expandSignature writes the field of sig, which follows %a or %c, and returns 2 or 0 if the field is unknown
+func expandSignature(sb *strings.Builder, sig *object.Signature, field byte) int {
+	switch field {
+	case 'n':
+		sb.WriteString(sig.Name)
+	case 'e':
+		sb.WriteString(sig.Email)
+	case 'd':
+		sb.WriteString(sig.When.Format(common.DefaultDateLayout))
+	case 'r':
+		sb.WriteString(common.FormatRelativeDate(sig.When, time.Now()))
+	case 't':
+		sb.WriteString(strconv.FormatInt(sig.When.Unix(), 10))
+	case 'i':
+		sb.WriteString(sig.When.Format("2006-01-02 15:04:05 -0700"))
+	case 'I':
+		sb.WriteString(sig.When.Format("2006-01-02T15:04:05-07:00"))
+	case 's':
+		sb.WriteString(sig.When.Format("2006-01-02"))
+	default:
+		return 0
+	}
+	return 2
+}
+
+// commitSubject returns the first paragraph of the message, whose lines are joined with spaces
+func commitSubject(msg string) string {
+	lines := messageLines(msg)
+	for i, line := range lines {
+		if line == "" {
+			lines = lines[:i]
+			break
+		}
+	}
+	return strings.Join(lines, " ")
+}
+
+// commitBody returns the message after the first paragraph and blank lines following it
+func commitBody(msg string) string {
+	lines := strings.SplitAfter(msg, "\n")
+	i := 0
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	for i < len(lines) && strings.TrimSpace(lines[i]) != "" {
+		i++
+	}
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	return strings.Join(lines[i:], "")
+}
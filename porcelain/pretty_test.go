@@ -0,0 +1,173 @@
+package porcelain
+
+import (
+	"testing"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core"
+	"github.com/izhujiang/gogit/core/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogPretty(t *testing.T) {
+	h := newTestHistory(t)
+	a := h.commit("a", h.tree(map[string]string{"a": "a\n"}))
+	b := h.commit("b\nsubject\n\nbody\n\nmore body", h.tree(map[string]string{"b": "b\n"}), a)
+	h.ref("refs/heads/main", b)
+
+	cases := []struct {
+		format string
+		output string
+	}{
+		{"%H %h %T %t %P %p", `a9b5da942055b116a71686647673356f29af9044 a9b5da9 6be660545b31f61a82a87d2b1915f0b88bb9f16f 6be6605 fc8965097009e6f2f7bfd7372b191004b205def3 fc89650
+fc8965097009e6f2f7bfd7372b191004b205def3 fc89650 aaff74984cccd156a469afa7d9ab10e4777beb24 aaff749  
+`},
+		{"%an <%ae> %ad|%ai|%aI|%as|%at", `A U Thor <author@example.com> Thu Apr 7 22:16:33 2005 +0000|2005-04-07 22:16:33 +0000|2005-04-07T22:16:33+00:00|2005-04-07|1112912193
+A U Thor <author@example.com> Thu Apr 7 22:14:53 2005 +0000|2005-04-07 22:14:53 +0000|2005-04-07T22:14:53+00:00|2005-04-07|1112912093
+`},
+		{"%cn <%ce> %cd", `A U Thor <author@example.com> Thu Apr 7 22:16:33 2005 +0000
+A U Thor <author@example.com> Thu Apr 7 22:14:53 2005 +0000
+`},
+		{"[%s][%b][%B]", `[b subject][body
+
+more body
+][b
+subject
+
+body
+
+more body
+]
+[a][][a
+]
+`},
+		{"%%%n%x41%C(red)%Cgreen%Creset%Z%", `%
+A%Z%
+%
+A%Z%
+`},
+		{"format:%s", `b subject
+a`},
+		{"tformat:%s", `b subject
+a
+`},
+		{"oneline", `a9b5da942055b116a71686647673356f29af9044 b subject
+fc8965097009e6f2f7bfd7372b191004b205def3 a
+`},
+		{"short", `commit a9b5da942055b116a71686647673356f29af9044
+Author: A U Thor <author@example.com>
+
+    b
+    subject
+
+commit fc8965097009e6f2f7bfd7372b191004b205def3
+Author: A U Thor <author@example.com>
+
+    a
+`},
+		{"medium", `commit a9b5da942055b116a71686647673356f29af9044
+Author: A U Thor <author@example.com>
+Date:   Thu Apr 7 22:16:33 2005 +0000
+
+    b
+    subject
+    
+    body
+    
+    more body
+
+commit fc8965097009e6f2f7bfd7372b191004b205def3
+Author: A U Thor <author@example.com>
+Date:   Thu Apr 7 22:14:53 2005 +0000
+
+    a
+`},
+		{"full", `commit a9b5da942055b116a71686647673356f29af9044
+Author: A U Thor <author@example.com>
+Commit: A U Thor <author@example.com>
+
+    b
+    subject
+    
+    body
+    
+    more body
+
+commit fc8965097009e6f2f7bfd7372b191004b205def3
+Author: A U Thor <author@example.com>
+Commit: A U Thor <author@example.com>
+
+    a
+`},
+		{"fuller", `commit a9b5da942055b116a71686647673356f29af9044
+Author:     A U Thor <author@example.com>
+AuthorDate: Thu Apr 7 22:16:33 2005 +0000
+Commit:     A U Thor <author@example.com>
+CommitDate: Thu Apr 7 22:16:33 2005 +0000
+
+    b
+    subject
+    
+    body
+    
+    more body
+
+commit fc8965097009e6f2f7bfd7372b191004b205def3
+Author:     A U Thor <author@example.com>
+AuthorDate: Thu Apr 7 22:14:53 2005 +0000
+Commit:     A U Thor <author@example.com>
+CommitDate: Thu Apr 7 22:14:53 2005 +0000
+
+    a
+`},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.output, h.log(nil, &LogOption{Pretty: c.format}), c.format)
+	}
+	assert.Equal(t, `a
+b subject
+`, h.log(nil, &LogOption{Format: "%s", Reverse: true}))
+}
+
+func TestLogDecorations(t *testing.T) {
+	h := newTestHistory(t)
+	a := h.commit("a", common.ZeroHash)
+	b := h.commit("b", common.ZeroHash, a)
+	h.ref("refs/heads/main", b)
+	h.ref("refs/heads/topic", a)
+	h.ref("refs/heads/zz", b)
+	h.ref("refs/remotes/origin/main", a)
+	assert.Nil(t, h.ws.References().WriteSymbolicRef("refs/remotes/origin/HEAD", "refs/remotes/origin/main"))
+	h.ref("refs/tags/v1", a)
+	h.ref("refs/stash", b)
+	h.ref("refs/notes/commits", b)
+
+	tag := object.NewTag(common.ZeroHash, a, object.Kind_Commit, "v1a", h.sig(), "annotated").ToGitObject()
+	assert.Nil(t, h.ws.Repository().Put(tag))
+	h.ref("refs/tags/v1a", tag.Id())
+
+	option := &LogOption{All: true, Format: "%s%d|%D|"}
+	assert.Equal(t, `b (HEAD -> main, refs/stash, zz)|HEAD -> main, refs/stash, zz|
+a (tag: v1a, tag: v1, origin/main, origin/HEAD, topic)|tag: v1a, tag: v1, origin/main, origin/HEAD, topic|
+`, h.log(nil, option))
+
+	// --decorate shows the names in built-in formats only
+	assert.Equal(t, `0a466a3 (HEAD -> main, refs/stash, zz) b
+64e0c33 (tag: v1a, tag: v1, origin/main, origin/HEAD, topic) a
+`, h.log(nil, &LogOption{All: true, Oneline: true, Decorate: true}))
+	assert.Equal(t, `commit 0a466a35170ffc1f26840589f8e42202b9f096d1 (HEAD -> main, refs/stash, zz)
+Author: A U Thor <author@example.com>
+Date:   Thu Apr 7 22:16:33 2005 +0000
+
+    b
+`, h.log(nil, &LogOption{MaxCount: 1, Decorate: true}))
+	assert.Equal(t, "b\n", h.log(nil, &LogOption{MaxCount: 1, Format: "%s", Decorate: true}))
+
+	// HEAD is shown on its own once it is detached
+	tx := h.ws.References().NewTransaction()
+	assert.Nil(t, tx.Queue(core.RefUpdate{Name: "HEAD", NewId: a, HaveNew: true, NoDeref: true}))
+	assert.Nil(t, tx.Commit())
+	assert.Equal(t, `b (refs/stash, zz, main)|refs/stash, zz, main|
+a (HEAD, tag: v1a, tag: v1, origin/main, origin/HEAD, topic)|HEAD, tag: v1a, tag: v1, origin/main, origin/HEAD, topic|
+`, h.log(nil, option))
+}
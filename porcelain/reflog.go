@@ -0,0 +1,234 @@
+package porcelain
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core"
+)
+
+const (
+	default_reflog_expire             = "90.days.ago"
+	default_reflog_expire_unreachable = "30.days.ago"
+)
+
+type ReflogShowOption struct {
+	// limit the number of entries to show, all entries if it is not positive
+	MaxCount int
+}
+
+// ReflogShow writes entries in the reflog of ref from the newest, HEAD if ref is empty, such as "1a2b3c4 HEAD@{0}: commit: msg"
+func ReflogShow(ws *core.Workspace, w io.Writer, ref string, option *ReflogShowOption) error {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	refs := ws.References()
+	name, err := refs.ReflogRef(ref)
+	if err != nil {
+		return fmt.Errorf("ambiguous argument '%s': unknown revision or path not in the working tree.", ref)
+	}
+
+	entries, err := refs.Reflog(name)
+	if err == core.ErrReflogNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < len(entries); i++ {
+		if option.MaxCount > 0 && i >= option.MaxCount {
+			break
+		}
+		e := entries[len(entries)-1-i]
+		fmt.Fprintf(w, "%s %s@{%d}: %s\n", e.New.String()[:7], ref, i, e.Message)
+	}
+	return nil
+}
+
+type ReflogExpireOption struct {
+	// prune entries older than the time, gc.reflogExpire or 90 days ago by default
+	Expire string
+	// prune entries older than the time which are not reachable from the current tip, gc.reflogExpireUnreachable or 30 days ago by default
+	ExpireUnreachable string
+	// process reflogs of all references
+	All    bool
+	DryRun bool
+	// print pruned and kept entries
+	Verbose bool
+}
+
+// ReflogExpire prunes old entries from reflogs of refs, or all reflogs with option.All
+func ReflogExpire(ws *core.Workspace, w io.Writer, refNames []string, option *ReflogExpireOption) error {
+	c, err := ws.Config()
+	if err != nil {
+		return err
+	}
+	expireValue, expireUnreachableValue := option.Expire, option.ExpireUnreachable
+	if expireValue == "" {
+		expireValue, _ = c.Get("gc.reflogExpire")
+	}
+	if expireUnreachableValue == "" {
+		expireUnreachableValue, _ = c.Get("gc.reflogExpireUnreachable")
+	}
+	expire, err := parseExpiry(expireValue, default_reflog_expire)
+	if err != nil {
+		return fmt.Errorf("'%s' is not a valid timestamp", expireValue)
+	}
+	expireUnreachable, err := parseExpiry(expireUnreachableValue, default_reflog_expire_unreachable)
+	if err != nil {
+		return fmt.Errorf("'%s' is not a valid timestamp", expireUnreachableValue)
+	}
+
+	refs := ws.References()
+	names := make([]string, 0, len(refNames))
+	if option.All {
+		if names, err = refs.ReflogNames(); err != nil {
+			return err
+		}
+	}
+	for _, ref := range refNames {
+		name, err := refs.ReflogRef(ref)
+		if err != nil || !refs.HasReflog(name) {
+			return fmt.Errorf("reflog could not be found: '%s'", ref)
+		}
+		names = append(names, name)
+	}
+
+	repo := ws.Repository()
+	for _, name := range names {
+		entries, err := refs.Reflog(name)
+		if err != nil {
+			return err
+		}
+		tip, _ := refs.ReadRef(name)
+
+		kept := make([]*core.ReflogEntry, 0, len(entries))
+		for _, e := range entries {
+			when := e.Committer.When
+			prune := when.Before(expire)
+			if !prune && when.Before(expireUnreachable) {
+				reachable, err := repo.IsAncestor(e.New, tip)
+				prune = err != nil || !reachable
+			}
+
+			if option.Verbose {
+				switch {
+				case !prune:
+					fmt.Fprintf(w, "keep %s\n", e.Message)
+				case option.DryRun:
+					fmt.Fprintf(w, "would prune %s\n", e.Message)
+				default:
+					fmt.Fprintf(w, "prune %s\n", e.Message)
+				}
+			}
+			if !prune {
+				kept = append(kept, e)
+			}
+		}
+
+		if !option.DryRun && len(kept) != len(entries) {
+			if err := refs.WriteReflog(name, kept); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseExpiry parses the time before which entries are pruned, "never" and "false" prune nothing, while "all" and "now" prune everything
+func parseExpiry(s string, def string) (time.Time, error) {
+	if s == "" {
+		s = def
+	}
+	switch s {
+	case "never", "false":
+		return time.Time{}, nil
+	case "all", "now":
+		return time.Unix(math.MaxInt32, 0), nil
+	}
+	return common.ParseApproxidate(s, time.Now())
+}
+
+type ReflogDeleteOption struct {
+	// adjust the old value of the entry after a deleted one to the new value of the entry before
+	Rewrite bool
+	// update the reference to the new value of the newest entry which is left
+	UpdateRef bool
+	DryRun    bool
+}
+
+// ReflogDelete removes entries selected by <ref>@{n} in selectors from reflogs
+func ReflogDelete(ws *core.Workspace, w io.Writer, selectors []string, option *ReflogDeleteOption) error {
+	refs := ws.References()
+
+	// indexes of entries from the newest by references, which are deleted together
+	deletes := make(map[string][]int)
+	order := make([]string, 0)
+	for _, s := range selectors {
+		ref, selector, found := strings.Cut(s, "@{")
+		n := -1
+		if found && strings.HasSuffix(selector, "}") {
+			n = core.ReflogIndex(selector[:len(selector)-1])
+		}
+		if n < 0 {
+			return fmt.Errorf("not a reflog: %s", s)
+		}
+		name, err := refs.ReflogRef(ref)
+		if err != nil || !refs.HasReflog(name) {
+			return fmt.Errorf("no reflog for '%s'", s)
+		}
+		if _, ok := deletes[name]; !ok {
+			order = append(order, name)
+		}
+		deletes[name] = append(deletes[name], n)
+	}
+
+	for _, name := range order {
+		entries, err := refs.Reflog(name)
+		if err != nil {
+			return err
+		}
+		removed := make(map[int]bool)
+		for _, n := range deletes[name] {
+			if n < len(entries) {
+				removed[len(entries)-1-n] = true
+			}
+		}
+		if len(removed) == 0 || option.DryRun {
+			continue
+		}
+
+		kept := make([]*core.ReflogEntry, 0, len(entries))
+		for i, e := range entries {
+			if removed[i] {
+				continue
+			}
+			if option.Rewrite && len(kept) > 0 {
+				e.Old = kept[len(kept)-1].New
+			}
+			kept = append(kept, e)
+		}
+		if err := refs.WriteReflog(name, kept); err != nil {
+			return err
+		}
+
+		if option.UpdateRef && len(kept) > 0 {
+			if err := updateRefToReflog(refs, name, kept[len(kept)-1].New); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// updateRefToReflog points the reference in name to id, the change is not recorded in reflogs
+func updateRefToReflog(refs *core.References, name string, id common.Hash) error {
+	tx := refs.NewTransaction()
+	tx.Queue(core.RefUpdate{Name: name, NewId: id, HaveNew: true, NoDeref: true, SkipReflog: true})
+	return tx.Commit()
+}
@@ -8,7 +8,8 @@ type RemoveOption struct {
 	Recursive bool
 }
 
-func Remove(paths []string, option *RemoveOption) error {
+// Remove paths from the index, paths are relative to the directory where the workspace is discovered
+func Remove(ws *core.Workspace, paths []string, option *RemoveOption) error {
 	// TODO: check if some files have local modifications, remove all files and directories of working area if with --force
 
 	// fi, err := os.Stat(path)
@@ -18,9 +19,22 @@ func Remove(paths []string, option *RemoveOption) error {
 	// 	os.Remove(path)
 	// }
 
-	sa := core.GetStagingArea()
+	if err := ws.CheckWorkTree(); err != nil {
+		return err
+	}
+
+	relPaths := make([]string, 0, len(paths))
+	for _, path := range paths {
+		rel, err := ws.RelPath(path)
+		if err != nil {
+			return err
+		}
+		relPaths = append(relPaths, rel)
+	}
+
+	sa := ws.StagingArea()
 	sa.Load()
-	sa.Unstage(paths, option.Recursive)
+	sa.Unstage(relPaths, option.Recursive)
 	err := sa.Save()
 
 	return err
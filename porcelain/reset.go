@@ -0,0 +1,185 @@
+package porcelain
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core"
+	"github.com/izhujiang/gogit/core/object"
+)
+
+var (
+	errResetSoftInMerge = errors.New("Cannot do a soft reset in the middle of a merge.")
+)
+
+type ResetOption struct {
+	// Soft moves HEAD only, Mixed resets the index as well, which is the default, and Hard resets the work tree too
+	Soft  bool
+	Mixed bool
+	Hard  bool
+	// don't report unstaged changes or the new HEAD
+	Quiet bool
+}
+
+// Reset points the current branch, or HEAD if it is detached, to the commit args[0], HEAD if args are empty, and
+// resets the index, and the work tree with Hard, to its tree. The commit HEAD was at is saved in ORIG_HEAD.
+// paths are those given after "--", nil without "--", in which case args following the revision are paths, and
+// args are all paths if args[0] is not a revision. Only index entries of paths are reset, to the tree-ish args[0],
+// if there are any.
+func Reset(ws *core.Workspace, w io.Writer, args []string, paths []string, option *ResetOption) error {
+	if err := ws.CheckWorkTree(); err != nil {
+		return err
+	}
+
+	rev := "HEAD"
+	switch {
+	case paths != nil && len(args) > 1:
+		return fmt.Errorf("only one reference expected, %d given.", len(args))
+	case paths != nil && len(args) == 1:
+		rev = args[0]
+		if _, err := ws.ResolveRevision(rev); err != nil {
+			return fmt.Errorf("Failed to resolve '%s' as a valid revision.", rev)
+		}
+	case len(args) > 0:
+		if _, err := ws.ResolveRevision(args[0]); err == nil {
+			rev, paths = args[0], args[1:]
+			break
+		}
+		// paths must exist in the work tree without "--"
+		for _, p := range args {
+			if _, err := os.Lstat(p); err != nil {
+				return fmt.Errorf("ambiguous argument '%s': unknown revision or path not in the working tree.\n"+
+					"Use '--' to separate paths from revisions, like this:\n'gg <command> [<revision>...] -- [<file>...]'", p)
+			}
+		}
+		paths = args
+	}
+
+	if len(paths) > 0 {
+		return resetPaths(ws, w, rev, paths, option)
+	}
+
+	refs := ws.References()
+	repo := ws.Repository()
+	head, err := refs.HeadCommit()
+	unborn := err != nil
+	var commit *object.Commit
+	if !unborn || rev != "HEAD" {
+		oid, err := ws.ResolveRevision(rev)
+		if err != nil {
+			return fmt.Errorf("Failed to resolve '%s' as a valid revision.", rev)
+		}
+		if commit, err = repo.PeelToCommit(oid); err != nil {
+			return fmt.Errorf("Could not parse object '%s'.", rev)
+		}
+	}
+	treeId := common.ZeroHash
+	if commit != nil {
+		treeId = commit.Tree()
+	}
+
+	switch {
+	case option.Soft:
+		status, err := ws.Status(nil, core.Untracked_No, false)
+		if err != nil {
+			return err
+		}
+		for _, s := range status.Files {
+			if s.Unmerged() {
+				return errResetSoftInMerge
+			}
+		}
+	case option.Hard:
+		if err := ws.CheckoutTree(common.ZeroHash, treeId, true); err != nil {
+			return err
+		}
+	default:
+		if err := ws.ResetIndex(treeId, nil); err != nil {
+			return err
+		}
+	}
+
+	if commit != nil {
+		if !unborn {
+			if err := refs.WriteRef("ORIG_HEAD", head); err != nil {
+				return err
+			}
+		}
+		if err := refs.UpdateRef("HEAD", commit.Id(), head, "reset: moving to "+rev); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case option.Quiet || option.Soft:
+	case option.Hard && commit != nil:
+		fmt.Fprintf(w, "HEAD is now at %s %s\n", repo.ShortId(commit.Id(), default_abbrev), commitSubject(commit.Message()))
+	case !option.Hard:
+		return writeUnstagedChanges(ws, w)
+	}
+	return nil
+}
+
+// resetPaths resets index entries of paths, which are relative to the current directory, to the tree-ish rev
+func resetPaths(ws *core.Workspace, w io.Writer, rev string, paths []string, option *ResetOption) error {
+	switch {
+	case option.Soft:
+		return errors.New("Cannot do soft reset with paths.")
+	case option.Hard:
+		return errors.New("Cannot do hard reset with paths.")
+	}
+
+	treeId := common.ZeroHash
+	if _, err := ws.References().HeadCommit(); err == nil || rev != "HEAD" {
+		oid, err := ws.ResolveRevisionAs(rev, object.Kind_Tree)
+		if err != nil {
+			return fmt.Errorf("Failed to resolve '%s' as a valid tree.", rev)
+		}
+		treeId = oid
+	}
+
+	specs := make([]string, 0, len(paths))
+	for _, p := range paths {
+		rel, err := ws.RelPath(p)
+		if err != nil {
+			return err
+		}
+		specs = append(specs, filepath.ToSlash(rel))
+	}
+	if err := ws.ResetIndex(treeId, specs); err != nil {
+		return err
+	}
+
+	if option.Quiet {
+		return nil
+	}
+	return writeUnstagedChanges(ws, w)
+}
+
+// writeUnstagedChanges lists files which differ between the index and the work tree after reset, such as "M\tpath"
+func writeUnstagedChanges(ws *core.Workspace, w io.Writer) error {
+	status, err := ws.Status(nil, core.Untracked_No, false)
+	if err != nil {
+		return err
+	}
+	header := false
+	for _, s := range status.Files {
+		code := s.Worktree
+		if s.Unmerged() {
+			code = core.Status_Unmerged
+		}
+		if code == core.Status_Unmodified {
+			continue
+		}
+		if !header {
+			fmt.Fprintln(w, "Unstaged changes after reset:")
+			header = true
+		}
+		fmt.Fprintf(w, "%c\t%s\n", code, s.Path)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package porcelain
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core"
+	"github.com/izhujiang/gogit/core/object"
+)
+
+var (
+	errRestoreNoPaths = errors.New("you must specify path(s) to restore")
+)
+
+type RestoreOption struct {
+	// the tree-ish to restore from, the index by default, or HEAD with Staged
+	Source string
+	// restore the index, and the work tree as well with Worktree, which is the default without Staged
+	Staged   bool
+	Worktree bool
+}
+
+// Restore restores files matching paths, which are relative to the current directory, in the work tree from the index,
+// or in the index and the work tree from the tree-ish option.Source, or HEAD if the index is restored. Tracked files
+// which are not in the source are removed.
+func Restore(ws *core.Workspace, paths []string, option *RestoreOption) error {
+	if err := ws.CheckWorkTree(); err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return errRestoreNoPaths
+	}
+	worktree := option.Worktree || !option.Staged
+
+	source := option.Source
+	if source == "" && option.Staged {
+		source = "HEAD"
+	}
+	treeId := common.ZeroHash
+	if source != "" {
+		oid, err := ws.ResolveRevisionAs(source, object.Kind_Tree)
+		switch {
+		case err == nil:
+			treeId = oid
+		case option.Source != "":
+			return fmt.Errorf("could not resolve %s", source)
+		default:
+			// HEAD is unborn, the index is restored to the empty tree
+			if _, headErr := ws.References().HeadCommit(); headErr == nil {
+				return err
+			}
+		}
+	}
+
+	specs := make([]string, 0, len(paths))
+	for _, p := range paths {
+		rel, err := ws.RelPath(p)
+		if err != nil {
+			return err
+		}
+		specs = append(specs, filepath.ToSlash(rel))
+	}
+	return ws.Restore(treeId, specs, option.Staged, worktree)
+}
@@ -0,0 +1,580 @@
+package porcelain
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core"
+)
+
+const (
+	status_long         = ""
+	status_short        = "short"
+	status_porcelain_v1 = "v1"
+	status_porcelain_v2 = "v2"
+)
+
+var (
+	errStatusUntrackedMode = errors.New("Invalid untracked files mode.")
+	errStatusFormat        = errors.New("Unsupported porcelain version.")
+)
+
+type StatusOption struct {
+	// give the output in the short format
+	Short bool
+	// give the output in a stable format for scripts, "v1" or "v2"
+	Porcelain string
+	// show the branch and tracking info in the short and porcelain formats
+	Branch bool
+	// terminate entries with NUL instead of LF, and paths are not quoted, which implies Porcelain "v1" if no
+	// format is given
+	NullTerminated bool
+	// "no", "normal" or "all", normal by default
+	UntrackedFiles string
+	// show ignored files as well
+	Ignored bool
+	// only paths matching any of them are shown, relative to the current directory
+	Paths []string
+}
+
+// statusBranch is the branch info shown in the header of status
+type statusBranch struct {
+	// the current branch, empty if HEAD is detached
+	name string
+	head common.Hash
+	// HEAD is an unborn branch
+	initial bool
+	// the short name of the upstream, and ahead and behind counts unless it is gone
+	upstream      string
+	gone          bool
+	ahead, behind int
+}
+
+// Status shows paths which differ between HEAD and the index, between the index and the work tree, and paths not
+// tracked in the work tree
+func Status(ws *core.Workspace, w io.Writer, option *StatusOption) error {
+	if err := ws.CheckWorkTree(); err != nil {
+		return err
+	}
+
+	format := status_long
+	switch {
+	case option.Porcelain != "":
+		format = option.Porcelain
+		if format != status_porcelain_v1 && format != status_porcelain_v2 {
+			return errStatusFormat
+		}
+	case option.Short:
+		format = status_short
+	case option.NullTerminated:
+		format = status_porcelain_v1
+	}
+
+	untracked := core.Untracked_Normal
+	switch option.UntrackedFiles {
+	case "", "normal":
+	case "no":
+		untracked = core.Untracked_No
+	case "all":
+		untracked = core.Untracked_All
+	default:
+		return errStatusUntrackedMode
+	}
+
+	paths := make([]string, 0, len(option.Paths))
+	for _, p := range option.Paths {
+		rel, err := ws.RelPath(p)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+	status, err := ws.Status(paths, untracked, option.Ignored)
+	if err != nil {
+		return err
+	}
+	branch, err := readStatusBranch(ws)
+	if err != nil {
+		return err
+	}
+
+	// paths are relative to the current directory in human readable formats, and porcelain v2 unless they are
+	// terminated by NUL
+	prefix := ""
+	if format == status_long || format == status_short || (format == status_porcelain_v2 && !option.NullTerminated) {
+		if prefix, err = ws.RelPath("."); err != nil {
+			return err
+		}
+		if prefix = filepath.ToSlash(prefix); prefix == "." {
+			prefix = ""
+		}
+	}
+
+	switch format {
+	case status_long:
+		return writeLongStatus(ws, w, status, branch, prefix, untracked != core.Untracked_No)
+	case status_porcelain_v2:
+		writePorcelainV2Status(w, status, branch, prefix, option.Branch, option.NullTerminated)
+	default:
+		writeShortStatus(w, status, branch, prefix, option.Branch, option.NullTerminated)
+	}
+	return nil
+}
+
+func readStatusBranch(ws *core.Workspace) (*statusBranch, error) {
+	refs := ws.References()
+	b := &statusBranch{name: refs.CurrentBranch()}
+	head, err := refs.HeadCommit()
+	if err != nil {
+		b.initial = true
+		return b, nil
+	}
+	b.head = head
+	if b.name == "" {
+		return b, nil
+	}
+
+	c, err := ws.Config()
+	if err != nil {
+		return nil, err
+	}
+	upstream, ok := core.UpstreamRef(c, b.name)
+	if !ok {
+		return b, nil
+	}
+	b.upstream = shortRefName(upstream)
+	tip, err := refs.ReadRef(upstream)
+	if err != nil {
+		b.gone = true
+		return b, nil
+	}
+	b.ahead, b.behind, err = ws.Repository().AheadBehind(head, tip)
+	return b, err
+}
+
+// writeShortStatus writes status in the short format, which is porcelain v1 if prefix is empty
+func writeShortStatus(w io.Writer, status *core.Status, branch *statusBranch, prefix string, withBranch bool, nul bool) {
+	eol := "\n"
+	if nul {
+		eol = "\x00"
+	}
+	path := func(p string) string {
+		p = relativeStatusPath(p, prefix)
+		if nul {
+			return p
+		}
+		return quotePath(p, true)
+	}
+
+	if withBranch {
+		fmt.Fprintf(w, "## %s%s", shortBranchHeader(branch), eol)
+	}
+	for _, s := range status.Files {
+		fmt.Fprintf(w, "%c%c %s%s", s.Staging, s.Worktree, path(s.Path), eol)
+	}
+	for _, p := range status.Untracked {
+		fmt.Fprintf(w, "?? %s%s", path(p), eol)
+	}
+	for _, p := range status.Ignored {
+		fmt.Fprintf(w, "!! %s%s", path(p), eol)
+	}
+}
+
+// shortBranchHeader is like "main...origin/main [ahead 1, behind 2]"
+func shortBranchHeader(b *statusBranch) string {
+	switch {
+	case b.initial:
+		return "No commits yet on " + b.name
+	case b.name == "":
+		return "HEAD (no branch)"
+	case b.upstream == "":
+		return b.name
+	}
+
+	header := b.name + "..." + b.upstream
+	switch {
+	case b.gone:
+		header += " [gone]"
+	case b.ahead > 0 && b.behind > 0:
+		header += fmt.Sprintf(" [ahead %d, behind %d]", b.ahead, b.behind)
+	case b.ahead > 0:
+		header += fmt.Sprintf(" [ahead %d]", b.ahead)
+	case b.behind > 0:
+		header += fmt.Sprintf(" [behind %d]", b.behind)
+	}
+	return header
+}
+
+// writePorcelainV2Status writes status in porcelain v2 format, in which modes and ids of tracked files are shown
+func writePorcelainV2Status(w io.Writer, status *core.Status, branch *statusBranch, prefix string, withBranch bool, nul bool) {
+	eol := "\n"
+	if nul {
+		eol = "\x00"
+	}
+	path := func(p string) string {
+		if nul {
+			return p
+		}
+		return quotePath(relativeStatusPath(p, prefix), false)
+	}
+	code := func(c core.StatusCode) byte {
+		if c == core.Status_Unmodified {
+			return '.'
+		}
+		return byte(c)
+	}
+
+	if withBranch {
+		if branch.initial {
+			fmt.Fprintf(w, "# branch.oid (initial)%s", eol)
+		} else {
+			fmt.Fprintf(w, "# branch.oid %s%s", branch.head, eol)
+		}
+		if branch.name == "" {
+			fmt.Fprintf(w, "# branch.head (detached)%s", eol)
+		} else {
+			fmt.Fprintf(w, "# branch.head %s%s", branch.name, eol)
+		}
+		if branch.upstream != "" {
+			fmt.Fprintf(w, "# branch.upstream %s%s", branch.upstream, eol)
+			if !branch.gone {
+				fmt.Fprintf(w, "# branch.ab +%d -%d%s", branch.ahead, branch.behind, eol)
+			}
+		}
+	}
+
+	for _, s := range status.Files {
+		if s.Unmerged() {
+			fmt.Fprintf(w, "u %c%c N... %06o %06o %06o %06o %s %s %s %s%s", code(s.Staging), code(s.Worktree),
+				uint32(s.StageModes[0]), uint32(s.StageModes[1]), uint32(s.StageModes[2]), uint32(s.WorktreeMode),
+				s.StageOids[0], s.StageOids[1], s.StageOids[2], path(s.Path), eol)
+			continue
+		}
+		fmt.Fprintf(w, "1 %c%c N... %06o %06o %06o %s %s %s%s", code(s.Staging), code(s.Worktree),
+			uint32(s.HeadMode), uint32(s.IndexMode), uint32(s.WorktreeMode), s.HeadOid, s.IndexOid, path(s.Path), eol)
+	}
+	for _, p := range status.Untracked {
+		fmt.Fprintf(w, "? %s%s", path(p), eol)
+	}
+	for _, p := range status.Ignored {
+		fmt.Fprintf(w, "! %s%s", path(p), eol)
+	}
+}
+
+// writeLongStatus writes status in the human readable format of git status, with hints of commands
+func writeLongStatus(ws *core.Workspace, w io.Writer, status *core.Status, branch *statusBranch, prefix string, showUntracked bool) error {
+	path := func(p string) string {
+		return quotePath(relativeStatusPath(p, prefix), false)
+	}
+
+	if branch.name != "" {
+		fmt.Fprintf(w, "On branch %s\n", branch.name)
+	} else {
+		line, err := detachedHeadLine(ws, branch.head)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, line)
+	}
+	writeLongTracking(w, branch)
+	if branch.initial {
+		fmt.Fprint(w, "\nNo commits yet\n\n")
+	}
+
+	unmerged, staged, changed := make([]*core.FileStatus, 0), make([]*core.FileStatus, 0), make([]*core.FileStatus, 0)
+	bothDeleted, delModConflict, hasDeleted := false, false, false
+	for _, s := range status.Files {
+		if s.Unmerged() {
+			unmerged = append(unmerged, s)
+			switch {
+			case s.Staging == core.Status_Deleted && s.Worktree == core.Status_Deleted:
+				bothDeleted = true
+			case s.Staging == core.Status_Deleted || s.Worktree == core.Status_Deleted:
+				delModConflict = true
+			}
+			continue
+		}
+		if s.Staging != core.Status_Unmodified {
+			staged = append(staged, s)
+		}
+		if s.Worktree != core.Status_Unmodified {
+			changed = append(changed, s)
+			hasDeleted = hasDeleted || s.Worktree == core.Status_Deleted
+		}
+	}
+
+	// there are no hints to unstage files while merging
+	_, err := os.Stat(filepath.Join(ws.Repository().Path, "MERGE_HEAD"))
+	merging := err == nil
+	unstageHint := `  (use "git restore --staged <file>..." to unstage)`
+	if branch.initial {
+		unstageHint = `  (use "git rm --cached <file>..." to unstage)`
+	}
+	if merging {
+		if len(unmerged) > 0 {
+			fmt.Fprintln(w, "You have unmerged paths.")
+			fmt.Fprintln(w, `  (fix conflicts and run "git commit")`)
+			fmt.Fprintln(w, `  (use "git merge --abort" to abort the merge)`)
+		} else {
+			fmt.Fprintln(w, "All conflicts fixed but you are still merging.")
+			fmt.Fprintln(w, `  (use "git commit" to conclude merge)`)
+		}
+		fmt.Fprintln(w)
+		unstageHint = ""
+	}
+
+	if len(unmerged) > 0 {
+		fmt.Fprintln(w, "Unmerged paths:")
+		if unstageHint != "" {
+			fmt.Fprintln(w, unstageHint)
+		}
+		switch {
+		case delModConflict:
+			fmt.Fprintln(w, `  (use "git add/rm <file>..." as appropriate to mark resolution)`)
+		case bothDeleted:
+			fmt.Fprintln(w, `  (use "git rm <file>..." to mark resolution)`)
+		default:
+			fmt.Fprintln(w, `  (use "git add <file>..." to mark resolution)`)
+		}
+		for _, s := range unmerged {
+			fmt.Fprintf(w, "\t%-17s%s\n", unmergedLabel(s), path(s.Path))
+		}
+		fmt.Fprintln(w)
+	}
+	if len(staged) > 0 {
+		fmt.Fprintln(w, "Changes to be committed:")
+		if unstageHint != "" {
+			fmt.Fprintln(w, unstageHint)
+		}
+		for _, s := range staged {
+			fmt.Fprintf(w, "\t%-12s%s\n", changeLabel(s.Staging), path(s.Path))
+		}
+		fmt.Fprintln(w)
+	}
+	if len(changed) > 0 {
+		fmt.Fprintln(w, "Changes not staged for commit:")
+		if hasDeleted {
+			fmt.Fprintln(w, `  (use "git add/rm <file>..." to update what will be committed)`)
+		} else {
+			fmt.Fprintln(w, `  (use "git add <file>..." to update what will be committed)`)
+		}
+		fmt.Fprintln(w, `  (use "git restore <file>..." to discard changes in working directory)`)
+		for _, s := range changed {
+			fmt.Fprintf(w, "\t%-12s%s\n", changeLabel(s.Worktree), path(s.Path))
+		}
+		fmt.Fprintln(w)
+	}
+	if len(status.Untracked) > 0 {
+		fmt.Fprintln(w, "Untracked files:")
+		fmt.Fprintln(w, `  (use "git add <file>..." to include in what will be committed)`)
+		for _, p := range status.Untracked {
+			fmt.Fprintf(w, "\t%s\n", path(p))
+		}
+		fmt.Fprintln(w)
+	}
+	if len(status.Ignored) > 0 {
+		fmt.Fprintln(w, "Ignored files:")
+		fmt.Fprintln(w, `  (use "git add -f <file>..." to include in what will be committed)`)
+		for _, p := range status.Ignored {
+			fmt.Fprintf(w, "\t%s\n", path(p))
+		}
+		fmt.Fprintln(w)
+	}
+	if !showUntracked && len(staged) > 0 {
+		fmt.Fprintln(w, "Untracked files not listed (use -u option to show untracked files)")
+	}
+
+	switch {
+	case len(staged) > 0:
+	case len(changed) > 0 || len(unmerged) > 0:
+		fmt.Fprintln(w, `no changes added to commit (use "git add" and/or "git commit -a")`)
+	case len(status.Untracked) > 0:
+		fmt.Fprintln(w, `nothing added to commit but untracked files present (use "git add" to track)`)
+	case branch.initial:
+		fmt.Fprintln(w, `nothing to commit (create/copy files and use "git add" to track)`)
+	case !showUntracked:
+		fmt.Fprintln(w, "nothing to commit (use -u to show untracked files)")
+	default:
+		fmt.Fprintln(w, "nothing to commit, working tree clean")
+	}
+	return nil
+}
+
+// writeLongTracking writes how the current branch relates to its upstream, followed by a blank line
+func writeLongTracking(w io.Writer, b *statusBranch) {
+	if b.upstream == "" {
+		return
+	}
+	writeTracking(w, b)
+	fmt.Fprintln(w)
+}
+
+// writeTracking writes how the current branch relates to its upstream, nothing if it has no upstream
+func writeTracking(w io.Writer, b *statusBranch) {
+	if b.upstream == "" {
+		return
+	}
+	plural := func(n int) string {
+		if n == 1 {
+			return "commit"
+		}
+		return "commits"
+	}
+
+	switch {
+	case b.gone:
+		fmt.Fprintf(w, "Your branch is based on '%s', but the upstream is gone.\n", b.upstream)
+		fmt.Fprintln(w, `  (use "git branch --unset-upstream" to fixup)`)
+	case b.ahead == 0 && b.behind == 0:
+		fmt.Fprintf(w, "Your branch is up to date with '%s'.\n", b.upstream)
+	case b.behind == 0:
+		fmt.Fprintf(w, "Your branch is ahead of '%s' by %d %s.\n", b.upstream, b.ahead, plural(b.ahead))
+		fmt.Fprintln(w, `  (use "git push" to publish your local commits)`)
+	case b.ahead == 0:
+		fmt.Fprintf(w, "Your branch is behind '%s' by %d %s, and can be fast-forwarded.\n", b.upstream, b.behind, plural(b.behind))
+		fmt.Fprintln(w, `  (use "git pull" to update your local branch)`)
+	default:
+		fmt.Fprintf(w, "Your branch and '%s' have diverged,\nand have %d and %d different %s each, respectively.\n",
+			b.upstream, b.ahead, b.behind, plural(b.ahead+b.behind))
+		fmt.Fprintln(w, `  (use "git pull" to merge the remote branch into yours)`)
+	}
+}
+
+// detachedHeadLine tells where HEAD is detached at or from, by the last checkout recorded in the reflog of HEAD
+func detachedHeadLine(ws *core.Workspace, head common.Hash) (string, error) {
+	refs := ws.References()
+	entries, err := refs.Reflog("HEAD")
+	if err != nil && err != core.ErrReflogNotFound {
+		return "", err
+	}
+
+	const checkout = "checkout: moving from "
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if !strings.HasPrefix(e.Message, checkout) {
+			continue
+		}
+		i := strings.LastIndex(e.Message, " to ")
+		if i < 0 {
+			continue
+		}
+		target := e.Message[i+len(" to "):]
+
+		// the target is named if it still points to the commit which was checked out
+		from := ws.Repository().ShortId(e.New, default_abbrev)
+		if name, err := refs.FullRefName(target); err == nil {
+			if oid, err := refs.ReadRef(name); err == nil {
+				if c, err := ws.Repository().PeelToCommit(oid); err == nil && c.Id() == e.New {
+					from = strings.TrimPrefix(strings.TrimPrefix(name, core.RefPrefix_Tags), core.RefPrefix_Remotes)
+				}
+			}
+		}
+		if head == e.New {
+			return "HEAD detached at " + from, nil
+		}
+		return "HEAD detached from " + from, nil
+	}
+	return "Not currently on any branch.", nil
+}
+
+func changeLabel(code core.StatusCode) string {
+	switch code {
+	case core.Status_Added:
+		return "new file:"
+	case core.Status_Deleted:
+		return "deleted:"
+	case core.Status_Modified:
+		return "modified:"
+	case core.Status_TypeChanged:
+		return "typechange:"
+	}
+	return "unknown:"
+}
+
+func unmergedLabel(s *core.FileStatus) string {
+	switch [2]core.StatusCode{s.Staging, s.Worktree} {
+	case [2]core.StatusCode{core.Status_Deleted, core.Status_Deleted}:
+		return "both deleted:"
+	case [2]core.StatusCode{core.Status_Added, core.Status_Unmerged}:
+		return "added by us:"
+	case [2]core.StatusCode{core.Status_Unmerged, core.Status_Deleted}:
+		return "deleted by them:"
+	case [2]core.StatusCode{core.Status_Unmerged, core.Status_Added}:
+		return "added by them:"
+	case [2]core.StatusCode{core.Status_Deleted, core.Status_Unmerged}:
+		return "deleted by us:"
+	case [2]core.StatusCode{core.Status_Added, core.Status_Added}:
+		return "both added:"
+	}
+	return "both modified:"
+}
+
+// relativeStatusPath converts p relative to the root of the work tree into the path relative to prefix, keeping the
+// trailing slash of directories
+func relativeStatusPath(p string, prefix string) string {
+	if prefix == "" {
+		return p
+	}
+	rel, err := filepath.Rel(prefix, strings.TrimSuffix(p, "/"))
+	if err != nil {
+		return p
+	}
+	rel = filepath.ToSlash(rel)
+	if strings.HasSuffix(p, "/") {
+		rel += "/"
+	}
+	return rel
+}
+
+// quotePath quotes p as a C string literal if it has double quotes, backslashes, control characters or bytes which
+// are not ASCII, or spaces with quoteSpace
+func quotePath(p string, quoteSpace bool) string {
+	needed := false
+	for i := 0; i < len(p); i++ {
+		if c := p[i]; c < 0x20 || c >= 0x7f || c == '"' || c == '\\' || (quoteSpace && c == ' ') {
+			needed = true
+			break
+		}
+	}
+	if !needed {
+		return p
+	}
+
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch c {
+		case '\a':
+			sb.WriteString(`\a`)
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\v':
+			sb.WriteString(`\v`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '"', '\\':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		default:
+			if c < 0x20 || c >= 0x7f {
+				fmt.Fprintf(&sb, "\\%03o", c)
+			} else {
+				sb.WriteByte(c)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
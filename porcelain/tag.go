@@ -0,0 +1,140 @@
+package porcelain
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/izhujiang/gogit/common"
+	"github.com/izhujiang/gogit/core"
+	"github.com/izhujiang/gogit/core/object"
+)
+
+var (
+	errTagNoMessage = errors.New("No tag message, please give one with -m.")
+)
+
+type TagOption struct {
+	// create an annotated tag object, which is implied by Message
+	Annotate bool
+	Message  string
+	// replace an existing tag
+	Force bool
+}
+
+// Tag creates the tag name pointing to target, which is an object id or a reference name, HEAD if it is empty.
+// A lightweight tag is a reference to target, and an annotated tag is a reference to a tag object written with the tagger and message.
+func Tag(ws *core.Workspace, w io.Writer, name string, target string, option *TagOption) error {
+	refName := core.RefPrefix_Tags + name
+	if !core.CheckRefName(refName) {
+		return fmt.Errorf("'%s' is not a valid tag name.", name)
+	}
+
+	refs := ws.References()
+	old, err := refs.ReadRef(refName)
+	exists := err == nil
+	if exists && !option.Force {
+		return fmt.Errorf("tag '%s' already exists", name)
+	}
+
+	rev := target
+	if rev == "" {
+		rev = "HEAD"
+	}
+	oid, err := ws.ResolveRevision(rev)
+	if err != nil {
+		return fmt.Errorf("Failed to resolve '%s' as a valid ref: %w", target, err)
+	}
+
+	repo := ws.Repository()
+	if option.Annotate || option.Message != "" {
+		if option.Message == "" {
+			return errTagNoMessage
+		}
+
+		g, err := repo.Get(oid)
+		if err != nil {
+			return err
+		}
+		tagger, err := ws.Identity(core.Role_Committer)
+		if err != nil {
+			return err
+		}
+
+		tag := object.NewTag(common.ZeroHash, oid, g.Kind(), name, tagger, option.Message)
+		tg := tag.ToGitObject()
+		if err := repo.Put(tg); err != nil {
+			return err
+		}
+		oid = tg.Id()
+	}
+
+	if err := refs.WriteRef(refName, oid); err != nil {
+		return err
+	}
+	if exists && old != oid {
+		fmt.Fprintf(w, "Updated tag '%s' (was %s)\n", name, old.String()[:7])
+	}
+	return nil
+}
+
+// ListTags writes names of tags in order, only those matching any of the shell patterns if patterns are given
+func ListTags(ws *core.Workspace, w io.Writer, patterns []string) error {
+	names := make([]string, 0)
+	err := ws.References().ForEach(func(refName string, id common.Hash) error {
+		if !strings.HasPrefix(refName, core.RefPrefix_Tags) {
+			return nil
+		}
+
+		name := refName[len(core.RefPrefix_Tags):]
+		if matchPatterns(patterns, name) {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintln(w, name)
+	}
+	return nil
+}
+
+// DeleteTags removes tags of names, the others are deleted even though some of them are not found, and the last missing one is reported
+func DeleteTags(ws *core.Workspace, w io.Writer, names []string) error {
+	refs := ws.References()
+
+	var result error
+	for _, name := range names {
+		refName := core.RefPrefix_Tags + name
+		oid, err := refs.ReadRef(refName)
+		if err == nil {
+			err = refs.DeleteRef(refName)
+		}
+		if err != nil {
+			result = fmt.Errorf("tag '%s' not found.", name)
+			continue
+		}
+		fmt.Fprintf(w, "Deleted tag '%s' (was %s)\n", name, oid.String()[:7])
+	}
+	return result
+}
+
+// matchPatterns returns true if there are no patterns
+func matchPatterns(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if matched, _ := path.Match(p, name); matched {
+			return true
+		}
+	}
+	return false
+}